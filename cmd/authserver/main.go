@@ -1,31 +1,55 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"flag"
+	"fmt"
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres" // Required for migrations
 	"github.com/golang-migrate/migrate/v4/source/iofs"
 	_ "github.com/lib/pq" // Required for postgres
 	"github.com/spf13/viper"
 	"log"
+	"math"
+	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 	"yadro-microservices/internal/adapter/handler/grpc/auth"
+	"yadro-microservices/internal/adapter/notifier"
 	"yadro-microservices/internal/adapter/repository/pg"
+	"yadro-microservices/internal/adapter/token"
+	"yadro-microservices/internal/core/port"
 	"yadro-microservices/internal/core/service"
 	"yadro-microservices/internal/migrations"
 )
 
+// Lifetimes of tokens used when the configuration does not set them.
+const (
+	defaultRefreshTokenTTL = 30 * 24 * time.Hour
+	defaultSignUpTokenTTL  = 24 * time.Hour
+)
+
+// purgeInterval is how often expired sessions and old login failures are deleted.
+const purgeInterval = time.Hour
+
+// defaultLoginFailureRetention is the number of days failed logins are kept if the configuration does not set it.
+const defaultLoginFailureRetention = 90
+
 func main() {
 	// Parse command line flags
 	var configPath string
 	var port string
+	var jwksPort string
+	var dev bool
 	flag.StringVar(&configPath, "c", "config/authserver.yaml", "Path to configuration file")
 	flag.StringVar(&port, "p", "50051", "Port to start server on")
+	flag.StringVar(&jwksPort, "j", "8082", "Port to publish the token public keys (JWKS) on")
+	flag.BoolVar(&dev, "dev", false, "Allow settings only fit for development, such as a temporary token key")
 	flag.Parse()
 
 	// Initialize and load configuration from file
@@ -45,10 +69,76 @@ func main() {
 		log.Panic("Error applying migrations:", err)
 	}
 
+	// Load the keys signing tokens and publish their public parts
+	keys, err := loadTokenKeys(dev)
+	if err != nil {
+		log.Panic("Error loading token keys:", err)
+	}
+	keys.SetIssuer(tokenIssuer())
+	jwksMux := http.NewServeMux()
+	jwksMux.HandleFunc("GET /.well-known/jwks.json", keys.ServeJWKS)
+	jwksSrv := &http.Server{Addr: ":" + jwksPort, Handler: jwksMux, ReadHeaderTimeout: 5 * time.Second}
+	go func() {
+		if err := jwksSrv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Panicf("Error starting JWKS server: %v", err)
+		}
+	}()
+	defer jwksSrv.Close()
+
 	// Create and start the server
 	tokenMaxTime := viper.GetInt("token_max_time")
+	refreshTokenTTL := viper.GetDuration("refresh_token_ttl")
+	if refreshTokenTTL <= 0 {
+		// Older configurations do not set the lifetime of refresh tokens
+		refreshTokenTTL = defaultRefreshTokenTTL
+	}
 	usersRep := pg.NewUserRepository(pgClient)
-	authService := service.NewAuthService(usersRep, time.Duration(tokenMaxTime)*time.Minute)
+	rolesRep := pg.NewRoleRepository(pgClient)
+	sessionRep := pg.NewSessionRepository(pgClient)
+	failureRep := pg.NewLoginFailureRepository(pgClient)
+	apiKeyRep := pg.NewAPIKeyRepository(pgClient)
+	authService := service.NewAuthService(
+		usersRep,
+		rolesRep,
+		sessionRep,
+		failureRep,
+		apiKeyRep,
+		keys,
+		time.Duration(tokenMaxTime)*time.Minute,
+		refreshTokenTTL,
+	)
+	if viper.GetBool("signup_enabled") {
+		signUpNotifier, err := newNotifier()
+		if err != nil {
+			log.Panic("Error creating notifier:", err)
+		}
+
+		signUpTokenTTL := viper.GetDuration("signup_token_ttl")
+		if signUpTokenTTL <= 0 {
+			signUpTokenTTL = defaultSignUpTokenTTL
+		}
+		authService.EnableSignUp(signUpNotifier, signUpTokenTTL)
+	}
+	authService.ThrottleLogins(loginThrottle())
+	loginFailureRetention := viper.GetInt("login_failure_retention")
+	if !viper.IsSet("login_failure_retention") {
+		// Older configurations purged failed logins with the throttling window
+		loginFailureRetention = defaultLoginFailureRetention
+	}
+	authService.SetLoginFailureRetention(time.Duration(loginFailureRetention) * 24 * time.Hour)
+	hashing, err := passwordHashing()
+	if err != nil {
+		log.Panic("Error configuring password hashing:", err)
+	}
+	authService.SetPasswordHashing(hashing)
+	if concurrency := viper.GetInt("password_hash_concurrency"); concurrency > 0 {
+		authService.SetHashConcurrency(concurrency)
+	}
+	policy, err := passwordPolicy()
+	if err != nil {
+		log.Panic("Error loading password policy:", err)
+	}
+	authService.SetPasswordPolicy(policy)
 	srv := auth.NewServer(authService)
 	go func() {
 		if err := srv.Start(port); err != nil {
@@ -57,6 +147,11 @@ func main() {
 	}()
 	defer srv.Stop()
 
+	// Delete expired sessions in the background
+	purgeCtx, stopPurge := context.WithCancel(context.Background())
+	defer stopPurge()
+	go purgeExpired(purgeCtx, authService)
+
 	// Set up a signal channel to handle interrupt and termination signals
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
@@ -66,6 +161,165 @@ func main() {
 	log.Printf("Received signal %v. Shutting down...", sig)
 }
 
+// purgeExpired deletes expired sessions and old login failures every purge interval until the context is done.
+func purgeExpired(ctx context.Context, authService *service.AuthService) {
+	ticker := time.NewTicker(purgeInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := authService.PurgeExpired(ctx); err != nil {
+			log.Println("Error purging expired sessions and login failures:", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// loginThrottle returns the limits of failed logins set in the configuration.
+func loginThrottle() service.LoginThrottle {
+	return service.LoginThrottle{
+		Window:      viper.GetDuration("login_failure_window"),
+		BaseDelay:   viper.GetDuration("login_base_delay"),
+		MaxDelay:    viper.GetDuration("login_max_delay"),
+		LockoutTime: viper.GetDuration("login_lockout_time"),
+		User: service.AttemptLimit{
+			Free:    viper.GetInt("login_free_attempts"),
+			Lockout: viper.GetInt("login_lockout_attempts"),
+		},
+		IP: service.AttemptLimit{
+			Free:    viper.GetInt("login_ip_free_attempts"),
+			Lockout: viper.GetInt("login_ip_lockout_attempts"),
+		},
+	}
+}
+
+// tokenKeyConfig is a key signing tokens in the configuration.
+type tokenKeyConfig struct {
+	ID   string `mapstructure:"id"`
+	File string `mapstructure:"file"`
+}
+
+// loadTokenKeys loads the keys listed in the configuration. If no keys are configured, a temporary key
+// is generated in development, so the tokens become invalid once the server restarts; otherwise it fails.
+func loadTokenKeys(dev bool) (*token.KeySet, error) {
+	var configs []tokenKeyConfig
+	if err := viper.UnmarshalKey("token_keys", &configs); err != nil {
+		return nil, fmt.Errorf("error parsing token keys: %w", err)
+	}
+
+	if len(configs) == 0 {
+		if !dev {
+			return nil, errors.New("no token keys configured; set token_keys or start with -dev for a temporary key")
+		}
+		log.Println("No token keys configured, generating a temporary key")
+		key, err := token.GenerateKey("temporary")
+		if err != nil {
+			return nil, err
+		}
+		return token.NewKeySet("", key)
+	}
+
+	keys := make([]*token.Key, 0, len(configs))
+	for _, c := range configs {
+		data, err := os.ReadFile(c.File)
+		if err != nil {
+			return nil, fmt.Errorf("error reading key %q: %w", c.ID, err)
+		}
+
+		key, err := token.ParseKey(c.ID, data)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing key %q: %w", c.ID, err)
+		}
+		keys = append(keys, key)
+	}
+
+	return token.NewKeySet(viper.GetString("token_signing_key"), keys...)
+}
+
+// tokenIssuer returns the issuer and the audience of tokens set in the configuration.
+func tokenIssuer() (string, string) {
+	issuer := viper.GetString("token_issuer")
+	if issuer == "" {
+		issuer = token.DefaultIssuer
+	}
+	audience := viper.GetString("token_audience")
+	if audience == "" {
+		audience = token.DefaultAudience
+	}
+	return issuer, audience
+}
+
+// passwordHashing returns the hashing of new passwords set in the configuration. Without it passwords
+// are hashed by bcrypt, and unset argon2id parameters take their default values.
+func passwordHashing() (service.PasswordHashing, error) {
+	hashing := service.DefaultPasswordHashing
+	if algorithm := viper.GetString("password_hash"); algorithm != "" {
+		hashing.Algorithm = algorithm
+	}
+	if cost := viper.GetInt("bcrypt_cost"); cost != 0 {
+		hashing.BcryptCost = cost
+	}
+
+	hashing.Argon2 = service.DefaultArgon2Params
+	if t := viper.GetUint32("argon2_time"); t != 0 {
+		hashing.Argon2.Time = t
+	}
+	if memory := viper.GetUint32("argon2_memory"); memory != 0 {
+		hashing.Argon2.Memory = memory
+	}
+	if threads := viper.GetUint("argon2_threads"); threads != 0 {
+		hashing.Argon2.Threads = uint8(min(threads, math.MaxUint8))
+	}
+
+	return hashing, hashing.Validate()
+}
+
+// passwordPolicy returns the password policy set in the configuration, reading the common passwords
+// from password_denylist_file, one per line. Empty lines and lines starting with # are skipped.
+func passwordPolicy() (service.PasswordPolicy, error) {
+	var denylist []string
+	if path := viper.GetString("password_denylist_file"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return service.PasswordPolicy{}, fmt.Errorf("error reading password denylist: %w", err)
+		}
+
+		for _, line := range strings.Split(string(data), "\n") {
+			line = strings.TrimSpace(line)
+			if line != "" && !strings.HasPrefix(line, "#") {
+				denylist = append(denylist, line)
+			}
+		}
+	}
+
+	return service.NewPasswordPolicy(
+		viper.GetInt("password_min_length"),
+		viper.GetInt("password_max_length"),
+		viper.GetInt("password_min_classes"),
+		denylist,
+	), nil
+}
+
+// newNotifier creates the notifier sending sign-up confirmations, configured by notifier and notifier_file.
+func newNotifier() (port.Notifier, error) {
+	switch kind := viper.GetString("notifier"); kind {
+	case "", "log":
+		return notifier.NewLogNotifier(), nil
+	case "file":
+		path := viper.GetString("notifier_file")
+		if path == "" {
+			return nil, errors.New("notifier_file is required by the file notifier")
+		}
+		return notifier.NewFileNotifier(path), nil
+	default:
+		return nil, fmt.Errorf("unknown notifier %q", kind)
+	}
+}
+
 // applyMigrations applies all available migrations to the database.
 func applyMigrations(dbURL string) error {
 	log.Println("Trying to apply migrations...")
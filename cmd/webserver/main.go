@@ -41,8 +41,16 @@ func main() {
 		time.Duration(viper.GetInt("token_max_time"))*time.Minute,
 	)
 	comicsHandler := web.NewComicHandler(viper.GetString("comics_url"))
+	collectionHandler := web.NewCollectionHandler(viper.GetString("me_url"))
 
 	mux.HandleFunc("GET /comics", comicsHandler.SearchComics)
+	mux.HandleFunc("GET /collections", collectionHandler.Collections)
+	mux.HandleFunc("POST /collections", collectionHandler.CreateCollection)
+	mux.HandleFunc("POST /collections/{id}/delete", collectionHandler.DeleteCollection)
+	mux.HandleFunc("POST /collections/{id}/comics/{comic_id}/delete", collectionHandler.RemoveFromCollection)
+	mux.HandleFunc("POST /comics/{id}/collections", collectionHandler.AddToCollection)
+	mux.HandleFunc("POST /favorites/{id}", collectionHandler.AddFavorite)
+	mux.HandleFunc("POST /favorites/{id}/delete", collectionHandler.RemoveFavorite)
 	mux.HandleFunc("POST /login", authHandler.Login)
 	mux.HandleFunc("GET /login", authHandler.LoginForm)
 
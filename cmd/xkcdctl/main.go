@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/go-redis/redis/v8"
+	"github.com/spf13/viper"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+	"yadro-microservices/cmd/xkcdserver/launcher"
+	"yadro-microservices/internal/adapter/archive"
+	"yadro-microservices/internal/adapter/judgment"
+	"yadro-microservices/internal/core/domain"
+	"yadro-microservices/internal/core/service"
+)
+
+const usage = `Usage: xkcdctl [-c config] <command> [flags]
+
+Commands:
+  export -o <file>  Export all comics to a JSON Lines archive (gzip-compressed if the name ends with .gz)
+  import -i <file>  Import comics from a JSON Lines archive and add them to the search engine
+  eval -q <file> [-k 10] [-engine redis|postgres] [-json]
+                    Evaluate search relevance against judged queries (JSON Lines with query and relevant IDs)
+`
+
+func main() {
+	// Parse command line flags
+	var configPath string
+	flag.StringVar(&configPath, "c", "config/xkcdserver.yaml", "Path to configuration file")
+	flag.Usage = func() { fmt.Fprint(flag.CommandLine.Output(), usage) }
+	flag.Parse()
+
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// Initialize and load configuration from file
+	viper.SetConfigFile(configPath)
+	if err := viper.ReadInConfig(); err != nil {
+		log.Panic("Error loading configuration:", err)
+	}
+
+	// Add context with cancel function
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	// Initialize services
+	pgClient := launcher.NewPostgresClient()
+	defer pgClient.Close()
+	redisClient := launcher.NewRedisClient()
+	if redisClient != nil {
+		defer redisClient.Close()
+	}
+	xkcdService := launcher.NewXkcdService(pgClient, redisClient)
+	// Imported comics are matched against the saved searches and published to the webhooks here,
+	// while the queued deliveries are sent by the server
+	webhookService := launcher.NewWebhookService(pgClient)
+	xkcdService.AddComicsListener(launcher.NewSavedSearchService(pgClient, webhookService))
+	xkcdService.AddComicsListener(webhookService)
+
+	var err error
+	switch cmd, args := flag.Arg(0), flag.Args()[1:]; cmd {
+	case "export":
+		err = runExport(ctx, xkcdService, args)
+	case "import":
+		err = runImport(ctx, xkcdService, args)
+	case "eval":
+		err = runEval(ctx, pgClient, redisClient, args)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// runExport dumps all comics to the archive file.
+func runExport(ctx context.Context, xkcdService *service.XkcdService, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	output := fs.String("o", "comics.jsonl.gz", "Path to the archive file")
+	_ = fs.Parse(args)
+
+	comics, err := xkcdService.ExportComics(ctx)
+	if err != nil {
+		return fmt.Errorf("error exporting comics: %w", err)
+	}
+
+	if err = archive.WriteFile(*output, comics); err != nil {
+		return fmt.Errorf("error writing archive: %w", err)
+	}
+
+	log.Printf("Exported %d comics to %s", len(comics), *output)
+	return nil
+}
+
+// runImport loads comics from the archive file into the database and the search engine.
+func runImport(ctx context.Context, xkcdService *service.XkcdService, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	input := fs.String("i", "comics.jsonl.gz", "Path to the archive file")
+	_ = fs.Parse(args)
+
+	comics, err := archive.ReadFile(*input)
+	if err != nil {
+		return fmt.Errorf("error reading archive: %w", err)
+	}
+
+	imported, err := xkcdService.ImportComics(ctx, comics)
+	if err != nil {
+		return fmt.Errorf("error importing comics: %w", err)
+	}
+
+	log.Printf("Imported %d of %d comics from %s", imported, len(comics), *input)
+	return nil
+}
+
+// runEval evaluates the relevance of the search engine against the judged queries and prints the report.
+func runEval(ctx context.Context, pgClient *sql.DB, redisClient *redis.Client, args []string) error {
+	fs := flag.NewFlagSet("eval", flag.ExitOnError)
+	input := fs.String("q", "judgments.jsonl", "Path to the judged queries file")
+	k := fs.Int("k", 10, "Number of the top results to evaluate")
+	engine := fs.String("engine", viper.GetString("search_engine"), "Search engine to evaluate: redis or postgres")
+	asJSON := fs.Bool("json", false, "Print the report as JSON")
+	_ = fs.Parse(args)
+
+	queries, err := judgment.ReadFile(*input)
+	if err != nil {
+		return fmt.Errorf("error reading judged queries: %w", err)
+	}
+
+	searchEngine, err := launcher.NewSearchEngine(*engine, pgClient, redisClient, launcher.NewClickRepository(pgClient))
+	if err != nil {
+		return fmt.Errorf("error configuring search engine: %w", err)
+	}
+
+	report, err := service.NewEvaluationService(launcher.NewTextProcessor(), searchEngine).Evaluate(ctx, queries, *k)
+	if err != nil {
+		return fmt.Errorf("error evaluating search: %w", err)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+
+	return printReport(report)
+}
+
+// printReport prints the evaluation report as a table.
+func printReport(report *domain.EvaluationReport) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "QUERY\tP@%d\tR@%d\tRR\tNDCG@%d\tERROR\n", report.K, report.K, report.K)
+	for _, q := range report.Queries {
+		fmt.Fprintf(w, "%s\t%.3f\t%.3f\t%.3f\t%.3f\t%s\n",
+			q.Query, q.Precision, q.Recall, q.ReciprocalRank, q.NDCG, q.Error)
+	}
+	fmt.Fprintf(w, "MEAN\t%.3f\t%.3f\t%.3f\t%.3f\t%d failed\n",
+		report.Precision, report.Recall, report.MRR, report.NDCG, report.Failed)
+
+	return w.Flush()
+}
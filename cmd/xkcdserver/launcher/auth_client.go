@@ -0,0 +1,64 @@
+package launcher
+
+import (
+	"context"
+	"fmt"
+	"github.com/spf13/viper"
+	"log"
+	"time"
+	"yadro-microservices/internal/adapter/client/auth"
+	"yadro-microservices/internal/adapter/token"
+	"yadro-microservices/internal/core/port"
+)
+
+// Settings of the local token verification used by older configurations.
+const (
+	defaultKeysRefresh  = 15 * time.Minute
+	defaultAuthCacheTTL = 30 * time.Second
+	defaultChangesPoll  = 5 * time.Second
+	jwksTimeout         = 5 * time.Second
+)
+
+// NewAuthClient creates a client of the auth server. If the JWKS URL of the auth server is configured,
+// tokens are verified locally with its public keys instead of being sent to the auth server,
+// and the changes of users are fetched from it until the context is done.
+func NewAuthClient(ctx context.Context) (port.AuthClient, error) {
+	client, err := auth.NewClient(viper.GetString("auth_server_url"))
+	if err != nil {
+		return nil, fmt.Errorf("error creating auth client: %w", err)
+	}
+
+	jwksURL := viper.GetString("auth_jwks_url")
+	if jwksURL == "" {
+		log.Println("Auth JWKS URL is not configured, tokens are validated by the auth server")
+		return client, nil
+	}
+
+	refresh := viper.GetDuration("auth_keys_refresh")
+	if refresh <= 0 {
+		refresh = defaultKeysRefresh
+	}
+	cacheTTL := viper.GetDuration("auth_cache_ttl")
+	if cacheTTL <= 0 {
+		cacheTTL = defaultAuthCacheTTL
+	}
+	changesPoll := viper.GetDuration("auth_changes_poll")
+	if changesPoll <= 0 {
+		changesPoll = defaultChangesPoll
+	}
+
+	verifier := token.NewRemoteKeySet(jwksURL, refresh, jwksTimeout)
+	issuer := viper.GetString("token_issuer")
+	if issuer == "" {
+		issuer = token.DefaultIssuer
+	}
+	audience := viper.GetString("token_audience")
+	if audience == "" {
+		audience = token.DefaultAudience
+	}
+	verifier.SetIssuer(issuer, audience)
+	verifying := auth.NewVerifyingClient(client, verifier, cacheTTL, changesPoll)
+	go verifying.Watch(ctx)
+
+	return verifying, nil
+}
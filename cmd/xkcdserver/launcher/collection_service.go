@@ -0,0 +1,12 @@
+package launcher
+
+import (
+	"database/sql"
+	"yadro-microservices/internal/adapter/repository/pg"
+	"yadro-microservices/internal/core/service"
+)
+
+// NewCollectionService creates a new instance of the CollectionService.
+func NewCollectionService(pgClient *sql.DB) *service.CollectionService {
+	return service.NewCollectionService(pg.NewCollectionRepository(pgClient))
+}
@@ -29,8 +29,14 @@ func NewPostgresClient() *sql.DB {
 }
 
 // NewRedisClient creates a new instance of the Redis client.
+// If Redis is not configured, nil is returned.
 func NewRedisClient() *redis.Client {
-	opt, err := redis.ParseURL(viper.GetString("redis_url"))
+	redisURL := viper.GetString("redis_url")
+	if redisURL == "" {
+		return nil
+	}
+
+	opt, err := redis.ParseURL(redisURL)
 	if err != nil {
 		log.Panic("Error parsing redis url:", err)
 	}
@@ -0,0 +1,20 @@
+package launcher
+
+import (
+	"database/sql"
+	"yadro-microservices/internal/adapter/repository/pg"
+	"yadro-microservices/internal/adapter/search"
+	"yadro-microservices/internal/core/port"
+	"yadro-microservices/internal/core/service"
+)
+
+// NewSavedSearchService creates a new instance of the SavedSearchService.
+// Notifications of the saved searches are delivered by the notifier, normally the WebhookService.
+func NewSavedSearchService(pgClient *sql.DB, notifier port.SavedSearchNotifier) *service.SavedSearchService {
+	return service.NewSavedSearchService(
+		NewTextProcessor(),
+		pg.NewSavedSearchRepository(pgClient),
+		search.NewMatcher(),
+		notifier,
+	)
+}
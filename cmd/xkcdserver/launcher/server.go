@@ -6,9 +6,9 @@ import (
 	"net"
 	"net/http"
 	"time"
-	"yadro-microservices/internal/adapter/client/auth"
 	handler "yadro-microservices/internal/adapter/handler/http"
 	"yadro-microservices/internal/core/domain"
+	"yadro-microservices/internal/core/port"
 	"yadro-microservices/internal/core/service"
 	"yadro-microservices/pkg/middleware"
 )
@@ -17,28 +17,220 @@ import (
 func NewServer(
 	ctx context.Context,
 	xkcdService *service.XkcdService,
-	authClient *auth.Client,
+	collectionService *service.CollectionService,
+	savedSearchService *service.SavedSearchService,
+	webhookService *service.WebhookService,
+	userService *service.UserService,
+	authClient port.AuthClient,
 	port string,
 ) *http.Server {
 	// Initialize http mux and handlers
 	mux := http.NewServeMux()
 	xkcdHandler := handler.NewXkcdHandler(xkcdService)
+	collectionHandler := handler.NewCollectionHandler(collectionService)
+	savedSearchHandler := handler.NewSavedSearchHandler(savedSearchService)
+	webhookHandler := handler.NewWebhookHandler(webhookService)
 	authHandler := handler.NewAuthHandler(authClient)
+	authHandler.SetTrustedProxies(viper.GetStringSlice("trusted_proxies"))
+	userHandler := handler.NewUserHandler(authClient, userService)
+	apiKeyHandler := handler.NewAPIKeyHandler(authClient)
 	mux.HandleFunc("POST /update", middleware.Chain(
 		xkcdHandler.Update,
 		handler.AuthenticationMiddleware(authClient, true),
-		handler.AuthorizationMiddleware(domain.ADMIN),
+		handler.AuthorizationMiddleware(domain.PermComicsUpdate),
+	))
+	mux.HandleFunc("GET /update/{id}", middleware.Chain(
+		xkcdHandler.UpdateStatus,
+		handler.AuthenticationMiddleware(authClient, true),
+		handler.AuthorizationMiddleware(domain.PermComicsUpdate),
+	))
+	mux.HandleFunc("GET /admin/schedule", middleware.Chain(
+		xkcdHandler.Schedule,
+		handler.AuthenticationMiddleware(authClient, true),
+		handler.AuthorizationMiddleware(domain.PermComicsUpdate),
+	))
+	mux.HandleFunc("GET /admin/webhooks", middleware.Chain(
+		webhookHandler.List,
+		handler.AuthenticationMiddleware(authClient, true),
+		handler.AuthorizationMiddleware(domain.PermWebhooksManage),
+	))
+	mux.HandleFunc("POST /admin/webhooks", middleware.Chain(
+		webhookHandler.Create,
+		handler.AuthenticationMiddleware(authClient, true),
+		handler.AuthorizationMiddleware(domain.PermWebhooksManage),
+	))
+	mux.HandleFunc("DELETE /admin/webhooks/{id}", middleware.Chain(
+		webhookHandler.Delete,
+		handler.AuthenticationMiddleware(authClient, true),
+		handler.AuthorizationMiddleware(domain.PermWebhooksManage),
+	))
+	mux.HandleFunc("GET /admin/webhooks/{id}/deliveries", middleware.Chain(
+		webhookHandler.Deliveries,
+		handler.AuthenticationMiddleware(authClient, true),
+		handler.AuthorizationMiddleware(domain.PermWebhooksManage),
+	))
+	mux.HandleFunc("GET /admin/users", middleware.Chain(
+		userHandler.List,
+		handler.AuthenticationMiddleware(authClient, true),
+		handler.AuthorizationMiddleware(domain.PermUsersManage),
+	))
+	mux.HandleFunc("PUT /admin/users/{username}/role", middleware.Chain(
+		userHandler.SetRole,
+		handler.AuthenticationMiddleware(authClient, true),
+		handler.AuthorizationMiddleware(domain.PermUsersManage),
+	))
+	mux.HandleFunc("POST /admin/users/{username}/disable", middleware.Chain(
+		userHandler.Disable,
+		handler.AuthenticationMiddleware(authClient, true),
+		handler.AuthorizationMiddleware(domain.PermUsersManage),
+	))
+	mux.HandleFunc("POST /admin/users/{username}/enable", middleware.Chain(
+		userHandler.Enable,
+		handler.AuthenticationMiddleware(authClient, true),
+		handler.AuthorizationMiddleware(domain.PermUsersManage),
+	))
+	mux.HandleFunc("DELETE /admin/users/{username}", middleware.Chain(
+		userHandler.Delete,
+		handler.AuthenticationMiddleware(authClient, true),
+		handler.AuthorizationMiddleware(domain.PermUsersManage),
+	))
+	mux.HandleFunc("POST /import", middleware.Chain(
+		xkcdHandler.Import,
+		handler.AuthenticationMiddleware(authClient, true),
+		handler.AuthorizationMiddleware(domain.PermComicsUpdate),
 	))
 	mux.HandleFunc("GET /pics", middleware.Chain(
 		xkcdHandler.Search,
 		handler.AuthenticationMiddleware(authClient, true),
-		handler.AuthorizationMiddleware(domain.USER),
+		handler.AuthorizationMiddleware(domain.PermComicsSearch),
+	))
+	mux.HandleFunc("POST /comics/{id}/click", middleware.Chain(
+		xkcdHandler.Click,
+		handler.AuthenticationMiddleware(authClient, true),
+		handler.AuthorizationMiddleware(domain.PermComicsSearch),
+	))
+	mux.HandleFunc("GET /admin/analytics", middleware.Chain(
+		xkcdHandler.Analytics,
+		handler.AuthenticationMiddleware(authClient, true),
+		handler.AuthorizationMiddleware(domain.PermAnalyticsRead),
+	))
+	mux.HandleFunc("GET /me/history", middleware.Chain(
+		xkcdHandler.History,
+		handler.AuthenticationMiddleware(authClient, true),
+		handler.AuthorizationMiddleware(domain.PermAccountManage),
+	))
+	mux.HandleFunc("DELETE /me/history", middleware.Chain(
+		xkcdHandler.ClearHistory,
+		handler.AuthenticationMiddleware(authClient, true),
+		handler.AuthorizationMiddleware(domain.PermAccountManage),
+	))
+	mux.HandleFunc("GET /me/settings", middleware.Chain(
+		authHandler.Settings,
+		handler.AuthenticationMiddleware(authClient, true),
+		handler.AuthorizationMiddleware(domain.PermAccountManage),
+	))
+	mux.HandleFunc("PUT /me/settings", middleware.Chain(
+		authHandler.UpdateSettings,
+		handler.AuthenticationMiddleware(authClient, true),
+		handler.AuthorizationMiddleware(domain.PermAccountManage),
+	))
+	mux.HandleFunc("PUT /me/password", middleware.Chain(
+		authHandler.ChangePassword,
+		handler.AuthenticationMiddleware(authClient, true),
+		handler.AuthorizationMiddleware(domain.PermAccountManage),
+	))
+	mux.HandleFunc("GET /me/api-keys", middleware.Chain(
+		apiKeyHandler.List,
+		handler.AuthenticationMiddleware(authClient, true),
+		handler.AuthorizationMiddleware(domain.PermAccountManage),
+	))
+	mux.HandleFunc("POST /me/api-keys", middleware.Chain(
+		apiKeyHandler.Create,
+		handler.AuthenticationMiddleware(authClient, true),
+		handler.AuthorizationMiddleware(domain.PermAccountManage),
+	))
+	mux.HandleFunc("DELETE /me/api-keys/{id}", middleware.Chain(
+		apiKeyHandler.Revoke,
+		handler.AuthenticationMiddleware(authClient, true),
+		handler.AuthorizationMiddleware(domain.PermAccountManage),
+	))
+	mux.HandleFunc("GET /me/favorites", middleware.Chain(
+		collectionHandler.Favorites,
+		handler.AuthenticationMiddleware(authClient, true),
+		handler.AuthorizationMiddleware(domain.PermAccountManage),
+	))
+	mux.HandleFunc("POST /me/favorites/{id}", middleware.Chain(
+		collectionHandler.AddFavorite,
+		handler.AuthenticationMiddleware(authClient, true),
+		handler.AuthorizationMiddleware(domain.PermAccountManage),
+	))
+	mux.HandleFunc("DELETE /me/favorites/{id}", middleware.Chain(
+		collectionHandler.RemoveFavorite,
+		handler.AuthenticationMiddleware(authClient, true),
+		handler.AuthorizationMiddleware(domain.PermAccountManage),
+	))
+	mux.HandleFunc("GET /me/collections", middleware.Chain(
+		collectionHandler.Collections,
+		handler.AuthenticationMiddleware(authClient, true),
+		handler.AuthorizationMiddleware(domain.PermAccountManage),
+	))
+	mux.HandleFunc("POST /me/collections", middleware.Chain(
+		collectionHandler.CreateCollection,
+		handler.AuthenticationMiddleware(authClient, true),
+		handler.AuthorizationMiddleware(domain.PermAccountManage),
+	))
+	mux.HandleFunc("DELETE /me/collections/{id}", middleware.Chain(
+		collectionHandler.DeleteCollection,
+		handler.AuthenticationMiddleware(authClient, true),
+		handler.AuthorizationMiddleware(domain.PermAccountManage),
+	))
+	mux.HandleFunc("POST /me/collections/{id}/comics/{comic_id}", middleware.Chain(
+		collectionHandler.AddToCollection,
+		handler.AuthenticationMiddleware(authClient, true),
+		handler.AuthorizationMiddleware(domain.PermAccountManage),
+	))
+	mux.HandleFunc("DELETE /me/collections/{id}/comics/{comic_id}", middleware.Chain(
+		collectionHandler.RemoveFromCollection,
+		handler.AuthenticationMiddleware(authClient, true),
+		handler.AuthorizationMiddleware(domain.PermAccountManage),
+	))
+	mux.HandleFunc("GET /me/searches", middleware.Chain(
+		savedSearchHandler.List,
+		handler.AuthenticationMiddleware(authClient, true),
+		handler.AuthorizationMiddleware(domain.PermAccountManage),
+	))
+	mux.HandleFunc("POST /me/searches", middleware.Chain(
+		savedSearchHandler.Create,
+		handler.AuthenticationMiddleware(authClient, true),
+		handler.AuthorizationMiddleware(domain.PermAccountManage),
+	))
+	mux.HandleFunc("DELETE /me/searches/{id}", middleware.Chain(
+		savedSearchHandler.Delete,
+		handler.AuthenticationMiddleware(authClient, true),
+		handler.AuthorizationMiddleware(domain.PermAccountManage),
+	))
+	mux.HandleFunc("GET /me/inbox", middleware.Chain(
+		savedSearchHandler.Inbox,
+		handler.AuthenticationMiddleware(authClient, true),
+		handler.AuthorizationMiddleware(domain.PermAccountManage),
+	))
+	mux.HandleFunc("POST /me/inbox/{id}/read", middleware.Chain(
+		savedSearchHandler.MarkRead,
+		handler.AuthenticationMiddleware(authClient, true),
+		handler.AuthorizationMiddleware(domain.PermAccountManage),
 	))
 	mux.HandleFunc("POST /login", authHandler.Login)
+	mux.HandleFunc("POST /refresh", authHandler.Refresh)
+	mux.HandleFunc("POST /logout", middleware.Chain(
+		authHandler.Logout,
+		handler.AuthenticationMiddleware(authClient, true),
+	))
+	mux.HandleFunc("POST /signup", authHandler.SignUp)
+	mux.HandleFunc("POST /signup/confirm", authHandler.ConfirmSignUp)
 	mux.HandleFunc("POST /register", middleware.Chain(
 		authHandler.Register,
 		handler.AuthenticationMiddleware(authClient, true),
-		handler.AuthorizationMiddleware(domain.ADMIN),
+		handler.AuthorizationMiddleware(domain.PermUsersRegister),
 	))
 
 	rl := middleware.NewRateLimiter(viper.GetInt64("rate_limit"), viper.GetInt64("max_tokens"))
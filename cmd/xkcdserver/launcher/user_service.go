@@ -0,0 +1,13 @@
+package launcher
+
+import (
+	"database/sql"
+	"yadro-microservices/internal/adapter/repository/pg"
+	"yadro-microservices/internal/core/port"
+	"yadro-microservices/internal/core/service"
+)
+
+// NewUserService creates a new instance of the UserService.
+func NewUserService(pgClient *sql.DB, authClient port.AuthClient) *service.UserService {
+	return service.NewUserService(authClient, pg.NewUserDataRepository(pgClient))
+}
@@ -0,0 +1,44 @@
+package launcher
+
+import (
+	"database/sql"
+	"github.com/spf13/viper"
+	"log"
+	"time"
+	"yadro-microservices/internal/adapter/client/webhook"
+	"yadro-microservices/internal/adapter/repository/pg"
+	"yadro-microservices/internal/core/service"
+)
+
+// webhookTimeout is the timeout of a single webhook request.
+const webhookTimeout = 10 * time.Second
+
+// Webhook delivery settings used by older configurations.
+const (
+	defaultWebhookMaxAttempts = 5
+	defaultWebhookBackoff     = 30 * time.Second
+)
+
+// NewWebhookService creates a new instance of the WebhookService.
+func NewWebhookService(pgClient *sql.DB) *service.WebhookService {
+	maxAttempts := viper.GetInt("webhook_max_attempts")
+	if maxAttempts <= 0 {
+		maxAttempts = defaultWebhookMaxAttempts
+	}
+	backoff := viper.GetDuration("webhook_backoff")
+	if backoff <= 0 {
+		backoff = defaultWebhookBackoff
+	}
+
+	allowed, err := webhook.ParseAllowlist(viper.GetStringSlice("webhook_allowed_networks"))
+	if err != nil {
+		log.Panic("Error parsing webhook allowlist:", err)
+	}
+
+	return service.NewWebhookService(
+		pg.NewWebhookRepository(pgClient),
+		webhook.NewClient(webhookTimeout, viper.GetBool("webhook_allow_private_networks"), allowed),
+		maxAttempts,
+		backoff,
+	)
+}
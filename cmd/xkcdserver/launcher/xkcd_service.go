@@ -3,40 +3,78 @@ package launcher
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"github.com/go-redis/redis/v8"
 	"github.com/spf13/viper"
 	"log"
 	"time"
+	feedadapter "yadro-microservices/internal/adapter/client/feed"
+	"yadro-microservices/internal/adapter/client/jsondir"
+	"yadro-microservices/internal/adapter/client/source"
 	xkcdadapter "yadro-microservices/internal/adapter/client/xkcd"
 	"yadro-microservices/internal/adapter/repository/pg"
 	redisrep "yadro-microservices/internal/adapter/repository/redis"
 	"yadro-microservices/internal/adapter/search"
+	"yadro-microservices/internal/core/domain"
+	"yadro-microservices/internal/core/port"
 	"yadro-microservices/internal/core/service"
+	"yadro-microservices/pkg/feed"
 	"yadro-microservices/pkg/fts"
 	"yadro-microservices/pkg/words"
 	"yadro-microservices/pkg/xkcd"
 )
 
-// NewXkcdService creates a new instance of the XkcdService.
-func NewXkcdService(ctx context.Context, pgClient *sql.DB, redisClient *redis.Client) *service.XkcdService {
-	maxComics := viper.GetInt("max_comics_load")
-	goroutinesLimit := viper.GetInt("parallel")
-	gapsLimit := viper.GetUint32("gaps_limit")
-	sourceURL := viper.GetString("source_url")
+const (
+	// defaultReconcileInterval is used if the reconcile interval is not configured.
+	defaultReconcileInterval = 5 * time.Minute
+	// defaultHistoryMaxEntries is used if the search history size is not configured.
+	defaultHistoryMaxEntries = 1000
+)
 
+// sourceConfig describes a comic source in the configuration file.
+type sourceConfig struct {
+	Name      string `mapstructure:"name"`
+	Type      string `mapstructure:"type"`
+	Namespace int    `mapstructure:"namespace"`
+	URL       string `mapstructure:"url"`
+	Path      string `mapstructure:"path"`
+}
+
+// NewXkcdService creates a new instance of the XkcdService.
+func NewXkcdService(pgClient *sql.DB, redisClient *redis.Client) *service.XkcdService {
 	// Add comic client
-	xkcdClient := xkcd.NewClient(sourceURL, maxComics, goroutinesLimit, gapsLimit)
-	processor := words.NewTextProcessor("en", "config/extended_stopwords_eng.txt")
-	comicClient := xkcdadapter.NewComicClient(xkcdClient, processor)
+	processor := NewTextProcessor()
+	comicClient, err := newComicClient(processor)
+	if err != nil {
+		log.Panic("Error configuring comic sources:", err)
+	}
 
 	// Add repositories
 	comicsRep := pg.NewComicRepository(pgClient)
-	indexRep := redisrep.NewIndexRepository(redisClient)
+	stateRep := pg.NewUpdateStateRepository(pgClient)
+	queryRep := pg.NewSearchQueryRepository(pgClient)
+	clickRep := NewClickRepository(pgClient)
+	historyMaxEntries := viper.GetInt("search_history_max_entries")
+	if historyMaxEntries <= 0 {
+		// Older configurations do not limit the history
+		historyMaxEntries = defaultHistoryMaxEntries
+	}
+	historyRep := pg.NewSearchHistoryRepository(pgClient, historyMaxEntries)
 
 	// Add search engine
-	indexer := fts.NewInvertedIndexer(indexRep)
-	searcher := &fts.FullTextSearcher{}
-	searchEngine := search.NewFtsEngine(indexer, searcher)
+	searchEngine, err := NewSearchEngine(viper.GetString("search_engine"), pgClient, redisClient, clickRep)
+	if err != nil {
+		log.Panic("Error configuring search engine:", err)
+	}
+	if shadowName := viper.GetString("shadow_search_engine"); shadowName != "" {
+		shadowEngine, err := NewSearchEngine(shadowName, pgClient, redisClient, clickRep)
+		if err != nil {
+			log.Panic("Error configuring shadow search engine:", err)
+		}
+		comparisonsRep := pg.NewShadowComparisonRepository(pgClient)
+		searchEngine = search.NewShadowEngine(searchEngine, shadowEngine, comparisonsRep)
+	}
 
 	// Add xkcd service
 	xkcdService := service.NewXkcdService(
@@ -44,15 +82,141 @@ func NewXkcdService(ctx context.Context, pgClient *sql.DB, redisClient *redis.Cl
 		comicsRep,
 		processor,
 		searchEngine,
+		stateRep,
+		queryRep,
+		clickRep,
+		historyRep,
 	)
+	// The lock keeps xkcdctl from changing the comics while the server does, and vice versa
+	xkcdService.SetUpdateLock(pg.NewUpdateLock(pgClient))
+
+	return xkcdService
+}
+
+// NewTextProcessor creates the processor of comics and search queries text.
+func NewTextProcessor() *words.TextProcessor {
+	return words.NewTextProcessor("en", "config/extended_stopwords_eng.txt")
+}
+
+// NewClickRepository creates the repository of click-throughs with the configured half-life.
+func NewClickRepository(pgClient *sql.DB) *pg.ClickRepository {
+	return pg.NewClickRepository(pgClient, viper.GetDuration("click_half_life"))
+}
+
+// NewSearchEngine creates the search engine with the given name.
+// Redis-based engine is used by default, while Postgres-based one does not need Redis at all.
+// Both engines boost comics by their click-throughs in clickRep if click_weight is set.
+func NewSearchEngine(
+	engine string,
+	pgClient *sql.DB,
+	redisClient *redis.Client,
+	clickRep *pg.ClickRepository,
+) (port.SearchEngine, error) {
+	var clickThrough *fts.ClickThrough
+	if weight := viper.GetFloat64("click_weight"); weight != 0 {
+		clickThrough = &fts.ClickThrough{
+			Popularity: clickRep,
+			Weight:     weight,
+			HalfLife:   viper.GetDuration("click_half_life"),
+		}
+	}
 
-	// Schedule comics update
-	updateTimeStr := viper.GetString("update_time")
-	updateTime, err := time.Parse("15:04", updateTimeStr)
+	switch engine {
+	case "", "redis":
+		if redisClient == nil {
+			return nil, errors.New("redis search engine requires redis_url")
+		}
+		indexRep := redisrep.NewIndexRepository(redisClient)
+		if err := indexRep.TrackDocumentTokens(context.Background()); err != nil {
+			return nil, fmt.Errorf("error tracking tokens of indexed documents: %w", err)
+		}
+		indexer := fts.NewInvertedIndexer(indexRep)
+		searcher := &fts.FullTextSearcher{}
+		return search.NewFtsEngine(indexer, searcher).WithClickThrough(clickThrough), nil
+	case "postgres":
+		return search.NewPgEngine(pgClient).WithClickThrough(clickThrough), nil
+	default:
+		return nil, fmt.Errorf("unknown search engine %q", engine)
+	}
+}
+
+// ScheduleUpdate schedules comics update according to the configuration.
+func ScheduleUpdate(ctx context.Context, xkcdService *service.XkcdService) {
+	cronSpec := viper.GetString("update_schedule")
+	if cronSpec == "" {
+		// Fall back to the daily update time used by older configurations
+		updateTime, err := time.Parse("15:04", viper.GetString("update_time"))
+		if err != nil {
+			log.Panic("Error parsing update time:", err)
+		}
+		cronSpec = fmt.Sprintf("%d %d * * *", updateTime.Minute(), updateTime.Hour())
+	}
+
+	timezone := viper.GetString("update_timezone")
+	if timezone == "" {
+		timezone = "Local"
+	}
+	loc, err := time.LoadLocation(timezone)
 	if err != nil {
-		log.Panic("Error parsing update time:", err)
+		log.Panic("Error loading update time zone:", err)
 	}
-	xkcdService.ScheduleUpdate(ctx, updateTime)
 
-	return xkcdService
+	err = xkcdService.ScheduleUpdate(ctx, &domain.UpdateSchedule{
+		Cron:         cronSpec,
+		Location:     loc,
+		RunOnStartup: viper.GetBool("update_on_startup"),
+		CatchUp:      viper.GetBool("update_catch_up"),
+	})
+	if err != nil {
+		log.Panic("Error scheduling update:", err)
+	}
+}
+
+// ScheduleReconcile schedules indexing of the comics missing in the search engine according to the configuration.
+func ScheduleReconcile(ctx context.Context, xkcdService *service.XkcdService) {
+	interval := viper.GetDuration("reconcile_interval")
+	if interval <= 0 {
+		// Older configurations do not set the interval
+		interval = defaultReconcileInterval
+	}
+	xkcdService.ScheduleReconcile(ctx, interval)
+}
+
+// newComicClient creates a registry with all comic sources listed in the configuration.
+// If no sources are configured, xkcd.com is used as the only source.
+func newComicClient(processor port.ComicProcessor) (*source.Registry, error) {
+	var sources []sourceConfig
+	if err := viper.UnmarshalKey("sources", &sources); err != nil {
+		return nil, fmt.Errorf("error parsing sources: %w", err)
+	}
+	if len(sources) == 0 {
+		sources = []sourceConfig{{Name: "xkcd", Type: "xkcd", URL: viper.GetString("source_url")}}
+	}
+
+	registry := source.NewRegistry()
+	for _, sc := range sources {
+		var client port.ComicClient
+		switch sc.Type {
+		case "xkcd":
+			xkcdClient := xkcd.NewClient(
+				sc.URL,
+				viper.GetInt("max_comics_load"),
+				viper.GetInt("parallel"),
+				viper.GetUint32("gaps_limit"),
+			)
+			client = xkcdadapter.NewComicClient(xkcdClient, processor)
+		case "feed":
+			client = feedadapter.NewComicClient(feed.NewClient(), sc.URL, processor)
+		case "jsondir":
+			client = jsondir.NewComicClient(sc.Path, processor)
+		default:
+			return nil, fmt.Errorf("unknown type %q of source %s", sc.Type, sc.Name)
+		}
+
+		if err := registry.Register(sc.Name, sc.Namespace, client); err != nil {
+			return nil, err
+		}
+	}
+
+	return registry, nil
 }
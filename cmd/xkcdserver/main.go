@@ -9,8 +9,8 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	_ "time/tzdata" // Required for update time zones in images without tzdata
 	"yadro-microservices/cmd/xkcdserver/launcher"
-	"yadro-microservices/internal/adapter/client/auth"
 )
 
 func main() {
@@ -34,12 +34,31 @@ func main() {
 	// Initialize services and server
 	pgClient := launcher.NewPostgresClient()
 	redisClient := launcher.NewRedisClient()
-	xkcdService := launcher.NewXkcdService(ctx, pgClient, redisClient)
-	authClient, err := auth.NewClient(viper.GetString("auth_server_url"))
+	xkcdService := launcher.NewXkcdService(pgClient, redisClient)
+	webhookService := launcher.NewWebhookService(pgClient)
+	webhookService.RunDeliveries(ctx)
+	savedSearchService := launcher.NewSavedSearchService(pgClient, webhookService)
+	xkcdService.AddComicsListener(savedSearchService)
+	xkcdService.AddComicsListener(webhookService)
+	xkcdService.AddUpdateListener(webhookService)
+	launcher.ScheduleUpdate(ctx, xkcdService)
+	launcher.ScheduleReconcile(ctx, xkcdService)
+	authClient, err := launcher.NewAuthClient(ctx)
 	if err != nil {
-		log.Panic("Error creating auth client:", err)
+		log.Panic(err)
 	}
-	srv := launcher.NewServer(ctx, xkcdService, authClient, port)
+	collectionService := launcher.NewCollectionService(pgClient)
+	userService := launcher.NewUserService(pgClient, authClient)
+	srv := launcher.NewServer(
+		ctx,
+		xkcdService,
+		collectionService,
+		savedSearchService,
+		webhookService,
+		userService,
+		authClient,
+		port,
+	)
 
 	// Run the server
 	g, gCtx := errgroup.WithContext(ctx)
@@ -56,4 +75,8 @@ func main() {
 	if err := g.Wait(); err != nil {
 		log.Println("Exit reason:", err)
 	}
+
+	// Attempts started by the webhook workers are finished, the rest stay queued for the next start
+	cancel()
+	webhookService.Wait()
 }
@@ -60,6 +60,7 @@ func TestE2EStartupScenario(t *testing.T) {
 		"config/authserver.test.yaml",
 		"-p",
 		"50051",
+		"-dev",
 	)
 
 	err = authCmd.Start()
@@ -120,7 +121,33 @@ func TestE2EStartupScenario(t *testing.T) {
 	updateResp, err := http.DefaultClient.Do(updateReq)
 	require.NoError(t, err)
 	defer updateResp.Body.Close()
-	assert.Equal(t, http.StatusOK, updateResp.StatusCode)
+	assert.Equal(t, http.StatusAccepted, updateResp.StatusCode)
+
+	// Wait for the update job to finish
+	statusURL := "http://localhost:8081" + updateResp.Header.Get("Location")
+	// The closure runs in another goroutine, so it must not call require; it only polls until the job finishes
+	var status any
+	require.Eventually(t, func() bool {
+		statusReq, err := http.NewRequest("GET", statusURL, nil)
+		if err != nil {
+			return false
+		}
+		statusReq.Header.Set("Authorization", "Bearer "+token)
+
+		statusResp, err := http.DefaultClient.Do(statusReq)
+		if err != nil {
+			return false
+		}
+		defer statusResp.Body.Close()
+
+		var job map[string]any
+		if err = json.NewDecoder(statusResp.Body).Decode(&job); err != nil {
+			return false
+		}
+		status = job["status"]
+		return status == "succeeded" || status == "failed"
+	}, 3*time.Minute, time.Second)
+	require.Equal(t, "succeeded", status)
 
 	// Search for comics with "apple" and "doctor" and check if the expected comic is in the results
 	searchReq, err := http.NewRequest("GET", "http://localhost:8081/pics?search=apple,doctor", nil)
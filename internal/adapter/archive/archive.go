@@ -0,0 +1,128 @@
+package archive
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"yadro-microservices/internal/core/domain"
+)
+
+// record is a single line of the archive: a comic together with its ID.
+type record struct {
+	ID int `json:"id"`
+	*domain.Comic
+}
+
+// Write writes comics to w in the JSON Lines format, one comic per line ordered by ID.
+func Write(w io.Writer, comics domain.Comics) error {
+	ids := make([]int, 0, len(comics))
+	for id := range comics {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	enc := json.NewEncoder(w)
+	for _, id := range ids {
+		if err := enc.Encode(record{ID: id, Comic: comics[id]}); err != nil {
+			return fmt.Errorf("error encoding comic %d: %w", id, err)
+		}
+	}
+
+	return nil
+}
+
+// Read reads comics in the JSON Lines format from r. Gzip-compressed input is detected and decompressed.
+// Records without an ID, a source or an image URL, and records repeating an ID, are rejected.
+func Read(r io.Reader) (domain.Comics, error) {
+	br := bufio.NewReader(r)
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("error opening gzip stream: %w", err)
+		}
+		defer gz.Close()
+		br = bufio.NewReader(gz)
+	}
+
+	comics := make(domain.Comics)
+	dec := json.NewDecoder(br)
+	for line := 1; ; line++ {
+		rec := record{Comic: &domain.Comic{}}
+		err := dec.Decode(&rec)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error decoding record %d: %w", line, err)
+		}
+
+		if err = validate(rec); err != nil {
+			return nil, fmt.Errorf("invalid record %d: %w", line, err)
+		}
+		if _, ok := comics[rec.ID]; ok {
+			return nil, fmt.Errorf("invalid record %d: duplicate comic %d", line, rec.ID)
+		}
+
+		comics[rec.ID] = rec.Comic
+	}
+
+	return comics, nil
+}
+
+// validate checks that the record has the fields every stored comic must have.
+func validate(rec record) error {
+	switch {
+	case rec.ID <= 0:
+		return errors.New("id must be positive")
+	case rec.Source == "":
+		return errors.New("source is required")
+	case rec.Img == "":
+		return errors.New("url is required")
+	}
+
+	return nil
+}
+
+// WriteFile writes comics to the file at path. The file is gzip-compressed if its name ends with ".gz".
+func WriteFile(path string, comics domain.Comics) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("error creating file: %w", err)
+	}
+	defer f.Close()
+
+	if !strings.HasSuffix(path, ".gz") {
+		if err = Write(f, comics); err != nil {
+			return err
+		}
+
+		return f.Close()
+	}
+
+	gz := gzip.NewWriter(f)
+	if err = Write(gz, comics); err != nil {
+		return err
+	}
+	if err = gz.Close(); err != nil {
+		return fmt.Errorf("error closing gzip stream: %w", err)
+	}
+
+	return f.Close()
+}
+
+// ReadFile reads comics from the file at path.
+func ReadFile(path string) (domain.Comics, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening file: %w", err)
+	}
+	defer f.Close()
+
+	return Read(f)
+}
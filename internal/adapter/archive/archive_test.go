@@ -0,0 +1,69 @@
+package archive
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"path/filepath"
+	"strings"
+	"testing"
+	"yadro-microservices/internal/core/domain"
+)
+
+var comics = domain.Comics{
+	2: {Source: "xkcd", Img: "https://example.com/2.png", Keywords: []string{"petit", "tree"}},
+	1: {Source: "xkcd", Title: "Barrel - Part 1", Img: "https://example.com/1.png", Keywords: []string{"barrel"}},
+}
+
+func TestWriteRead(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, comics))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.JSONEq(t, `{"id":1,"source":"xkcd","title":"Barrel - Part 1","url":"https://example.com/1.png","keywords":["barrel"]}`, lines[0])
+
+	read, err := Read(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, comics, read)
+}
+
+func TestWriteReadFile_Gzip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "comics.jsonl.gz")
+	require.NoError(t, WriteFile(path, comics))
+
+	read, err := ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, comics, read)
+}
+
+func TestRead_InvalidRecord(t *testing.T) {
+	_, err := Read(strings.NewReader("{\"id\":1,\"source\":\"xkcd\",\"url\":\"a.png\"}\n{invalid json}\n"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "record 2")
+}
+
+func TestRead_IncompleteRecord(t *testing.T) {
+	tests := []struct {
+		name   string
+		record string
+		err    string
+	}{
+		{name: "no id", record: `{"source":"xkcd","url":"a.png"}`, err: "id must be positive"},
+		{name: "no source", record: `{"id":1,"url":"a.png"}`, err: "source is required"},
+		{name: "no url", record: `{"id":1,"source":"xkcd"}`, err: "url is required"},
+		{
+			name:   "duplicate id",
+			record: `{"id":1,"source":"xkcd","url":"a.png"}` + "\n" + `{"id":1,"source":"xkcd","url":"b.png"}`,
+			err:    "duplicate comic 1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Read(strings.NewReader(tt.record))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
@@ -4,7 +4,10 @@ import (
 	"context"
 	"fmt"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"time"
 	authv1 "yadro-microservices/api/gen/go/auth"
 	"yadro-microservices/internal/core/domain"
 )
@@ -41,21 +44,56 @@ func (c *Client) Close() {
 	}
 }
 
-// Login logs in the user with the specified username and password.
-func (c *Client) Login(ctx context.Context, username, password string) (string, error) {
+// Login logs in the user with the specified username and password coming from the client IP.
+// Wrong credentials fail with ErrInvalidCredentials and throttled logins with ThrottledError.
+func (c *Client) Login(ctx context.Context, username, password, clientIP string) (*domain.TokenPair, error) {
 	resp, err := c.client.Login(
 		ctx,
 		&authv1.LoginRequest{
 			Username: username,
 			Password: password,
+			ClientIp: clientIP,
 		},
 	)
 
+	if status.Code(err) == codes.Unauthenticated {
+		return nil, fmt.Errorf("failed to login: %w", domain.ErrInvalidCredentials)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to login: %w", fromStatus(err))
+	}
+
+	return &domain.TokenPair{AccessToken: resp.GetToken(), RefreshToken: resp.GetRefreshToken()}, nil
+}
+
+// Refresh exchanges the refresh token for a new pair of tokens.
+func (c *Client) Refresh(ctx context.Context, refreshToken string) (*domain.TokenPair, error) {
+	resp, err := c.client.Refresh(
+		ctx,
+		&authv1.RefreshRequest{
+			RefreshToken: refreshToken,
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to refresh token: %w", fromStatus(err))
+	}
+
+	return &domain.TokenPair{AccessToken: resp.GetToken(), RefreshToken: resp.GetRefreshToken()}, nil
+}
+
+// Logout revokes the session of the specified token.
+func (c *Client) Logout(ctx context.Context, token string) error {
+	_, err := c.client.Logout(
+		ctx,
+		&authv1.LogoutRequest{
+			Token: token,
+		},
+	)
 	if err != nil {
-		return "", fmt.Errorf("failed to login: %w", err)
+		return fmt.Errorf("failed to logout: %w", fromStatus(err))
 	}
 
-	return resp.GetToken(), nil
+	return nil
 }
 
 // Register registers a new user with the specified username, password, and role.
@@ -74,14 +112,46 @@ func (c *Client) Register(ctx context.Context, username, password string, role d
 		},
 	)
 	if err != nil {
-		return fmt.Errorf("failed to register: %w", err)
+		return fmt.Errorf("failed to register: %w", fromStatus(err))
+	}
+
+	return nil
+}
+
+// SignUp creates a pending account of the user with the specified username, email, and password.
+func (c *Client) SignUp(ctx context.Context, username, email, password string) error {
+	_, err := c.client.SignUp(
+		ctx,
+		&authv1.SignUpRequest{
+			Username: username,
+			Email:    email,
+			Password: password,
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to sign up: %w", fromStatus(err))
+	}
+
+	return nil
+}
+
+// ConfirmSignUp activates the pending account confirmed by the specified token.
+func (c *Client) ConfirmSignUp(ctx context.Context, token string) error {
+	_, err := c.client.ConfirmSignUp(
+		ctx,
+		&authv1.ConfirmSignUpRequest{
+			Token: token,
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to confirm sign-up: %w", fromStatus(err))
 	}
 
 	return nil
 }
 
-// ValidateToken validates the specified token.
-func (c *Client) ValidateToken(ctx context.Context, token string) (*domain.User, error) {
+// ValidateToken validates the specified token and returns the principal authenticated by it.
+func (c *Client) ValidateToken(ctx context.Context, token string) (*domain.Principal, error) {
 	resp, err := c.client.ValidateToken(
 		ctx,
 		&authv1.ValidateTokenRequest{
@@ -89,12 +159,275 @@ func (c *Client) ValidateToken(ctx context.Context, token string) (*domain.User,
 		},
 	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to validate token: %w", err)
+		return nil, fmt.Errorf("failed to validate token: %w", fromStatus(err))
 	}
 
-	return &domain.User{
-		Username: resp.GetUsername(),
-		Password: resp.GetPassword(),
-		Role:     domain.Role(resp.GetRole()),
-	}, nil
+	if resp.GetPrincipal() == nil {
+		return nil, fmt.Errorf("failed to validate token: %w", domain.ErrInvalidToken)
+	}
+
+	return fromPrincipal(resp.GetPrincipal()), nil
+}
+
+// fromPrincipal converts the message to the principal.
+func fromPrincipal(p *authv1.Principal) *domain.Principal {
+	permissions := make([]domain.Permission, len(p.GetPermissions()))
+	for i, perm := range p.GetPermissions() {
+		permissions[i] = domain.Permission(perm)
+	}
+
+	principal := &domain.Principal{
+		Username:        p.GetUsername(),
+		Role:            domain.Role(p.GetRole()),
+		Permissions:     permissions,
+		HistoryDisabled: p.GetHistoryDisabled(),
+	}
+	if p.GetExpiresAt() != 0 {
+		principal.ExpiresAt = time.Unix(p.GetExpiresAt(), 0)
+	}
+
+	return principal
+}
+
+// SetHistoryDisabled sets whether search history of the user is recorded.
+func (c *Client) SetHistoryDisabled(ctx context.Context, username string, disabled bool) error {
+	_, err := c.client.UpdateSettings(
+		ctx,
+		&authv1.UpdateSettingsRequest{
+			Username:        username,
+			HistoryDisabled: disabled,
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update settings: %w", fromStatus(err))
+	}
+
+	return nil
+}
+
+// ListUsers returns the page of user accounts ordered by username, skipping offset accounts.
+func (c *Client) ListUsers(ctx context.Context, offset, limit int) (*domain.UserPage, error) {
+	resp, err := c.client.ListUsers(
+		ctx,
+		&authv1.ListUsersRequest{
+			Offset: int32(offset),
+			Limit:  int32(limit),
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", fromStatus(err))
+	}
+
+	page := &domain.UserPage{
+		Users: make([]*domain.UserAccount, len(resp.GetUsers())),
+		Total: int(resp.GetTotal()),
+	}
+	for i, u := range resp.GetUsers() {
+		account := &domain.UserAccount{
+			Username:  u.GetUsername(),
+			Email:     u.GetEmail(),
+			Role:      domain.Role(u.GetRole()),
+			Pending:   u.GetPending(),
+			Disabled:  u.GetDisabled(),
+			CreatedAt: time.Unix(u.GetCreatedAt(), 0),
+		}
+		if u.GetLastLogin() != 0 {
+			lastLogin := time.Unix(u.GetLastLogin(), 0)
+			account.LastLogin = &lastLogin
+		}
+		page.Users[i] = account
+	}
+
+	return page, nil
+}
+
+// SetRole changes the role of the user.
+func (c *Client) SetRole(ctx context.Context, username string, role domain.Role) error {
+	_, err := c.client.SetRole(
+		ctx,
+		&authv1.SetRoleRequest{
+			Username: username,
+			Role:     string(role),
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to set role: %w", fromStatus(err))
+	}
+
+	return nil
+}
+
+// SetDisabled disables or enables the user.
+func (c *Client) SetDisabled(ctx context.Context, username string, disabled bool) error {
+	_, err := c.client.SetDisabled(
+		ctx,
+		&authv1.SetDisabledRequest{
+			Username: username,
+			Disabled: disabled,
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update user: %w", fromStatus(err))
+	}
+
+	return nil
+}
+
+// DeleteUser deletes the user.
+func (c *Client) DeleteUser(ctx context.Context, username string) error {
+	_, err := c.client.DeleteUser(
+		ctx,
+		&authv1.DeleteUserRequest{
+			Username: username,
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to delete user: %w", fromStatus(err))
+	}
+
+	return nil
+}
+
+// ChangePassword replaces the password of the user, who has to know the old one.
+// Wrong old passwords are throttled like failed logins, so too many of them fail with ThrottledError.
+func (c *Client) ChangePassword(ctx context.Context, username, oldPassword, newPassword, clientIP string) error {
+	_, err := c.client.ChangePassword(
+		ctx,
+		&authv1.ChangePasswordRequest{
+			Username:    username,
+			OldPassword: oldPassword,
+			NewPassword: newPassword,
+			ClientIp:    clientIP,
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to change password: %w", fromStatus(err))
+	}
+
+	return nil
+}
+
+// CreateAPIKey creates an API key of the user limited to the permissions. The key expires after ttl
+// unless it is zero. The returned key contains its value, which is not shown afterwards.
+func (c *Client) CreateAPIKey(
+	ctx context.Context,
+	username, name string,
+	permissions []domain.Permission,
+	ttl time.Duration,
+) (*domain.APIKey, error) {
+	perms := make([]string, len(permissions))
+	for i, perm := range permissions {
+		perms[i] = string(perm)
+	}
+
+	resp, err := c.client.CreateAPIKey(
+		ctx,
+		&authv1.CreateAPIKeyRequest{
+			Username:    username,
+			Name:        name,
+			Permissions: perms,
+			TtlSeconds:  int64(ttl / time.Second),
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create API key: %w", fromStatus(err))
+	}
+
+	k := fromAPIKey(resp.GetApiKey())
+	k.Username = username
+	k.Key = resp.GetKey()
+
+	return k, nil
+}
+
+// ListAPIKeys returns the API keys of the user.
+func (c *Client) ListAPIKeys(ctx context.Context, username string) ([]*domain.APIKey, error) {
+	resp, err := c.client.ListAPIKeys(ctx, &authv1.ListAPIKeysRequest{Username: username})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list API keys: %w", fromStatus(err))
+	}
+
+	keys := make([]*domain.APIKey, len(resp.GetApiKeys()))
+	for i, k := range resp.GetApiKeys() {
+		keys[i] = fromAPIKey(k)
+		keys[i].Username = username
+	}
+
+	return keys, nil
+}
+
+// RevokeAPIKey revokes the API key of the user.
+func (c *Client) RevokeAPIKey(ctx context.Context, username string, id int64) error {
+	_, err := c.client.RevokeAPIKey(ctx, &authv1.RevokeAPIKeyRequest{Username: username, Id: id})
+	if err != nil {
+		return fmt.Errorf("failed to revoke API key: %w", fromStatus(err))
+	}
+
+	return nil
+}
+
+// ValidateAPIKey validates the API key and returns the principal authenticated by it.
+func (c *Client) ValidateAPIKey(ctx context.Context, key string) (*domain.Principal, error) {
+	resp, err := c.client.ValidateAPIKey(ctx, &authv1.ValidateAPIKeyRequest{Key: key})
+	if err != nil {
+		return nil, fmt.Errorf("failed to validate API key: %w", fromStatus(err))
+	}
+
+	if resp.GetPrincipal() == nil {
+		return nil, fmt.Errorf("failed to validate API key: %w", domain.ErrInvalidToken)
+	}
+
+	return fromPrincipal(resp.GetPrincipal()), nil
+}
+
+// GetChanges returns the changes of users made and the sessions revoked since the given time.
+func (c *Client) GetChanges(ctx context.Context, since time.Time) (*domain.Changes, error) {
+	resp, err := c.client.GetChanges(ctx, &authv1.GetChangesRequest{Since: since.Unix()})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get changes: %w", fromStatus(err))
+	}
+
+	changes := &domain.Changes{
+		Users:        make([]*domain.UserChange, len(resp.GetUsers())),
+		Sessions:     make([]*domain.SessionRevocation, len(resp.GetSessions())),
+		Now:          time.Unix(resp.GetNow(), 0),
+		TokenMaxTime: time.Duration(resp.GetTokenMaxTime()) * time.Second,
+	}
+	for i, u := range resp.GetUsers() {
+		changes.Users[i] = &domain.UserChange{Username: u.GetUsername(), ChangedAt: time.Unix(u.GetChangedAt(), 0)}
+	}
+	for i, rev := range resp.GetSessions() {
+		changes.Sessions[i] = &domain.SessionRevocation{
+			SessionID: rev.GetSessionId(),
+			RevokedAt: time.Unix(rev.GetRevokedAt(), 0),
+		}
+	}
+
+	return changes, nil
+}
+
+// fromAPIKey converts the message to the API key.
+func fromAPIKey(k *authv1.APIKey) *domain.APIKey {
+	permissions := make([]domain.Permission, len(k.GetPermissions()))
+	for i, perm := range k.GetPermissions() {
+		permissions[i] = domain.Permission(perm)
+	}
+
+	key := &domain.APIKey{
+		ID:          k.GetId(),
+		Name:        k.GetName(),
+		Prefix:      k.GetPrefix(),
+		Permissions: permissions,
+		CreatedAt:   time.Unix(k.GetCreatedAt(), 0),
+	}
+	if k.GetExpiresAt() != 0 {
+		expiresAt := time.Unix(k.GetExpiresAt(), 0)
+		key.ExpiresAt = &expiresAt
+	}
+	if k.GetLastUsedAt() != 0 {
+		lastUsedAt := time.Unix(k.GetLastUsedAt(), 0)
+		key.LastUsedAt = &lastUsedAt
+	}
+
+	return key
 }
@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"fmt"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	authv1 "yadro-microservices/api/gen/go/auth"
+	"yadro-microservices/internal/core/domain"
+)
+
+// fromStatus converts the gRPC status returned by the auth server back to the domain error it stands for.
+func fromStatus(err error) error {
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+
+	var target error
+	switch st.Code() {
+	case codes.NotFound:
+		target = domain.ErrNotFound
+	case codes.AlreadyExists:
+		target = domain.ErrAlreadyExists
+	case codes.InvalidArgument:
+		target = domain.ErrInvalidInput
+		for _, d := range st.Details() {
+			if details, ok := d.(*authv1.ValidationErrorDetails); ok {
+				target = toValidationError(details)
+			}
+		}
+	case codes.Unauthenticated:
+		target = domain.ErrInvalidToken
+	case codes.PermissionDenied:
+		target = domain.ErrForbidden
+	case codes.ResourceExhausted:
+		throttled := &domain.ThrottledError{}
+		for _, d := range st.Details() {
+			if info, ok := d.(*errdetails.RetryInfo); ok {
+				throttled.RetryAfter = info.GetRetryDelay().AsDuration()
+			}
+		}
+		target = throttled
+	default:
+		return err
+	}
+
+	return fmt.Errorf("%s: %w", st.Message(), target)
+}
+
+// toValidationError converts the violations attached to the status to the domain error.
+func toValidationError(details *authv1.ValidationErrorDetails) *domain.ValidationError {
+	violations := make([]domain.FieldViolation, 0, len(details.GetViolations()))
+	for _, v := range details.GetViolations() {
+		violations = append(violations, domain.FieldViolation{
+			Field:       v.GetField(),
+			Code:        v.GetCode(),
+			Description: v.GetDescription(),
+		})
+	}
+
+	return &domain.ValidationError{Violations: violations}
+}
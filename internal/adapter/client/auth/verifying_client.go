@@ -0,0 +1,346 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+	"yadro-microservices/internal/core/domain"
+	"yadro-microservices/internal/core/port"
+)
+
+// changesOverlap is how far back the changes of users are fetched again. A change carries the time
+// its transaction started, so it may become visible after changes made later.
+const changesOverlap = 10 * time.Second
+
+// changesMaxDelay is how many poll intervals the changes of users may fail to be fetched for
+// before tokens are no longer validated locally.
+const changesMaxDelay = 3
+
+// VerifyingClient is an AuthClient validating tokens locally with the public keys of the auth server,
+// so most requests need no round-trip to it. The claims of a token describe the user at the time it was issued:
+// once the user is changed, their older tokens are validated by the auth server, whose answers are cached
+// for a short time, as are its answers for API keys. Sessions logged out through this client are rejected at once.
+// Changes of users and sessions revoked elsewhere, such as through another replica, are fetched from the auth server
+// every poll interval; while they cannot be fetched, all tokens are validated by the auth server.
+type VerifyingClient struct {
+	port.AuthClient
+	verifier     port.TokenVerifier
+	cacheTTL     time.Duration
+	pollInterval time.Duration
+
+	mu        sync.Mutex
+	changed   map[string]time.Time // Time of the last change of the user by username
+	revoked   map[string]time.Time // Time the session was revoked by session ID
+	validated map[[sha256.Size]byte]validation
+	since     time.Time // Time of the auth server to fetch the next changes since
+	syncedAt  time.Time // Time the changes were last fetched
+}
+
+// validation is a cached answer of the auth server.
+type validation struct {
+	principal *domain.Principal
+	expiresAt time.Time
+}
+
+// NewVerifyingClient creates a new instance of VerifyingClient. Answers of the auth server are cached for cacheTTL,
+// and changes of users are fetched every pollInterval once Watch is started.
+func NewVerifyingClient(
+	client port.AuthClient,
+	verifier port.TokenVerifier,
+	cacheTTL time.Duration,
+	pollInterval time.Duration,
+) *VerifyingClient {
+	return &VerifyingClient{
+		AuthClient:   client,
+		verifier:     verifier,
+		cacheTTL:     cacheTTL,
+		pollInterval: pollInterval,
+		changed:      make(map[string]time.Time),
+		revoked:      make(map[string]time.Time),
+		validated:    make(map[[sha256.Size]byte]validation),
+	}
+}
+
+// Watch fetches the changes of users from the auth server every poll interval until the context is done.
+func (c *VerifyingClient) Watch(ctx context.Context) {
+	ticker := time.NewTicker(c.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := c.poll(ctx); err != nil {
+			log.Println("Error fetching changes of users:", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll fetches the changes of users and the sessions revoked since the last poll. Older tokens of the changed users
+// are validated by the auth server from now on, and tokens of the revoked sessions are rejected.
+func (c *VerifyingClient) poll(ctx context.Context) error {
+	c.mu.Lock()
+	since := c.since
+	c.mu.Unlock()
+
+	changes, err := c.AuthClient.GetChanges(ctx, since)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, u := range changes.Users {
+		c.changeUser(u.Username, u.ChangedAt)
+	}
+	for _, rev := range changes.Sessions {
+		c.revoked[rev.SessionID] = rev.RevokedAt
+	}
+	forget(c.changed, changes.Now.Add(-changes.TokenMaxTime))
+	forget(c.revoked, changes.Now.Add(-changes.TokenMaxTime))
+	c.since = changes.Now.Add(-changesOverlap)
+	c.syncedAt = time.Now()
+
+	return nil
+}
+
+// ValidateToken verifies the token locally and returns the principal described by its claims.
+// Tokens issued before the last change of their user, and all tokens while the changes of users
+// cannot be fetched, are validated by the auth server.
+func (c *VerifyingClient) ValidateToken(ctx context.Context, token string) (*domain.Principal, error) {
+	claims, err := c.verifier.Verify(token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to validate token: %w", err)
+	}
+
+	if c.isRevoked(claims.SessionID) {
+		return nil, fmt.Errorf("failed to validate token: %w", domain.ErrInvalidToken)
+	}
+
+	if c.inSync() && !c.changedSince(claims.Username, claims.IssuedAt) {
+		return &domain.Principal{
+			Username:        claims.Username,
+			Role:            claims.Role,
+			Permissions:     claims.Permissions,
+			ExpiresAt:       claims.ExpiresAt,
+			HistoryDisabled: claims.HistoryDisabled,
+		}, nil
+	}
+
+	key := sha256.Sum256([]byte(token))
+	if p := c.cached(key); p != nil {
+		return p, nil
+	}
+
+	p, err := c.AuthClient.ValidateToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	c.cache(key, p)
+	return p, nil
+}
+
+// ValidateAPIKey validates the API key by the auth server. Its answers are cached like those for tokens,
+// so a key revoked or changed through another replica is accepted until its answer expires.
+func (c *VerifyingClient) ValidateAPIKey(ctx context.Context, apiKey string) (*domain.Principal, error) {
+	key := sha256.Sum256([]byte(apiKey))
+	if p := c.cached(key); p != nil {
+		return p, nil
+	}
+
+	p, err := c.AuthClient.ValidateAPIKey(ctx, apiKey)
+	if err != nil {
+		return nil, err
+	}
+
+	c.cache(key, p)
+	return p, nil
+}
+
+// RevokeAPIKey revokes the API key of the user. Cached answers for the keys of the user are dropped,
+// so the key is rejected at once by this client.
+func (c *VerifyingClient) RevokeAPIKey(ctx context.Context, username string, id int64) error {
+	if err := c.AuthClient.RevokeAPIKey(ctx, username, id); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.dropCached(username)
+
+	return nil
+}
+
+// SetHistoryDisabled sets whether search history of the user is recorded.
+func (c *VerifyingClient) SetHistoryDisabled(ctx context.Context, username string, disabled bool) error {
+	if err := c.AuthClient.SetHistoryDisabled(ctx, username, disabled); err != nil {
+		return err
+	}
+
+	c.userChanged(username)
+	return nil
+}
+
+// SetRole changes the role of the user.
+func (c *VerifyingClient) SetRole(ctx context.Context, username string, role domain.Role) error {
+	if err := c.AuthClient.SetRole(ctx, username, role); err != nil {
+		return err
+	}
+
+	c.userChanged(username)
+	return nil
+}
+
+// SetDisabled disables or enables the user.
+func (c *VerifyingClient) SetDisabled(ctx context.Context, username string, disabled bool) error {
+	if err := c.AuthClient.SetDisabled(ctx, username, disabled); err != nil {
+		return err
+	}
+
+	c.userChanged(username)
+	return nil
+}
+
+// DeleteUser deletes the user.
+func (c *VerifyingClient) DeleteUser(ctx context.Context, username string) error {
+	if err := c.AuthClient.DeleteUser(ctx, username); err != nil {
+		return err
+	}
+
+	c.userChanged(username)
+	return nil
+}
+
+// ChangePassword replaces the password of the user, which revokes all their sessions.
+func (c *VerifyingClient) ChangePassword(
+	ctx context.Context,
+	username, oldPassword, newPassword, clientIP string,
+) error {
+	if err := c.AuthClient.ChangePassword(ctx, username, oldPassword, newPassword, clientIP); err != nil {
+		return err
+	}
+
+	c.userChanged(username)
+	return nil
+}
+
+// Logout revokes the session of the token.
+func (c *VerifyingClient) Logout(ctx context.Context, token string) error {
+	if err := c.AuthClient.Logout(ctx, token); err != nil {
+		return err
+	}
+
+	if claims, err := c.verifier.Verify(token); err == nil && claims.SessionID != "" {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		c.revoked[claims.SessionID] = time.Now()
+	}
+
+	return nil
+}
+
+// userChanged makes the tokens of the user issued until now to be validated by the auth server.
+func (c *VerifyingClient) userChanged(username string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.changeUser(username, time.Now())
+}
+
+// changeUser makes the tokens of the user issued until changedAt to be validated by the auth server.
+// The caller must hold the mutex.
+func (c *VerifyingClient) changeUser(username string, changedAt time.Time) {
+	if last, ok := c.changed[username]; ok && !changedAt.After(last) {
+		return
+	}
+	c.changed[username] = changedAt
+
+	// Answers cached before the change are outdated
+	c.dropCached(username)
+}
+
+// dropCached deletes the cached answers of the auth server for the user. The caller must hold the mutex.
+func (c *VerifyingClient) dropCached(username string) {
+	for k, v := range c.validated {
+		if v.principal.Username == username {
+			delete(c.validated, k)
+		}
+	}
+}
+
+// inSync reports whether the changes of users were fetched recently enough to validate tokens locally.
+func (c *VerifyingClient) inSync() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return !c.syncedAt.IsZero() && time.Since(c.syncedAt) <= changesMaxDelay*c.pollInterval
+}
+
+// changedSince reports whether the user was changed after the given time.
+func (c *VerifyingClient) changedSince(username string, issuedAt time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	changedAt, ok := c.changed[username]
+	return ok && !issuedAt.After(changedAt)
+}
+
+// isRevoked reports whether the session was logged out through this client or its revocation was fetched.
+func (c *VerifyingClient) isRevoked(sessionID string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	_, ok := c.revoked[sessionID]
+	return ok
+}
+
+// cached returns the cached answer of the auth server for the token, or nil if there is none.
+func (c *VerifyingClient) cached(key [sha256.Size]byte) *domain.Principal {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	v, ok := c.validated[key]
+	if !ok || time.Now().After(v.expiresAt) {
+		return nil
+	}
+
+	return v.principal
+}
+
+// cache caches the answer of the auth server for the token or the API key for the cache TTL,
+// or until the principal expires if it is sooner. Expired answers are deleted.
+func (c *VerifyingClient) cache(key [sha256.Size]byte, p *domain.Principal) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	for k, v := range c.validated {
+		if now.After(v.expiresAt) {
+			delete(c.validated, k)
+		}
+	}
+
+	expiresAt := now.Add(c.cacheTTL)
+	if !p.ExpiresAt.IsZero() && p.ExpiresAt.Before(expiresAt) {
+		expiresAt = p.ExpiresAt
+	}
+	c.validated[key] = validation{principal: p, expiresAt: expiresAt}
+}
+
+// forget deletes the entries older than the given time, as all tokens issued before them have expired.
+func forget(entries map[string]time.Time, before time.Time) {
+	for k, t := range entries {
+		if t.Before(before) {
+			delete(entries, k)
+		}
+	}
+}
@@ -0,0 +1,254 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"yadro-microservices/internal/core/domain"
+	"yadro-microservices/internal/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// newSyncedClient creates a VerifyingClient which has fetched the changes of users, finding none.
+func newSyncedClient(t *testing.T, client *mocks.AuthClient, verifier *mocks.TokenVerifier) *VerifyingClient {
+	t.Helper()
+	client.On("GetChanges", mock.Anything, time.Time{}).
+		Return(&domain.Changes{Now: time.Now(), TokenMaxTime: time.Hour}, nil).Once()
+
+	vc := NewVerifyingClient(client, verifier, time.Minute, time.Minute)
+	require.NoError(t, vc.poll(context.Background()))
+	return vc
+}
+
+func TestVerifyingClient_ValidateTokenLocally(t *testing.T) {
+	client := new(mocks.AuthClient)
+	verifier := new(mocks.TokenVerifier)
+	expiresAt := time.Now().Add(time.Hour)
+	verifier.On("Verify", "token").Return(&domain.TokenClaims{
+		Username:        "user",
+		Role:            domain.ADMIN,
+		Permissions:     []domain.Permission{domain.PermComicsUpdate},
+		HistoryDisabled: true,
+		IssuedAt:        time.Now(),
+		ExpiresAt:       expiresAt,
+	}, nil)
+
+	vc := newSyncedClient(t, client, verifier)
+	user, err := vc.ValidateToken(context.Background(), "token")
+
+	require.NoError(t, err)
+	assert.Equal(t, &domain.Principal{
+		Username:        "user",
+		Role:            domain.ADMIN,
+		Permissions:     []domain.Permission{domain.PermComicsUpdate},
+		ExpiresAt:       expiresAt,
+		HistoryDisabled: true,
+	}, user)
+	client.AssertNotCalled(t, "ValidateToken")
+}
+
+func TestVerifyingClient_ValidateTokenInvalid(t *testing.T) {
+	client := new(mocks.AuthClient)
+	verifier := new(mocks.TokenVerifier)
+	verifier.On("Verify", "token").Return(nil, errors.New("token is expired"))
+
+	vc := newSyncedClient(t, client, verifier)
+	_, err := vc.ValidateToken(context.Background(), "token")
+
+	require.Error(t, err)
+	client.AssertNotCalled(t, "ValidateToken")
+}
+
+func TestVerifyingClient_ValidateTokenAfterChange(t *testing.T) {
+	ctx := context.Background()
+	client := new(mocks.AuthClient)
+	verifier := new(mocks.TokenVerifier)
+	issuedAt := time.Now().Add(-time.Minute)
+	verifier.On("Verify", "old").Return(&domain.TokenClaims{Username: "user", IssuedAt: issuedAt}, nil)
+	verifier.On("Verify", "other").Return(&domain.TokenClaims{Username: "other", IssuedAt: issuedAt}, nil)
+	client.On("SetHistoryDisabled", ctx, "user", true).Return(nil).Once()
+	client.On("ValidateToken", ctx, "old").Return(&domain.Principal{Username: "user", HistoryDisabled: true}, nil).Once()
+
+	vc := newSyncedClient(t, client, verifier)
+	require.NoError(t, vc.SetHistoryDisabled(ctx, "user", true))
+
+	// Tokens issued before the change are validated by the auth server once and then cached
+	for range 2 {
+		user, err := vc.ValidateToken(ctx, "old")
+		require.NoError(t, err)
+		assert.True(t, user.HistoryDisabled)
+	}
+
+	// Tokens of other users are still validated locally
+	_, err := vc.ValidateToken(ctx, "other")
+	require.NoError(t, err)
+	client.AssertExpectations(t)
+}
+
+func TestVerifyingClient_SetHistoryDisabledError(t *testing.T) {
+	ctx := context.Background()
+	client := new(mocks.AuthClient)
+	verifier := new(mocks.TokenVerifier)
+	verifier.On("Verify", "token").Return(&domain.TokenClaims{Username: "user", IssuedAt: time.Now()}, nil)
+	client.On("SetHistoryDisabled", ctx, "user", true).Return(errors.New("unavailable")).Once()
+
+	vc := newSyncedClient(t, client, verifier)
+	require.Error(t, vc.SetHistoryDisabled(ctx, "user", true))
+
+	// The user has not changed, so the token is still validated locally
+	_, err := vc.ValidateToken(ctx, "token")
+	require.NoError(t, err)
+	client.AssertExpectations(t)
+}
+
+func TestVerifyingClient_Logout(t *testing.T) {
+	ctx := context.Background()
+	client := new(mocks.AuthClient)
+	verifier := new(mocks.TokenVerifier)
+	claims := &domain.TokenClaims{Username: "user", SessionID: "s1", IssuedAt: time.Now()}
+	verifier.On("Verify", "token").Return(claims, nil)
+	verifier.On("Verify", "other").Return(&domain.TokenClaims{Username: "user", SessionID: "s2"}, nil)
+	client.On("Logout", ctx, "token").Return(nil).Once()
+
+	vc := newSyncedClient(t, client, verifier)
+	require.NoError(t, vc.Logout(ctx, "token"))
+
+	// The logged out session is rejected at once, other sessions of the user are not affected
+	_, err := vc.ValidateToken(ctx, "token")
+	require.ErrorIs(t, err, domain.ErrInvalidToken)
+	_, err = vc.ValidateToken(ctx, "other")
+	require.NoError(t, err)
+	client.AssertExpectations(t)
+}
+
+func TestVerifyingClient_ValidateTokenAfterDisable(t *testing.T) {
+	ctx := context.Background()
+	client := new(mocks.AuthClient)
+	verifier := new(mocks.TokenVerifier)
+	verifier.On("Verify", "token").Return(&domain.TokenClaims{
+		Username: "user",
+		IssuedAt: time.Now().Add(-time.Minute),
+	}, nil)
+	client.On("SetDisabled", ctx, "user", true).Return(nil).Once()
+	client.On("ValidateToken", ctx, "token").Return(nil, domain.ErrInvalidToken).Once()
+
+	vc := newSyncedClient(t, client, verifier)
+	require.NoError(t, vc.SetDisabled(ctx, "user", true))
+
+	_, err := vc.ValidateToken(ctx, "token")
+	require.ErrorIs(t, err, domain.ErrInvalidToken)
+	client.AssertExpectations(t)
+}
+
+func TestVerifyingClient_ValidateTokenAfterRemoteChange(t *testing.T) {
+	ctx := context.Background()
+	client := new(mocks.AuthClient)
+	verifier := new(mocks.TokenVerifier)
+	issuedAt := time.Now().Add(-time.Minute)
+	verifier.On("Verify", "token").Return(&domain.TokenClaims{Username: "user", IssuedAt: issuedAt}, nil)
+	client.On("ValidateToken", ctx, "token").Return(nil, domain.ErrInvalidToken).Once()
+
+	vc := newSyncedClient(t, client, verifier)
+	_, err := vc.ValidateToken(ctx, "token")
+	require.NoError(t, err)
+
+	// The user is disabled through another replica
+	now := time.Now()
+	client.On("GetChanges", ctx, mock.Anything).Return(&domain.Changes{
+		Users:        []*domain.UserChange{{Username: "user", ChangedAt: now}},
+		Now:          now,
+		TokenMaxTime: time.Hour,
+	}, nil).Once()
+	require.NoError(t, vc.poll(ctx))
+
+	_, err = vc.ValidateToken(ctx, "token")
+	require.ErrorIs(t, err, domain.ErrInvalidToken)
+	client.AssertExpectations(t)
+}
+
+func TestVerifyingClient_ValidateTokenOutOfSync(t *testing.T) {
+	ctx := context.Background()
+	client := new(mocks.AuthClient)
+	verifier := new(mocks.TokenVerifier)
+	verifier.On("Verify", "token").Return(&domain.TokenClaims{Username: "user", IssuedAt: time.Now()}, nil)
+	client.On("GetChanges", ctx, time.Time{}).Return(nil, errors.New("unavailable")).Once()
+	client.On("ValidateToken", ctx, "token").Return(&domain.Principal{Username: "user"}, nil).Once()
+
+	// Without the changes of users the token is validated by the auth server
+	vc := NewVerifyingClient(client, verifier, time.Minute, time.Minute)
+	require.Error(t, vc.poll(ctx))
+
+	user, err := vc.ValidateToken(ctx, "token")
+	require.NoError(t, err)
+	assert.Equal(t, "user", user.Username)
+	client.AssertExpectations(t)
+}
+
+func TestVerifyingClient_ValidateTokenRemotelyRevoked(t *testing.T) {
+	ctx := context.Background()
+	client := new(mocks.AuthClient)
+	verifier := new(mocks.TokenVerifier)
+	claims := &domain.TokenClaims{Username: "user", SessionID: "s1", IssuedAt: time.Now()}
+	verifier.On("Verify", "token").Return(claims, nil)
+
+	// The session is logged out through another replica
+	vc := newSyncedClient(t, client, verifier)
+	now := time.Now()
+	client.On("GetChanges", ctx, mock.Anything).Return(&domain.Changes{
+		Sessions:     []*domain.SessionRevocation{{SessionID: "s1", RevokedAt: now}},
+		Now:          now,
+		TokenMaxTime: time.Hour,
+	}, nil).Once()
+	require.NoError(t, vc.poll(ctx))
+
+	_, err := vc.ValidateToken(ctx, "token")
+	require.ErrorIs(t, err, domain.ErrInvalidToken)
+	client.AssertExpectations(t)
+}
+
+func TestVerifyingClient_ValidateAPIKey(t *testing.T) {
+	ctx := context.Background()
+	client := new(mocks.AuthClient)
+	principal := &domain.Principal{Username: "user", Permissions: []domain.Permission{domain.PermComicsSearch}}
+	client.On("ValidateAPIKey", ctx, "xk_key").Return(principal, nil).Twice()
+	client.On("ValidateAPIKey", ctx, "xk_wrong").Return(nil, domain.ErrInvalidToken).Twice()
+	client.On("RevokeAPIKey", ctx, "user", int64(1)).Return(nil).Once()
+
+	vc := NewVerifyingClient(client, new(mocks.TokenVerifier), time.Minute, time.Minute)
+
+	// Valid keys are validated by the auth server once and then cached, invalid ones every time
+	for range 2 {
+		p, err := vc.ValidateAPIKey(ctx, "xk_key")
+		require.NoError(t, err)
+		assert.Equal(t, principal, p)
+
+		_, err = vc.ValidateAPIKey(ctx, "xk_wrong")
+		require.ErrorIs(t, err, domain.ErrInvalidToken)
+	}
+
+	// Revoking a key of the user drops the cached answers
+	require.NoError(t, vc.RevokeAPIKey(ctx, "user", 1))
+	_, err := vc.ValidateAPIKey(ctx, "xk_key")
+	require.NoError(t, err)
+	client.AssertExpectations(t)
+}
+
+func TestVerifyingClient_ValidateAPIKeyExpiring(t *testing.T) {
+	ctx := context.Background()
+	client := new(mocks.AuthClient)
+	expiring := &domain.Principal{Username: "user", ExpiresAt: time.Now().Add(-time.Second)}
+	client.On("ValidateAPIKey", ctx, "xk_key").Return(expiring, nil).Twice()
+
+	vc := NewVerifyingClient(client, new(mocks.TokenVerifier), time.Minute, time.Minute)
+
+	// The answer is not cached beyond the expiration of the key
+	for range 2 {
+		_, err := vc.ValidateAPIKey(ctx, "xk_key")
+		require.NoError(t, err)
+	}
+	client.AssertExpectations(t)
+}
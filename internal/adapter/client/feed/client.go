@@ -0,0 +1,83 @@
+package feed
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"strings"
+	"yadro-microservices/internal/core/domain"
+	"yadro-microservices/internal/core/port"
+	"yadro-microservices/pkg/feed"
+)
+
+// ComicClient retrieves comics from an RSS or Atom feed.
+type ComicClient struct {
+	client    *feed.Client
+	url       string
+	processor port.ComicProcessor
+}
+
+// NewComicClient creates a new instance of feed comic client for the feed at the given URL.
+func NewComicClient(client *feed.Client, url string, processor port.ComicProcessor) *ComicClient {
+	return &ComicClient{
+		client:    client,
+		url:       url,
+		processor: processor,
+	}
+}
+
+// GetComics retrieves the feed items which are not stored yet and converts them to comics.
+// Feed items have no numeric IDs, so the ID of a comic is derived from the GUID of its item.
+// Items whose IDs collide with another item of the feed are logged and skipped, so neither overwrites the other.
+func (cc *ComicClient) GetComics(ctx context.Context, existingIDs map[int]bool) (domain.Comics, error) {
+	items, err := cc.client.Fetch(ctx, cc.url)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching feed %s: %w", cc.url, err)
+	}
+
+	guids := make(map[int]string, len(items))
+	for _, item := range items {
+		id := ItemID(item.GUID)
+		if guid, ok := guids[id]; ok {
+			if guid != item.GUID {
+				log.Printf("Skipping feed item %q of %s: its ID %d collides with item %q", item.GUID, cc.url, id, guid)
+			}
+			continue
+		}
+		guids[id] = item.GUID
+	}
+
+	comics := make(domain.Comics, len(items))
+	for _, item := range items {
+		id := ItemID(item.GUID)
+		if existingIDs[id] || guids[id] != item.GUID {
+			continue // Skip if the comic ID already exists or belongs to a colliding item
+		}
+
+		kw, err := cc.processor.FullProcess(strings.Join([]string{item.Title, item.Description}, " "))
+		if err != nil {
+			return nil, fmt.Errorf("error extracting keywords: %w", err)
+		}
+
+		img := item.Image
+		if img == "" {
+			img = item.Link
+		}
+
+		comics[id] = &domain.Comic{
+			Title:    item.Title,
+			Img:      img,
+			Keywords: kw,
+		}
+	}
+
+	return comics, nil
+}
+
+// ItemID returns the comic ID for the feed item with the given GUID.
+func ItemID(guid string) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(guid))
+	return int(h.Sum32())
+}
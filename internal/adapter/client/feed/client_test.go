@@ -0,0 +1,86 @@
+package feed
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"yadro-microservices/pkg/feed"
+	"yadro-microservices/pkg/words"
+)
+
+const rssFeed = `<?xml version="1.0"?>
+<rss version="2.0">
+  <channel>
+    <item>
+      <title>Doctor Apple</title>
+      <guid>comic-1</guid>
+      <enclosure url="https://example.com/1.png" type="image/png"/>
+    </item>
+    <item>
+      <title>Old Comic</title>
+      <guid>comic-0</guid>
+      <link>https://example.com/0</link>
+    </item>
+  </channel>
+</rss>`
+
+func TestComicClient_GetComics(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, err := w.Write([]byte(rssFeed))
+		assert.NoError(t, err)
+	}))
+	defer mockServer.Close()
+
+	cc := NewComicClient(feed.NewClient(), mockServer.URL, words.NewTextProcessor("en", ""))
+	comics, err := cc.GetComics(context.Background(), map[int]bool{ItemID("comic-0"): true})
+
+	require.NoError(t, err)
+	assert.Len(t, comics, 1)
+	comic := comics[ItemID("comic-1")]
+	require.NotNil(t, comic)
+	assert.Equal(t, "Doctor Apple", comic.Title)
+	assert.Equal(t, "https://example.com/1.png", comic.Img)
+	assert.ElementsMatch(t, []string{"doctor", "appl"}, comic.Keywords)
+}
+
+func TestComicClient_GetComics_FetchError(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer mockServer.Close()
+
+	cc := NewComicClient(feed.NewClient(), mockServer.URL, words.NewTextProcessor("en", ""))
+	_, err := cc.GetComics(context.Background(), map[int]bool{})
+
+	require.Error(t, err)
+}
+
+func TestComicClient_GetComics_Collision(t *testing.T) {
+	// The GUIDs of the first two items have the same FNV-32a hash
+	const collidingFeed = `<?xml version="1.0"?>
+<rss version="2.0">
+  <channel>
+    <item><title>First</title><guid>comic-89962</guid><link>https://example.com/1</link></item>
+    <item><title>Second</title><guid>comic-1236000</guid><link>https://example.com/2</link></item>
+    <item><title>Third</title><guid>comic-3</guid><link>https://example.com/3</link></item>
+  </channel>
+</rss>`
+	require.Equal(t, ItemID("comic-89962"), ItemID("comic-1236000"))
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, err := w.Write([]byte(collidingFeed))
+		assert.NoError(t, err)
+	}))
+	defer mockServer.Close()
+
+	cc := NewComicClient(feed.NewClient(), mockServer.URL, words.NewTextProcessor("en", ""))
+	comics, err := cc.GetComics(context.Background(), map[int]bool{})
+
+	require.NoError(t, err)
+	assert.Len(t, comics, 2)
+	assert.Equal(t, "First", comics[ItemID("comic-89962")].Title, "the colliding item must not overwrite the first one")
+	assert.Equal(t, "Third", comics[ItemID("comic-3")].Title)
+}
@@ -0,0 +1,86 @@
+package jsondir
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"yadro-microservices/internal/core/domain"
+	"yadro-microservices/internal/core/port"
+	"yadro-microservices/pkg/xkcd"
+)
+
+// ComicClient retrieves comics from a local directory with JSON dumps.
+// Every *.json file in the directory tree holds a single comic in the xkcd info.0.json format.
+type ComicClient struct {
+	dir       string
+	processor port.ComicProcessor
+}
+
+// NewComicClient creates a new instance of JSON directory comic client.
+func NewComicClient(dir string, processor port.ComicProcessor) *ComicClient {
+	return &ComicClient{
+		dir:       dir,
+		processor: processor,
+	}
+}
+
+// GetComics reads the comics which are not stored yet from the directory.
+func (cc *ComicClient) GetComics(ctx context.Context, existingIDs map[int]bool) (domain.Comics, error) {
+	comics := make(domain.Comics)
+	err := filepath.WalkDir(cc.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if d.IsDir() || filepath.Ext(path) != ".json" {
+			return nil
+		}
+
+		comic, err := readComic(path)
+		if err != nil {
+			return err
+		}
+		if existingIDs[comic.Num] {
+			return nil // Skip if the comic ID already exists
+		}
+
+		kw, err := cc.processor.FullProcess(strings.Join([]string{comic.Alt, comic.Transcript, comic.Title}, " "))
+		if err != nil {
+			return fmt.Errorf("error extracting keywords: %w", err)
+		}
+
+		comics[comic.Num] = &domain.Comic{
+			Title:    comic.Title,
+			Img:      comic.Img,
+			Keywords: kw,
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error reading comics from %s: %w", cc.dir, err)
+	}
+
+	return comics, nil
+}
+
+// readComic decodes a single comic dump file.
+func readComic(path string) (*xkcd.ComicResponse, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading file %s: %w", path, err)
+	}
+
+	var comic xkcd.ComicResponse
+	if err = json.Unmarshal(data, &comic); err != nil {
+		return nil, fmt.Errorf("error decoding file %s: %w", path, err)
+	}
+
+	return &comic, nil
+}
@@ -0,0 +1,56 @@
+package jsondir
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+	"yadro-microservices/pkg/words"
+	"yadro-microservices/pkg/xkcd"
+)
+
+func writeComic(t *testing.T, path string, comic xkcd.ComicResponse) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	data, err := json.Marshal(comic)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+}
+
+func TestComicClient_GetComics(t *testing.T) {
+	dir := t.TempDir()
+	writeComic(t, filepath.Join(dir, "1", "info.0.json"), xkcd.ComicResponse{
+		Num:   1,
+		Title: "Barrel",
+		Img:   "https://example.com/1.png",
+	})
+	writeComic(t, filepath.Join(dir, "2.json"), xkcd.ComicResponse{
+		Num:   2,
+		Title: "Petit Trees",
+		Img:   "https://example.com/2.png",
+		Alt:   "Sketch",
+	})
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("not a comic"), 0o600))
+
+	cc := NewComicClient(dir, words.NewTextProcessor("en", ""))
+	comics, err := cc.GetComics(context.Background(), map[int]bool{1: true})
+
+	require.NoError(t, err)
+	assert.Len(t, comics, 1)
+	assert.Equal(t, "Petit Trees", comics[2].Title)
+	assert.Equal(t, "https://example.com/2.png", comics[2].Img)
+	assert.ElementsMatch(t, []string{"sketch", "petit", "tree"}, comics[2].Keywords)
+}
+
+func TestComicClient_GetComics_InvalidFile(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "1.json"), []byte("{invalid json}"), 0o600))
+
+	cc := NewComicClient(dir, words.NewTextProcessor("en", ""))
+	_, err := cc.GetComics(context.Background(), map[int]bool{})
+
+	require.Error(t, err)
+}
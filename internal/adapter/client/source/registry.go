@@ -0,0 +1,115 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"yadro-microservices/internal/core/domain"
+	"yadro-microservices/internal/core/port"
+)
+
+// NamespaceSize is the number of comic IDs reserved for every source.
+// Comic with local ID n from the source in namespace k is stored with ID k*NamespaceSize + n.
+const NamespaceSize = 1 << 32
+
+// Source is a named comic client registered in the Registry.
+type Source struct {
+	Name      string
+	Namespace int
+	Client    port.ComicClient
+}
+
+// Registry combines several comic sources into a single port.ComicClient.
+// Each source owns its own range of comic IDs, so sources never overwrite each other's comics.
+type Registry struct {
+	sources []*Source
+}
+
+// NewRegistry creates a new empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register adds the comic client to the registry under the given name and ID namespace.
+func (r *Registry) Register(name string, namespace int, client port.ComicClient) error {
+	if name == "" {
+		return errors.New("source name is empty")
+	}
+	if namespace < 0 || namespace >= NamespaceSize>>1 {
+		return fmt.Errorf("source %s: namespace %d is out of range", name, namespace)
+	}
+
+	for _, s := range r.sources {
+		if s.Name == name {
+			return fmt.Errorf("source %s is already registered", name)
+		}
+		if s.Namespace == namespace {
+			return fmt.Errorf("source %s: namespace %d is already used by source %s", name, namespace, s.Name)
+		}
+	}
+
+	r.sources = append(r.sources, &Source{
+		Name:      name,
+		Namespace: namespace,
+		Client:    client,
+	})
+
+	return nil
+}
+
+// Sources returns the registered sources.
+func (r *Registry) Sources() []*Source {
+	return r.sources
+}
+
+// GetComics retrieves new comics from all registered sources.
+// A failing source does not prevent the others from being processed: the comics retrieved from the rest
+// are returned together with the error. If all sources fail, no comics are returned.
+func (r *Registry) GetComics(ctx context.Context, existingIDs map[int]bool) (domain.Comics, error) {
+	comics := make(domain.Comics)
+	var errs []error
+
+	for _, s := range r.sources {
+		localIDs := make(map[int]bool)
+		for id := range existingIDs {
+			if namespace, localID := split(id); namespace == s.Namespace {
+				localIDs[localID] = true
+			}
+		}
+
+		log.Printf("Retrieving comics data from source %s...", s.Name)
+		sourceComics, err := s.Client.GetComics(ctx, localIDs)
+		if err != nil {
+			log.Printf("Error retrieving comics from source %s: %v", s.Name, err)
+			errs = append(errs, fmt.Errorf("source %s: %w", s.Name, err))
+			continue
+		}
+
+		for localID, comic := range sourceComics {
+			if localID < 0 || localID >= NamespaceSize {
+				log.Printf("Skipping comic %d from source %s: ID is out of namespace range", localID, s.Name)
+				continue
+			}
+
+			comic.Source = s.Name
+			comics[ID(s.Namespace, localID)] = comic
+		}
+	}
+
+	if len(errs) > 0 && len(errs) == len(r.sources) {
+		return nil, errors.Join(errs...)
+	}
+
+	return comics, errors.Join(errs...)
+}
+
+// ID returns the global comic ID for the local ID of a comic in the given namespace.
+func ID(namespace, localID int) int {
+	return namespace*NamespaceSize + localID
+}
+
+// split splits the global comic ID into the namespace and the local ID.
+func split(id int) (int, int) {
+	return id / NamespaceSize, id % NamespaceSize
+}
@@ -0,0 +1,83 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"yadro-microservices/internal/core/domain"
+	"yadro-microservices/internal/mocks"
+)
+
+func TestRegistry_GetComics(t *testing.T) {
+	xkcdClient := new(mocks.ComicClient)
+	feedClient := new(mocks.ComicClient)
+
+	registry := NewRegistry()
+	require.NoError(t, registry.Register("xkcd", 0, xkcdClient))
+	require.NoError(t, registry.Register("feed", 1, feedClient))
+
+	existingIDs := map[int]bool{1: true, ID(1, 7): true}
+	xkcdClient.On("GetComics", mock.Anything, map[int]bool{1: true}).Return(domain.Comics{
+		2: {Img: "https://example.com/xkcd2.png"},
+	}, nil).Once()
+	feedClient.On("GetComics", mock.Anything, map[int]bool{7: true}).Return(domain.Comics{
+		2: {Img: "https://example.com/feed2.png"},
+	}, nil).Once()
+
+	comics, err := registry.GetComics(context.Background(), existingIDs)
+
+	require.NoError(t, err)
+	assert.Len(t, comics, 2)
+	assert.Equal(t, &domain.Comic{Source: "xkcd", Img: "https://example.com/xkcd2.png"}, comics[2])
+	assert.Equal(t, &domain.Comic{Source: "feed", Img: "https://example.com/feed2.png"}, comics[ID(1, 2)])
+	xkcdClient.AssertExpectations(t)
+	feedClient.AssertExpectations(t)
+}
+
+func TestRegistry_GetComics_PartialFailure(t *testing.T) {
+	xkcdClient := new(mocks.ComicClient)
+	feedClient := new(mocks.ComicClient)
+
+	registry := NewRegistry()
+	require.NoError(t, registry.Register("xkcd", 0, xkcdClient))
+	require.NoError(t, registry.Register("feed", 1, feedClient))
+
+	xkcdClient.On("GetComics", mock.Anything, mock.Anything).Return(nil, errors.New("source error")).Once()
+	feedClient.On("GetComics", mock.Anything, mock.Anything).Return(domain.Comics{
+		1: {Img: "https://example.com/feed1.png"},
+	}, nil).Once()
+
+	comics, err := registry.GetComics(context.Background(), map[int]bool{})
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "source xkcd")
+	assert.Len(t, comics, 1)
+	assert.Contains(t, comics, ID(1, 1))
+}
+
+func TestRegistry_GetComics_AllFailed(t *testing.T) {
+	xkcdClient := new(mocks.ComicClient)
+
+	registry := NewRegistry()
+	require.NoError(t, registry.Register("xkcd", 0, xkcdClient))
+
+	xkcdClient.On("GetComics", mock.Anything, mock.Anything).Return(nil, errors.New("source error")).Once()
+
+	_, err := registry.GetComics(context.Background(), map[int]bool{})
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "source xkcd")
+}
+
+func TestRegistry_Register_Duplicates(t *testing.T) {
+	registry := NewRegistry()
+	require.NoError(t, registry.Register("xkcd", 0, new(mocks.ComicClient)))
+
+	require.Error(t, registry.Register("xkcd", 1, new(mocks.ComicClient)))
+	require.Error(t, registry.Register("feed", 0, new(mocks.ComicClient)))
+	require.Error(t, registry.Register("", 2, new(mocks.ComicClient)))
+	require.Error(t, registry.Register("feed", -1, new(mocks.ComicClient)))
+}
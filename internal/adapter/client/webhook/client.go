@@ -0,0 +1,250 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+	"yadro-microservices/internal/core/domain"
+)
+
+// Headers of the signed event deliveries.
+const (
+	HeaderEventID   = "X-Webhook-ID"
+	HeaderEventType = "X-Webhook-Event"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// sharedAddressSpace is the carrier-grade NAT range, which is not routed on the internet either.
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// Allowlist lists the internal hosts and networks webhooks are allowed on although they are not public.
+type Allowlist struct {
+	hosts    map[string]bool
+	networks []*net.IPNet
+}
+
+// ParseAllowlist parses the entries of the allowlist, each of which is a host name, an IP address or a CIDR.
+func ParseAllowlist(entries []string) (*Allowlist, error) {
+	a := &Allowlist{hosts: make(map[string]bool)}
+	for _, entry := range entries {
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			a.networks = append(a.networks, network)
+			continue
+		}
+
+		if ip := net.ParseIP(entry); ip != nil {
+			if ip4 := ip.To4(); ip4 != nil {
+				ip = ip4
+			}
+			a.networks = append(a.networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(len(ip)*8, len(ip)*8)})
+			continue
+		}
+
+		if entry == "" || strings.ContainsAny(entry, "/:[] ") {
+			return nil, fmt.Errorf("invalid allowed host or network %q", entry)
+		}
+		a.hosts[strings.ToLower(entry)] = true
+	}
+
+	return a, nil
+}
+
+// allowsHost reports whether the host is allowed regardless of its addresses.
+func (a *Allowlist) allowsHost(host string) bool {
+	return a != nil && a.hosts[strings.ToLower(host)]
+}
+
+// allowsIP reports whether the address is public or belongs to an allowed network.
+func (a *Allowlist) allowsIP(ip net.IP) bool {
+	if publicIP(ip) {
+		return true
+	}
+	if a == nil {
+		return false
+	}
+
+	for _, network := range a.networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Client posts notifications to webhooks as JSON. Unless private networks are allowed, it connects only
+// to public addresses and to the allowlisted ones, so webhooks cannot reach other internal services of the deployment.
+type Client struct {
+	client       *http.Client
+	allowPrivate bool
+	allowed      *Allowlist
+}
+
+// NewClient creates a new instance of Client. Webhooks on private, loopback and link-local addresses
+// are refused unless allowPrivate is set or they are allowed by the allowlist, which may be nil.
+func NewClient(timeout time.Duration, allowPrivate bool, allowed *Allowlist) *Client {
+	c := &Client{
+		allowPrivate: allowPrivate,
+		allowed:      allowed,
+	}
+
+	dialer := &net.Dialer{Timeout: timeout}
+	dialContext := dialer.DialContext
+	if !allowPrivate {
+		// The address is checked when connecting, so a host cannot resolve to a public address on save
+		// and to a private one on delivery
+		restricted := &net.Dialer{Timeout: timeout, Control: c.allowedOnly}
+		dialContext = func(ctx context.Context, network, address string) (net.Conn, error) {
+			if host, _, err := net.SplitHostPort(address); err == nil && allowed.allowsHost(host) {
+				return dialer.DialContext(ctx, network, address)
+			}
+
+			return restricted.DialContext(ctx, network, address)
+		}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialContext
+	c.client = &http.Client{Timeout: timeout, Transport: transport}
+
+	return c
+}
+
+// CheckURL returns an error if the host of the webhook URL resolves to an address the client refuses to connect to.
+func (c *Client) CheckURL(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("error parsing URL: %w", err)
+	}
+	if c.allowPrivate || c.allowed.allowsHost(u.Hostname()) {
+		return nil
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, u.Hostname())
+	if err != nil {
+		return fmt.Errorf("error resolving host %s: %w", u.Hostname(), err)
+	}
+	for _, addr := range addrs {
+		if !c.allowed.allowsIP(addr.IP) {
+			return fmt.Errorf("host %s resolves to non-public address %s", u.Hostname(), addr.IP)
+		}
+	}
+
+	return nil
+}
+
+// Notify posts the notification about new comics matching the saved search to the webhook URL.
+// It returns the status code of the response, or zero if no response was received.
+func (c *Client) Notify(ctx context.Context, url string, n *domain.SavedSearchNotification) (int, error) {
+	body, err := json.Marshal(n)
+	if err != nil {
+		return 0, fmt.Errorf("error encoding notification: %w", err)
+	}
+
+	status, err := c.post(ctx, url, body, nil)
+	if err != nil {
+		return 0, fmt.Errorf("error sending notification: %w", err)
+	}
+
+	if !successful(status) {
+		return status, fmt.Errorf("unexpected status code: %d", status)
+	}
+
+	return status, nil
+}
+
+// Send posts the event to the webhook URL. The request is signed with the secret of the webhook,
+// so the receiver can verify it by computing Sign over the timestamp header and the body.
+// It returns the status code of the response, or zero if no response was received.
+func (c *Client) Send(ctx context.Context, url, secret string, e *domain.Event) (int, error) {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return 0, fmt.Errorf("error encoding event: %w", err)
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	header := http.Header{}
+	header.Set(HeaderEventID, e.ID)
+	header.Set(HeaderEventType, string(e.Type))
+	header.Set(HeaderTimestamp, timestamp)
+	header.Set(HeaderSignature, "sha256="+Sign(secret, timestamp, body))
+
+	status, err := c.post(ctx, url, body, header)
+	if err != nil {
+		return 0, fmt.Errorf("error sending event: %w", err)
+	}
+
+	if !successful(status) {
+		return status, fmt.Errorf("unexpected status code: %d", status)
+	}
+
+	return status, nil
+}
+
+// Sign returns the hex-encoded HMAC-SHA256 of the timestamp and the body joined by a dot.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// post posts the JSON body with the additional headers and returns the status code of the response.
+func (c *Client) post(ctx context.Context, url string, body []byte, header http.Header) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("error creating request: %w", err)
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	return resp.StatusCode, nil
+}
+
+// allowedOnly refuses connections to addresses which are neither public nor allowed by the allowlist.
+func (c *Client) allowedOnly(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	if ip := net.ParseIP(host); ip == nil || !c.allowed.allowsIP(ip) {
+		return fmt.Errorf("connection to non-public address %s is not allowed", host)
+	}
+
+	return nil
+}
+
+// publicIP reports whether the address is a public unicast one.
+func publicIP(ip net.IP) bool {
+	return !ip.IsPrivate() && !ip.IsLoopback() && !ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() && !ip.IsMulticast() && !ip.IsUnspecified() && !sharedAddressSpace.Contains(ip)
+}
+
+// successful reports whether the status code is 2xx.
+func successful(status int) bool {
+	return status >= http.StatusOK && status < http.StatusMultipleChoices
+}
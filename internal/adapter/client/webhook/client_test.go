@@ -0,0 +1,161 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"yadro-microservices/internal/core/domain"
+)
+
+func TestClient_Notify(t *testing.T) {
+	var received domain.SavedSearchNotification
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer mockServer.Close()
+
+	n := &domain.SavedSearchNotification{
+		SavedSearchID: 1,
+		Query:         "apple",
+		Comics:        []*domain.FoundComic{{ID: 4, URL: "https://example.com/4.png"}},
+	}
+	_, err := NewClient(time.Second, true, nil).Notify(context.Background(), mockServer.URL, n)
+
+	require.NoError(t, err)
+	assert.Equal(t, *n, received)
+}
+
+func TestClient_Notify_ErrorStatus(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer mockServer.Close()
+
+	client := NewClient(time.Second, true, nil)
+	status, err := client.Notify(context.Background(), mockServer.URL, &domain.SavedSearchNotification{})
+	require.Error(t, err)
+	assert.Equal(t, http.StatusInternalServerError, status)
+}
+
+func TestClient_Send(t *testing.T) {
+	var received domain.Event
+	var signature, timestamp string
+	var body []byte
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "evt-1", r.Header.Get(HeaderEventID))
+		assert.Equal(t, "update.completed", r.Header.Get(HeaderEventType))
+		signature = r.Header.Get(HeaderSignature)
+		timestamp = r.Header.Get(HeaderTimestamp)
+		body, _ = io.ReadAll(r.Body)
+		assert.NoError(t, json.Unmarshal(body, &received))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer mockServer.Close()
+
+	e := &domain.Event{ID: "evt-1", Type: domain.EventUpdateCompleted, Data: map[string]int{"indexed": 3}}
+	status, err := NewClient(time.Second, true, nil).Send(context.Background(), mockServer.URL, "secret", e)
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusAccepted, status)
+	assert.Equal(t, "evt-1", received.ID)
+	assert.NotEmpty(t, timestamp)
+	assert.Equal(t, "sha256="+Sign("secret", timestamp, body), signature)
+	assert.NotEqual(t, signature, "sha256="+Sign("other", timestamp, body))
+}
+
+func TestClient_Send_ErrorStatus(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer mockServer.Close()
+
+	status, err := NewClient(time.Second, true, nil).Send(context.Background(), mockServer.URL, "secret", &domain.Event{})
+
+	require.Error(t, err)
+	assert.Equal(t, http.StatusBadGateway, status)
+}
+
+func TestClient_RefusesPrivateAddresses(t *testing.T) {
+	delivered := false
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		delivered = true
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer mockServer.Close()
+
+	client := NewClient(time.Second, false, nil)
+	_, err := client.Send(context.Background(), mockServer.URL, "secret", &domain.Event{})
+	require.Error(t, err)
+	assert.False(t, delivered)
+
+	require.Error(t, client.CheckURL(context.Background(), mockServer.URL))
+	require.Error(t, client.CheckURL(context.Background(), "http://169.254.169.254/latest/meta-data"))
+	require.Error(t, client.CheckURL(context.Background(), "http://[::1]:8080/hook"))
+	require.NoError(t, client.CheckURL(context.Background(), "https://8.8.8.8/hook"))
+	require.NoError(t, NewClient(time.Second, true, nil).CheckURL(context.Background(), mockServer.URL))
+}
+
+func TestClient_AllowsAllowlistedAddresses(t *testing.T) {
+	delivered := 0
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		delivered++
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer mockServer.Close()
+	port := mockServer.Listener.Addr().(*net.TCPAddr).Port
+
+	allowed, err := ParseAllowlist([]string{"127.0.0.0/8"})
+	require.NoError(t, err)
+	client := NewClient(time.Second, false, allowed)
+	_, err = client.Send(context.Background(), mockServer.URL, "secret", &domain.Event{})
+	require.NoError(t, err)
+	require.NoError(t, client.CheckURL(context.Background(), mockServer.URL))
+	require.Error(t, client.CheckURL(context.Background(), "http://169.254.169.254/latest/meta-data"))
+
+	allowed, err = ParseAllowlist([]string{"LocalHost", "10.0.0.1"})
+	require.NoError(t, err)
+	client = NewClient(time.Second, false, allowed)
+	hostURL := fmt.Sprintf("http://localhost:%d/hook", port)
+	_, err = client.Send(context.Background(), hostURL, "secret", &domain.Event{})
+	require.NoError(t, err)
+	require.NoError(t, client.CheckURL(context.Background(), hostURL))
+	require.NoError(t, client.CheckURL(context.Background(), "http://10.0.0.1/hook"))
+	require.Error(t, client.CheckURL(context.Background(), "http://10.0.0.2/hook"))
+	_, err = client.Send(context.Background(), mockServer.URL, "secret", &domain.Event{})
+	require.Error(t, err)
+	assert.Equal(t, 2, delivered)
+}
+
+func TestParseAllowlist_Invalid(t *testing.T) {
+	for _, entry := range []string{"", "10.0.0.0/33", "hooks.internal:8080"} {
+		_, err := ParseAllowlist([]string{entry})
+		assert.Error(t, err, entry)
+	}
+}
+
+func TestPublicIP(t *testing.T) {
+	for _, addr := range []string{"8.8.8.8", "2001:4860:4860::8888"} {
+		assert.True(t, publicIP(net.ParseIP(addr)), addr)
+	}
+	for _, addr := range []string{"10.0.0.1", "172.16.0.1", "192.168.1.1", "127.0.0.1", "169.254.169.254",
+		"100.64.0.1", "0.0.0.0", "::1", "fe80::1", "fc00::1", "::ffff:127.0.0.1", "224.0.0.1"} {
+		assert.False(t, publicIP(net.ParseIP(addr)), addr)
+	}
+}
+
+func TestSign(t *testing.T) {
+	// Computed with: printf '1700000000.{}' | openssl dgst -sha256 -hmac secret
+	expected := "b8569b78799ff9e3cbff0fc2d63a33a2b57f3282abd07c37ae5e8e7d79a5f163"
+	assert.Equal(t, expected, Sign("secret", "1700000000", []byte("{}")))
+}
@@ -41,6 +41,7 @@ func (cc *ComicClient) GetComics(ctx context.Context, existingIDs map[int]bool)
 		}
 
 		comics[comicsResponses[i].Num] = &domain.Comic{
+			Title:    comicsResponses[i].Title,
 			Img:      comicsResponses[i].Img,
 			Keywords: kw,
 		}
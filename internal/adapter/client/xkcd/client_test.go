@@ -39,6 +39,7 @@ func TestComicClient_GetComics(t *testing.T) {
 
 	require.NoError(t, err)
 	assert.Len(t, comics, 1)
+	assert.Equal(t, "Test Comic.", comics[1].Title)
 	assert.Equal(t, "https://example.com/comic.png", comics[1].Img)
 	assert.ElementsMatch(t, []string{"test", "alt", "test", "transcript", "test", "comic"}, comics[1].Keywords)
 }
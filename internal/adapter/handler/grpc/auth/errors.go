@@ -0,0 +1,65 @@
+package auth
+
+import (
+	"errors"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/types/known/durationpb"
+	authv1 "yadro-microservices/api/gen/go/auth"
+	"yadro-microservices/internal/core/domain"
+)
+
+// toStatus converts the error of the auth service to a gRPC status whose code tells clients the domain error.
+// The message of the status is made of the given message and the domain error only, so internal details
+// of the error, such as database errors, are never sent to clients.
+// A throttled login carries the time to wait in RetryInfo details, and a validation error its violations.
+func toStatus(err error, message string) error {
+	var throttled *domain.ThrottledError
+	if errors.As(err, &throttled) {
+		st := status.Newf(codes.ResourceExhausted, "%s: %v", message, domain.ErrTooManyAttempts)
+		return withDetails(st, &errdetails.RetryInfo{RetryDelay: durationpb.New(throttled.RetryAfter)})
+	}
+
+	var invalid *domain.ValidationError
+	if errors.As(err, &invalid) {
+		details := &authv1.ValidationErrorDetails{}
+		for _, v := range invalid.Violations {
+			details.Violations = append(details.Violations, &authv1.FieldViolation{
+				Field:       v.Field,
+				Code:        v.Code,
+				Description: v.Description,
+			})
+		}
+
+		return withDetails(status.Newf(codes.InvalidArgument, "%s: %v", message, domain.ErrInvalidInput), details)
+	}
+
+	for _, e := range []struct {
+		target error
+		code   codes.Code
+	}{
+		{domain.ErrNotFound, codes.NotFound},
+		{domain.ErrAlreadyExists, codes.AlreadyExists},
+		{domain.ErrInvalidInput, codes.InvalidArgument},
+		{domain.ErrInvalidCredentials, codes.Unauthenticated},
+		{domain.ErrInvalidToken, codes.Unauthenticated},
+		{domain.ErrForbidden, codes.PermissionDenied},
+	} {
+		if errors.Is(err, e.target) {
+			return status.Errorf(e.code, "%s: %v", message, e.target)
+		}
+	}
+
+	return status.Error(codes.Internal, message)
+}
+
+// withDetails returns the error of the status with the details attached, or without them if they cannot be.
+func withDetails(st *status.Status, details protoadapt.MessageV1) error {
+	if withDetails, err := st.WithDetails(details); err == nil {
+		return withDetails.Err()
+	}
+
+	return st.Err()
+}
@@ -6,6 +6,7 @@ import (
 	"google.golang.org/grpc"
 	"log"
 	"net"
+	"time"
 	authv1 "yadro-microservices/api/gen/go/auth"
 	"yadro-microservices/internal/core/domain"
 	"yadro-microservices/internal/core/port"
@@ -57,44 +58,315 @@ func (s *Server) Stop() {
 // Login logs in the user with the specified username and password.
 func (s *Server) Login(ctx context.Context, req *authv1.LoginRequest) (*authv1.LoginResponse, error) {
 	log.Printf("Logging in user: %s\n", req.GetUsername())
-	token, err := s.authService.Login(ctx, req.GetUsername(), req.GetPassword())
+	tokens, err := s.authService.Login(ctx, req.GetUsername(), req.GetPassword(), req.GetClientIp())
 	if err != nil {
-		return nil, fmt.Errorf("failed to login: %w", err)
+		return nil, toStatus(err, "failed to login")
 	}
 
-	return &authv1.LoginResponse{Token: token}, nil
+	return &authv1.LoginResponse{Token: tokens.AccessToken, RefreshToken: tokens.RefreshToken}, nil
+}
+
+// Refresh exchanges the refresh token for a new pair of tokens.
+func (s *Server) Refresh(ctx context.Context, req *authv1.RefreshRequest) (*authv1.RefreshResponse, error) {
+	tokens, err := s.authService.Refresh(ctx, req.GetRefreshToken())
+	if err != nil {
+		log.Println("Error refreshing token:", err)
+		return nil, toStatus(err, "failed to refresh token")
+	}
+
+	return &authv1.RefreshResponse{Token: tokens.AccessToken, RefreshToken: tokens.RefreshToken}, nil
+}
+
+// Logout revokes the session of the specified token.
+func (s *Server) Logout(ctx context.Context, req *authv1.LogoutRequest) (*authv1.LogoutResponse, error) {
+	if err := s.authService.Logout(ctx, req.GetToken()); err != nil {
+		log.Println("Error logging out:", err)
+		return nil, toStatus(err, "failed to logout")
+	}
+
+	return &authv1.LogoutResponse{}, nil
 }
 
 // Register registers a new user.
 func (s *Server) Register(ctx context.Context, req *authv1.RegisterRequest) (*authv1.RegisterResponse, error) {
 	log.Printf("Registering user: %s\n", req.GetUsername())
-	err := s.authService.Register(ctx, &domain.User{
-		Username: req.GetUsername(),
-		Password: req.GetPassword(),
-		Role:     domain.Role(req.GetRole()),
-	})
+	err := s.authService.Register(ctx, req.GetUsername(), req.GetPassword(), domain.Role(req.GetRole()))
 	if err != nil {
 		log.Println("Error registering user:", err)
-		return nil, fmt.Errorf("failed to register: %w", err)
+		return nil, toStatus(err, "failed to register")
 	}
 
 	return &authv1.RegisterResponse{}, nil
 }
 
-// ValidateToken validates the specified token and returns the user details.
+// SignUp creates a pending account of the user and sends them the token confirming it.
+func (s *Server) SignUp(ctx context.Context, req *authv1.SignUpRequest) (*authv1.SignUpResponse, error) {
+	log.Printf("Signing up user: %s\n", req.GetUsername())
+	if err := s.authService.SignUp(ctx, req.GetUsername(), req.GetEmail(), req.GetPassword()); err != nil {
+		log.Println("Error signing up user:", err)
+		return nil, toStatus(err, "failed to sign up")
+	}
+
+	return &authv1.SignUpResponse{}, nil
+}
+
+// ConfirmSignUp activates the pending account confirmed by the specified token.
+func (s *Server) ConfirmSignUp(
+	ctx context.Context,
+	req *authv1.ConfirmSignUpRequest,
+) (*authv1.ConfirmSignUpResponse, error) {
+	if err := s.authService.ConfirmSignUp(ctx, req.GetToken()); err != nil {
+		log.Println("Error confirming sign-up:", err)
+		return nil, toStatus(err, "failed to confirm sign-up")
+	}
+
+	return &authv1.ConfirmSignUpResponse{}, nil
+}
+
+// ValidateToken validates the specified token and returns the principal authenticated by it.
 func (s *Server) ValidateToken(
 	ctx context.Context,
 	req *authv1.ValidateTokenRequest,
 ) (*authv1.ValidateTokenResponse, error) {
-	user, err := s.authService.ValidateToken(ctx, req.GetToken())
+	p, err := s.authService.ValidateToken(ctx, req.GetToken())
 	if err != nil {
 		log.Println("Error validating token:", err)
-		return nil, fmt.Errorf("failed to validate token: %w", err)
+		return nil, toStatus(err, "failed to validate token")
+	}
+
+	return &authv1.ValidateTokenResponse{Principal: toPrincipal(p)}, nil
+}
+
+// toPrincipal converts the principal to its message.
+func toPrincipal(p *domain.Principal) *authv1.Principal {
+	permissions := make([]string, len(p.Permissions))
+	for i, perm := range p.Permissions {
+		permissions[i] = string(perm)
+	}
+
+	principal := &authv1.Principal{
+		Username:        p.Username,
+		Role:            string(p.Role),
+		Permissions:     permissions,
+		HistoryDisabled: p.HistoryDisabled,
+	}
+	if !p.ExpiresAt.IsZero() {
+		principal.ExpiresAt = p.ExpiresAt.Unix()
+	}
+
+	return principal
+}
+
+// UpdateSettings updates the settings of the user.
+func (s *Server) UpdateSettings(
+	ctx context.Context,
+	req *authv1.UpdateSettingsRequest,
+) (*authv1.UpdateSettingsResponse, error) {
+	log.Printf("Updating settings of user: %s\n", req.GetUsername())
+	err := s.authService.SetHistoryDisabled(ctx, req.GetUsername(), req.GetHistoryDisabled())
+	if err != nil {
+		log.Println("Error updating settings:", err)
+		return nil, toStatus(err, "failed to update settings")
+	}
+
+	return &authv1.UpdateSettingsResponse{}, nil
+}
+
+// ListUsers returns the page of user accounts ordered by username.
+func (s *Server) ListUsers(ctx context.Context, req *authv1.ListUsersRequest) (*authv1.ListUsersResponse, error) {
+	page, err := s.authService.ListUsers(ctx, int(req.GetOffset()), int(req.GetLimit()))
+	if err != nil {
+		log.Println("Error listing users:", err)
+		return nil, toStatus(err, "failed to list users")
+	}
+
+	resp := &authv1.ListUsersResponse{
+		Users: make([]*authv1.UserAccount, len(page.Users)),
+		Total: int32(page.Total),
+	}
+	for i, u := range page.Users {
+		account := &authv1.UserAccount{
+			Username:  u.Username,
+			Email:     u.Email,
+			Role:      string(u.Role),
+			Pending:   u.Pending,
+			Disabled:  u.Disabled,
+			CreatedAt: u.CreatedAt.Unix(),
+		}
+		if u.LastLogin != nil {
+			account.LastLogin = u.LastLogin.Unix()
+		}
+		resp.Users[i] = account
+	}
+
+	return resp, nil
+}
+
+// SetRole changes the role of the user.
+func (s *Server) SetRole(ctx context.Context, req *authv1.SetRoleRequest) (*authv1.SetRoleResponse, error) {
+	log.Printf("Setting role of user %s to %s\n", req.GetUsername(), req.GetRole())
+	if err := s.authService.SetRole(ctx, req.GetUsername(), domain.Role(req.GetRole())); err != nil {
+		log.Println("Error setting role:", err)
+		return nil, toStatus(err, "failed to set role")
+	}
+
+	return &authv1.SetRoleResponse{}, nil
+}
+
+// SetDisabled disables or enables the user.
+func (s *Server) SetDisabled(ctx context.Context, req *authv1.SetDisabledRequest) (*authv1.SetDisabledResponse, error) {
+	log.Printf("Setting user %s disabled: %t\n", req.GetUsername(), req.GetDisabled())
+	if err := s.authService.SetDisabled(ctx, req.GetUsername(), req.GetDisabled()); err != nil {
+		log.Println("Error updating user:", err)
+		return nil, toStatus(err, "failed to update user")
+	}
+
+	return &authv1.SetDisabledResponse{}, nil
+}
+
+// DeleteUser deletes the user.
+func (s *Server) DeleteUser(ctx context.Context, req *authv1.DeleteUserRequest) (*authv1.DeleteUserResponse, error) {
+	log.Printf("Deleting user: %s\n", req.GetUsername())
+	if err := s.authService.DeleteUser(ctx, req.GetUsername()); err != nil {
+		log.Println("Error deleting user:", err)
+		return nil, toStatus(err, "failed to delete user")
+	}
+
+	return &authv1.DeleteUserResponse{}, nil
+}
+
+// ChangePassword replaces the password of the user, checking the old one.
+func (s *Server) ChangePassword(
+	ctx context.Context,
+	req *authv1.ChangePasswordRequest,
+) (*authv1.ChangePasswordResponse, error) {
+	log.Printf("Changing password of user: %s\n", req.GetUsername())
+	err := s.authService.ChangePassword(
+		ctx,
+		req.GetUsername(),
+		req.GetOldPassword(),
+		req.GetNewPassword(),
+		req.GetClientIp(),
+	)
+	if err != nil {
+		log.Println("Error changing password:", err)
+		return nil, toStatus(err, "failed to change password")
+	}
+
+	return &authv1.ChangePasswordResponse{}, nil
+}
+
+// CreateAPIKey creates an API key of the user and returns it together with its value.
+func (s *Server) CreateAPIKey(
+	ctx context.Context,
+	req *authv1.CreateAPIKeyRequest,
+) (*authv1.CreateAPIKeyResponse, error) {
+	log.Printf("Creating API key %q of user: %s\n", req.GetName(), req.GetUsername())
+	permissions := make([]domain.Permission, len(req.GetPermissions()))
+	for i, perm := range req.GetPermissions() {
+		permissions[i] = domain.Permission(perm)
+	}
+
+	k, err := s.authService.CreateAPIKey(
+		ctx,
+		req.GetUsername(),
+		req.GetName(),
+		permissions,
+		time.Duration(req.GetTtlSeconds())*time.Second,
+	)
+	if err != nil {
+		log.Println("Error creating API key:", err)
+		return nil, toStatus(err, "failed to create API key")
+	}
+
+	return &authv1.CreateAPIKeyResponse{ApiKey: toAPIKey(k), Key: k.Key}, nil
+}
+
+// ListAPIKeys returns the API keys of the user.
+func (s *Server) ListAPIKeys(ctx context.Context, req *authv1.ListAPIKeysRequest) (*authv1.ListAPIKeysResponse, error) {
+	keys, err := s.authService.ListAPIKeys(ctx, req.GetUsername())
+	if err != nil {
+		log.Println("Error listing API keys:", err)
+		return nil, toStatus(err, "failed to list API keys")
+	}
+
+	resp := &authv1.ListAPIKeysResponse{ApiKeys: make([]*authv1.APIKey, len(keys))}
+	for i, k := range keys {
+		resp.ApiKeys[i] = toAPIKey(k)
+	}
+
+	return resp, nil
+}
+
+// RevokeAPIKey revokes the API key of the user.
+func (s *Server) RevokeAPIKey(ctx context.Context, req *authv1.RevokeAPIKeyRequest) (*authv1.RevokeAPIKeyResponse, error) {
+	log.Printf("Revoking API key %d of user: %s\n", req.GetId(), req.GetUsername())
+	if err := s.authService.RevokeAPIKey(ctx, req.GetUsername(), req.GetId()); err != nil {
+		log.Println("Error revoking API key:", err)
+		return nil, toStatus(err, "failed to revoke API key")
+	}
+
+	return &authv1.RevokeAPIKeyResponse{}, nil
+}
+
+// ValidateAPIKey validates the API key and returns the principal authenticated by it.
+func (s *Server) ValidateAPIKey(
+	ctx context.Context,
+	req *authv1.ValidateAPIKeyRequest,
+) (*authv1.ValidateAPIKeyResponse, error) {
+	p, err := s.authService.ValidateAPIKey(ctx, req.GetKey())
+	if err != nil {
+		log.Println("Error validating API key:", err)
+		return nil, toStatus(err, "failed to validate API key")
+	}
+
+	return &authv1.ValidateAPIKeyResponse{Principal: toPrincipal(p)}, nil
+}
+
+// GetChanges returns the changes of users made and the sessions revoked since the requested time.
+func (s *Server) GetChanges(ctx context.Context, req *authv1.GetChangesRequest) (*authv1.GetChangesResponse, error) {
+	changes, err := s.authService.GetChanges(ctx, time.Unix(req.GetSince(), 0))
+	if err != nil {
+		log.Println("Error getting changes:", err)
+		return nil, toStatus(err, "failed to get changes")
+	}
+
+	resp := &authv1.GetChangesResponse{
+		Users:        make([]*authv1.UserChange, len(changes.Users)),
+		Sessions:     make([]*authv1.SessionRevocation, len(changes.Sessions)),
+		Now:          changes.Now.Unix(),
+		TokenMaxTime: int64(changes.TokenMaxTime / time.Second),
+	}
+	for i, u := range changes.Users {
+		resp.Users[i] = &authv1.UserChange{Username: u.Username, ChangedAt: u.ChangedAt.Unix()}
+	}
+	for i, rev := range changes.Sessions {
+		resp.Sessions[i] = &authv1.SessionRevocation{SessionId: rev.SessionID, RevokedAt: rev.RevokedAt.Unix()}
+	}
+
+	return resp, nil
+}
+
+// toAPIKey converts the API key to its message without the value of the key.
+func toAPIKey(k *domain.APIKey) *authv1.APIKey {
+	permissions := make([]string, len(k.Permissions))
+	for i, perm := range k.Permissions {
+		permissions[i] = string(perm)
+	}
+
+	key := &authv1.APIKey{
+		Id:          k.ID,
+		Name:        k.Name,
+		Prefix:      k.Prefix,
+		Permissions: permissions,
+		CreatedAt:   k.CreatedAt.Unix(),
+	}
+	if k.ExpiresAt != nil {
+		key.ExpiresAt = k.ExpiresAt.Unix()
+	}
+	if k.LastUsedAt != nil {
+		key.LastUsedAt = k.LastUsedAt.Unix()
 	}
 
-	return &authv1.ValidateTokenResponse{
-		Username: user.Username,
-		Password: user.Password,
-		Role:     string(user.Role),
-	}, nil
+	return key
 }
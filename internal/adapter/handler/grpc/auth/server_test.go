@@ -3,18 +3,23 @@ package auth_test
 import (
 	"context"
 	"errors"
+	"fmt"
 	"github.com/stretchr/testify/require"
 	"log"
 	"net"
 	"testing"
+	"time"
 	"yadro-microservices/internal/adapter/handler/grpc/auth"
 	"yadro-microservices/internal/core/port"
 	"yadro-microservices/internal/mocks"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 	"google.golang.org/grpc/test/bufconn"
 
 	authv1 "yadro-microservices/api/gen/go/auth"
@@ -63,13 +68,22 @@ func TestServer_Login(t *testing.T) {
 		mock.Anything,
 		"testuser",
 		"testpassword",
-	).Return("token123", nil)
+		"10.0.0.1",
+	).Return(&domain.TokenPair{AccessToken: "token123", RefreshToken: "refresh123"}, nil)
 	mockAuthService.On(
 		"Login",
 		mock.Anything,
 		"wronguser",
 		"wrongpassword",
-	).Return("", errors.New("invalid credentials"))
+		"10.0.0.1",
+	).Return(nil, domain.ErrInvalidCredentials)
+	mockAuthService.On(
+		"Login",
+		mock.Anything,
+		"lockeduser",
+		"password",
+		"10.0.0.1",
+	).Return(nil, &domain.ThrottledError{RetryAfter: 90 * time.Second})
 
 	conn, client := startTestServer(mockAuthService)
 	defer conn.Close()
@@ -78,22 +92,41 @@ func TestServer_Login(t *testing.T) {
 		resp, err := client.Login(context.Background(), &authv1.LoginRequest{
 			Username: "testuser",
 			Password: "testpassword",
+			ClientIp: "10.0.0.1",
 		})
 
 		require.NoError(t, err)
 		assert.NotNil(t, resp)
 		assert.Equal(t, "token123", resp.GetToken())
+		assert.Equal(t, "refresh123", resp.GetRefreshToken())
 	})
 
 	t.Run("failed login", func(t *testing.T) {
 		resp, err := client.Login(context.Background(), &authv1.LoginRequest{
 			Username: "wronguser",
 			Password: "wrongpassword",
+			ClientIp: "10.0.0.1",
 		})
 
 		require.Error(t, err)
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
 		assert.Nil(t, resp)
 	})
+
+	t.Run("throttled login", func(t *testing.T) {
+		_, err := client.Login(context.Background(), &authv1.LoginRequest{
+			Username: "lockeduser",
+			Password: "password",
+			ClientIp: "10.0.0.1",
+		})
+
+		st, _ := status.FromError(err)
+		require.Equal(t, codes.ResourceExhausted, st.Code())
+		require.Len(t, st.Details(), 1)
+		info, ok := st.Details()[0].(*errdetails.RetryInfo)
+		require.True(t, ok)
+		assert.Equal(t, 90*time.Second, info.GetRetryDelay().AsDuration())
+	})
 }
 
 func TestServer_Register(t *testing.T) {
@@ -101,13 +134,17 @@ func TestServer_Register(t *testing.T) {
 	mockAuthService.On(
 		"Register",
 		mock.Anything,
-		mock.AnythingOfType("*domain.User"),
+		"newuser",
+		"newpassword",
+		domain.USER,
 	).Return(nil).Once()
 	mockAuthService.On(
 		"Register",
 		mock.Anything,
-		mock.AnythingOfType("*domain.User"),
-	).Return(errors.New("registration error")).Once()
+		"existinguser",
+		"password",
+		domain.USER,
+	).Return(errors.New("pq: connection refused to 10.0.0.5")).Once()
 
 	conn, client := startTestServer(mockAuthService)
 	defer conn.Close()
@@ -132,15 +169,47 @@ func TestServer_Register(t *testing.T) {
 
 		require.Error(t, err)
 		assert.Nil(t, resp)
+		// Internal details of the error are not sent to the client
+		assert.Equal(t, codes.Internal, status.Code(err))
+		assert.Equal(t, "failed to register", status.Convert(err).Message())
+	})
+}
+
+func TestServer_RegisterPolicyViolation(t *testing.T) {
+	mockAuthService := new(mocks.AuthService)
+	mockAuthService.On("Register", mock.Anything, "newuser", "short", domain.USER).Return(
+		&domain.ValidationError{Violations: []domain.FieldViolation{
+			{Field: "password", Code: "too_short", Description: "must be at least 8 characters long"},
+		}},
+	).Once()
+
+	conn, client := startTestServer(mockAuthService)
+	defer conn.Close()
+
+	_, err := client.Register(context.Background(), &authv1.RegisterRequest{
+		Username: "newuser",
+		Password: "short",
+		Role:     "user",
 	})
+
+	st, _ := status.FromError(err)
+	require.Equal(t, codes.InvalidArgument, st.Code())
+	require.Len(t, st.Details(), 1)
+	details, ok := st.Details()[0].(*authv1.ValidationErrorDetails)
+	require.True(t, ok)
+	require.Len(t, details.GetViolations(), 1)
+	assert.Equal(t, "password", details.GetViolations()[0].GetField())
+	assert.Equal(t, "too_short", details.GetViolations()[0].GetCode())
 }
 
 func TestServer_ValidateToken(t *testing.T) {
 	mockAuthService := new(mocks.AuthService)
-	mockAuthService.On("ValidateToken", mock.Anything, "validtoken").Return(&domain.User{
-		Username: "validuser",
-		Password: "validpassword",
-		Role:     domain.USER,
+	expiresAt := time.Unix(1700000000, 0)
+	mockAuthService.On("ValidateToken", mock.Anything, "validtoken").Return(&domain.Principal{
+		Username:    "validuser",
+		Role:        domain.USER,
+		Permissions: []domain.Permission{domain.PermComicsSearch},
+		ExpiresAt:   expiresAt,
 	}, nil)
 	mockAuthService.On(
 		"ValidateToken",
@@ -158,9 +227,11 @@ func TestServer_ValidateToken(t *testing.T) {
 
 		require.NoError(t, err)
 		assert.NotNil(t, resp)
-		assert.Equal(t, "validuser", resp.GetUsername())
-		assert.Equal(t, "validpassword", resp.GetPassword())
-		assert.Equal(t, "user", resp.GetRole())
+		p := resp.GetPrincipal()
+		assert.Equal(t, "validuser", p.GetUsername())
+		assert.Equal(t, "user", p.GetRole())
+		assert.Equal(t, []string{"comics:search"}, p.GetPermissions())
+		assert.Equal(t, expiresAt.Unix(), p.GetExpiresAt())
 	})
 
 	t.Run("failed token validation", func(t *testing.T) {
@@ -172,3 +243,218 @@ func TestServer_ValidateToken(t *testing.T) {
 		assert.Nil(t, resp)
 	})
 }
+
+func TestServer_UpdateSettings(t *testing.T) {
+	mockAuthService := new(mocks.AuthService)
+	mockAuthService.On("SetHistoryDisabled", mock.Anything, "validuser", true).Return(nil).Once()
+	mockAuthService.On("SetHistoryDisabled", mock.Anything, "missinguser", true).Return(domain.ErrNotFound).Once()
+
+	conn, client := startTestServer(mockAuthService)
+	defer conn.Close()
+
+	_, err := client.UpdateSettings(context.Background(), &authv1.UpdateSettingsRequest{
+		Username:        "validuser",
+		HistoryDisabled: true,
+	})
+	require.NoError(t, err)
+
+	_, err = client.UpdateSettings(context.Background(), &authv1.UpdateSettingsRequest{
+		Username:        "missinguser",
+		HistoryDisabled: true,
+	})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	mockAuthService.AssertExpectations(t)
+}
+
+func TestServer_Refresh(t *testing.T) {
+	mockAuthService := new(mocks.AuthService)
+	mockAuthService.On("Refresh", mock.Anything, "refresh123").Return(
+		&domain.TokenPair{AccessToken: "token456", RefreshToken: "refresh456"},
+		nil,
+	).Once()
+	mockAuthService.On("Refresh", mock.Anything, "reused").Return(nil, domain.ErrInvalidToken).Once()
+
+	conn, client := startTestServer(mockAuthService)
+	defer conn.Close()
+
+	resp, err := client.Refresh(context.Background(), &authv1.RefreshRequest{RefreshToken: "refresh123"})
+	require.NoError(t, err)
+	assert.Equal(t, "token456", resp.GetToken())
+	assert.Equal(t, "refresh456", resp.GetRefreshToken())
+
+	_, err = client.Refresh(context.Background(), &authv1.RefreshRequest{RefreshToken: "reused"})
+	require.Error(t, err)
+	mockAuthService.AssertExpectations(t)
+}
+
+func TestServer_Logout(t *testing.T) {
+	mockAuthService := new(mocks.AuthService)
+	mockAuthService.On("Logout", mock.Anything, "token123").Return(nil).Once()
+
+	conn, client := startTestServer(mockAuthService)
+	defer conn.Close()
+
+	_, err := client.Logout(context.Background(), &authv1.LogoutRequest{Token: "token123"})
+	require.NoError(t, err)
+	mockAuthService.AssertExpectations(t)
+}
+
+func TestServer_SignUp(t *testing.T) {
+	mockAuthService := new(mocks.AuthService)
+	mockAuthService.On("SignUp", mock.Anything, "newuser", "new@example.com", "password").Return(nil).Once()
+	mockAuthService.On("SignUp", mock.Anything, "admin", "admin@example.com", "password").
+		Return(fmt.Errorf("failed to save user: %w", domain.ErrAlreadyExists)).Once()
+
+	conn, client := startTestServer(mockAuthService)
+	defer conn.Close()
+
+	_, err := client.SignUp(context.Background(), &authv1.SignUpRequest{
+		Username: "newuser",
+		Email:    "new@example.com",
+		Password: "password",
+	})
+	require.NoError(t, err)
+
+	_, err = client.SignUp(context.Background(), &authv1.SignUpRequest{
+		Username: "admin",
+		Email:    "admin@example.com",
+		Password: "password",
+	})
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+	mockAuthService.AssertExpectations(t)
+}
+
+func TestServer_ConfirmSignUp(t *testing.T) {
+	mockAuthService := new(mocks.AuthService)
+	mockAuthService.On("ConfirmSignUp", mock.Anything, "token").Return(nil).Once()
+	mockAuthService.On("ConfirmSignUp", mock.Anything, "expired").Return(domain.ErrInvalidToken).Once()
+
+	conn, client := startTestServer(mockAuthService)
+	defer conn.Close()
+
+	_, err := client.ConfirmSignUp(context.Background(), &authv1.ConfirmSignUpRequest{Token: "token"})
+	require.NoError(t, err)
+
+	_, err = client.ConfirmSignUp(context.Background(), &authv1.ConfirmSignUpRequest{Token: "expired"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	mockAuthService.AssertExpectations(t)
+}
+
+func TestServer_ListUsers(t *testing.T) {
+	createdAt := time.Unix(1700000000, 0)
+	lastLogin := time.Unix(1700001000, 0)
+	mockAuthService := new(mocks.AuthService)
+	mockAuthService.On("ListUsers", mock.Anything, 50, 50).Return(&domain.UserPage{
+		Users: []*domain.UserAccount{
+			{Username: "admin", Role: domain.ADMIN, CreatedAt: createdAt, LastLogin: &lastLogin},
+			{Username: "newuser", Email: "new@example.com", Role: domain.USER, Pending: true, CreatedAt: createdAt},
+		},
+		Total: 52,
+	}, nil).Once()
+
+	conn, client := startTestServer(mockAuthService)
+	defer conn.Close()
+
+	resp, err := client.ListUsers(context.Background(), &authv1.ListUsersRequest{Offset: 50, Limit: 50})
+
+	require.NoError(t, err)
+	assert.Equal(t, int32(52), resp.GetTotal())
+	require.Len(t, resp.GetUsers(), 2)
+	assert.Equal(t, "admin", resp.GetUsers()[0].GetUsername())
+	assert.Equal(t, lastLogin.Unix(), resp.GetUsers()[0].GetLastLogin())
+	assert.True(t, resp.GetUsers()[1].GetPending())
+	assert.Zero(t, resp.GetUsers()[1].GetLastLogin())
+	mockAuthService.AssertExpectations(t)
+}
+
+func TestServer_ManageUsers(t *testing.T) {
+	mockAuthService := new(mocks.AuthService)
+	mockAuthService.On("SetRole", mock.Anything, "user1", domain.ADMIN).Return(nil).Once()
+	mockAuthService.On("SetDisabled", mock.Anything, "user1", true).Return(nil).Once()
+	mockAuthService.On("DeleteUser", mock.Anything, "missing").Return(domain.ErrNotFound).Once()
+	mockAuthService.On("ChangePassword", mock.Anything, "user1", "wrong", "new_password", "10.0.0.1").
+		Return(domain.ErrInvalidInput).Once()
+
+	conn, client := startTestServer(mockAuthService)
+	defer conn.Close()
+
+	ctx := context.Background()
+	_, err := client.SetRole(ctx, &authv1.SetRoleRequest{Username: "user1", Role: "admin"})
+	require.NoError(t, err)
+
+	_, err = client.SetDisabled(ctx, &authv1.SetDisabledRequest{Username: "user1", Disabled: true})
+	require.NoError(t, err)
+
+	_, err = client.DeleteUser(ctx, &authv1.DeleteUserRequest{Username: "missing"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = client.ChangePassword(ctx, &authv1.ChangePasswordRequest{
+		Username:    "user1",
+		OldPassword: "wrong",
+		NewPassword: "new_password",
+		ClientIp:    "10.0.0.1",
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	mockAuthService.AssertExpectations(t)
+}
+
+func TestServer_APIKeys(t *testing.T) {
+	createdAt := time.Unix(1700000000, 0)
+	k := &domain.APIKey{
+		ID:          1,
+		Name:        "cron",
+		Prefix:      "xk_0123abcd",
+		Key:         "xk_0123abcdef",
+		Permissions: []domain.Permission{domain.PermComicsUpdate},
+		CreatedAt:   createdAt,
+	}
+	mockAuthService := new(mocks.AuthService)
+	mockAuthService.On(
+		"CreateAPIKey",
+		mock.Anything,
+		"admin",
+		"cron",
+		[]domain.Permission{domain.PermComicsUpdate},
+		time.Hour,
+	).Return(k, nil).Once()
+	mockAuthService.On("ListAPIKeys", mock.Anything, "admin").Return([]*domain.APIKey{k}, nil).Once()
+	mockAuthService.On("RevokeAPIKey", mock.Anything, "admin", int64(2)).Return(domain.ErrNotFound).Once()
+	mockAuthService.On("ValidateAPIKey", mock.Anything, "xk_0123abcdef").Return(&domain.Principal{
+		Username:    "admin",
+		Role:        domain.ADMIN,
+		Permissions: []domain.Permission{domain.PermComicsUpdate},
+	}, nil).Once()
+	mockAuthService.On("ValidateAPIKey", mock.Anything, "xk_revoked").Return(nil, domain.ErrInvalidToken).Once()
+
+	conn, client := startTestServer(mockAuthService)
+	defer conn.Close()
+
+	ctx := context.Background()
+	created, err := client.CreateAPIKey(ctx, &authv1.CreateAPIKeyRequest{
+		Username:    "admin",
+		Name:        "cron",
+		Permissions: []string{"comics:update"},
+		TtlSeconds:  3600,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "xk_0123abcdef", created.GetKey())
+	assert.Equal(t, createdAt.Unix(), created.GetApiKey().GetCreatedAt())
+	assert.Zero(t, created.GetApiKey().GetExpiresAt())
+
+	listed, err := client.ListAPIKeys(ctx, &authv1.ListAPIKeysRequest{Username: "admin"})
+	require.NoError(t, err)
+	require.Len(t, listed.GetApiKeys(), 1)
+	assert.Equal(t, "xk_0123abcd", listed.GetApiKeys()[0].GetPrefix())
+
+	_, err = client.RevokeAPIKey(ctx, &authv1.RevokeAPIKeyRequest{Username: "admin", Id: 2})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	validated, err := client.ValidateAPIKey(ctx, &authv1.ValidateAPIKeyRequest{Key: "xk_0123abcdef"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"comics:update"}, validated.GetPrincipal().GetPermissions())
+	assert.Zero(t, validated.GetPrincipal().GetExpiresAt())
+
+	_, err = client.ValidateAPIKey(ctx, &authv1.ValidateAPIKeyRequest{Key: "xk_revoked"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	mockAuthService.AssertExpectations(t)
+}
@@ -0,0 +1,119 @@
+package http
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+	"yadro-microservices/internal/core/domain"
+	"yadro-microservices/internal/core/port"
+)
+
+// APIKeyHandler provides methods for managing the API keys of the current user.
+type APIKeyHandler struct {
+	authClient port.AuthClient
+}
+
+// NewAPIKeyHandler creates a new instance of APIKeyHandler.
+func NewAPIKeyHandler(authClient port.AuthClient) *APIKeyHandler {
+	return &APIKeyHandler{authClient: authClient}
+}
+
+// List handles requests for the API keys of the current user. The values of the keys are not returned.
+func (kh *APIKeyHandler) List(w http.ResponseWriter, r *http.Request) {
+	if !notByAPIKey(w, r) {
+		return
+	}
+
+	keys, err := kh.authClient.ListAPIKeys(r.Context(), currentUsername(r.Context()))
+	if err != nil {
+		writeServiceError(w, err, "Failed to list API keys")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err = json.NewEncoder(w).Encode(keys); err != nil {
+		log.Printf("Error encoding response: %v", err)
+		return
+	}
+}
+
+// Create handles requests to create an API key of the current user. The value of the key is returned
+// only in this response.
+func (kh *APIKeyHandler) Create(w http.ResponseWriter, r *http.Request) {
+	if !notByAPIKey(w, r) {
+		return
+	}
+
+	var request struct {
+		Name        string              `json:"name"`
+		Permissions []domain.Permission `json:"permissions"`
+		TTL         string              `json:"ttl"` // Go duration such as "720h"; empty for a key that does not expire
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	var ttl time.Duration
+	if request.TTL != "" {
+		var err error
+		if ttl, err = time.ParseDuration(request.TTL); err != nil {
+			http.Error(w, "Invalid ttl", http.StatusBadRequest)
+			return
+		}
+	}
+
+	k, err := kh.authClient.CreateAPIKey(
+		r.Context(),
+		currentUsername(r.Context()),
+		request.Name,
+		request.Permissions,
+		ttl,
+	)
+	if err != nil {
+		writeServiceError(w, err, "Failed to create API key")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/me/api-keys/"+strconv.FormatInt(k.ID, 10))
+	w.WriteHeader(http.StatusCreated)
+	if err = json.NewEncoder(w).Encode(k); err != nil {
+		log.Printf("Error encoding response: %v", err)
+		return
+	}
+}
+
+// Revoke handles requests to revoke an API key of the current user.
+func (kh *APIKeyHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	if !notByAPIKey(w, r) {
+		return
+	}
+
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid API key ID", http.StatusBadRequest)
+		return
+	}
+
+	if err = kh.authClient.RevokeAPIKey(r.Context(), currentUsername(r.Context()), id); err != nil {
+		writeServiceError(w, err, "Failed to revoke API key")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// notByAPIKey checks that the request is not authenticated by an API key, as API keys are managed only
+// with the token of a login: a leaked key can neither be turned into a key with more permissions nor
+// list and revoke the other keys of the user. Otherwise it responds with 403 Forbidden and returns false.
+func notByAPIKey(w http.ResponseWriter, r *http.Request) bool {
+	if _, ok := apiKey(r); ok {
+		http.Error(w, "API keys cannot be managed with an API key", http.StatusForbidden)
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,149 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"yadro-microservices/internal/core/domain"
+	"yadro-microservices/internal/mocks"
+)
+
+func TestAPIKeyHandler_Create(t *testing.T) {
+	permissions := []domain.Permission{domain.PermComicsUpdate}
+	authClient := new(mocks.AuthClient)
+	authClient.On("CreateAPIKey", mock.Anything, "admin", "cron", permissions, 720*time.Hour).Return(&domain.APIKey{
+		ID:          7,
+		Name:        "cron",
+		Prefix:      "xk_0123abcd",
+		Hash:        "hash",
+		Key:         "xk_0123abcdef",
+		Permissions: permissions,
+	}, nil).Once()
+
+	handler := NewAPIKeyHandler(authClient)
+	body := `{"name":"cron","permissions":["comics:update"],"ttl":"720h"}`
+	req := httptest.NewRequest(http.MethodPost, "/me/api-keys", bytes.NewBufferString(body))
+	rr := httptest.NewRecorder()
+	handler.Create(rr, withUser(req, "admin"))
+
+	require.Equal(t, http.StatusCreated, rr.Code)
+	assert.Equal(t, "/me/api-keys/7", rr.Header().Get("Location"))
+
+	var response map[string]any
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, "xk_0123abcdef", response["key"])
+	assert.NotContains(t, response, "hash")
+	authClient.AssertExpectations(t)
+}
+
+func TestAPIKeyHandler_CreateInvalid(t *testing.T) {
+	invalid := &domain.ValidationError{Violations: []domain.FieldViolation{
+		{Field: "permissions", Code: "not_granted", Description: `permission "users:manage" is not granted to the user`},
+	}}
+	authClient := new(mocks.AuthClient)
+	authClient.On("CreateAPIKey", mock.Anything, "user", "cron", mock.Anything, time.Duration(0)).
+		Return(nil, invalid).Once()
+
+	handler := NewAPIKeyHandler(authClient)
+	tests := []struct {
+		name string
+		body string
+		code int
+	}{
+		{name: "not granted", body: `{"name":"cron","permissions":["users:manage"]}`, code: http.StatusBadRequest},
+		{name: "malformed body", body: `{`, code: http.StatusBadRequest},
+		{
+			name: "malformed ttl",
+			body: `{"name":"cron","permissions":["comics:update"],"ttl":"month"}`,
+			code: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/me/api-keys", bytes.NewBufferString(tt.body))
+			rr := httptest.NewRecorder()
+			handler.Create(rr, withUser(req, "user"))
+			assert.Equal(t, tt.code, rr.Code)
+		})
+	}
+	authClient.AssertExpectations(t)
+}
+
+func TestAPIKeyHandler_CreateWithAPIKey(t *testing.T) {
+	authClient := new(mocks.AuthClient)
+
+	handler := NewAPIKeyHandler(authClient)
+	req := httptest.NewRequest(http.MethodPost, "/me/api-keys", bytes.NewBufferString(`{"name":"copy"}`))
+	req.Header.Set("X-API-Key", "xk_leaked")
+	rr := httptest.NewRecorder()
+	handler.Create(rr, withUser(req, "user"))
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	authClient.AssertNotCalled(t, "CreateAPIKey")
+}
+
+func TestAPIKeyHandler_ListRevokeWithAPIKey(t *testing.T) {
+	authClient := new(mocks.AuthClient)
+
+	handler := NewAPIKeyHandler(authClient)
+	req := httptest.NewRequest(http.MethodGet, "/me/api-keys", nil)
+	req.Header.Set("Authorization", "ApiKey xk_leaked")
+	rr := httptest.NewRecorder()
+	handler.List(rr, withUser(req, "user"))
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	req = httptest.NewRequest(http.MethodDelete, "/me/api-keys/1", nil)
+	req.SetPathValue("id", "1")
+	req.Header.Set("X-API-Key", "xk_leaked")
+	rr = httptest.NewRecorder()
+	handler.Revoke(rr, withUser(req, "user"))
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	authClient.AssertNotCalled(t, "ListAPIKeys", mock.Anything, mock.Anything)
+	authClient.AssertNotCalled(t, "RevokeAPIKey", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestAPIKeyHandler_List(t *testing.T) {
+	authClient := new(mocks.AuthClient)
+	authClient.On("ListAPIKeys", mock.Anything, "user").Return([]*domain.APIKey{{ID: 1, Name: "cron"}}, nil).Once()
+
+	handler := NewAPIKeyHandler(authClient)
+	req := httptest.NewRequest(http.MethodGet, "/me/api-keys", nil)
+	rr := httptest.NewRecorder()
+	handler.List(rr, withUser(req, "user"))
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	var keys []map[string]any
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &keys))
+	require.Len(t, keys, 1)
+	assert.Equal(t, "cron", keys[0]["name"])
+	assert.NotContains(t, keys[0], "key")
+	authClient.AssertExpectations(t)
+}
+
+func TestAPIKeyHandler_Revoke(t *testing.T) {
+	authClient := new(mocks.AuthClient)
+	authClient.On("RevokeAPIKey", mock.Anything, "user", int64(1)).Return(nil).Once()
+	authClient.On("RevokeAPIKey", mock.Anything, "user", int64(2)).Return(domain.ErrNotFound).Once()
+
+	handler := NewAPIKeyHandler(authClient)
+	for id, code := range map[string]int{
+		"1":   http.StatusNoContent,
+		"2":   http.StatusNotFound,
+		"abc": http.StatusBadRequest,
+	} {
+		req := httptest.NewRequest(http.MethodDelete, "/me/api-keys/"+id, nil)
+		req.SetPathValue("id", id)
+		rr := httptest.NewRecorder()
+		handler.Revoke(rr, withUser(req, "user"))
+		assert.Equal(t, code, rr.Code, id)
+	}
+	authClient.AssertExpectations(t)
+}
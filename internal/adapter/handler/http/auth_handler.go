@@ -2,16 +2,22 @@ package http
 
 import (
 	"encoding/json"
+	"errors"
 	"github.com/go-playground/validator/v10"
 	"log"
+	"math"
+	"net"
 	"net/http"
+	"strconv"
+	"strings"
 	"yadro-microservices/internal/core/domain"
 	"yadro-microservices/internal/core/port"
 )
 
 // AuthHandler provides methods for handling auth requests.
 type AuthHandler struct {
-	authClient port.AuthClient
+	authClient     port.AuthClient
+	trustedProxies map[string]bool
 }
 
 // NewAuthHandler creates a new instance of AuthHandler.
@@ -19,11 +25,20 @@ func NewAuthHandler(authClient port.AuthClient) *AuthHandler {
 	return &AuthHandler{authClient: authClient}
 }
 
+// SetTrustedProxies sets the addresses of the proxies, such as the web server, whose X-Forwarded-For
+// header tells the client IP by which failed logins are throttled.
+func (ah *AuthHandler) SetTrustedProxies(proxies []string) {
+	ah.trustedProxies = make(map[string]bool, len(proxies))
+	for _, p := range proxies {
+		ah.trustedProxies[p] = true
+	}
+}
+
 // Login handles login requests.
 func (ah *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	var creds struct {
 		Username string `json:"username" validate:"required,min=5,max=20"`
-		Password string `json:"password" validate:"required,min=5,max=20"`
+		Password string `json:"password" validate:"required,max=1024"`
 	}
 
 	err := json.NewDecoder(r.Body).Decode(&creds)
@@ -41,25 +56,117 @@ func (ah *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	token, err := ah.authClient.Login(r.Context(), creds.Username, creds.Password)
+	tokens, err := ah.authClient.Login(r.Context(), creds.Username, creds.Password, ah.clientIP(r))
 	if err != nil {
-		log.Printf("Error logging in: %v", err)
-		http.Error(w, err.Error(), http.StatusUnauthorized)
+		writeLoginError(w, err)
 		return
 	}
 
-	if err = json.NewEncoder(w).Encode(map[string]string{"token": token}); err != nil {
+	if err = json.NewEncoder(w).Encode(tokens); err != nil {
 		log.Printf("Error encoding response: %v", err)
 		return
 	}
 }
 
+// clientIP returns the address of the client. Requests of trusted proxies come from the last address
+// in their X-Forwarded-For header.
+func (ah *AuthHandler) clientIP(r *http.Request) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+
+	if !ah.trustedProxies[ip] {
+		return ip
+	}
+
+	forwarded := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	if last := strings.TrimSpace(forwarded[len(forwarded)-1]); last != "" {
+		return last
+	}
+
+	return ip
+}
+
+// writeLoginError writes the response to a failed login. Wrong usernames and passwords get the same answer.
+func writeLoginError(w http.ResponseWriter, err error) {
+	log.Printf("Error logging in: %v", err)
+
+	var throttled *domain.ThrottledError
+	switch {
+	case errors.As(err, &throttled):
+		setRetryAfter(w, throttled)
+		http.Error(w, "Too many failed login attempts, try again later", http.StatusTooManyRequests)
+	case errors.Is(err, domain.ErrInvalidCredentials):
+		http.Error(w, "Invalid username or password", http.StatusUnauthorized)
+	case errors.Is(err, domain.ErrForbidden):
+		http.Error(w, "Account is not confirmed or disabled", http.StatusForbidden)
+	default:
+		http.Error(w, "Failed to login", http.StatusInternalServerError)
+	}
+}
+
+// setRetryAfter sets the Retry-After header to the whole seconds the throttled client has to wait.
+func setRetryAfter(w http.ResponseWriter, throttled *domain.ThrottledError) {
+	retryAfter := max(int(math.Ceil(throttled.RetryAfter.Seconds())), 1)
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+}
+
+// Refresh handles requests to exchange a refresh token for a new pair of tokens.
+func (ah *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		RefreshToken string `json:"refresh_token" validate:"required"`
+	}
+
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		log.Printf("Error decoding request: %v", err)
+		http.Error(w, "Failed to parse request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err = validator.New().Struct(request); err != nil {
+		log.Printf("Error validating request: %v", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	tokens, err := ah.authClient.Refresh(r.Context(), request.RefreshToken)
+	if err != nil {
+		log.Printf("Error refreshing token: %v", err)
+		http.Error(w, "Invalid refresh token", http.StatusUnauthorized)
+		return
+	}
+
+	if err = json.NewEncoder(w).Encode(tokens); err != nil {
+		log.Printf("Error encoding response: %v", err)
+		return
+	}
+}
+
+// Logout handles requests to revoke the session of the current token.
+func (ah *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	token, ok := bearerToken(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err := ah.authClient.Logout(r.Context(), token); err != nil {
+		log.Printf("Error logging out: %v", err)
+		http.Error(w, "Failed to logout", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
 // Register handles register requests.
 func (ah *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 	var creds struct {
 		Username string `json:"username" validate:"required,min=5,max=20"`
-		Password string `json:"password" validate:"required,min=5,max=20"`
-		Role     string `json:"role" validate:"required,oneof=admin user"`
+		Password string `json:"password" validate:"required,max=1024"` // Checked by the password policy
+		Role     string `json:"role" validate:"required,max=64"`
 	}
 
 	err := json.NewDecoder(r.Body).Decode(&creds)
@@ -77,6 +184,11 @@ func (ah *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Granting a role other than the default one is managing users
+	if domain.Role(creds.Role) != domain.USER && !RequirePermission(w, r, domain.PermUsersManage) {
+		return
+	}
+
 	err = ah.authClient.Register(
 		r.Context(),
 		creds.Username,
@@ -85,10 +197,168 @@ func (ah *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 	)
 
 	if err != nil {
-		log.Printf("Error registering user: %v", err)
-		http.Error(w, "Failed to register user", http.StatusInternalServerError)
+		writeServiceError(w, err, "Failed to register user")
 		return
 	}
 
 	w.WriteHeader(http.StatusCreated)
 }
+
+// SignUp handles requests of users to create their own accounts. The account is pending
+// until it is confirmed with the token sent to the user.
+func (ah *AuthHandler) SignUp(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Username string `json:"username" validate:"required,min=5,max=20"`
+		Email    string `json:"email" validate:"required,email"`
+		Password string `json:"password" validate:"required,max=1024"` // Checked by the password policy
+	}
+
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		log.Printf("Error decoding request: %v", err)
+		http.Error(w, "Failed to parse request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err = validator.New().Struct(request); err != nil {
+		log.Printf("Error validating request: %v", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	err = ah.authClient.SignUp(r.Context(), request.Username, request.Email, request.Password)
+	if err != nil {
+		writeServiceError(w, err, "Failed to sign up")
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// ConfirmSignUp handles requests to activate a pending account with the token sent to the user.
+func (ah *AuthHandler) ConfirmSignUp(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Token string `json:"token" validate:"required"`
+	}
+
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		log.Printf("Error decoding request: %v", err)
+		http.Error(w, "Failed to parse request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err = validator.New().Struct(request); err != nil {
+		log.Printf("Error validating request: %v", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err = ah.authClient.ConfirmSignUp(r.Context(), request.Token); err != nil {
+		if errors.Is(err, domain.ErrInvalidToken) {
+			http.Error(w, "Invalid or expired token", http.StatusBadRequest)
+			return
+		}
+
+		writeServiceError(w, err, "Failed to confirm sign-up")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// userSettings is the representation of the settings of the user.
+type userSettings struct {
+	HistoryDisabled bool `json:"history_disabled"`
+}
+
+// Settings handles requests for the settings of the current user.
+func (ah *AuthHandler) Settings(w http.ResponseWriter, r *http.Request) {
+	user := currentUser(r.Context())
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(userSettings{HistoryDisabled: user.HistoryDisabled}); err != nil {
+		log.Printf("Error encoding response: %v", err)
+		return
+	}
+}
+
+// UpdateSettings handles requests to update the settings of the current user.
+func (ah *AuthHandler) UpdateSettings(w http.ResponseWriter, r *http.Request) {
+	user := currentUser(r.Context())
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var settings userSettings
+	if err := json.NewDecoder(r.Body).Decode(&settings); err != nil {
+		log.Printf("Error decoding request: %v", err)
+		http.Error(w, "Failed to parse request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := ah.authClient.SetHistoryDisabled(r.Context(), user.Username, settings.HistoryDisabled); err != nil {
+		writeServiceError(w, err, "Failed to update settings")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(settings); err != nil {
+		log.Printf("Error encoding response: %v", err)
+		return
+	}
+}
+
+// ChangePassword handles requests of the current user to change their password. The old password is required,
+// and all sessions of the user are revoked, so they have to log in again.
+func (ah *AuthHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	user := currentUser(r.Context())
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var request struct {
+		OldPassword string `json:"old_password" validate:"required"`
+		NewPassword string `json:"new_password" validate:"required,max=1024"` // Checked by the password policy
+	}
+
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		log.Printf("Error decoding request: %v", err)
+		http.Error(w, "Failed to parse request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err = validator.New().Struct(request); err != nil {
+		log.Printf("Error validating request: %v", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	err = ah.authClient.ChangePassword(
+		r.Context(),
+		user.Username,
+		request.OldPassword,
+		request.NewPassword,
+		ah.clientIP(r),
+	)
+	var throttled *domain.ThrottledError
+	if errors.As(err, &throttled) {
+		log.Printf("Error changing password: %v", err)
+		setRetryAfter(w, throttled)
+		http.Error(w, "Too many failed attempts, try again later", http.StatusTooManyRequests)
+		return
+	}
+	if err != nil {
+		writeServiceError(w, err, "Failed to change password")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -5,11 +5,14 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 	"yadro-microservices/internal/core/domain"
 	"yadro-microservices/internal/mocks"
 )
@@ -21,7 +24,8 @@ func TestAuthHandler_Login(t *testing.T) {
 		mock.Anything,
 		"valid_user",
 		"valid_pass",
-	).Return("valid_token", nil).Once()
+		"10.0.0.1",
+	).Return(&domain.TokenPair{AccessToken: "valid_token", RefreshToken: "refresh_token"}, nil).Once()
 
 	handler := NewAuthHandler(authClient)
 	creds := map[string]string{
@@ -30,10 +34,12 @@ func TestAuthHandler_Login(t *testing.T) {
 	}
 	credsBytes, _ := json.Marshal(creds)
 	req, _ := http.NewRequest(http.MethodPost, "/login", bytes.NewBuffer(credsBytes))
+	req.RemoteAddr = "10.0.0.1:51234"
 	rr := httptest.NewRecorder()
 	handler.Login(rr, req)
 
 	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"token":"valid_token","refresh_token":"refresh_token"}`, rr.Body.String())
 	authClient.AssertExpectations(t)
 }
 
@@ -44,7 +50,8 @@ func TestAuthHandler_LoginInvalidCredentials(t *testing.T) {
 		mock.Anything,
 		"invalid_user",
 		"invalid_pass",
-	).Return("", errors.New("invalid")).Once()
+		mock.Anything,
+	).Return(nil, fmt.Errorf("failed to login: %w", domain.ErrInvalidCredentials)).Once()
 
 	handler := NewAuthHandler(authClient)
 	creds := map[string]string{
@@ -57,9 +64,98 @@ func TestAuthHandler_LoginInvalidCredentials(t *testing.T) {
 	handler.Login(rr, req)
 
 	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	assert.Equal(t, "Invalid username or password\n", rr.Body.String())
 	authClient.AssertExpectations(t)
 }
 
+const validLogin = `{"username":"valid_user","password":"valid_pass"}`
+
+func TestAuthHandler_LoginThrottled(t *testing.T) {
+	authClient := new(mocks.AuthClient)
+	authClient.On("Login", mock.Anything, "valid_user", "valid_pass", mock.Anything).Return(
+		nil,
+		fmt.Errorf("failed to login: %w", &domain.ThrottledError{RetryAfter: 1500 * time.Millisecond}),
+	).Once()
+
+	handler := NewAuthHandler(authClient)
+	req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(validLogin))
+	rr := httptest.NewRecorder()
+	handler.Login(rr, req)
+
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+	assert.Equal(t, "2", rr.Header().Get("Retry-After"))
+	authClient.AssertExpectations(t)
+}
+
+func TestAuthHandler_LoginErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{
+			name:   "inactive account",
+			err:    fmt.Errorf("account is disabled: %w", domain.ErrForbidden),
+			status: http.StatusForbidden,
+		},
+		{
+			name:   "auth server error",
+			err:    errors.New("connection refused"),
+			status: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authClient := new(mocks.AuthClient)
+			authClient.On("Login", mock.Anything, "valid_user", "valid_pass", mock.Anything).Return(nil, tt.err).Once()
+
+			handler := NewAuthHandler(authClient)
+			req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(validLogin))
+			rr := httptest.NewRecorder()
+			handler.Login(rr, req)
+
+			assert.Equal(t, tt.status, rr.Code)
+		})
+	}
+}
+
+func TestAuthHandler_LoginClientIP(t *testing.T) {
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  string
+		clientIP   string
+	}{
+		{name: "direct", remoteAddr: "10.0.0.1:51234", forwarded: "10.0.0.9", clientIP: "10.0.0.1"},
+		{name: "trusted proxy", remoteAddr: "10.0.0.2:51234", forwarded: "10.0.0.8, 10.0.0.9", clientIP: "10.0.0.9"},
+		{name: "trusted proxy without header", remoteAddr: "10.0.0.2:51234", clientIP: "10.0.0.2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authClient := new(mocks.AuthClient)
+			authClient.On("Login", mock.Anything, "valid_user", "valid_pass", tt.clientIP).Return(
+				&domain.TokenPair{AccessToken: "valid_token", RefreshToken: "refresh_token"},
+				nil,
+			).Once()
+
+			handler := NewAuthHandler(authClient)
+			handler.SetTrustedProxies([]string{"10.0.0.2"})
+			req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(validLogin))
+			req.RemoteAddr = tt.remoteAddr
+			if tt.forwarded != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+			rr := httptest.NewRecorder()
+			handler.Login(rr, req)
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+			authClient.AssertExpectations(t)
+		})
+	}
+}
+
 func TestAuthHandler_Register(t *testing.T) {
 	authClient := new(mocks.AuthClient)
 	authClient.On(
@@ -78,7 +174,10 @@ func TestAuthHandler_Register(t *testing.T) {
 	}
 	credsBytes, _ := json.Marshal(creds)
 	req, _ := http.NewRequest(http.MethodPost, "/register", bytes.NewBuffer(credsBytes))
-	req = req.WithContext(context.WithValue(req.Context(), currentUserKey, &domain.User{Role: domain.ADMIN}))
+	req = req.WithContext(context.WithValue(req.Context(), currentUserKey, &domain.Principal{
+		Role:        domain.ADMIN,
+		Permissions: []domain.Permission{domain.PermUsersRegister, domain.PermUsersManage},
+	}))
 	rr := httptest.NewRecorder()
 	handler.Register(rr, req)
 
@@ -86,8 +185,80 @@ func TestAuthHandler_Register(t *testing.T) {
 	authClient.AssertExpectations(t)
 }
 
+func TestAuthHandler_RegisterAdminForbidden(t *testing.T) {
+	authClient := new(mocks.AuthClient)
+
+	// Registering users does not allow to grant them the admin role
+	handler := NewAuthHandler(authClient)
+	req := httptest.NewRequest(
+		http.MethodPost,
+		"/register",
+		strings.NewReader(`{"username":"valid_user","password":"valid_pass","role":"admin"}`),
+	)
+	req = req.WithContext(context.WithValue(req.Context(), currentUserKey, &domain.Principal{
+		Username:    "registrar",
+		Permissions: []domain.Permission{domain.PermUsersRegister},
+	}))
+	rr := httptest.NewRecorder()
+	handler.Register(rr, req)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	authClient.AssertNotCalled(t, "Register")
+}
+
+func TestAuthHandler_RegisterPolicyViolation(t *testing.T) {
+	authClient := new(mocks.AuthClient)
+	authClient.On("Register", mock.Anything, "valid_user", "valid_pass", domain.USER).Return(
+		fmt.Errorf("failed to register: %w", &domain.ValidationError{Violations: []domain.FieldViolation{
+			{Field: "password", Code: "too_short", Description: "must be at least 12 characters long"},
+			{Field: "password", Code: "too_few_classes", Description: "must contain at least 2 classes"},
+		}}),
+	).Once()
+
+	handler := NewAuthHandler(authClient)
+	req := httptest.NewRequest(
+		http.MethodPost,
+		"/register",
+		strings.NewReader(`{"username":"valid_user","password":"valid_pass","role":"user"}`),
+	)
+	rr := httptest.NewRecorder()
+	handler.Register(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{
+		"error": "Invalid input",
+		"violations": [
+			{"field": "password", "code": "too_short", "description": "must be at least 12 characters long"},
+			{"field": "password", "code": "too_few_classes", "description": "must contain at least 2 classes"}
+		]
+	}`, rr.Body.String())
+	authClient.AssertExpectations(t)
+}
+
+func TestAuthHandler_RegisterLongPassword(t *testing.T) {
+	authClient := new(mocks.AuthClient)
+	authClient.On("Register", mock.Anything, "valid_user", strings.Repeat("long pass ", 5), domain.USER).Return(nil).Once()
+
+	handler := NewAuthHandler(authClient)
+	body, _ := json.Marshal(map[string]string{
+		"username": "valid_user",
+		"password": strings.Repeat("long pass ", 5),
+		"role":     "user",
+	})
+	req := httptest.NewRequest(http.MethodPost, "/register", bytes.NewBuffer(body))
+	rr := httptest.NewRecorder()
+	handler.Register(rr, req)
+
+	assert.Equal(t, http.StatusCreated, rr.Code, "passphrases are not capped at 20 characters")
+	authClient.AssertExpectations(t)
+}
+
 func TestAuthHandler_RegisterInvalidRole(t *testing.T) {
 	authClient := new(mocks.AuthClient)
+	// Roles are kept by the auth server, which rejects unknown ones
+	authClient.On("Register", mock.Anything, "valid_user", "valid_pass", domain.Role("unknown_role")).
+		Return(fmt.Errorf("role unknown_role: %w", domain.ErrInvalidInput)).Once()
 
 	handler := NewAuthHandler(authClient)
 	creds := map[string]string{
@@ -97,7 +268,10 @@ func TestAuthHandler_RegisterInvalidRole(t *testing.T) {
 	}
 	credsBytes, _ := json.Marshal(creds)
 	req, _ := http.NewRequest(http.MethodPost, "/register", bytes.NewBuffer(credsBytes))
-	req = req.WithContext(context.WithValue(req.Context(), currentUserKey, &domain.User{Role: domain.ADMIN}))
+	req = req.WithContext(context.WithValue(req.Context(), currentUserKey, &domain.Principal{
+		Role:        domain.ADMIN,
+		Permissions: []domain.Permission{domain.PermUsersRegister, domain.PermUsersManage},
+	}))
 	rr := httptest.NewRecorder()
 	handler.Register(rr, req)
 
@@ -112,11 +286,11 @@ func TestAuthHandler_Register_InvalidRole(t *testing.T) {
 	creds := map[string]string{
 		"username": "valid_user",
 		"password": "valid_pass",
-		"role":     "unknown_role",
+		"role":     strings.Repeat("r", 65),
 	}
 	credsBytes, _ := json.Marshal(creds)
 	req, _ := http.NewRequest(http.MethodPost, "/register", bytes.NewBuffer(credsBytes))
-	req = req.WithContext(context.WithValue(req.Context(), currentUserKey, &domain.User{Role: domain.ADMIN}))
+	req = req.WithContext(context.WithValue(req.Context(), currentUserKey, &domain.Principal{Role: domain.ADMIN}))
 	rr := httptest.NewRecorder()
 	handler.Register(rr, req)
 
@@ -129,7 +303,7 @@ func TestAuthHandler_Register_DecodeError(t *testing.T) {
 
 	handler := NewAuthHandler(authClient)
 	req, _ := http.NewRequest(http.MethodPost, "/register", bytes.NewBufferString("{invalid json}"))
-	req = req.WithContext(context.WithValue(req.Context(), currentUserKey, &domain.User{Role: domain.ADMIN}))
+	req = req.WithContext(context.WithValue(req.Context(), currentUserKey, &domain.Principal{Role: domain.ADMIN}))
 	rr := httptest.NewRecorder()
 	handler.Register(rr, req)
 
@@ -148,7 +322,7 @@ func TestAuthHandler_Register_ValidationError(t *testing.T) {
 	}
 	credsBytes, _ := json.Marshal(creds)
 	req, _ := http.NewRequest(http.MethodPost, "/register", bytes.NewBuffer(credsBytes))
-	req = req.WithContext(context.WithValue(req.Context(), currentUserKey, &domain.User{Role: domain.ADMIN}))
+	req = req.WithContext(context.WithValue(req.Context(), currentUserKey, &domain.Principal{Role: domain.ADMIN}))
 	rr := httptest.NewRecorder()
 	handler.Register(rr, req)
 
@@ -170,14 +344,199 @@ func TestAuthHandler_Register_ServiceError(t *testing.T) {
 	creds := map[string]string{
 		"username": "valid_user",
 		"password": "valid_pass",
-		"role":     "admin",
+		"role":     "user",
 	}
 	credsBytes, _ := json.Marshal(creds)
 	req, _ := http.NewRequest(http.MethodPost, "/register", bytes.NewBuffer(credsBytes))
-	req = req.WithContext(context.WithValue(req.Context(), currentUserKey, &domain.User{Role: domain.ADMIN}))
+	req = req.WithContext(context.WithValue(req.Context(), currentUserKey, &domain.Principal{Role: domain.ADMIN}))
 	rr := httptest.NewRecorder()
 	handler.Register(rr, req)
 
 	assert.Equal(t, http.StatusInternalServerError, rr.Code)
 	authClient.AssertExpectations(t)
 }
+
+func TestAuthHandler_Settings(t *testing.T) {
+	handler := NewAuthHandler(nil)
+
+	req, _ := http.NewRequest(http.MethodGet, "/me/settings", nil)
+	rr := httptest.NewRecorder()
+	handler.Settings(rr, withUser(req, "user"))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"history_disabled":false}`, rr.Body.String())
+
+	req, _ = http.NewRequest(http.MethodGet, "/me/settings", nil)
+	rr = httptest.NewRecorder()
+	handler.Settings(rr, req)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+}
+
+func TestAuthHandler_UpdateSettings(t *testing.T) {
+	authClient := new(mocks.AuthClient)
+	authClient.On("SetHistoryDisabled", mock.Anything, "user", true).Return(nil).Once()
+
+	handler := NewAuthHandler(authClient)
+	req, _ := http.NewRequest(http.MethodPut, "/me/settings", bytes.NewBufferString(`{"history_disabled":true}`))
+	rr := httptest.NewRecorder()
+	handler.UpdateSettings(rr, withUser(req, "user"))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"history_disabled":true}`, rr.Body.String())
+	authClient.AssertExpectations(t)
+}
+
+func TestAuthHandler_UpdateSettings_Errors(t *testing.T) {
+	authClient := new(mocks.AuthClient)
+	authClient.On("SetHistoryDisabled", mock.Anything, "user", false).Return(errors.New("auth is down")).Once()
+	authClient.On("SetHistoryDisabled", mock.Anything, "gone", false).Return(domain.ErrNotFound).Once()
+
+	handler := NewAuthHandler(authClient)
+	req, _ := http.NewRequest(http.MethodPut, "/me/settings", bytes.NewBufferString(`{"history_disabled":false}`))
+	rr := httptest.NewRecorder()
+	handler.UpdateSettings(rr, withUser(req, "user"))
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+
+	req, _ = http.NewRequest(http.MethodPut, "/me/settings", bytes.NewBufferString(`{"history_disabled":false}`))
+	rr = httptest.NewRecorder()
+	handler.UpdateSettings(rr, withUser(req, "gone"))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	req, _ = http.NewRequest(http.MethodPut, "/me/settings", bytes.NewBufferString(`invalid`))
+	rr = httptest.NewRecorder()
+	handler.UpdateSettings(rr, withUser(req, "user"))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	authClient.AssertExpectations(t)
+}
+
+func TestAuthHandler_Refresh(t *testing.T) {
+	authClient := new(mocks.AuthClient)
+	authClient.On("Refresh", mock.Anything, "refresh_token").Return(
+		&domain.TokenPair{AccessToken: "new_token", RefreshToken: "new_refresh_token"},
+		nil,
+	).Once()
+	authClient.On("Refresh", mock.Anything, "reused_token").Return(nil, errors.New("invalid token")).Once()
+
+	handler := NewAuthHandler(authClient)
+	req, _ := http.NewRequest(http.MethodPost, "/refresh", bytes.NewBufferString(`{"refresh_token":"refresh_token"}`))
+	rr := httptest.NewRecorder()
+	handler.Refresh(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"token":"new_token","refresh_token":"new_refresh_token"}`, rr.Body.String())
+
+	req, _ = http.NewRequest(http.MethodPost, "/refresh", bytes.NewBufferString(`{"refresh_token":"reused_token"}`))
+	rr = httptest.NewRecorder()
+	handler.Refresh(rr, req)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	req, _ = http.NewRequest(http.MethodPost, "/refresh", bytes.NewBufferString(`{}`))
+	rr = httptest.NewRecorder()
+	handler.Refresh(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	authClient.AssertExpectations(t)
+}
+
+func TestAuthHandler_Logout(t *testing.T) {
+	authClient := new(mocks.AuthClient)
+	authClient.On("Logout", mock.Anything, "valid_token").Return(nil).Once()
+
+	handler := NewAuthHandler(authClient)
+	req, _ := http.NewRequest(http.MethodPost, "/logout", nil)
+	req.Header.Set("Authorization", "Bearer valid_token")
+	rr := httptest.NewRecorder()
+	handler.Logout(rr, req)
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+
+	req, _ = http.NewRequest(http.MethodPost, "/logout", nil)
+	rr = httptest.NewRecorder()
+	handler.Logout(rr, req)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	authClient.AssertExpectations(t)
+}
+
+func TestAuthHandler_SignUp(t *testing.T) {
+	authClient := new(mocks.AuthClient)
+	authClient.On("SignUp", mock.Anything, "new_user", "new@example.com", "password").Return(nil).Once()
+	authClient.On("SignUp", mock.Anything, "other_user", "other@example.com", "password").
+		Return(domain.ErrForbidden).Once()
+
+	handler := NewAuthHandler(authClient)
+	for _, tc := range []struct {
+		name string
+		body string
+		code int
+	}{
+		{"accepted", `{"username":"new_user","email":"new@example.com","password":"password"}`, http.StatusAccepted},
+		{"disabled", `{"username":"other_user","email":"other@example.com","password":"password"}`, http.StatusForbidden},
+		{"invalid email", `{"username":"new_user","email":"new","password":"password"}`, http.StatusBadRequest},
+		{"malformed", `{`, http.StatusBadRequest},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPost, "/signup", bytes.NewBufferString(tc.body))
+			rr := httptest.NewRecorder()
+			handler.SignUp(rr, req)
+			assert.Equal(t, tc.code, rr.Code)
+		})
+	}
+	authClient.AssertExpectations(t)
+}
+
+func TestAuthHandler_ConfirmSignUp(t *testing.T) {
+	authClient := new(mocks.AuthClient)
+	authClient.On("ConfirmSignUp", mock.Anything, "token").Return(nil).Once()
+	authClient.On("ConfirmSignUp", mock.Anything, "expired").Return(domain.ErrInvalidToken).Once()
+
+	handler := NewAuthHandler(authClient)
+	req, _ := http.NewRequest(http.MethodPost, "/signup/confirm", bytes.NewBufferString(`{"token":"token"}`))
+	rr := httptest.NewRecorder()
+	handler.ConfirmSignUp(rr, req)
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+
+	req, _ = http.NewRequest(http.MethodPost, "/signup/confirm", bytes.NewBufferString(`{"token":"expired"}`))
+	rr = httptest.NewRecorder()
+	handler.ConfirmSignUp(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	req, _ = http.NewRequest(http.MethodPost, "/signup/confirm", bytes.NewBufferString(`{}`))
+	rr = httptest.NewRecorder()
+	handler.ConfirmSignUp(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	authClient.AssertExpectations(t)
+}
+
+func TestAuthHandler_ChangePassword(t *testing.T) {
+	authClient := new(mocks.AuthClient)
+	authClient.On("ChangePassword", mock.Anything, "user", "password", "new_password", mock.Anything).Return(nil).Once()
+	authClient.On("ChangePassword", mock.Anything, "user", "wrong", "new_password", mock.Anything).
+		Return(fmt.Errorf("old password is incorrect: %w", domain.ErrInvalidInput)).Once()
+	authClient.On("ChangePassword", mock.Anything, "user", "guessed", "new_password", mock.Anything).
+		Return(&domain.ThrottledError{RetryAfter: 1500 * time.Millisecond}).Once()
+	authClient.On("ChangePassword", mock.Anything, "user", "password", "new", mock.Anything).
+		Return(&domain.ValidationError{Violations: []domain.FieldViolation{{Field: "new_password", Code: "too_short"}}}).
+		Once()
+
+	handler := NewAuthHandler(authClient)
+	for _, tc := range []struct {
+		name string
+		body string
+		code int
+	}{
+		{"changed", `{"old_password":"password","new_password":"new_password"}`, http.StatusNoContent},
+		{"wrong old password", `{"old_password":"wrong","new_password":"new_password"}`, http.StatusBadRequest},
+		{"throttled", `{"old_password":"guessed","new_password":"new_password"}`, http.StatusTooManyRequests},
+		{"short new password", `{"old_password":"password","new_password":"new"}`, http.StatusBadRequest},
+		{"missing new password", `{"old_password":"password"}`, http.StatusBadRequest},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPut, "/me/password", bytes.NewBufferString(tc.body))
+			rr := httptest.NewRecorder()
+			handler.ChangePassword(rr, withUser(req, "user"))
+			assert.Equal(t, tc.code, rr.Code)
+		})
+	}
+
+	req, _ := http.NewRequest(http.MethodPut, "/me/password", bytes.NewBufferString(`{}`))
+	rr := httptest.NewRecorder()
+	handler.ChangePassword(rr, req)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	authClient.AssertExpectations(t)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"log"
 	"net/http"
+	"strings"
 	"yadro-microservices/internal/core/domain"
 	"yadro-microservices/internal/core/port"
 )
@@ -12,20 +13,73 @@ type key int
 
 const currentUserKey key = 0
 
-// AuthorizationMiddleware is a middleware that checks if the user is authorized to access the resource.
-func AuthorizationMiddleware(role domain.Role) func(http.HandlerFunc) http.HandlerFunc {
+// currentUser returns the principal authenticated by AuthenticationMiddleware, or nil if the user is anonymous.
+func currentUser(ctx context.Context) *domain.Principal {
+	user, _ := ctx.Value(currentUserKey).(*domain.Principal)
+	return user
+}
+
+// currentUsername returns the name of the user authenticated by AuthenticationMiddleware,
+// or an empty string if the user is anonymous.
+func currentUsername(ctx context.Context) string {
+	user := currentUser(ctx)
+	if user == nil {
+		return ""
+	}
+
+	return user.Username
+}
+
+// bearerToken returns the token of the Authorization header of the request with the Bearer scheme.
+func bearerToken(r *http.Request) (string, bool) {
+	return authorization(r, "Bearer")
+}
+
+// apiKey returns the API key of the request, given by the Authorization header with the ApiKey scheme
+// or by the X-API-Key header.
+func apiKey(r *http.Request) (string, bool) {
+	if key, ok := authorization(r, "ApiKey"); ok {
+		return key, true
+	}
+
+	key := r.Header.Get("X-API-Key")
+	return key, key != ""
+}
+
+// authorization returns the credentials of the Authorization header of the request if it has the scheme.
+func authorization(r *http.Request, scheme string) (string, bool) {
+	header := r.Header.Get("Authorization")
+	if len(header) <= len(scheme)+1 || header[len(scheme)] != ' ' || !strings.EqualFold(header[:len(scheme)], scheme) {
+		return "", false
+	}
+
+	return header[len(scheme)+1:], true
+}
+
+// RequirePermission checks that the user of the request has the permission. Otherwise it responds
+// with 401 Unauthorized to anonymous users and 403 Forbidden to the others, and returns false.
+// Handlers use it for permissions depending on the request, beyond the one of their route.
+func RequirePermission(w http.ResponseWriter, r *http.Request, perm domain.Permission) bool {
+	user := currentUser(r.Context())
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return false
+	}
+
+	if !user.Can(perm) {
+		log.Printf("User %s with role %s has no permission %s", user.Username, user.Role, perm)
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return false
+	}
+
+	return true
+}
+
+// AuthorizationMiddleware is a middleware that checks if the user has the permission to access the resource.
+func AuthorizationMiddleware(perm domain.Permission) func(http.HandlerFunc) http.HandlerFunc {
 	return func(next http.HandlerFunc) http.HandlerFunc {
 		return func(w http.ResponseWriter, r *http.Request) {
-			userData := r.Context().Value(currentUserKey)
-			user, _ := userData.(*domain.User)
-
-			if user == nil || user.Role > role {
-				log.Printf(
-					"User %s is not authorized to access the resource with role %s",
-					user.Username,
-					user.Role,
-				)
-				http.Error(w, "Forbidden", http.StatusForbidden)
+			if !RequirePermission(w, r, perm) {
 				return
 			}
 
@@ -35,13 +89,18 @@ func AuthorizationMiddleware(role domain.Role) func(http.HandlerFunc) http.Handl
 }
 
 // AuthenticationMiddleware is a middleware that checks if the user is
-// authenticated. If required is true, the middleware will return an error if the
-// user is not authenticated.
+// authenticated by a bearer token or an API key. If required is true, the middleware
+// will return an error if the user is not authenticated.
 func AuthenticationMiddleware(authClient port.AuthClient, required bool) func(http.HandlerFunc) http.HandlerFunc {
 	return func(next http.HandlerFunc) http.HandlerFunc {
 		return func(w http.ResponseWriter, r *http.Request) {
-			tokenString := r.Header.Get("Authorization")
-			if len(tokenString) <= len("Bearer ") {
+			var user *domain.Principal
+			var err error
+			if key, ok := apiKey(r); ok {
+				user, err = authClient.ValidateAPIKey(r.Context(), key)
+			} else if token, ok := bearerToken(r); ok {
+				user, err = authClient.ValidateToken(r.Context(), token)
+			} else {
 				if required {
 					http.Error(w, "Unauthorized", http.StatusUnauthorized)
 					return
@@ -51,9 +110,8 @@ func AuthenticationMiddleware(authClient port.AuthClient, required bool) func(ht
 				return
 			}
 
-			user, err := authClient.ValidateToken(r.Context(), tokenString[len("Bearer "):])
 			if err != nil {
-				log.Printf("Error validating token: %v", err)
+				log.Printf("Error validating credentials: %v", err)
 				if required {
 					http.Error(w, "Unauthorized", http.StatusUnauthorized)
 					return
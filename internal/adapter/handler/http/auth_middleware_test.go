@@ -11,29 +11,50 @@ import (
 	"yadro-microservices/internal/mocks"
 )
 
-func TestAuthorizationMiddlewareWithAdminRole(t *testing.T) {
-	handler := AuthorizationMiddleware(domain.ADMIN)(http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {}))
+func TestAuthorizationMiddlewareWithPermission(t *testing.T) {
+	handler := AuthorizationMiddleware(domain.PermComicsUpdate)(
+		http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {}),
+	)
 	req, _ := http.NewRequest(http.MethodGet, "/", nil)
-	req = req.WithContext(context.WithValue(req.Context(), currentUserKey, &domain.User{Role: domain.ADMIN}))
+	req = req.WithContext(context.WithValue(req.Context(), currentUserKey, &domain.Principal{
+		Role:        domain.ADMIN,
+		Permissions: []domain.Permission{domain.PermComicsSearch, domain.PermComicsUpdate},
+	}))
 	rr := httptest.NewRecorder()
 	handler.ServeHTTP(rr, req)
 
 	assert.Equal(t, http.StatusOK, rr.Code)
 }
 
-func TestAuthorizationMiddlewareWithUserRole(t *testing.T) {
-	handler := AuthorizationMiddleware(domain.ADMIN)(http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {}))
+func TestAuthorizationMiddlewareWithoutPermission(t *testing.T) {
+	handler := AuthorizationMiddleware(domain.PermComicsUpdate)(
+		http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {}),
+	)
 	req, _ := http.NewRequest(http.MethodGet, "/", nil)
-	req = req.WithContext(context.WithValue(req.Context(), currentUserKey, &domain.User{Role: domain.USER}))
+	req = req.WithContext(context.WithValue(req.Context(), currentUserKey, &domain.Principal{
+		Role:        domain.USER,
+		Permissions: []domain.Permission{domain.PermComicsSearch},
+	}))
 	rr := httptest.NewRecorder()
 	handler.ServeHTTP(rr, req)
 
 	assert.Equal(t, http.StatusForbidden, rr.Code)
 }
 
+func TestAuthorizationMiddlewareWithoutUser(t *testing.T) {
+	handler := AuthorizationMiddleware(domain.PermComicsSearch)(
+		http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {}),
+	)
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+}
+
 func TestAuthenticationMiddlewareWithValidToken(t *testing.T) {
 	authClient := new(mocks.AuthClient)
-	authClient.On("ValidateToken", mock.Anything, "valid_token").Return(&domain.User{}, nil).Once()
+	authClient.On("ValidateToken", mock.Anything, "valid_token").Return(&domain.Principal{}, nil).Once()
 
 	var handler = AuthenticationMiddleware(
 		authClient,
@@ -82,3 +103,47 @@ func TestAuthenticationMiddlewareWithoutToken(t *testing.T) {
 
 	assert.Equal(t, http.StatusUnauthorized, rr.Code)
 }
+
+func TestAuthenticationMiddlewareWithAPIKey(t *testing.T) {
+	for name, header := range map[string][2]string{
+		"ApiKey scheme": {"Authorization", "ApiKey xk_valid"},
+		"X-API-Key":     {"X-API-Key", "xk_valid"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			authClient := new(mocks.AuthClient)
+			principal := &domain.Principal{Username: "cron", Permissions: []domain.Permission{domain.PermComicsUpdate}}
+			authClient.On("ValidateAPIKey", mock.Anything, "xk_valid").Return(principal, nil).Once()
+
+			var user *domain.Principal
+			handler := AuthenticationMiddleware(
+				authClient,
+				true,
+			)(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) { user = currentUser(r.Context()) }))
+			req, _ := http.NewRequest(http.MethodPost, "/update", nil)
+			req.Header.Set(header[0], header[1])
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+			assert.Equal(t, principal, user)
+			authClient.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAuthenticationMiddlewareWithInvalidAPIKey(t *testing.T) {
+	authClient := new(mocks.AuthClient)
+	authClient.On("ValidateAPIKey", mock.Anything, "xk_revoked").Return(nil, domain.ErrInvalidToken).Once()
+
+	handler := AuthenticationMiddleware(
+		authClient,
+		true,
+	)(http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {}))
+	req, _ := http.NewRequest(http.MethodPost, "/update", nil)
+	req.Header.Set("Authorization", "apikey xk_revoked")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	authClient.AssertExpectations(t)
+}
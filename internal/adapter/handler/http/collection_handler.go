@@ -0,0 +1,195 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"yadro-microservices/internal/core/domain"
+	"yadro-microservices/internal/core/port"
+)
+
+type CollectionHandler struct {
+	service port.CollectionService
+}
+
+func NewCollectionHandler(service port.CollectionService) *CollectionHandler {
+	return &CollectionHandler{service: service}
+}
+
+func (ch *CollectionHandler) AddFavorite(w http.ResponseWriter, r *http.Request) {
+	comicID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid comic ID", http.StatusBadRequest)
+		return
+	}
+
+	if err = ch.service.AddFavorite(r.Context(), currentUsername(r.Context()), comicID); err != nil {
+		writeServiceError(w, err, "Failed to add favorite")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (ch *CollectionHandler) RemoveFavorite(w http.ResponseWriter, r *http.Request) {
+	comicID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid comic ID", http.StatusBadRequest)
+		return
+	}
+
+	if err = ch.service.RemoveFavorite(r.Context(), currentUsername(r.Context()), comicID); err != nil {
+		writeServiceError(w, err, "Failed to remove favorite")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (ch *CollectionHandler) Favorites(w http.ResponseWriter, r *http.Request) {
+	favorites, err := ch.service.GetFavorites(r.Context(), currentUsername(r.Context()))
+	if err != nil {
+		writeServiceError(w, err, "Failed to get favorites")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err = json.NewEncoder(w).Encode(favorites); err != nil {
+		log.Printf("Error encoding response: %v", err)
+		return
+	}
+}
+
+func (ch *CollectionHandler) Collections(w http.ResponseWriter, r *http.Request) {
+	collections, err := ch.service.GetCollections(r.Context(), currentUsername(r.Context()))
+	if err != nil {
+		writeServiceError(w, err, "Failed to get collections")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err = json.NewEncoder(w).Encode(collections); err != nil {
+		log.Printf("Error encoding response: %v", err)
+		return
+	}
+}
+
+func (ch *CollectionHandler) CreateCollection(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	collection, err := ch.service.CreateCollection(r.Context(), currentUsername(r.Context()), request.Name)
+	if err != nil {
+		writeServiceError(w, err, "Failed to create collection")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/me/collections/"+strconv.FormatInt(collection.ID, 10))
+	w.WriteHeader(http.StatusCreated)
+	if err = json.NewEncoder(w).Encode(collection); err != nil {
+		log.Printf("Error encoding response: %v", err)
+		return
+	}
+}
+
+func (ch *CollectionHandler) DeleteCollection(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid collection ID", http.StatusBadRequest)
+		return
+	}
+
+	if err = ch.service.DeleteCollection(r.Context(), currentUsername(r.Context()), id); err != nil {
+		writeServiceError(w, err, "Failed to delete collection")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (ch *CollectionHandler) AddToCollection(w http.ResponseWriter, r *http.Request) {
+	id, comicID, err := collectionComicPath(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err = ch.service.AddToCollection(r.Context(), currentUsername(r.Context()), id, comicID); err != nil {
+		writeServiceError(w, err, "Failed to add comic to collection")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (ch *CollectionHandler) RemoveFromCollection(w http.ResponseWriter, r *http.Request) {
+	id, comicID, err := collectionComicPath(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err = ch.service.RemoveFromCollection(r.Context(), currentUsername(r.Context()), id, comicID); err != nil {
+		writeServiceError(w, err, "Failed to remove comic from collection")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// collectionComicPath parses the collection ID and the comic ID from the request path.
+func collectionComicPath(r *http.Request) (int64, int, error) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		return 0, 0, errors.New("invalid collection ID")
+	}
+
+	comicID, err := strconv.Atoi(r.PathValue("comic_id"))
+	if err != nil {
+		return 0, 0, errors.New("invalid comic ID")
+	}
+
+	return id, comicID, nil
+}
+
+// writeServiceError responds with the status matching the domain error returned by the service.
+func writeServiceError(w http.ResponseWriter, err error, message string) {
+	var invalid *domain.ValidationError
+	switch {
+	case errors.As(err, &invalid):
+		writeValidationError(w, invalid)
+	case errors.Is(err, domain.ErrNotFound):
+		http.Error(w, "Not found", http.StatusNotFound)
+	case errors.Is(err, domain.ErrAlreadyExists):
+		http.Error(w, "Already exists", http.StatusConflict)
+	case errors.Is(err, domain.ErrInvalidInput):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, domain.ErrForbidden):
+		http.Error(w, "Forbidden", http.StatusForbidden)
+	default:
+		log.Printf("%s: %v", message, err)
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
+
+// writeValidationError responds with 400 Bad Request listing every violated rule as JSON.
+func writeValidationError(w http.ResponseWriter, err *domain.ValidationError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+
+	response := struct {
+		Error      string                  `json:"error"`
+		Violations []domain.FieldViolation `json:"violations"`
+	}{Error: "Invalid input", Violations: err.Violations}
+	if encodeErr := json.NewEncoder(w).Encode(response); encodeErr != nil {
+		log.Printf("Error encoding response: %v", encodeErr)
+	}
+}
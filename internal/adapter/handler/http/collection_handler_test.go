@@ -0,0 +1,153 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"yadro-microservices/internal/core/domain"
+	"yadro-microservices/internal/mocks"
+)
+
+// withUser returns the request made by the authenticated user.
+func withUser(req *http.Request, username string) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), currentUserKey, &domain.Principal{Username: username}))
+}
+
+func TestAddFavoriteSuccess(t *testing.T) {
+	service := new(mocks.CollectionService)
+	service.On("AddFavorite", mock.Anything, "user", 353).Return(nil).Once()
+
+	handler := NewCollectionHandler(service)
+	req, _ := http.NewRequest(http.MethodPost, "/me/favorites/353", nil)
+	req.SetPathValue("id", "353")
+	rr := httptest.NewRecorder()
+	handler.AddFavorite(rr, withUser(req, "user"))
+
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	service.AssertExpectations(t)
+}
+
+func TestAddFavoriteNotFound(t *testing.T) {
+	service := new(mocks.CollectionService)
+	service.On("AddFavorite", mock.Anything, "user", 9999).Return(domain.ErrNotFound).Once()
+
+	handler := NewCollectionHandler(service)
+	req, _ := http.NewRequest(http.MethodPost, "/me/favorites/9999", nil)
+	req.SetPathValue("id", "9999")
+	rr := httptest.NewRecorder()
+	handler.AddFavorite(rr, withUser(req, "user"))
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	service.AssertExpectations(t)
+}
+
+func TestFavoritesSuccess(t *testing.T) {
+	service := new(mocks.CollectionService)
+	service.On("GetFavorites", mock.Anything, "user").Return([]*domain.FoundComic{
+		{ID: 353, URL: "https://imgs.xkcd.com/comics/python.png"},
+	}, nil).Once()
+
+	handler := NewCollectionHandler(service)
+	req, _ := http.NewRequest(http.MethodGet, "/me/favorites", nil)
+	rr := httptest.NewRecorder()
+	handler.Favorites(rr, withUser(req, "user"))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `[{"id":353,"url":"https://imgs.xkcd.com/comics/python.png"}]`, rr.Body.String())
+	service.AssertExpectations(t)
+}
+
+func TestCollectionsFailure(t *testing.T) {
+	service := new(mocks.CollectionService)
+	service.On("GetCollections", mock.Anything, "user").Return(nil, errors.New("db error")).Once()
+
+	handler := NewCollectionHandler(service)
+	req, _ := http.NewRequest(http.MethodGet, "/me/collections", nil)
+	rr := httptest.NewRecorder()
+	handler.Collections(rr, withUser(req, "user"))
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	service.AssertExpectations(t)
+}
+
+func TestCreateCollectionSuccess(t *testing.T) {
+	service := new(mocks.CollectionService)
+	service.On("CreateCollection", mock.Anything, "user", "Physics").Return(&domain.Collection{
+		ID:     7,
+		Name:   "Physics",
+		Comics: []*domain.FoundComic{},
+	}, nil).Once()
+
+	handler := NewCollectionHandler(service)
+	req, _ := http.NewRequest(http.MethodPost, "/me/collections", bytes.NewBufferString(`{"name":"Physics"}`))
+	rr := httptest.NewRecorder()
+	handler.CreateCollection(rr, withUser(req, "user"))
+
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.Equal(t, "/me/collections/7", rr.Header().Get("Location"))
+	assert.Contains(t, rr.Body.String(), `"name":"Physics"`)
+	service.AssertExpectations(t)
+}
+
+func TestCreateCollectionErrors(t *testing.T) {
+	service := new(mocks.CollectionService)
+	service.On("CreateCollection", mock.Anything, "user", "Physics").Return(nil, domain.ErrAlreadyExists).Once()
+	service.On("CreateCollection", mock.Anything, "user", "").Return(nil, domain.ErrInvalidInput).Once()
+
+	handler := NewCollectionHandler(service)
+	req, _ := http.NewRequest(http.MethodPost, "/me/collections", bytes.NewBufferString(`{"name":"Physics"}`))
+	rr := httptest.NewRecorder()
+	handler.CreateCollection(rr, withUser(req, "user"))
+	assert.Equal(t, http.StatusConflict, rr.Code)
+
+	req, _ = http.NewRequest(http.MethodPost, "/me/collections", bytes.NewBufferString(`{}`))
+	rr = httptest.NewRecorder()
+	handler.CreateCollection(rr, withUser(req, "user"))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	req, _ = http.NewRequest(http.MethodPost, "/me/collections", bytes.NewBufferString(`not json`))
+	rr = httptest.NewRecorder()
+	handler.CreateCollection(rr, withUser(req, "user"))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	service.AssertExpectations(t)
+}
+
+func TestAddToCollection(t *testing.T) {
+	service := new(mocks.CollectionService)
+	service.On("AddToCollection", mock.Anything, "user", int64(7), 353).Return(nil).Once()
+
+	handler := NewCollectionHandler(service)
+	req, _ := http.NewRequest(http.MethodPost, "/me/collections/7/comics/353", nil)
+	req.SetPathValue("id", "7")
+	req.SetPathValue("comic_id", "353")
+	rr := httptest.NewRecorder()
+	handler.AddToCollection(rr, withUser(req, "user"))
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+
+	req, _ = http.NewRequest(http.MethodPost, "/me/collections/7/comics/abc", nil)
+	req.SetPathValue("id", "7")
+	req.SetPathValue("comic_id", "abc")
+	rr = httptest.NewRecorder()
+	handler.AddToCollection(rr, withUser(req, "user"))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	service.AssertExpectations(t)
+}
+
+func TestDeleteCollectionNotFound(t *testing.T) {
+	service := new(mocks.CollectionService)
+	service.On("DeleteCollection", mock.Anything, "user", int64(7)).Return(domain.ErrNotFound).Once()
+
+	handler := NewCollectionHandler(service)
+	req, _ := http.NewRequest(http.MethodDelete, "/me/collections/7", nil)
+	req.SetPathValue("id", "7")
+	rr := httptest.NewRecorder()
+	handler.DeleteCollection(rr, withUser(req, "user"))
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	service.AssertExpectations(t)
+}
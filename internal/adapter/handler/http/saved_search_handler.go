@@ -0,0 +1,113 @@
+package http
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"yadro-microservices/internal/core/port"
+)
+
+// Default and maximum number of inbox items returned at once.
+const (
+	defaultInboxLimit = 50
+	maxInboxLimit     = 1000
+)
+
+type SavedSearchHandler struct {
+	service port.SavedSearchService
+}
+
+func NewSavedSearchHandler(service port.SavedSearchService) *SavedSearchHandler {
+	return &SavedSearchHandler{service: service}
+}
+
+func (sh *SavedSearchHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Query      string `json:"query"`
+		WebhookURL string `json:"webhook_url"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	s, err := sh.service.Create(r.Context(), currentUsername(r.Context()), request.Query, request.WebhookURL)
+	if err != nil {
+		writeServiceError(w, err, "Failed to save search")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/me/searches/"+strconv.FormatInt(s.ID, 10))
+	w.WriteHeader(http.StatusCreated)
+	if err = json.NewEncoder(w).Encode(s); err != nil {
+		log.Printf("Error encoding response: %v", err)
+		return
+	}
+}
+
+func (sh *SavedSearchHandler) List(w http.ResponseWriter, r *http.Request) {
+	searches, err := sh.service.Get(r.Context(), currentUsername(r.Context()))
+	if err != nil {
+		writeServiceError(w, err, "Failed to get saved searches")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err = json.NewEncoder(w).Encode(searches); err != nil {
+		log.Printf("Error encoding response: %v", err)
+		return
+	}
+}
+
+func (sh *SavedSearchHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid saved search ID", http.StatusBadRequest)
+		return
+	}
+
+	if err = sh.service.Delete(r.Context(), currentUsername(r.Context()), id); err != nil {
+		writeServiceError(w, err, "Failed to delete saved search")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (sh *SavedSearchHandler) Inbox(w http.ResponseWriter, r *http.Request) {
+	limit, err := positiveQueryParam(r, "limit", defaultInboxLimit, maxInboxLimit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	unreadOnly := r.URL.Query().Get("unread") == "true"
+
+	inbox, err := sh.service.GetInbox(r.Context(), currentUsername(r.Context()), unreadOnly, limit)
+	if err != nil {
+		writeServiceError(w, err, "Failed to get inbox")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err = json.NewEncoder(w).Encode(inbox); err != nil {
+		log.Printf("Error encoding response: %v", err)
+		return
+	}
+}
+
+func (sh *SavedSearchHandler) MarkRead(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid inbox item ID", http.StatusBadRequest)
+		return
+	}
+
+	if err = sh.service.MarkRead(r.Context(), currentUsername(r.Context()), id); err != nil {
+		writeServiceError(w, err, "Failed to mark inbox item as read")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,96 @@
+package http
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"yadro-microservices/internal/core/domain"
+	"yadro-microservices/internal/mocks"
+)
+
+func TestCreateSavedSearchSuccess(t *testing.T) {
+	service := new(mocks.SavedSearchService)
+	service.On("Create", mock.Anything, "user", "apple doctor", "").Return(&domain.SavedSearch{
+		ID:    3,
+		Query: "apple doctor",
+	}, nil).Once()
+
+	handler := NewSavedSearchHandler(service)
+	req, _ := http.NewRequest(http.MethodPost, "/me/searches", bytes.NewBufferString(`{"query":"apple doctor"}`))
+	rr := httptest.NewRecorder()
+	handler.Create(rr, withUser(req, "user"))
+
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.Equal(t, "/me/searches/3", rr.Header().Get("Location"))
+	assert.Contains(t, rr.Body.String(), `"query":"apple doctor"`)
+	service.AssertExpectations(t)
+}
+
+func TestCreateSavedSearchInvalid(t *testing.T) {
+	service := new(mocks.SavedSearchService)
+	service.On("Create", mock.Anything, "user", "", "").Return(nil, domain.ErrInvalidInput).Once()
+
+	handler := NewSavedSearchHandler(service)
+	req, _ := http.NewRequest(http.MethodPost, "/me/searches", bytes.NewBufferString(`{}`))
+	rr := httptest.NewRecorder()
+	handler.Create(rr, withUser(req, "user"))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	req, _ = http.NewRequest(http.MethodPost, "/me/searches", bytes.NewBufferString(`invalid`))
+	rr = httptest.NewRecorder()
+	handler.Create(rr, withUser(req, "user"))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	service.AssertExpectations(t)
+}
+
+func TestDeleteSavedSearchNotFound(t *testing.T) {
+	service := new(mocks.SavedSearchService)
+	service.On("Delete", mock.Anything, "user", int64(3)).Return(domain.ErrNotFound).Once()
+
+	handler := NewSavedSearchHandler(service)
+	req, _ := http.NewRequest(http.MethodDelete, "/me/searches/3", nil)
+	req.SetPathValue("id", "3")
+	rr := httptest.NewRecorder()
+	handler.Delete(rr, withUser(req, "user"))
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	service.AssertExpectations(t)
+}
+
+func TestInboxSuccess(t *testing.T) {
+	service := new(mocks.SavedSearchService)
+	service.On("GetInbox", mock.Anything, "user", true, 10).Return([]*domain.InboxItem{
+		{ID: 1, SavedSearchID: 3, Query: "apple", Comic: domain.FoundComic{ID: 4, URL: "https://example.com/4.png"}},
+	}, nil).Once()
+
+	handler := NewSavedSearchHandler(service)
+	req, _ := http.NewRequest(http.MethodGet, "/me/inbox?unread=true&limit=10", nil)
+	rr := httptest.NewRecorder()
+	handler.Inbox(rr, withUser(req, "user"))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"comic":{"id":4,"url":"https://example.com/4.png"}`)
+	service.AssertExpectations(t)
+}
+
+func TestMarkRead(t *testing.T) {
+	service := new(mocks.SavedSearchService)
+	service.On("MarkRead", mock.Anything, "user", int64(1)).Return(nil).Once()
+
+	handler := NewSavedSearchHandler(service)
+	req, _ := http.NewRequest(http.MethodPost, "/me/inbox/1/read", nil)
+	req.SetPathValue("id", "1")
+	rr := httptest.NewRecorder()
+	handler.MarkRead(rr, withUser(req, "user"))
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+
+	req, _ = http.NewRequest(http.MethodPost, "/me/inbox/x/read", nil)
+	req.SetPathValue("id", "x")
+	rr = httptest.NewRecorder()
+	handler.MarkRead(rr, withUser(req, "user"))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	service.AssertExpectations(t)
+}
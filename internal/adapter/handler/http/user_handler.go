@@ -0,0 +1,137 @@
+package http
+
+import (
+	"encoding/json"
+	"github.com/go-playground/validator/v10"
+	"log"
+	"net/http"
+	"yadro-microservices/internal/core/domain"
+	"yadro-microservices/internal/core/port"
+)
+
+// Default and maximum number of users returned on a page, and the maximum page number.
+const (
+	defaultUsersLimit = 50
+	maxUsersLimit     = 500
+	maxUsersPage      = 1_000_000
+)
+
+// UserHandler provides methods for managing users by administrators.
+type UserHandler struct {
+	authClient  port.AuthClient
+	userService port.UserService
+}
+
+// NewUserHandler creates a new instance of UserHandler.
+func NewUserHandler(authClient port.AuthClient, userService port.UserService) *UserHandler {
+	return &UserHandler{authClient: authClient, userService: userService}
+}
+
+// List handles requests for a page of user accounts ordered by username.
+func (uh *UserHandler) List(w http.ResponseWriter, r *http.Request) {
+	page, err := positiveQueryParam(r, "page", 1, maxUsersPage)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	limit, err := positiveQueryParam(r, "limit", defaultUsersLimit, maxUsersLimit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	users, err := uh.authClient.ListUsers(r.Context(), (page-1)*limit, limit)
+	if err != nil {
+		writeServiceError(w, err, "Failed to list users")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err = json.NewEncoder(w).Encode(users); err != nil {
+		log.Printf("Error encoding response: %v", err)
+		return
+	}
+}
+
+// SetRole handles requests to change the role of a user.
+func (uh *UserHandler) SetRole(w http.ResponseWriter, r *http.Request) {
+	username := r.PathValue("username")
+	if !notSelf(w, r, username) {
+		return
+	}
+
+	var request struct {
+		Role string `json:"role" validate:"required,max=64"`
+	}
+
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		log.Printf("Error decoding request: %v", err)
+		http.Error(w, "Failed to parse request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err = validator.New().Struct(request); err != nil {
+		log.Printf("Error validating request: %v", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err = uh.authClient.SetRole(r.Context(), username, domain.Role(request.Role)); err != nil {
+		writeServiceError(w, err, "Failed to set role")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Disable handles requests to disable a user, which also logs them out.
+func (uh *UserHandler) Disable(w http.ResponseWriter, r *http.Request) {
+	uh.setDisabled(w, r, true)
+}
+
+// Enable handles requests to enable a disabled user.
+func (uh *UserHandler) Enable(w http.ResponseWriter, r *http.Request) {
+	uh.setDisabled(w, r, false)
+}
+
+func (uh *UserHandler) setDisabled(w http.ResponseWriter, r *http.Request, disabled bool) {
+	username := r.PathValue("username")
+	if !notSelf(w, r, username) {
+		return
+	}
+
+	if err := uh.authClient.SetDisabled(r.Context(), username, disabled); err != nil {
+		writeServiceError(w, err, "Failed to update user")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Delete handles requests to delete a user together with their favorites, collections, history and saved searches.
+func (uh *UserHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	username := r.PathValue("username")
+	if !notSelf(w, r, username) {
+		return
+	}
+
+	if err := uh.userService.DeleteUser(r.Context(), username); err != nil {
+		writeServiceError(w, err, "Failed to delete user")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// notSelf checks that the user of the request is not the given one, so administrators
+// cannot lock themselves out. Otherwise it responds with 403 Forbidden and returns false.
+func notSelf(w http.ResponseWriter, r *http.Request, username string) bool {
+	if username == currentUsername(r.Context()) {
+		http.Error(w, "Administrators cannot change their own account", http.StatusForbidden)
+		return false
+	}
+
+	return true
+}
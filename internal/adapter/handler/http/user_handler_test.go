@@ -0,0 +1,137 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"yadro-microservices/internal/core/domain"
+	"yadro-microservices/internal/mocks"
+)
+
+func TestUserHandler_List(t *testing.T) {
+	lastLogin := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	page := &domain.UserPage{
+		Users: []*domain.UserAccount{{
+			Username:  "user1",
+			Role:      domain.USER,
+			CreatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			LastLogin: &lastLogin,
+		}},
+		Total: 11,
+	}
+	authClient := new(mocks.AuthClient)
+	authClient.On("ListUsers", mock.Anything, 10, 10).Return(page, nil).Once()
+
+	handler := NewUserHandler(authClient, nil)
+	req := httptest.NewRequest(http.MethodGet, "/admin/users?page=2&limit=10", nil)
+	rr := httptest.NewRecorder()
+	handler.List(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	var result domain.UserPage
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &result))
+	assert.Equal(t, 11, result.Total)
+	require.Len(t, result.Users, 1)
+	assert.Equal(t, "user1", result.Users[0].Username)
+	assert.NotContains(t, rr.Body.String(), "password")
+	authClient.AssertExpectations(t)
+}
+
+func TestUserHandler_ListInvalidPage(t *testing.T) {
+	authClient := new(mocks.AuthClient)
+
+	handler := NewUserHandler(authClient, nil)
+	req := httptest.NewRequest(http.MethodGet, "/admin/users?page=0", nil)
+	rr := httptest.NewRecorder()
+	handler.List(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	authClient.AssertNotCalled(t, "ListUsers", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUserHandler_SetRole(t *testing.T) {
+	authClient := new(mocks.AuthClient)
+	authClient.On("SetRole", mock.Anything, "user1", domain.ADMIN).Return(nil).Once()
+	authClient.On("SetRole", mock.Anything, "missing", domain.ADMIN).Return(domain.ErrNotFound).Once()
+	authClient.On("SetRole", mock.Anything, "user1", domain.Role("root")).Return(domain.ErrInvalidInput).Once()
+	authClient.On("SetRole", mock.Anything, "admin2", domain.USER).Return(domain.ErrForbidden).Once()
+
+	handler := NewUserHandler(authClient, nil)
+	for _, tc := range []struct {
+		name     string
+		username string
+		body     string
+		code     int
+	}{
+		{"changed", "user1", `{"role":"admin"}`, http.StatusNoContent},
+		{"missing user", "missing", `{"role":"admin"}`, http.StatusNotFound},
+		{"unknown role", "user1", `{"role":"root"}`, http.StatusBadRequest},
+		{"own account", "admin", `{"role":"user"}`, http.StatusForbidden},
+		{"last manager", "admin2", `{"role":"user"}`, http.StatusForbidden},
+		{"empty role", "user1", `{"role":""}`, http.StatusBadRequest},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, "/admin/users/"+tc.username+"/role", bytes.NewBufferString(tc.body))
+			req.SetPathValue("username", tc.username)
+			req = withUser(req, "admin")
+			rr := httptest.NewRecorder()
+			handler.SetRole(rr, req)
+			assert.Equal(t, tc.code, rr.Code)
+		})
+	}
+	authClient.AssertExpectations(t)
+}
+
+func TestUserHandler_DisableEnable(t *testing.T) {
+	authClient := new(mocks.AuthClient)
+	authClient.On("SetDisabled", mock.Anything, "user1", true).Return(nil).Once()
+	authClient.On("SetDisabled", mock.Anything, "user1", false).Return(nil).Once()
+
+	handler := NewUserHandler(authClient, nil)
+	req := httptest.NewRequest(http.MethodPost, "/admin/users/user1/disable", nil)
+	req.SetPathValue("username", "user1")
+	rr := httptest.NewRecorder()
+	handler.Disable(rr, withUser(req, "admin"))
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+
+	req = httptest.NewRequest(http.MethodPost, "/admin/users/user1/enable", nil)
+	req.SetPathValue("username", "user1")
+	rr = httptest.NewRecorder()
+	handler.Enable(rr, withUser(req, "admin"))
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+
+	req = httptest.NewRequest(http.MethodPost, "/admin/users/admin/disable", nil)
+	req.SetPathValue("username", "admin")
+	rr = httptest.NewRecorder()
+	handler.Disable(rr, withUser(req, "admin"))
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	authClient.AssertExpectations(t)
+}
+
+func TestUserHandler_Delete(t *testing.T) {
+	userService := new(mocks.UserService)
+	userService.On("DeleteUser", mock.Anything, "user1").Return(nil).Once()
+	userService.On("DeleteUser", mock.Anything, "missing").Return(domain.ErrNotFound).Once()
+	userService.On("DeleteUser", mock.Anything, "admin2").Return(domain.ErrForbidden).Once()
+
+	handler := NewUserHandler(nil, userService)
+	for username, code := range map[string]int{
+		"user1":   http.StatusNoContent,
+		"missing": http.StatusNotFound,
+		"admin":   http.StatusForbidden,
+		"admin2":  http.StatusForbidden,
+	} {
+		req := httptest.NewRequest(http.MethodDelete, "/admin/users/"+username, nil)
+		req.SetPathValue("username", username)
+		rr := httptest.NewRecorder()
+		handler.Delete(rr, withUser(req, "admin"))
+		assert.Equal(t, code, rr.Code, username)
+	}
+	userService.AssertExpectations(t)
+}
@@ -0,0 +1,104 @@
+package http
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"yadro-microservices/internal/core/domain"
+	"yadro-microservices/internal/core/port"
+)
+
+// Default and maximum number of webhook deliveries returned at once.
+const (
+	defaultDeliveriesLimit = 50
+	maxDeliveriesLimit     = 1000
+)
+
+type WebhookHandler struct {
+	service port.WebhookService
+}
+
+func NewWebhookHandler(service port.WebhookService) *WebhookHandler {
+	return &WebhookHandler{service: service}
+}
+
+func (wh *WebhookHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		URL    string             `json:"url"`
+		Events []domain.EventType `json:"events"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	webhook, err := wh.service.Create(r.Context(), request.URL, request.Events)
+	if err != nil {
+		writeServiceError(w, err, "Failed to create webhook")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/admin/webhooks/"+strconv.FormatInt(webhook.ID, 10))
+	w.WriteHeader(http.StatusCreated)
+	if err = json.NewEncoder(w).Encode(webhook); err != nil {
+		log.Printf("Error encoding response: %v", err)
+		return
+	}
+}
+
+func (wh *WebhookHandler) List(w http.ResponseWriter, r *http.Request) {
+	webhooks, err := wh.service.List(r.Context())
+	if err != nil {
+		writeServiceError(w, err, "Failed to get webhooks")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err = json.NewEncoder(w).Encode(webhooks); err != nil {
+		log.Printf("Error encoding response: %v", err)
+		return
+	}
+}
+
+func (wh *WebhookHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid webhook ID", http.StatusBadRequest)
+		return
+	}
+
+	if err = wh.service.Delete(r.Context(), id); err != nil {
+		writeServiceError(w, err, "Failed to delete webhook")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (wh *WebhookHandler) Deliveries(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid webhook ID", http.StatusBadRequest)
+		return
+	}
+
+	limit, err := positiveQueryParam(r, "limit", defaultDeliveriesLimit, maxDeliveriesLimit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	deliveries, err := wh.service.GetDeliveries(r.Context(), id, limit)
+	if err != nil {
+		writeServiceError(w, err, "Failed to get webhook deliveries")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err = json.NewEncoder(w).Encode(deliveries); err != nil {
+		log.Printf("Error encoding response: %v", err)
+		return
+	}
+}
@@ -0,0 +1,90 @@
+package http
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"yadro-microservices/internal/core/domain"
+	"yadro-microservices/internal/mocks"
+)
+
+func TestCreateWebhookSuccess(t *testing.T) {
+	service := new(mocks.WebhookService)
+	events := []domain.EventType{domain.EventComicAdded, domain.EventUpdateFailed}
+	service.On("Create", mock.Anything, "https://example.com/hook", events).Return(&domain.Webhook{
+		ID:     2,
+		URL:    "https://example.com/hook",
+		Events: events,
+		Secret: "secret",
+	}, nil).Once()
+
+	handler := NewWebhookHandler(service)
+	body := `{"url":"https://example.com/hook","events":["comic.added","update.failed"]}`
+	req, _ := http.NewRequest(http.MethodPost, "/admin/webhooks", bytes.NewBufferString(body))
+	rr := httptest.NewRecorder()
+	handler.Create(rr, req)
+
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.Equal(t, "/admin/webhooks/2", rr.Header().Get("Location"))
+	assert.Contains(t, rr.Body.String(), `"secret":"secret"`)
+	service.AssertExpectations(t)
+}
+
+func TestCreateWebhookInvalid(t *testing.T) {
+	service := new(mocks.WebhookService)
+	service.On("Create", mock.Anything, "https://example.com/hook", []domain.EventType{"comic.removed"}).
+		Return(nil, domain.ErrInvalidInput).Once()
+
+	handler := NewWebhookHandler(service)
+	body := `{"url":"https://example.com/hook","events":["comic.removed"]}`
+	req, _ := http.NewRequest(http.MethodPost, "/admin/webhooks", bytes.NewBufferString(body))
+	rr := httptest.NewRecorder()
+	handler.Create(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	req, _ = http.NewRequest(http.MethodPost, "/admin/webhooks", bytes.NewBufferString(`invalid`))
+	rr = httptest.NewRecorder()
+	handler.Create(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	service.AssertExpectations(t)
+}
+
+func TestDeleteWebhookNotFound(t *testing.T) {
+	service := new(mocks.WebhookService)
+	service.On("Delete", mock.Anything, int64(5)).Return(domain.ErrNotFound).Once()
+
+	handler := NewWebhookHandler(service)
+	req, _ := http.NewRequest(http.MethodDelete, "/admin/webhooks/5", nil)
+	req.SetPathValue("id", "5")
+	rr := httptest.NewRecorder()
+	handler.Delete(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	service.AssertExpectations(t)
+}
+
+func TestWebhookDeliveries(t *testing.T) {
+	service := new(mocks.WebhookService)
+	service.On("GetDeliveries", mock.Anything, int64(2), 10).Return([]*domain.WebhookDelivery{
+		{ID: 1, WebhookID: 2, EventID: "evt", EventType: domain.EventComicAdded, Attempt: 1, StatusCode: 200},
+	}, nil).Once()
+
+	handler := NewWebhookHandler(service)
+	req, _ := http.NewRequest(http.MethodGet, "/admin/webhooks/2/deliveries?limit=10", nil)
+	req.SetPathValue("id", "2")
+	rr := httptest.NewRecorder()
+	handler.Deliveries(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"status_code":200`)
+
+	req, _ = http.NewRequest(http.MethodGet, "/admin/webhooks/2/deliveries?limit=0", nil)
+	req.SetPathValue("id", "2")
+	rr = httptest.NewRecorder()
+	handler.Deliveries(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	service.AssertExpectations(t)
+}
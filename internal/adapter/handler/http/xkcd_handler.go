@@ -2,11 +2,34 @@ package http
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"strconv"
+	"time"
+	"yadro-microservices/internal/adapter/archive"
+	"yadro-microservices/internal/core/domain"
 	"yadro-microservices/internal/core/port"
 )
 
+// maxImportSize is the maximum size of the archive accepted by the import handler.
+const maxImportSize = 512 << 20
+
+// Defaults and limits of the search analytics period in days and of the number of top queries.
+const (
+	defaultAnalyticsDays  = 30
+	maxAnalyticsDays      = 366
+	defaultAnalyticsLimit = 20
+	maxAnalyticsLimit     = 1000
+)
+
+// Default and maximum number of search history entries returned at once.
+const (
+	defaultHistoryLimit = 50
+	maxHistoryLimit     = 1000
+)
+
 type XkcdHandler struct {
 	service port.ComicService
 }
@@ -17,39 +40,117 @@ func NewXkcdHandler(service port.ComicService) *XkcdHandler {
 
 func (xh *XkcdHandler) Update(w http.ResponseWriter, r *http.Request) {
 	log.Println("Got request to update comics")
-	before, err := xh.service.GetNumberOfComics(r.Context())
+	job, err := xh.service.StartUpdate(r.Context())
+	status := http.StatusAccepted
 	if err != nil {
-		log.Printf("Error getting number of comics: %v", err)
-		http.Error(w, "Failed to get number of comics", http.StatusInternalServerError)
+		if !errors.Is(err, domain.ErrUpdateInProgress) {
+			log.Printf("Error starting update: %v", err)
+			http.Error(w, "Failed to start update", http.StatusInternalServerError)
+			return
+		}
+
+		if job == nil {
+			log.Println("Update is already running in another process")
+			http.Error(w, "Update is already in progress", http.StatusConflict)
+			return
+		}
+
+		log.Printf("Update job %d is already running", job.ID)
+		status = http.StatusConflict
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/update/"+strconv.Itoa(job.ID))
+	w.WriteHeader(status)
+	if err = json.NewEncoder(w).Encode(job); err != nil {
+		log.Printf("Error encoding response: %v", err)
+		return
 	}
-	err = xh.service.UpdateComics(r.Context())
+}
+
+func (xh *XkcdHandler) UpdateStatus(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
 	if err != nil {
-		log.Printf("Error updating comics: %v", err)
-		http.Error(w, "Failed to update comics", http.StatusInternalServerError)
+		http.Error(w, "Invalid update job ID", http.StatusBadRequest)
+		return
+	}
+
+	job, err := xh.service.GetUpdateJob(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			http.Error(w, "Update job not found", http.StatusNotFound)
+			return
+		}
+
+		log.Printf("Error getting update job: %v", err)
+		http.Error(w, "Failed to get update job", http.StatusInternalServerError)
 		return
 	}
-	after, err := xh.service.GetNumberOfComics(r.Context())
+
+	w.Header().Set("Content-Type", "application/json")
+	if err = json.NewEncoder(w).Encode(job); err != nil {
+		log.Printf("Error encoding response: %v", err)
+		return
+	}
+}
+
+func (xh *XkcdHandler) Schedule(w http.ResponseWriter, r *http.Request) {
+	status, err := xh.service.GetScheduleStatus(r.Context())
+	if err != nil {
+		log.Printf("Error getting schedule status: %v", err)
+		http.Error(w, "Failed to get schedule status", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err = json.NewEncoder(w).Encode(status); err != nil {
+		log.Printf("Error encoding response: %v", err)
+		return
+	}
+}
+
+func (xh *XkcdHandler) Import(w http.ResponseWriter, r *http.Request) {
+	log.Println("Got request to import comics")
+	comics, err := archive.Read(http.MaxBytesReader(w, r.Body, maxImportSize))
+	if err != nil {
+		log.Printf("Error reading archive: %v", err)
+		http.Error(w, "Failed to parse archive: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	imported, err := xh.service.ImportComics(r.Context(), comics)
+	if errors.Is(err, domain.ErrUpdateInProgress) {
+		http.Error(w, "Update is already in progress", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		log.Printf("Error importing comics: %v", err)
+		http.Error(w, "Failed to import comics", http.StatusInternalServerError)
+		return
+	}
+
+	total, err := xh.service.GetNumberOfComics(r.Context())
 	if err != nil {
 		log.Printf("Error getting number of comics: %v", err)
 		http.Error(w, "Failed to get number of comics", http.StatusInternalServerError)
+		return
 	}
 
 	response := struct {
-		NewComics   int `json:"new"`
-		TotalComics int `json:"total"`
+		ImportedComics int `json:"imported"`
+		TotalComics    int `json:"total"`
 	}{
-		NewComics:   after - before,
-		TotalComics: after,
+		ImportedComics: imported,
+		TotalComics:    total,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	err = json.NewEncoder(w).Encode(response)
-	if err != nil {
+	if err = json.NewEncoder(w).Encode(response); err != nil {
 		log.Printf("Error encoding response: %v", err)
 		return
 	}
 
-	log.Printf("Updated comics: %d new comics, %d total comics", response.NewComics, response.TotalComics)
+	log.Printf("Imported comics: %d new comics, %d total comics", response.ImportedComics, response.TotalComics)
 }
 
 func (xh *XkcdHandler) Search(w http.ResponseWriter, r *http.Request) {
@@ -60,19 +161,144 @@ func (xh *XkcdHandler) Search(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	urls, err := xh.service.Search(r.Context(), query)
-	log.Println("Search results:", urls)
+	result, err := xh.service.Search(r.Context(), query, currentUser(r.Context()))
 	if err != nil {
 		log.Printf("Error searching comics: %v", err)
 		http.Error(w, "Failed to search comics", http.StatusInternalServerError)
 		return
 	}
+	log.Println("Search results:", result.URLs())
+
+	// The search ID is used to report which of the found comics was opened
+	var response any = result.URLs()
+	if r.URL.Query().Get("details") == "true" {
+		response = struct {
+			SearchID int64                `json:"search_id"`
+			Comics   []*domain.FoundComic `json:"comics"`
+		}{
+			SearchID: result.QueryID,
+			Comics:   result.Comics,
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if result.QueryID != 0 {
+		w.Header().Set("X-Search-ID", strconv.FormatInt(result.QueryID, 10))
+	}
+	if err = json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Error encoding response: %v", err)
+		return
+	}
+
+	log.Printf("Found %d comics", len(result.Comics))
+}
+
+func (xh *XkcdHandler) Click(w http.ResponseWriter, r *http.Request) {
+	comicID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid comic ID", http.StatusBadRequest)
+		return
+	}
+
+	var request struct {
+		SearchID int64 `json:"search_id"`
+	}
+	if err = json.NewDecoder(r.Body).Decode(&request); err != nil || request.SearchID <= 0 {
+		http.Error(w, "Invalid search ID", http.StatusBadRequest)
+		return
+	}
+
+	err = xh.service.ReportClick(r.Context(), request.SearchID, currentUser(r.Context()), comicID)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrNotFound):
+			http.Error(w, "Search not found", http.StatusNotFound)
+			return
+		case errors.Is(err, domain.ErrAlreadyExists):
+			http.Error(w, "Click already reported for this search", http.StatusConflict)
+			return
+		case errors.Is(err, domain.ErrInvalidInput):
+			http.Error(w, "Comic is not in the search results", http.StatusBadRequest)
+			return
+		}
+
+		log.Printf("Error reporting click: %v", err)
+		http.Error(w, "Failed to report click", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (xh *XkcdHandler) Analytics(w http.ResponseWriter, r *http.Request) {
+	days, err := positiveQueryParam(r, "days", defaultAnalyticsDays, maxAnalyticsDays)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	limit, err := positiveQueryParam(r, "limit", defaultAnalyticsLimit, maxAnalyticsLimit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	since := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 1-days)
+	analytics, err := xh.service.GetSearchAnalytics(r.Context(), since, limit)
+	if err != nil {
+		log.Printf("Error getting search analytics: %v", err)
+		http.Error(w, "Failed to get search analytics", http.StatusInternalServerError)
+		return
+	}
 
 	w.Header().Set("Content-Type", "application/json")
-	if err = json.NewEncoder(w).Encode(urls); err != nil {
+	if err = json.NewEncoder(w).Encode(analytics); err != nil {
 		log.Printf("Error encoding response: %v", err)
 		return
 	}
+}
+
+func (xh *XkcdHandler) History(w http.ResponseWriter, r *http.Request) {
+	limit, err := positiveQueryParam(r, "limit", defaultHistoryLimit, maxHistoryLimit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	history, err := xh.service.GetHistory(r.Context(), currentUsername(r.Context()), limit)
+	if err != nil {
+		log.Printf("Error getting search history: %v", err)
+		http.Error(w, "Failed to get search history", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err = json.NewEncoder(w).Encode(history); err != nil {
+		log.Printf("Error encoding response: %v", err)
+		return
+	}
+}
+
+func (xh *XkcdHandler) ClearHistory(w http.ResponseWriter, r *http.Request) {
+	if err := xh.service.ClearHistory(r.Context(), currentUsername(r.Context())); err != nil {
+		log.Printf("Error clearing search history: %v", err)
+		http.Error(w, "Failed to clear search history", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// positiveQueryParam parses the optional integer query parameter which must be in [1, maxValue].
+func positiveQueryParam(r *http.Request, name string, defaultValue, maxValue int) (int, error) {
+	s := r.URL.Query().Get(name)
+	if s == "" {
+		return defaultValue, nil
+	}
+
+	value, err := strconv.Atoi(s)
+	if err != nil || value < 1 || value > maxValue {
+		return 0, fmt.Errorf("%s must be an integer from 1 to %d", name, maxValue)
+	}
 
-	log.Printf("Found %d comics", len(urls))
+	return value, nil
 }
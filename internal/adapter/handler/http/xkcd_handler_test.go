@@ -2,68 +2,69 @@ package http
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"yadro-microservices/internal/core/domain"
 	"yadro-microservices/internal/mocks"
 )
 
 func TestUpdateComicsSuccess(t *testing.T) {
 	service := new(mocks.ComicService)
-	service.On("GetNumberOfComics", mock.Anything).Return(10, nil).Once()
-	service.On("UpdateComics", mock.Anything).Return(nil).Once()
-	service.On("GetNumberOfComics", mock.Anything).Return(15, nil).Once()
+	service.On("StartUpdate", mock.Anything).Return(&domain.UpdateJob{
+		ID:     1,
+		Status: domain.UpdateRunning,
+	}, nil).Once()
 
 	handler := NewXkcdHandler(service)
 	req, _ := http.NewRequest(http.MethodPost, "/update", nil)
 	rr := httptest.NewRecorder()
 	handler.Update(rr, req)
 
-	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, http.StatusAccepted, rr.Code)
+	assert.Equal(t, "/update/1", rr.Header().Get("Location"))
+	assert.Contains(t, rr.Body.String(), `"status":"running"`)
 	service.AssertExpectations(t)
 }
 
-func TestUpdateComicsFailure(t *testing.T) {
+func TestUpdateComicsInProgress(t *testing.T) {
 	service := new(mocks.ComicService)
-	service.On("GetNumberOfComics", mock.Anything).Return(10, nil).Once()
-	service.On("UpdateComics", mock.Anything).Return(errors.New("update error")).Once()
+	service.On("StartUpdate", mock.Anything).Return(&domain.UpdateJob{
+		ID:     2,
+		Status: domain.UpdateRunning,
+	}, domain.ErrUpdateInProgress).Once()
 
 	handler := NewXkcdHandler(service)
 	req, _ := http.NewRequest(http.MethodPost, "/update", nil)
 	rr := httptest.NewRecorder()
 	handler.Update(rr, req)
 
-	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	assert.Equal(t, http.StatusConflict, rr.Code)
+	assert.Equal(t, "/update/2", rr.Header().Get("Location"))
 	service.AssertExpectations(t)
 }
 
-func TestUpdateComics_GetNumberOfComicsBeforeError(t *testing.T) {
+func TestUpdateComicsInProgressElsewhere(t *testing.T) {
 	service := new(mocks.ComicService)
-	service.On(
-		"GetNumberOfComics",
-		mock.Anything,
-	).Return(0, errors.New("GetNumberOfComics error")).Twice()
-	service.On("UpdateComics", mock.Anything).Return(nil).Once()
+	service.On("StartUpdate", mock.Anything).Return(nil, domain.ErrUpdateInProgress).Once()
 
 	handler := NewXkcdHandler(service)
 	req, _ := http.NewRequest(http.MethodPost, "/update", nil)
 	rr := httptest.NewRecorder()
 	handler.Update(rr, req)
 
-	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	assert.Equal(t, http.StatusConflict, rr.Code)
+	assert.Empty(t, rr.Header().Get("Location"))
 	service.AssertExpectations(t)
 }
 
-func TestUpdateComics_GetNumberOfComicsAfterError(t *testing.T) {
+func TestUpdateComicsFailure(t *testing.T) {
 	service := new(mocks.ComicService)
-	service.On(
-		"GetNumberOfComics",
-		mock.Anything,
-	).Return(0, errors.New("GetNumberOfComics error")).Twice()
-	service.On("UpdateComics", mock.Anything).Return(nil).Once()
+	service.On("StartUpdate", mock.Anything).Return(nil, errors.New("update error")).Once()
 
 	handler := NewXkcdHandler(service)
 	req, _ := http.NewRequest(http.MethodPost, "/update", nil)
@@ -74,41 +75,89 @@ func TestUpdateComics_GetNumberOfComicsAfterError(t *testing.T) {
 	service.AssertExpectations(t)
 }
 
-func TestUpdateComics_EncodeError(t *testing.T) {
+func TestUpdateStatusSuccess(t *testing.T) {
 	service := new(mocks.ComicService)
-	service.On("GetNumberOfComics", mock.Anything).Return(10, nil).Once()
-	service.On("UpdateComics", mock.Anything).Return(nil).Once()
-	service.On("GetNumberOfComics", mock.Anything).Return(15, nil).Once()
+	service.On("GetUpdateJob", mock.Anything, 3).Return(&domain.UpdateJob{
+		ID:      3,
+		Status:  domain.UpdateSucceeded,
+		Fetched: 5,
+		Saved:   5,
+		Indexed: 5,
+	}, nil).Once()
 
 	handler := NewXkcdHandler(service)
-	req, _ := http.NewRequest(http.MethodPost, "/update", nil)
+	req, _ := http.NewRequest(http.MethodGet, "/update/3", nil)
+	req.SetPathValue("id", "3")
 	rr := httptest.NewRecorder()
+	handler.UpdateStatus(rr, req)
 
-	rr.Body = new(bytes.Buffer)
-	rr.Body.Grow(1)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"indexed":5`)
+	service.AssertExpectations(t)
+}
 
-	handler.Update(rr, req)
+func TestUpdateStatusNotFound(t *testing.T) {
+	service := new(mocks.ComicService)
+	service.On("GetUpdateJob", mock.Anything, 4).Return(nil, domain.ErrNotFound).Once()
 
-	assert.Equal(t, http.StatusOK, rr.Code)
+	handler := NewXkcdHandler(service)
+	req, _ := http.NewRequest(http.MethodGet, "/update/4", nil)
+	req.SetPathValue("id", "4")
+	rr := httptest.NewRecorder()
+	handler.UpdateStatus(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
 	service.AssertExpectations(t)
 }
 
+func TestUpdateStatusInvalidID(t *testing.T) {
+	handler := NewXkcdHandler(nil)
+	req, _ := http.NewRequest(http.MethodGet, "/update/abc", nil)
+	req.SetPathValue("id", "abc")
+	rr := httptest.NewRecorder()
+	handler.UpdateStatus(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+var searchResult = &domain.SearchResult{
+	QueryID: 42,
+	Comics:  []*domain.FoundComic{{ID: 1, URL: "url1"}, {ID: 2, URL: "url2"}},
+}
+
 func TestSearchComicsSuccess(t *testing.T) {
 	service := new(mocks.ComicService)
-	service.On("Search", mock.Anything, "test").Return([]string{"url1", "url2"}, nil).Once()
+	service.On("Search", mock.Anything, "test", &domain.Principal{Username: "user"}).Return(searchResult, nil).Once()
 
 	handler := NewXkcdHandler(service)
 	req, _ := http.NewRequest(http.MethodGet, "/pics?search=test", nil)
+	req = req.WithContext(context.WithValue(req.Context(), currentUserKey, &domain.Principal{Username: "user"}))
+	rr := httptest.NewRecorder()
+	handler.Search(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `["url1","url2"]`, rr.Body.String())
+	assert.Equal(t, "42", rr.Header().Get("X-Search-ID"))
+	service.AssertExpectations(t)
+}
+
+func TestSearchComicsDetails(t *testing.T) {
+	service := new(mocks.ComicService)
+	service.On("Search", mock.Anything, "test", (*domain.Principal)(nil)).Return(searchResult, nil).Once()
+
+	handler := NewXkcdHandler(service)
+	req, _ := http.NewRequest(http.MethodGet, "/pics?search=test&details=true", nil)
 	rr := httptest.NewRecorder()
 	handler.Search(rr, req)
 
 	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"search_id":42,"comics":[{"id":1,"url":"url1"},{"id":2,"url":"url2"}]}`, rr.Body.String())
 	service.AssertExpectations(t)
 }
 
 func TestSearchComicsFailure(t *testing.T) {
 	service := new(mocks.ComicService)
-	service.On("Search", mock.Anything, "test").Return([]string{}, errors.New("search error")).Once()
+	service.On("Search", mock.Anything, "test", (*domain.Principal)(nil)).Return(nil, errors.New("search error")).Once()
 
 	handler := NewXkcdHandler(service)
 	req, _ := http.NewRequest(http.MethodGet, "/pics?search=test", nil)
@@ -130,7 +179,7 @@ func TestSearchComicsEmptyQuery(t *testing.T) {
 
 func TestSearchComics_EncodeError(t *testing.T) {
 	service := new(mocks.ComicService)
-	service.On("Search", mock.Anything, "test").Return([]string{"url1", "url2"}, nil).Once()
+	service.On("Search", mock.Anything, "test", (*domain.Principal)(nil)).Return(searchResult, nil).Once()
 
 	handler := NewXkcdHandler(service)
 	req, _ := http.NewRequest(http.MethodGet, "/pics?search=test", nil)
@@ -144,3 +193,230 @@ func TestSearchComics_EncodeError(t *testing.T) {
 	assert.Equal(t, http.StatusOK, rr.Code)
 	service.AssertExpectations(t)
 }
+
+func TestImportComicsSuccess(t *testing.T) {
+	service := new(mocks.ComicService)
+	service.On("ImportComics", mock.Anything, domain.Comics{
+		1: {Source: "xkcd", Img: "url1", Keywords: []string{"barrel"}},
+	}).Return(1, nil).Once()
+	service.On("GetNumberOfComics", mock.Anything).Return(10, nil).Once()
+
+	handler := NewXkcdHandler(service)
+	body := `{"id":1,"source":"xkcd","url":"url1","keywords":["barrel"]}` + "\n"
+	req, _ := http.NewRequest(http.MethodPost, "/import", bytes.NewBufferString(body))
+	rr := httptest.NewRecorder()
+	handler.Import(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"imported":1,"total":10}`, rr.Body.String())
+	service.AssertExpectations(t)
+}
+
+func TestImportComics_InvalidArchive(t *testing.T) {
+	service := new(mocks.ComicService)
+
+	handler := NewXkcdHandler(service)
+	req, _ := http.NewRequest(http.MethodPost, "/import", bytes.NewBufferString("{invalid json}"))
+	rr := httptest.NewRecorder()
+	handler.Import(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	service.AssertNotCalled(t, "ImportComics", mock.Anything, mock.Anything)
+}
+
+func TestImportComicsFailure(t *testing.T) {
+	service := new(mocks.ComicService)
+	service.On("ImportComics", mock.Anything, mock.Anything).Return(0, errors.New("import error")).Once()
+
+	handler := NewXkcdHandler(service)
+	req, _ := http.NewRequest(http.MethodPost, "/import", bytes.NewBufferString(`{"id":1,"source":"xkcd","url":"url1"}`))
+	rr := httptest.NewRecorder()
+	handler.Import(rr, req)
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	service.AssertExpectations(t)
+}
+
+func TestImportComics_UpdateInProgress(t *testing.T) {
+	service := new(mocks.ComicService)
+	service.On("ImportComics", mock.Anything, mock.Anything).Return(0, domain.ErrUpdateInProgress).Once()
+
+	handler := NewXkcdHandler(service)
+	req, _ := http.NewRequest(http.MethodPost, "/import", bytes.NewBufferString(`{"id":1,"source":"xkcd","url":"url1"}`))
+	rr := httptest.NewRecorder()
+	handler.Import(rr, req)
+
+	assert.Equal(t, http.StatusConflict, rr.Code)
+	service.AssertExpectations(t)
+}
+
+func TestScheduleStatusSuccess(t *testing.T) {
+	service := new(mocks.ComicService)
+	service.On("GetScheduleStatus", mock.Anything).Return(&domain.UpdateScheduleStatus{
+		Cron:     "0 */6 * * *",
+		Timezone: "UTC",
+	}, nil).Once()
+
+	handler := NewXkcdHandler(service)
+	req, _ := http.NewRequest(http.MethodGet, "/admin/schedule", nil)
+	rr := httptest.NewRecorder()
+	handler.Schedule(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"cron":"0 */6 * * *"`)
+	service.AssertExpectations(t)
+}
+
+func TestScheduleStatusFailure(t *testing.T) {
+	service := new(mocks.ComicService)
+	service.On("GetScheduleStatus", mock.Anything).Return(nil, errors.New("database error")).Once()
+
+	handler := NewXkcdHandler(service)
+	req, _ := http.NewRequest(http.MethodGet, "/admin/schedule", nil)
+	rr := httptest.NewRecorder()
+	handler.Schedule(rr, req)
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	service.AssertExpectations(t)
+}
+
+func TestClickSuccess(t *testing.T) {
+	service := new(mocks.ComicService)
+	service.On("ReportClick", mock.Anything, int64(42), &domain.Principal{Username: "user"}, 353).Return(nil).Once()
+
+	handler := NewXkcdHandler(service)
+	req, _ := http.NewRequest(http.MethodPost, "/comics/353/click", bytes.NewBufferString(`{"search_id":42}`))
+	req.SetPathValue("id", "353")
+	req = req.WithContext(context.WithValue(req.Context(), currentUserKey, &domain.Principal{Username: "user"}))
+	rr := httptest.NewRecorder()
+	handler.Click(rr, req)
+
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	service.AssertExpectations(t)
+}
+
+func TestClickNotFound(t *testing.T) {
+	service := new(mocks.ComicService)
+	service.On("ReportClick", mock.Anything, int64(42), (*domain.Principal)(nil), 353).Return(domain.ErrNotFound).Once()
+
+	handler := NewXkcdHandler(service)
+	req, _ := http.NewRequest(http.MethodPost, "/comics/353/click", bytes.NewBufferString(`{"search_id":42}`))
+	req.SetPathValue("id", "353")
+	rr := httptest.NewRecorder()
+	handler.Click(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	service.AssertExpectations(t)
+}
+
+func TestClickRejected(t *testing.T) {
+	service := new(mocks.ComicService)
+	anonymous := (*domain.Principal)(nil)
+	service.On("ReportClick", mock.Anything, int64(42), anonymous, 353).Return(domain.ErrAlreadyExists).Once()
+	service.On("ReportClick", mock.Anything, int64(42), anonymous, 354).Return(domain.ErrInvalidInput).Once()
+
+	handler := NewXkcdHandler(service)
+	req, _ := http.NewRequest(http.MethodPost, "/comics/353/click", bytes.NewBufferString(`{"search_id":42}`))
+	req.SetPathValue("id", "353")
+	rr := httptest.NewRecorder()
+	handler.Click(rr, req)
+	assert.Equal(t, http.StatusConflict, rr.Code)
+
+	req, _ = http.NewRequest(http.MethodPost, "/comics/354/click", bytes.NewBufferString(`{"search_id":42}`))
+	req.SetPathValue("id", "354")
+	rr = httptest.NewRecorder()
+	handler.Click(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	service.AssertExpectations(t)
+}
+
+func TestClickInvalidRequest(t *testing.T) {
+	handler := NewXkcdHandler(nil)
+
+	req, _ := http.NewRequest(http.MethodPost, "/comics/abc/click", bytes.NewBufferString(`{"search_id":42}`))
+	req.SetPathValue("id", "abc")
+	rr := httptest.NewRecorder()
+	handler.Click(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	req, _ = http.NewRequest(http.MethodPost, "/comics/353/click", bytes.NewBufferString(`{}`))
+	req.SetPathValue("id", "353")
+	rr = httptest.NewRecorder()
+	handler.Click(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestAnalyticsSuccess(t *testing.T) {
+	service := new(mocks.ComicService)
+	service.On("GetSearchAnalytics", mock.Anything, mock.Anything, 5).Return(&domain.SearchAnalytics{
+		TopQueries:        []domain.QueryStats{{Query: "python", Count: 3}},
+		ZeroResultQueries: []domain.QueryStats{},
+		Days:              []domain.DailySearchStats{{Day: "2024-06-01", Queries: 3}},
+	}, nil).Once()
+
+	handler := NewXkcdHandler(service)
+	req, _ := http.NewRequest(http.MethodGet, "/admin/analytics?days=7&limit=5", nil)
+	rr := httptest.NewRecorder()
+	handler.Analytics(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"query":"python"`)
+	assert.Contains(t, rr.Body.String(), `"day":"2024-06-01"`)
+	service.AssertExpectations(t)
+}
+
+func TestAnalyticsInvalidParams(t *testing.T) {
+	handler := NewXkcdHandler(nil)
+
+	for _, query := range []string{"days=0", "days=abc", "limit=-1", "limit=100000"} {
+		req, _ := http.NewRequest(http.MethodGet, "/admin/analytics?"+query, nil)
+		rr := httptest.NewRecorder()
+		handler.Analytics(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code, query)
+	}
+}
+
+func TestHistorySuccess(t *testing.T) {
+	service := new(mocks.ComicService)
+	service.On("GetHistory", mock.Anything, "user", 5).Return([]*domain.HistoryEntry{
+		{ID: 1, Query: "python", ResultCount: 3},
+	}, nil).Once()
+
+	handler := NewXkcdHandler(service)
+	req, _ := http.NewRequest(http.MethodGet, "/me/history?limit=5", nil)
+	rr := httptest.NewRecorder()
+	handler.History(rr, withUser(req, "user"))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"query":"python"`)
+	service.AssertExpectations(t)
+}
+
+func TestHistoryInvalidLimit(t *testing.T) {
+	handler := NewXkcdHandler(nil)
+
+	req, _ := http.NewRequest(http.MethodGet, "/me/history?limit=0", nil)
+	rr := httptest.NewRecorder()
+	handler.History(rr, withUser(req, "user"))
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestClearHistory(t *testing.T) {
+	service := new(mocks.ComicService)
+	service.On("ClearHistory", mock.Anything, "user").Return(nil).Once()
+	service.On("ClearHistory", mock.Anything, "other").Return(errors.New("db is down")).Once()
+
+	handler := NewXkcdHandler(service)
+	req, _ := http.NewRequest(http.MethodDelete, "/me/history", nil)
+	rr := httptest.NewRecorder()
+	handler.ClearHistory(rr, withUser(req, "user"))
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+
+	req, _ = http.NewRequest(http.MethodDelete, "/me/history", nil)
+	rr = httptest.NewRecorder()
+	handler.ClearHistory(rr, withUser(req, "other"))
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	service.AssertExpectations(t)
+}
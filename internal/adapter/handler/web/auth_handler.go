@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"html/template"
 	"log"
+	"net"
 	"net/http"
 	"time"
 )
@@ -62,6 +63,11 @@ func (ah *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if ip, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		// Failed logins are throttled by the address of the user, not of the web server
+		req.Header.Set("X-Forwarded-For", ip)
+	}
+
 	client := &http.Client{Timeout: 10 * time.Second}
 	resp, err := client.Do(req)
 	if err != nil {
@@ -71,6 +77,12 @@ func (ah *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusTooManyRequests {
+		w.Header().Set("Retry-After", resp.Header.Get("Retry-After"))
+		http.Error(w, "Too many failed login attempts, try again later", http.StatusTooManyRequests)
+		return
+	}
+
 	if resp.StatusCode != http.StatusOK {
 		log.Printf("Invalid response: %d", resp.StatusCode)
 		http.Error(w, "Invalid credentials", http.StatusUnauthorized)
@@ -90,6 +102,7 @@ func (ah *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		Path:     "/",
 		Expires:  expiration,
 		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
 	})
 	http.Redirect(w, r, "/comics", http.StatusSeeOther)
 }
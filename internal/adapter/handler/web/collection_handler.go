@@ -0,0 +1,207 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// comic is a comic as returned by the comics server.
+type comic struct {
+	ID  int    `json:"id"`
+	URL string `json:"url"`
+}
+
+// collection is a comic collection as returned by the comics server.
+type collection struct {
+	ID     int64   `json:"id"`
+	Name   string  `json:"name"`
+	Comics []comic `json:"comics"`
+}
+
+// CollectionHandler is html handler for favorite comics and comic collections of the user.
+type CollectionHandler struct {
+	meURL string
+}
+
+// NewCollectionHandler creates new CollectionHandler.
+func NewCollectionHandler(meURL string) *CollectionHandler {
+	return &CollectionHandler{meURL: meURL}
+}
+
+// Collections renders the favorites and the collections of the user.
+func (ch *CollectionHandler) Collections(w http.ResponseWriter, r *http.Request) {
+	tokenCookie, err := r.Cookie("token")
+	if err != nil {
+		log.Printf("Failed to get token: %s", err)
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	var favorites []comic
+	if err = ch.do(r, tokenCookie, http.MethodGet, "/favorites", nil, &favorites); err != nil {
+		log.Printf("Failed to get favorites: %s", err)
+		http.Error(w, "Failed to get favorites", http.StatusBadGateway)
+		return
+	}
+
+	var collections []collection
+	if err = ch.do(r, tokenCookie, http.MethodGet, "/collections", nil, &collections); err != nil {
+		log.Printf("Failed to get collections: %s", err)
+		http.Error(w, "Failed to get collections", http.StatusBadGateway)
+		return
+	}
+
+	log.Printf("Rendering %d favorites and %d collections", len(favorites), len(collections))
+	tmpl := template.Must(template.ParseFiles("templates/collections.html"))
+	err = tmpl.Execute(w, map[string]interface{}{
+		"Favorites":   favorites,
+		"Collections": collections,
+		"CSRFToken":   csrfToken(w, r),
+	})
+	if err != nil {
+		log.Printf("Failed to render template: %s", err)
+		http.Error(w, "Failed to render template", http.StatusInternalServerError)
+	}
+}
+
+// AddFavorite adds the comic to the favorites and redirects back.
+func (ch *CollectionHandler) AddFavorite(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+	ch.change(w, r, http.MethodPost, "/favorites/"+id, nil)
+}
+
+// RemoveFavorite removes the comic from the favorites and redirects back.
+func (ch *CollectionHandler) RemoveFavorite(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+	ch.change(w, r, http.MethodDelete, "/favorites/"+id, nil)
+}
+
+// CreateCollection creates the collection with the name from the form and redirects back.
+func (ch *CollectionHandler) CreateCollection(w http.ResponseWriter, r *http.Request) {
+	ch.change(w, r, http.MethodPost, "/collections", map[string]string{"name": r.PostFormValue("name")})
+}
+
+// DeleteCollection deletes the collection and redirects back.
+func (ch *CollectionHandler) DeleteCollection(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+	ch.change(w, r, http.MethodDelete, "/collections/"+id, nil)
+}
+
+// AddToCollection adds the comic to the collection from the form and redirects back.
+func (ch *CollectionHandler) AddToCollection(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+	collectionID, err := strconv.Atoi(r.PostFormValue("collection_id"))
+	if err != nil {
+		http.Error(w, "Invalid collection ID", http.StatusBadRequest)
+		return
+	}
+	ch.change(w, r, http.MethodPost, "/collections/"+strconv.Itoa(collectionID)+"/comics/"+id, nil)
+}
+
+// RemoveFromCollection removes the comic from the collection and redirects back.
+func (ch *CollectionHandler) RemoveFromCollection(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+	comicID, ok := pathID(w, r, "comic_id")
+	if !ok {
+		return
+	}
+	ch.change(w, r, http.MethodDelete, "/collections/"+id+"/comics/"+comicID, nil)
+}
+
+// change sends the change request to the comics server and redirects back to the referring page.
+// The form must carry the CSRF token, so other sites cannot change the collections of the user.
+func (ch *CollectionHandler) change(w http.ResponseWriter, r *http.Request, method, path string, body any) {
+	tokenCookie, err := r.Cookie("token")
+	if err != nil {
+		log.Printf("Failed to get token: %s", err)
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	if !validCSRF(r) {
+		log.Print("Invalid CSRF token")
+		http.Error(w, "Invalid CSRF token", http.StatusForbidden)
+		return
+	}
+
+	if err = ch.do(r, tokenCookie, method, path, body, nil); err != nil {
+		log.Printf("Failed to change collections: %s", err)
+		http.Error(w, "Failed to change collections", http.StatusBadGateway)
+		return
+	}
+
+	http.Redirect(w, r, localReferer(r, "/collections"), http.StatusSeeOther)
+}
+
+// pathID returns the numeric path value with the given name. The request fails with 400 Bad Request
+// if the value is not a number, so it cannot change the path of the request to the comics server.
+func pathID(w http.ResponseWriter, r *http.Request, name string) (string, bool) {
+	id, err := strconv.Atoi(r.PathValue(name))
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return "", false
+	}
+
+	return strconv.Itoa(id), true
+}
+
+// do sends the request on behalf of the user to the comics server and decodes the response into result.
+func (ch *CollectionHandler) do(
+	r *http.Request,
+	tokenCookie *http.Cookie,
+	method, path string,
+	body, result any,
+) error {
+	var reqBody bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&reqBody).Encode(body); err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(r.Context(), method, ch.meURL+path, &reqBody)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+tokenCookie.Value)
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to do request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("invalid response: %d", resp.StatusCode)
+	}
+
+	if result != nil {
+		if err = json.NewDecoder(resp.Body).Decode(result); err != nil {
+			return fmt.Errorf("failed to parse response: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"html/template"
 	"log"
 	"net/http"
+	"net/url"
 	"time"
 )
 
@@ -46,7 +47,7 @@ func (ch *ComicHandler) SearchComics(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	req, err := http.NewRequestWithContext(r.Context(), http.MethodGet, ch.searchURL+"?search="+query, nil)
+	req, err := http.NewRequestWithContext(r.Context(), http.MethodGet, ch.searchURL+"?details=true&search="+url.QueryEscape(query), nil)
 	if err != nil {
 		log.Printf("Failed create request: %s", err)
 		http.Error(w, "Failed to search comics", http.StatusInternalServerError)
@@ -68,17 +69,20 @@ func (ch *ComicHandler) SearchComics(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var urls []string
-	if err := json.NewDecoder(resp.Body).Decode(&urls); err != nil {
+	var result struct {
+		Comics []comic `json:"comics"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
 		log.Printf("Failed to parse response: %s", err)
 		http.Error(w, "Failed to parse response", http.StatusInternalServerError)
 		return
 	}
 
-	log.Printf("Found %d comics", len(urls))
+	log.Printf("Found %d comics", len(result.Comics))
 	tmpl := template.Must(template.New("comics.html").ParseFiles("templates/comics.html"))
 	err = tmpl.Execute(w, map[string]interface{}{
-		"Comics": urls,
+		"Comics":    result.Comics,
+		"CSRFToken": csrfToken(w, r),
 	})
 	if err != nil {
 		log.Printf("Failed to render template: %s", err)
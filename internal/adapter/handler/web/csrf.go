@@ -0,0 +1,67 @@
+package web
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	// csrfCookie is the cookie with the CSRF token of the browser.
+	csrfCookie = "csrf"
+	// csrfField is the form field every changing form must send the CSRF token in.
+	csrfField = "csrf_token"
+)
+
+// csrfToken returns the CSRF token of the browser to render into forms.
+// A new token is generated and set as a cookie if the browser does not have one yet.
+func csrfToken(w http.ResponseWriter, r *http.Request) string {
+	if cookie, err := r.Cookie(csrfCookie); err == nil && cookie.Value != "" {
+		return cookie.Value
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		log.Printf("Failed to generate CSRF token: %s", err)
+		return ""
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(b)
+	http.SetCookie(w, &http.Cookie{
+		Name:     csrfCookie,
+		Value:    token,
+		Path:     "/",
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	return token
+}
+
+// validCSRF reports whether the form carries the CSRF token from the cookie of the browser.
+// Another site can make the browser send the cookie, but cannot read it to put it into the form.
+func validCSRF(r *http.Request) bool {
+	cookie, err := r.Cookie(csrfCookie)
+	if err != nil || cookie.Value == "" {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(r.PostFormValue(csrfField))) == 1
+}
+
+// localReferer returns the path of the referring page if it is a page of this site, or fallback otherwise,
+// so the user is never redirected to another site.
+func localReferer(r *http.Request, fallback string) string {
+	referer, err := url.Parse(r.Referer())
+	if err != nil || referer.Host != r.Host || !strings.HasPrefix(referer.Path, "/") ||
+		strings.HasPrefix(referer.Path, "//") || strings.Contains(referer.Path, `\`) {
+		return fallback
+	}
+
+	back := &url.URL{Path: referer.Path, RawQuery: referer.RawQuery}
+	return back.String()
+}
@@ -0,0 +1,45 @@
+package judgment
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"yadro-microservices/internal/core/domain"
+)
+
+// Read reads judged queries in the JSON Lines format from r, one query per line:
+//
+//	{"query": "password strength", "relevant": [936, 538]}
+func Read(r io.Reader) ([]domain.JudgedQuery, error) {
+	var queries []domain.JudgedQuery
+	dec := json.NewDecoder(r)
+	for line := 1; ; line++ {
+		var q domain.JudgedQuery
+		err := dec.Decode(&q)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error decoding judged query %d: %w", line, err)
+		}
+		if q.Query == "" {
+			return nil, fmt.Errorf("judged query %d: query is empty", line)
+		}
+
+		queries = append(queries, q)
+	}
+
+	return queries, nil
+}
+
+// ReadFile reads judged queries from the file at path.
+func ReadFile(path string) ([]domain.JudgedQuery, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening file: %w", err)
+	}
+	defer f.Close()
+
+	return Read(f)
+}
@@ -0,0 +1,31 @@
+package judgment
+
+import (
+	"strings"
+	"testing"
+	"yadro-microservices/internal/core/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRead(t *testing.T) {
+	queries, err := Read(strings.NewReader(
+		"{\"query\": \"password strength\", \"relevant\": [936, 538]}\n\n{\"query\": \"barrel\", \"relevant\": []}\n",
+	))
+
+	require.NoError(t, err)
+	assert.Equal(t, []domain.JudgedQuery{
+		{Query: "password strength", Relevant: []int{936, 538}},
+		{Query: "barrel", Relevant: []int{}},
+	}, queries)
+}
+
+func TestRead_Invalid(t *testing.T) {
+	_, err := Read(strings.NewReader("{\"query\": \"barrel\"}\n{invalid json}\n"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "judged query 2")
+
+	_, err = Read(strings.NewReader("{\"relevant\": [1]}\n"))
+	require.Error(t, err)
+}
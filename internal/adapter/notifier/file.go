@@ -0,0 +1,64 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+	"yadro-microservices/internal/core/domain"
+)
+
+// FileNotifier appends notifications to a file as JSON lines, so they can be read by tests and local tools.
+type FileNotifier struct {
+	path string
+	mu   sync.Mutex
+}
+
+// fileRecord is a notification as written to the file.
+type fileRecord struct {
+	Username string    `json:"username"`
+	Email    string    `json:"email"`
+	Subject  string    `json:"subject"`
+	Text     string    `json:"text"`
+	SentAt   time.Time `json:"sent_at"`
+}
+
+// NewFileNotifier creates a new instance of FileNotifier writing to the file at the given path.
+func NewFileNotifier(path string) *FileNotifier {
+	return &FileNotifier{path: path}
+}
+
+// Notify appends the notification to the file, creating it if needed.
+func (n *FileNotifier) Notify(_ context.Context, msg *domain.Notification) error {
+	line, err := json.Marshal(fileRecord{
+		Username: msg.Username,
+		Email:    msg.Email,
+		Subject:  msg.Subject,
+		Text:     msg.Text,
+		SentAt:   time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("error encoding notification: %w", err)
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	f, err := os.OpenFile(n.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("error opening notifications file: %w", err)
+	}
+
+	if _, err = f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("error writing notification: %w", err)
+	}
+
+	if err = f.Close(); err != nil {
+		return fmt.Errorf("error closing notifications file: %w", err)
+	}
+
+	return nil
+}
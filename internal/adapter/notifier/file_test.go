@@ -0,0 +1,54 @@
+package notifier
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+	"yadro-microservices/internal/core/domain"
+)
+
+func TestFileNotifier_Notify(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notifications.jsonl")
+	n := NewFileNotifier(path)
+
+	require.NoError(t, n.Notify(context.Background(), &domain.Notification{
+		Username: "first",
+		Email:    "first@example.com",
+		Subject:  "Confirm",
+		Text:     "token1",
+	}))
+	require.NoError(t, n.Notify(context.Background(), &domain.Notification{Username: "second", Text: "token2"}))
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	var records []fileRecord
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var r fileRecord
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &r))
+		records = append(records, r)
+	}
+	require.NoError(t, scanner.Err())
+
+	require.Len(t, records, 2)
+	assert.Equal(t, "first", records[0].Username)
+	assert.Equal(t, "first@example.com", records[0].Email)
+	assert.Equal(t, "token1", records[0].Text)
+	assert.False(t, records[0].SentAt.IsZero())
+	assert.Equal(t, "second", records[1].Username)
+}
+
+func TestFileNotifier_NotifyError(t *testing.T) {
+	n := NewFileNotifier(filepath.Join(t.TempDir(), "missing", "notifications.jsonl"))
+
+	err := n.Notify(context.Background(), &domain.Notification{Username: "user"})
+
+	assert.Error(t, err)
+}
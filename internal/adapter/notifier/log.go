@@ -0,0 +1,21 @@
+package notifier
+
+import (
+	"context"
+	"log"
+	"yadro-microservices/internal/core/domain"
+)
+
+// LogNotifier writes notifications to the log. It stands in for real delivery in local runs.
+type LogNotifier struct{}
+
+// NewLogNotifier creates a new instance of LogNotifier.
+func NewLogNotifier() *LogNotifier {
+	return &LogNotifier{}
+}
+
+// Notify logs the notification.
+func (n *LogNotifier) Notify(_ context.Context, msg *domain.Notification) error {
+	log.Printf("Notification to %s <%s>: %s\n%s", msg.Username, msg.Email, msg.Subject, msg.Text)
+	return nil
+}
@@ -0,0 +1,186 @@
+package pg
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"log"
+	"time"
+	"yadro-microservices/internal/core/domain"
+)
+
+// APIKeyRepository stores the API keys of users by their hashes.
+type APIKeyRepository struct {
+	db *sql.DB
+}
+
+// NewAPIKeyRepository creates a new instance of APIKeyRepository.
+func NewAPIKeyRepository(db *sql.DB) *APIKeyRepository {
+	return &APIKeyRepository{db: db}
+}
+
+// Create saves the API key and sets its ID and creation time. The key is refused with ErrInvalidInput
+// if the user already has maxKeys keys which have not expired. The user is locked while the keys are counted,
+// so concurrent requests cannot exceed the limit.
+func (r *APIKeyRepository) Create(ctx context.Context, k *domain.APIKey, maxKeys int) error {
+	permissions := make(pq.StringArray, len(k.Permissions))
+	for i, p := range k.Permissions {
+		permissions[i] = string(p)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer func(tx *sql.Tx) {
+		err := tx.Rollback()
+		if err != nil && !errors.Is(err, sql.ErrTxDone) {
+			log.Printf("error rolling back transaction: %v\n", err)
+		}
+	}(tx)
+
+	var count int
+	err = tx.QueryRowContext(
+		ctx,
+		`SELECT (SELECT COUNT(*) FROM api_keys
+			WHERE username = u.username AND (expires_at IS NULL OR expires_at > NOW()))
+		FROM users u WHERE u.username = $1 FOR UPDATE`,
+		k.Username,
+	).Scan(&count)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("user %s: %w", k.Username, domain.ErrNotFound)
+		}
+
+		return fmt.Errorf("error counting API keys: %w", err)
+	}
+	if count >= maxKeys {
+		return fmt.Errorf("at most %d API keys can be active: %w", maxKeys, domain.ErrInvalidInput)
+	}
+
+	row := tx.QueryRowContext(
+		ctx,
+		`INSERT INTO api_keys (username, name, prefix, hash, permissions, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at`,
+		k.Username,
+		k.Name,
+		k.Prefix,
+		k.Hash,
+		permissions,
+		k.ExpiresAt,
+	)
+
+	if err = row.Scan(&k.ID, &k.CreatedAt); err != nil {
+		return mapError(fmt.Errorf("error saving API key: %w", err), "user", k.Username)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return nil
+}
+
+// GetByHash returns the API key by its hash, or nil if there is no such key.
+func (r *APIKeyRepository) GetByHash(ctx context.Context, hash string) (*domain.APIKey, error) {
+	row := r.db.QueryRowContext(
+		ctx,
+		`SELECT id, username, name, prefix, hash, permissions, created_at, expires_at, last_used_at
+		FROM api_keys WHERE hash = $1`,
+		hash,
+	)
+
+	k, err := scanAPIKey(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting API key: %w", err)
+	}
+
+	return k, nil
+}
+
+// List returns the API keys of the user ordered by creation.
+func (r *APIKeyRepository) List(ctx context.Context, username string) ([]*domain.APIKey, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT id, username, name, prefix, hash, permissions, created_at, expires_at, last_used_at
+		FROM api_keys WHERE username = $1 ORDER BY id`,
+		username,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error getting API keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := make([]*domain.APIKey, 0)
+	for rows.Next() {
+		k, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning API key: %w", err)
+		}
+		keys = append(keys, k)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating API keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+// Delete deletes the API key of the user.
+func (r *APIKeyRepository) Delete(ctx context.Context, username string, id int64) error {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM api_keys WHERE id = $1 AND username = $2", id, username)
+	if err != nil {
+		return fmt.Errorf("error deleting API key: %w", err)
+	}
+
+	return checkAffected(res, "API key", id)
+}
+
+// RecordUse sets the time the API key was last used.
+func (r *APIKeyRepository) RecordUse(ctx context.Context, id int64, at time.Time) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE api_keys SET last_used_at = $2 WHERE id = $1", id, at)
+	if err != nil {
+		return fmt.Errorf("error recording API key use: %w", err)
+	}
+
+	return nil
+}
+
+// scanAPIKey scans the API key from the row of api_keys.
+func scanAPIKey(row interface{ Scan(dest ...any) error }) (*domain.APIKey, error) {
+	var k domain.APIKey
+	var permissions []string
+	var expiresAt, lastUsedAt sql.NullTime
+	err := row.Scan(
+		&k.ID,
+		&k.Username,
+		&k.Name,
+		&k.Prefix,
+		&k.Hash,
+		pq.Array(&permissions),
+		&k.CreatedAt,
+		&expiresAt,
+		&lastUsedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range permissions {
+		k.Permissions = append(k.Permissions, domain.Permission(p))
+	}
+	if expiresAt.Valid {
+		k.ExpiresAt = &expiresAt.Time
+	}
+	if lastUsedAt.Valid {
+		k.LastUsedAt = &lastUsedAt.Time
+	}
+
+	return &k, nil
+}
@@ -0,0 +1,163 @@
+package pg
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"log"
+	"time"
+	"yadro-microservices/internal/core/domain"
+	"yadro-microservices/pkg/fts"
+)
+
+// ClickRepository records clicks on search results and stores the number of click-throughs to comics
+// per query token.
+// Clicks decay with the half-life: the stored number is decayed up to the last click when a new one is added.
+type ClickRepository struct {
+	db       *sql.DB
+	halfLife time.Duration
+}
+
+func NewClickRepository(db *sql.DB, halfLife time.Duration) *ClickRepository {
+	return &ClickRepository{
+		db:       db,
+		halfLife: halfLife,
+	}
+}
+
+// AddClick records the comic opened from the results of the user's search query and adds a click-through
+// to the comic for every token of the query, both in one transaction. Only one click is accepted per query
+// and only on a comic the query returned.
+func (r *ClickRepository) AddClick(
+	ctx context.Context,
+	queryID int64,
+	username string,
+	comicID int,
+	at time.Time,
+) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer func(tx *sql.Tx) {
+		err := tx.Rollback()
+		if err != nil && !errors.Is(err, sql.ErrTxDone) {
+			log.Printf("error rolling back transaction: %v\n", err)
+		}
+	}(tx)
+
+	var (
+		tokens    []string
+		returned  bool
+		clickedID sql.NullInt64
+	)
+	err = tx.QueryRowContext(
+		ctx,
+		`SELECT tokens, $3 = ANY(result_ids), clicked_comic_id FROM search_queries
+		WHERE id = $1 AND username = $2 FOR UPDATE`,
+		queryID,
+		username,
+		comicID,
+	).Scan(pq.Array(&tokens), &returned, &clickedID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("search query %d: %w", queryID, domain.ErrNotFound)
+		}
+
+		return fmt.Errorf("error getting search query: %w", err)
+	}
+	if clickedID.Valid {
+		return fmt.Errorf("click on search query %d: %w", queryID, domain.ErrAlreadyExists)
+	}
+	if !returned {
+		return fmt.Errorf("comic %d was not returned by search query %d: %w", comicID, queryID, domain.ErrInvalidInput)
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE search_queries SET clicked_comic_id = $2 WHERE id = $1", queryID, comicID)
+	if err != nil {
+		return fmt.Errorf("error saving clicked comic: %w", err)
+	}
+
+	if err = r.addClickThrough(ctx, tx, tokens, comicID, at); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return nil
+}
+
+// addClickThrough adds a click-through to the comic for every token of the query.
+func (r *ClickRepository) addClickThrough(
+	ctx context.Context,
+	tx *sql.Tx,
+	tokens []string,
+	comicID int,
+	at time.Time,
+) error {
+	// A row cannot be upserted twice by the same statement, so tokens must be unique
+	unique := make(pq.StringArray, 0, len(tokens))
+	seen := make(map[string]bool, len(tokens))
+	for _, token := range tokens {
+		if !seen[token] {
+			seen[token] = true
+			unique = append(unique, token)
+		}
+	}
+
+	_, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO comic_clicks (token, comic_id, clicks, updated_at)
+		SELECT token, $2::BIGINT, 1, $3::TIMESTAMPTZ FROM unnest($1::TEXT[]) AS token
+		ON CONFLICT (token, comic_id) DO UPDATE SET
+			clicks = 1 + comic_clicks.clicks * CASE
+				WHEN $4::DOUBLE PRECISION <= 0 OR EXCLUDED.updated_at <= comic_clicks.updated_at THEN 1
+				ELSE power(
+					0.5,
+					EXTRACT(EPOCH FROM EXCLUDED.updated_at - comic_clicks.updated_at)::DOUBLE PRECISION / $4
+				)
+			END,
+			updated_at = GREATEST(EXCLUDED.updated_at, comic_clicks.updated_at)`,
+		unique,
+		comicID,
+		at,
+		r.halfLife.Seconds(),
+	)
+	if err != nil {
+		return fmt.Errorf("error saving click: %w", err)
+	}
+
+	return nil
+}
+
+// GetPopularity returns click-throughs to comics from the results of the given tokens.
+func (r *ClickRepository) GetPopularity(ctx context.Context, tokens []string) ([]*fts.Popularity, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		"SELECT token, comic_id, clicks, updated_at FROM comic_clicks WHERE token = ANY($1)",
+		pq.StringArray(tokens),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error executing query: %w", err)
+	}
+	defer rows.Close()
+
+	var popularity []*fts.Popularity
+	for rows.Next() {
+		p := &fts.Popularity{}
+		if err = rows.Scan(&p.Token, &p.ID, &p.Clicks, &p.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning row: %w", err)
+		}
+		popularity = append(popularity, p)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over rows: %w", err)
+	}
+
+	return popularity, nil
+}
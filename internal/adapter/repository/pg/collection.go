@@ -0,0 +1,252 @@
+package pg
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"yadro-microservices/internal/core/domain"
+)
+
+// Codes of the PostgreSQL errors mapped to domain errors.
+const (
+	foreignKeyViolation pq.ErrorCode = "23503"
+	uniqueViolation     pq.ErrorCode = "23505"
+)
+
+// CollectionRepository stores favorite comics and comic collections of users.
+type CollectionRepository struct {
+	db *sql.DB
+}
+
+func NewCollectionRepository(db *sql.DB) *CollectionRepository {
+	return &CollectionRepository{db: db}
+}
+
+// AddFavorite adds the comic to the favorites of the user. Adding a favorite comic again does nothing.
+func (r *CollectionRepository) AddFavorite(ctx context.Context, username string, comicID int) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		"INSERT INTO favorites (username, comic_id) VALUES ($1, $2) ON CONFLICT DO NOTHING",
+		username,
+		comicID,
+	)
+	if err != nil {
+		return fmt.Errorf("error adding favorite: %w", mapError(err, "comic", comicID))
+	}
+
+	return nil
+}
+
+// RemoveFavorite removes the comic from the favorites of the user.
+func (r *CollectionRepository) RemoveFavorite(ctx context.Context, username string, comicID int) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		"DELETE FROM favorites WHERE username = $1 AND comic_id = $2",
+		username,
+		comicID,
+	)
+	if err != nil {
+		return fmt.Errorf("error removing favorite: %w", err)
+	}
+
+	return nil
+}
+
+// GetFavorites returns the favorite comics of the user, most recently added first.
+func (r *CollectionRepository) GetFavorites(ctx context.Context, username string) ([]*domain.FoundComic, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT f.comic_id, COALESCE(c.img, '') FROM favorites f JOIN comics c ON c.id = f.comic_id
+		WHERE f.username = $1 ORDER BY f.created_at DESC, f.comic_id`,
+		username,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error getting favorites: %w", err)
+	}
+	defer rows.Close()
+
+	favorites := make([]*domain.FoundComic, 0)
+	for rows.Next() {
+		var comic domain.FoundComic
+		if err = rows.Scan(&comic.ID, &comic.URL); err != nil {
+			return nil, fmt.Errorf("error scanning row: %w", err)
+		}
+
+		favorites = append(favorites, &comic)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return favorites, nil
+}
+
+// CreateCollection creates an empty collection of the user.
+// It fails with domain.ErrAlreadyExists if the user already has a collection with the name.
+func (r *CollectionRepository) CreateCollection(
+	ctx context.Context,
+	username, name string,
+) (*domain.Collection, error) {
+	row := r.db.QueryRowContext(
+		ctx,
+		"INSERT INTO collections (username, name) VALUES ($1, $2) RETURNING id, created_at",
+		username,
+		name,
+	)
+
+	collection := &domain.Collection{Name: name, Comics: make([]*domain.FoundComic, 0)}
+	if err := row.Scan(&collection.ID, &collection.CreatedAt); err != nil {
+		return nil, fmt.Errorf("error creating collection: %w", mapError(err, "collection", name))
+	}
+
+	return collection, nil
+}
+
+// DeleteCollection deletes the collection of the user.
+func (r *CollectionRepository) DeleteCollection(ctx context.Context, username string, id int64) error {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM collections WHERE id = $1 AND username = $2", id, username)
+	if err != nil {
+		return fmt.Errorf("error deleting collection: %w", err)
+	}
+
+	return checkAffected(res, "collection", id)
+}
+
+// GetCollections returns the collections of the user with their comics, ordered by name.
+func (r *CollectionRepository) GetCollections(ctx context.Context, username string) ([]*domain.Collection, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT cl.id, cl.name, cl.created_at, cc.comic_id, c.img
+		FROM collections cl
+		LEFT JOIN collection_comics cc ON cc.collection_id = cl.id
+		LEFT JOIN comics c ON c.id = cc.comic_id
+		WHERE cl.username = $1
+		ORDER BY cl.name, cl.id, cc.added_at, cc.comic_id`,
+		username,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error getting collections: %w", err)
+	}
+	defer rows.Close()
+
+	collections := make([]*domain.Collection, 0)
+	var last *domain.Collection
+	for rows.Next() {
+		var collection domain.Collection
+		var comicID sql.NullInt64
+		var img sql.NullString
+		if err = rows.Scan(&collection.ID, &collection.Name, &collection.CreatedAt, &comicID, &img); err != nil {
+			return nil, fmt.Errorf("error scanning row: %w", err)
+		}
+
+		// Rows of the same collection come one after another
+		if last == nil || last.ID != collection.ID {
+			collection.Comics = make([]*domain.FoundComic, 0)
+			last = &collection
+			collections = append(collections, last)
+		}
+		if comicID.Valid {
+			last.Comics = append(last.Comics, &domain.FoundComic{ID: int(comicID.Int64), URL: img.String})
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return collections, nil
+}
+
+// AddToCollection adds the comic to the collection of the user. Adding a comic again does nothing.
+func (r *CollectionRepository) AddToCollection(ctx context.Context, username string, id int64, comicID int) error {
+	if err := r.checkOwner(ctx, username, id); err != nil {
+		return err
+	}
+
+	_, err := r.db.ExecContext(
+		ctx,
+		"INSERT INTO collection_comics (collection_id, comic_id) VALUES ($1, $2) ON CONFLICT DO NOTHING",
+		id,
+		comicID,
+	)
+	if err != nil {
+		return fmt.Errorf("error adding comic to collection: %w", mapError(err, "comic", comicID))
+	}
+
+	return nil
+}
+
+// RemoveFromCollection removes the comic from the collection of the user.
+func (r *CollectionRepository) RemoveFromCollection(
+	ctx context.Context,
+	username string,
+	id int64,
+	comicID int,
+) error {
+	if err := r.checkOwner(ctx, username, id); err != nil {
+		return err
+	}
+
+	_, err := r.db.ExecContext(
+		ctx,
+		"DELETE FROM collection_comics WHERE collection_id = $1 AND comic_id = $2",
+		id,
+		comicID,
+	)
+	if err != nil {
+		return fmt.Errorf("error removing comic from collection: %w", err)
+	}
+
+	return nil
+}
+
+// checkOwner fails with domain.ErrNotFound if the user has no collection with the ID.
+func (r *CollectionRepository) checkOwner(ctx context.Context, username string, id int64) error {
+	row := r.db.QueryRowContext(
+		ctx,
+		"SELECT EXISTS (SELECT 1 FROM collections WHERE id = $1 AND username = $2)",
+		id,
+		username,
+	)
+
+	var exists bool
+	if err := row.Scan(&exists); err != nil {
+		return fmt.Errorf("error checking collection: %w", err)
+	}
+	if !exists {
+		return fmt.Errorf("collection %d: %w", id, domain.ErrNotFound)
+	}
+
+	return nil
+}
+
+// checkAffected fails with domain.ErrNotFound if the statement affected no rows.
+func checkAffected(res sql.Result, entity string, id any) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error getting affected rows: %w", err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("%s %v: %w", entity, id, domain.ErrNotFound)
+	}
+
+	return nil
+}
+
+// mapError converts violations of the foreign key and unique constraints to the domain errors.
+func mapError(err error, entity string, id any) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case foreignKeyViolation:
+			return fmt.Errorf("%s %v: %w", entity, id, domain.ErrNotFound)
+		case uniqueViolation:
+			return fmt.Errorf("%s %v: %w", entity, id, domain.ErrAlreadyExists)
+		}
+	}
+
+	return err
+}
@@ -3,12 +3,19 @@ package pg
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"github.com/lib/pq"
 	"log"
+	"sort"
+	"strings"
 	"yadro-microservices/internal/core/domain"
 )
 
+// saveBatchSize is the number of comics upserted with a single statement.
+// Each comic takes 5 parameters, and Postgres allows at most 65535 parameters per statement.
+const saveBatchSize = 1000
+
 type ComicRepository struct {
 	db *sql.DB
 }
@@ -19,35 +26,97 @@ func NewComicRepository(db *sql.DB) *ComicRepository {
 	}
 }
 
-// Save saves comics to the database.
-func (r *ComicRepository) Save(ctx context.Context, c domain.Comics) error {
+// Save upserts comics to the database in batches. Stored comics with the same IDs are replaced,
+// so saving the same comics twice is safe.
+func (r *ComicRepository) Save(ctx context.Context, c domain.Comics) (*domain.SaveStats, error) {
 	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("error starting transaction: %w", err)
+		return nil, fmt.Errorf("error starting transaction: %w", err)
 	}
 	defer func(tx *sql.Tx) {
 		err := tx.Rollback()
-		if err != nil {
+		if err != nil && !errors.Is(err, sql.ErrTxDone) {
 			log.Printf("error rolling back transaction: %v\n", err)
 		}
 	}(tx)
 
-	stmt, err := tx.PrepareContext(ctx, "INSERT INTO comics(id, img, keywords) VALUES($1, $2, $3)")
-	if err != nil {
-		return fmt.Errorf("error preparing statement: %w", err)
+	// Rows are upserted in the order of IDs, so concurrent saves lock them in the same order
+	ids := make([]int, 0, len(c))
+	for id := range c {
+		ids = append(ids, id)
 	}
-	defer stmt.Close()
+	sort.Ints(ids)
 
-	for id, comic := range c {
-		_, err = stmt.ExecContext(ctx, id, comic.Img, pq.Array(comic.Keywords))
-		if err != nil {
-			return fmt.Errorf("error executing statement: %w", err)
+	stats := &domain.SaveStats{}
+	for start := 0; start < len(ids); start += saveBatchSize {
+		end := min(start+saveBatchSize, len(ids))
+		if err = r.saveBatch(ctx, tx, c, ids[start:end], stats); err != nil {
+			return nil, err
 		}
 	}
 
 	err = tx.Commit()
 	if err != nil {
-		return fmt.Errorf("error committing transaction: %w", err)
+		return nil, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return stats, nil
+}
+
+// saveBatch upserts comics with the given IDs using a single multi-row statement.
+func (r *ComicRepository) saveBatch(
+	ctx context.Context,
+	tx *sql.Tx,
+	c domain.Comics,
+	ids []int,
+	stats *domain.SaveStats,
+) error {
+	var query strings.Builder
+	query.WriteString("INSERT INTO comics(id, source, title, img, keywords) VALUES ")
+
+	args := make([]any, 0, len(ids)*5)
+	for i, id := range ids {
+		if i > 0 {
+			query.WriteString(", ")
+		}
+		n := len(args)
+		fmt.Fprintf(&query, "($%d, $%d, $%d, $%d, $%d)", n+1, n+2, n+3, n+4, n+5)
+
+		comic := c[id]
+		args = append(args, id, comic.Source, comic.Title, comic.Img, pq.Array(comic.Keywords))
+	}
+
+	// xmax of a freshly inserted row is zero, while an updated row gets the ID of the updating transaction.
+	// A comic whose keywords changed has to be indexed again by every search index.
+	query.WriteString(` ON CONFLICT (id) DO UPDATE SET
+		source = EXCLUDED.source, title = EXCLUDED.title, img = EXCLUDED.img, keywords = EXCLUDED.keywords,
+		indexed_by = CASE WHEN comics.keywords IS NOT DISTINCT FROM EXCLUDED.keywords
+			THEN comics.indexed_by ELSE '{}' END
+		RETURNING id, xmax = 0`)
+
+	rows, err := tx.QueryContext(ctx, query.String(), args...)
+	if err != nil {
+		return fmt.Errorf("error executing statement: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		var inserted bool
+		if err = rows.Scan(&id, &inserted); err != nil {
+			return fmt.Errorf("error scanning row: %w", err)
+		}
+
+		if inserted {
+			stats.Inserted++
+			stats.InsertedIDs = append(stats.InsertedIDs, id)
+		} else {
+			stats.Updated++
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("error iterating over rows: %w", err)
 	}
 
 	return nil
@@ -55,7 +124,7 @@ func (r *ComicRepository) Save(ctx context.Context, c domain.Comics) error {
 
 // GetAll retrieves all comics from the database.
 func (r *ComicRepository) GetAll(ctx context.Context) (domain.Comics, error) {
-	rows, err := r.db.QueryContext(ctx, "SELECT id, img, keywords FROM comics")
+	rows, err := r.db.QueryContext(ctx, "SELECT id, source, title, img, keywords FROM comics")
 	if err != nil {
 		return nil, fmt.Errorf("error executing query: %w", err)
 	}
@@ -64,13 +133,15 @@ func (r *ComicRepository) GetAll(ctx context.Context) (domain.Comics, error) {
 	comics := make(domain.Comics)
 	for rows.Next() {
 		var id int
-		var img string
+		var source, title, img string
 		var keywords []string
-		if err = rows.Scan(&id, &img, pq.Array(&keywords)); err != nil {
+		if err = rows.Scan(&id, &source, &title, &img, pq.Array(&keywords)); err != nil {
 			return nil, fmt.Errorf("error scanning row: %w", err)
 		}
 
 		comics[id] = &domain.Comic{
+			Source:   source,
+			Title:    title,
 			Img:      img,
 			Keywords: keywords,
 		}
@@ -85,15 +156,17 @@ func (r *ComicRepository) GetAll(ctx context.Context) (domain.Comics, error) {
 
 // GetByID retrieves a comic by its ID from the database.
 func (r *ComicRepository) GetByID(ctx context.Context, id int) (*domain.Comic, error) {
-	row := r.db.QueryRowContext(ctx, "SELECT img, keywords FROM comics WHERE id = $1", id)
+	row := r.db.QueryRowContext(ctx, "SELECT source, title, img, keywords FROM comics WHERE id = $1", id)
 
-	var img string
+	var source, title, img string
 	var keywords []string
-	if err := row.Scan(&img, pq.Array(&keywords)); err != nil {
+	if err := row.Scan(&source, &title, &img, pq.Array(&keywords)); err != nil {
 		return nil, fmt.Errorf("error scanning row: %w", err)
 	}
 
 	return &domain.Comic{
+		Source:   source,
+		Title:    title,
 		Img:      img,
 		Keywords: keywords,
 	}, nil
@@ -134,3 +207,59 @@ func (r *ComicRepository) GetTotalComics(ctx context.Context) (int, error) {
 
 	return count, nil
 }
+
+// GetUnindexed retrieves up to limit comics which are not acknowledged by the search index yet.
+func (r *ComicRepository) GetUnindexed(ctx context.Context, index string, limit int) (domain.Comics, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		"SELECT id, source, title, img, keywords FROM comics WHERE NOT $1 = ANY(indexed_by) ORDER BY id LIMIT $2",
+		index,
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error executing query: %w", err)
+	}
+	defer rows.Close()
+
+	comics := make(domain.Comics)
+	for rows.Next() {
+		var id int
+		var source, title, img string
+		var keywords []string
+		if err = rows.Scan(&id, &source, &title, &img, pq.Array(&keywords)); err != nil {
+			return nil, fmt.Errorf("error scanning row: %w", err)
+		}
+
+		comics[id] = &domain.Comic{
+			Source:   source,
+			Title:    title,
+			Img:      img,
+			Keywords: keywords,
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over rows: %w", err)
+	}
+
+	return comics, nil
+}
+
+// MarkIndexed marks comics with the given IDs as acknowledged by the search index.
+func (r *ComicRepository) MarkIndexed(ctx context.Context, index string, ids []int) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	_, err := r.db.ExecContext(
+		ctx,
+		"UPDATE comics SET indexed_by = array_append(indexed_by, $1) WHERE id = ANY($2) AND NOT $1 = ANY(indexed_by)",
+		index,
+		pq.Array(ids),
+	)
+	if err != nil {
+		return fmt.Errorf("error executing statement: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,106 @@
+package pg
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+	"yadro-microservices/internal/core/domain"
+)
+
+// LoginFailureRepository stores the audit records of failed logins.
+type LoginFailureRepository struct {
+	db *sql.DB
+}
+
+// NewLoginFailureRepository creates a new instance of LoginFailureRepository.
+func NewLoginFailureRepository(db *sql.DB) *LoginFailureRepository {
+	return &LoginFailureRepository{db: db}
+}
+
+// Save saves the failed login and sets its ID.
+func (r *LoginFailureRepository) Save(ctx context.Context, f *domain.LoginFailure) error {
+	row := r.db.QueryRowContext(
+		ctx,
+		"INSERT INTO login_failures (username, client_ip, reason, created_at) VALUES ($1, $2, $3, $4) RETURNING id",
+		f.Username,
+		f.ClientIP,
+		f.Reason,
+		f.CreatedAt,
+	)
+
+	if err := row.Scan(&f.ID); err != nil {
+		return fmt.Errorf("error saving login failure: %w", err)
+	}
+
+	return nil
+}
+
+// SetReason sets the reason of the recorded login attempt once it has failed.
+func (r *LoginFailureRepository) SetReason(ctx context.Context, id int64, reason domain.LoginFailureReason) error {
+	res, err := r.db.ExecContext(ctx, "UPDATE login_failures SET reason = $2 WHERE id = $1", id, reason)
+	if err != nil {
+		return fmt.Errorf("error updating login failure: %w", err)
+	}
+
+	return checkAffected(res, "login failure", id)
+}
+
+// Delete deletes the recorded login attempt.
+func (r *LoginFailureRepository) Delete(ctx context.Context, id int64) error {
+	if _, err := r.db.ExecContext(ctx, "DELETE FROM login_failures WHERE id = $1", id); err != nil {
+		return fmt.Errorf("error deleting login failure: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteBefore deletes the failed logins recorded before the given time.
+func (r *LoginFailureRepository) DeleteBefore(ctx context.Context, before time.Time) error {
+	if _, err := r.db.ExecContext(ctx, "DELETE FROM login_failures WHERE created_at < $1", before); err != nil {
+		return fmt.Errorf("error deleting login failures: %w", err)
+	}
+
+	return nil
+}
+
+// GetStats returns the failed logins of the username and of the client IP since the given time, recorded
+// before the login attempt with the given ID. Attempts still in progress count as failures, so concurrent
+// attempts cannot all pass the check. Failures of the username before its last successful login do not count,
+// nor do throttled attempts.
+func (r *LoginFailureRepository) GetStats(
+	ctx context.Context,
+	username, clientIP string,
+	since time.Time,
+	beforeID int64,
+) (*domain.LoginFailureStats, error) {
+	row := r.db.QueryRowContext(
+		ctx,
+		`WITH f AS (
+			SELECT username = $1 AND created_at > COALESCE((SELECT last_login FROM users WHERE username = $1), $3) AS by_user,
+				$2 <> '' AND client_ip = $2 AS by_ip,
+				created_at
+			FROM login_failures
+			WHERE created_at > $3 AND reason <> $4 AND id < $5 AND (username = $1 OR client_ip = $2)
+		)
+		SELECT COUNT(*) FILTER (WHERE by_user), MAX(created_at) FILTER (WHERE by_user),
+			COUNT(*) FILTER (WHERE by_ip), MAX(created_at) FILTER (WHERE by_ip)
+		FROM f`,
+		username,
+		clientIP,
+		since,
+		domain.LoginThrottled,
+		beforeID,
+	)
+
+	var stats domain.LoginFailureStats
+	var userLast, ipLast sql.NullTime
+	if err := row.Scan(&stats.UserFailures, &userLast, &stats.IPFailures, &ipLast); err != nil {
+		return nil, fmt.Errorf("error getting login failures: %w", err)
+	}
+
+	stats.UserLastFailure = userLast.Time
+	stats.IPLastFailure = ipLast.Time
+
+	return &stats, nil
+}
@@ -0,0 +1,47 @@
+package pg
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"yadro-microservices/internal/core/domain"
+)
+
+// RoleRepository reads the permissions granted to roles.
+type RoleRepository struct {
+	db *sql.DB
+}
+
+// NewRoleRepository creates a new instance of RoleRepository.
+func NewRoleRepository(db *sql.DB) *RoleRepository {
+	return &RoleRepository{db: db}
+}
+
+// GetPermissions returns the permissions granted to the role, sorted by name.
+// A role without permissions or an unknown role has none.
+func (r *RoleRepository) GetPermissions(ctx context.Context, role domain.Role) ([]domain.Permission, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		"SELECT permission FROM role_permissions WHERE role = $1 ORDER BY permission",
+		role,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error getting role permissions: %w", err)
+	}
+	defer rows.Close()
+
+	permissions := make([]domain.Permission, 0)
+	for rows.Next() {
+		var p domain.Permission
+		if err = rows.Scan(&p); err != nil {
+			return nil, fmt.Errorf("error scanning role permission: %w", err)
+		}
+		permissions = append(permissions, p)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating role permissions: %w", err)
+	}
+
+	return permissions, nil
+}
@@ -0,0 +1,207 @@
+package pg
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/lib/pq"
+	"time"
+	"yadro-microservices/internal/core/domain"
+)
+
+// SavedSearchRepository stores saved searches of users and the new comics matching them.
+type SavedSearchRepository struct {
+	db *sql.DB
+}
+
+func NewSavedSearchRepository(db *sql.DB) *SavedSearchRepository {
+	return &SavedSearchRepository{db: db}
+}
+
+// Create saves the search and sets its ID and creation time.
+func (r *SavedSearchRepository) Create(ctx context.Context, s *domain.SavedSearch) error {
+	row := r.db.QueryRowContext(
+		ctx,
+		`INSERT INTO saved_searches (username, query, tokens, webhook_url) VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at`,
+		s.Username,
+		s.Query,
+		pq.StringArray(append([]string{}, s.Tokens...)),
+		s.WebhookURL,
+	)
+
+	if err := row.Scan(&s.ID, &s.CreatedAt); err != nil {
+		return fmt.Errorf("error saving search: %w", err)
+	}
+
+	return nil
+}
+
+// CountByUsername returns the number of searches saved by the user.
+func (r *SavedSearchRepository) CountByUsername(ctx context.Context, username string) (int, error) {
+	row := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM saved_searches WHERE username = $1", username)
+
+	var count int
+	if err := row.Scan(&count); err != nil {
+		return 0, fmt.Errorf("error counting saved searches: %w", err)
+	}
+
+	return count, nil
+}
+
+// GetByUsername returns the searches saved by the user, oldest first.
+func (r *SavedSearchRepository) GetByUsername(ctx context.Context, username string) ([]*domain.SavedSearch, error) {
+	return r.query(
+		ctx,
+		`SELECT id, username, query, tokens, webhook_url, created_at FROM saved_searches
+		WHERE username = $1 ORDER BY id`,
+		username,
+	)
+}
+
+// GetAll returns the searches saved by all users.
+func (r *SavedSearchRepository) GetAll(ctx context.Context) ([]*domain.SavedSearch, error) {
+	return r.query(ctx, "SELECT id, username, query, tokens, webhook_url, created_at FROM saved_searches ORDER BY id")
+}
+
+// Delete deletes the saved search of the user together with its matches.
+func (r *SavedSearchRepository) Delete(ctx context.Context, username string, id int64) error {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM saved_searches WHERE id = $1 AND username = $2", id, username)
+	if err != nil {
+		return fmt.Errorf("error deleting saved search: %w", err)
+	}
+
+	return checkAffected(res, "saved search", id)
+}
+
+// AddMatches records the comics matching the saved search in the inbox of its user.
+// It returns the IDs of the comics which were not recorded before.
+func (r *SavedSearchRepository) AddMatches(ctx context.Context, s *domain.SavedSearch, comicIDs []int) ([]int, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`INSERT INTO saved_search_matches (saved_search_id, username, comic_id)
+		SELECT $1::BIGINT, $2::TEXT, unnest($3::BIGINT[])
+		ON CONFLICT (saved_search_id, comic_id) DO NOTHING
+		RETURNING comic_id`,
+		s.ID,
+		s.Username,
+		int64Array(comicIDs),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error adding saved search matches: %w", mapError(err, "saved search", s.ID))
+	}
+	defer rows.Close()
+
+	added := make([]int, 0, len(comicIDs))
+	for rows.Next() {
+		var id int
+		if err = rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("error scanning row: %w", err)
+		}
+
+		added = append(added, id)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return added, nil
+}
+
+// GetInbox returns the latest comics matching the saved searches of the user, most recent first.
+func (r *SavedSearchRepository) GetInbox(
+	ctx context.Context,
+	username string,
+	unreadOnly bool,
+	limit int,
+) ([]*domain.InboxItem, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT m.id, m.saved_search_id, s.query, m.comic_id, COALESCE(c.img, ''), m.created_at, m.read_at
+		FROM saved_search_matches m
+		JOIN saved_searches s ON s.id = m.saved_search_id
+		JOIN comics c ON c.id = m.comic_id
+		WHERE m.username = $1 AND (NOT $2 OR m.read_at IS NULL)
+		ORDER BY m.created_at DESC, m.id DESC
+		LIMIT $3`,
+		username,
+		unreadOnly,
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error getting inbox: %w", err)
+	}
+	defer rows.Close()
+
+	inbox := make([]*domain.InboxItem, 0)
+	for rows.Next() {
+		var item domain.InboxItem
+		var readAt sql.NullTime
+		err = rows.Scan(
+			&item.ID,
+			&item.SavedSearchID,
+			&item.Query,
+			&item.Comic.ID,
+			&item.Comic.URL,
+			&item.CreatedAt,
+			&readAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning row: %w", err)
+		}
+		if readAt.Valid {
+			item.ReadAt = &readAt.Time
+		}
+
+		inbox = append(inbox, &item)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return inbox, nil
+}
+
+// MarkRead marks the inbox item of the user as read. Items which are already read keep their read time.
+func (r *SavedSearchRepository) MarkRead(ctx context.Context, username string, id int64, at time.Time) error {
+	res, err := r.db.ExecContext(
+		ctx,
+		"UPDATE saved_search_matches SET read_at = COALESCE(read_at, $3) WHERE id = $1 AND username = $2",
+		id,
+		username,
+		at,
+	)
+	if err != nil {
+		return fmt.Errorf("error marking inbox item as read: %w", err)
+	}
+
+	return checkAffected(res, "inbox item", id)
+}
+
+// query returns the saved searches selected by the query.
+func (r *SavedSearchRepository) query(ctx context.Context, query string, args ...any) ([]*domain.SavedSearch, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error getting saved searches: %w", err)
+	}
+	defer rows.Close()
+
+	searches := make([]*domain.SavedSearch, 0)
+	for rows.Next() {
+		var s domain.SavedSearch
+		err = rows.Scan(&s.ID, &s.Username, &s.Query, pq.Array(&s.Tokens), &s.WebhookURL, &s.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning row: %w", err)
+		}
+
+		searches = append(searches, &s)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return searches, nil
+}
@@ -0,0 +1,92 @@
+package pg
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"yadro-microservices/internal/core/domain"
+)
+
+// SearchHistoryRepository stores search queries of users so they can be re-run later.
+// Only the latest maxEntries queries of every user are kept.
+type SearchHistoryRepository struct {
+	db         *sql.DB
+	maxEntries int
+}
+
+func NewSearchHistoryRepository(db *sql.DB, maxEntries int) *SearchHistoryRepository {
+	return &SearchHistoryRepository{
+		db:         db,
+		maxEntries: maxEntries,
+	}
+}
+
+// Add records the search query in the history of the user and deletes the entries beyond the limit.
+func (r *SearchHistoryRepository) Add(ctx context.Context, username string, e *domain.HistoryEntry) error {
+	row := r.db.QueryRowContext(
+		ctx,
+		"INSERT INTO search_history (username, query, result_count, created_at) VALUES ($1, $2, $3, $4) RETURNING id",
+		username,
+		e.Query,
+		e.ResultCount,
+		e.CreatedAt,
+	)
+
+	if err := row.Scan(&e.ID); err != nil {
+		return fmt.Errorf("error saving history entry: %w", err)
+	}
+
+	_, err := r.db.ExecContext(
+		ctx,
+		`DELETE FROM search_history WHERE id IN (
+			SELECT id FROM search_history WHERE username = $1 ORDER BY created_at DESC, id DESC OFFSET $2
+		)`,
+		username,
+		r.maxEntries,
+	)
+	if err != nil {
+		return fmt.Errorf("error trimming search history: %w", err)
+	}
+
+	return nil
+}
+
+// Get returns the latest search queries of the user, most recent first.
+func (r *SearchHistoryRepository) Get(ctx context.Context, username string, limit int) ([]*domain.HistoryEntry, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT id, query, result_count, created_at FROM search_history
+		WHERE username = $1 ORDER BY created_at DESC, id DESC LIMIT $2`,
+		username,
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error getting search history: %w", err)
+	}
+	defer rows.Close()
+
+	history := make([]*domain.HistoryEntry, 0)
+	for rows.Next() {
+		var e domain.HistoryEntry
+		if err = rows.Scan(&e.ID, &e.Query, &e.ResultCount, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning row: %w", err)
+		}
+
+		history = append(history, &e)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return history, nil
+}
+
+// Clear deletes the whole search history of the user.
+func (r *SearchHistoryRepository) Clear(ctx context.Context, username string) error {
+	if _, err := r.db.ExecContext(ctx, "DELETE FROM search_history WHERE username = $1", username); err != nil {
+		return fmt.Errorf("error clearing search history: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,162 @@
+package pg
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/lib/pq"
+	"strings"
+	"time"
+	"yadro-microservices/internal/core/domain"
+)
+
+// SearchQueryRepository logs search queries and aggregates them for analytics.
+type SearchQueryRepository struct {
+	db *sql.DB
+}
+
+func NewSearchQueryRepository(db *sql.DB) *SearchQueryRepository {
+	return &SearchQueryRepository{db: db}
+}
+
+// Save logs the search query and returns its ID.
+func (r *SearchQueryRepository) Save(ctx context.Context, q *domain.SearchQuery) (int64, error) {
+	row := r.db.QueryRowContext(
+		ctx,
+		`INSERT INTO search_queries
+			(query, tokens, normalized_query, username, result_count, result_ids, latency_ms, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id`,
+		q.Query,
+		pq.StringArray(append([]string{}, q.Tokens...)),
+		strings.Join(q.Tokens, " "),
+		q.Username,
+		q.ResultCount,
+		pq.Array(append([]int{}, q.ResultIDs...)),
+		milliseconds(q.Latency),
+		q.CreatedAt,
+	)
+
+	var id int64
+	if err := row.Scan(&id); err != nil {
+		return 0, fmt.Errorf("error saving search query: %w", err)
+	}
+
+	return id, nil
+}
+
+// Anonymize removes the user from the search queries they made.
+func (r *SearchQueryRepository) Anonymize(ctx context.Context, username string) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE search_queries SET username = '' WHERE username = $1", username)
+	if err != nil {
+		return fmt.Errorf("error anonymizing search queries: %w", err)
+	}
+
+	return nil
+}
+
+// GetAnalytics returns the most frequent queries, the most frequent queries without results
+// and daily statistics of the queries made since the given time.
+func (r *SearchQueryRepository) GetAnalytics(
+	ctx context.Context,
+	since time.Time,
+	limit int,
+) (*domain.SearchAnalytics, error) {
+	topQueries, err := r.getQueryStats(ctx, since, limit, false)
+	if err != nil {
+		return nil, fmt.Errorf("error getting top queries: %w", err)
+	}
+
+	zeroResultQueries, err := r.getQueryStats(ctx, since, limit, true)
+	if err != nil {
+		return nil, fmt.Errorf("error getting zero-result queries: %w", err)
+	}
+
+	days, err := r.getDailyStats(ctx, since)
+	if err != nil {
+		return nil, fmt.Errorf("error getting daily statistics: %w", err)
+	}
+
+	return &domain.SearchAnalytics{
+		Since:             since,
+		TopQueries:        topQueries,
+		ZeroResultQueries: zeroResultQueries,
+		Days:              days,
+	}, nil
+}
+
+// getQueryStats returns the most frequent normalized queries, optionally only the ones without results.
+func (r *SearchQueryRepository) getQueryStats(
+	ctx context.Context,
+	since time.Time,
+	limit int,
+	zeroResults bool,
+) ([]domain.QueryStats, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT normalized_query, COUNT(*), AVG(result_count), COUNT(clicked_comic_id)
+		FROM search_queries
+		WHERE created_at >= $1 AND (NOT $3 OR result_count = 0)
+		GROUP BY normalized_query
+		ORDER BY COUNT(*) DESC, normalized_query
+		LIMIT $2`,
+		since,
+		limit,
+		zeroResults,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error executing query: %w", err)
+	}
+	defer rows.Close()
+
+	stats := make([]domain.QueryStats, 0)
+	for rows.Next() {
+		var s domain.QueryStats
+		if err = rows.Scan(&s.Query, &s.Count, &s.AvgResults, &s.Clicks); err != nil {
+			return nil, fmt.Errorf("error scanning row: %w", err)
+		}
+		stats = append(stats, s)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over rows: %w", err)
+	}
+
+	return stats, nil
+}
+
+// getDailyStats returns statistics of the queries per day in UTC.
+func (r *SearchQueryRepository) getDailyStats(ctx context.Context, since time.Time) ([]domain.DailySearchStats, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT to_char(created_at AT TIME ZONE 'UTC', 'YYYY-MM-DD') AS day,
+			COUNT(*),
+			COUNT(*) FILTER (WHERE result_count = 0),
+			COUNT(DISTINCT username),
+			COUNT(clicked_comic_id),
+			AVG(latency_ms)
+		FROM search_queries
+		WHERE created_at >= $1
+		GROUP BY day
+		ORDER BY day`,
+		since,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error executing query: %w", err)
+	}
+	defer rows.Close()
+
+	stats := make([]domain.DailySearchStats, 0)
+	for rows.Next() {
+		var s domain.DailySearchStats
+		if err = rows.Scan(&s.Day, &s.Queries, &s.ZeroResults, &s.Users, &s.Clicks, &s.AvgLatencyMs); err != nil {
+			return nil, fmt.Errorf("error scanning row: %w", err)
+		}
+		stats = append(stats, s)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over rows: %w", err)
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,196 @@
+package pg
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+	"yadro-microservices/internal/core/domain"
+)
+
+// SessionRepository stores login sessions and their refresh tokens.
+type SessionRepository struct {
+	db *sql.DB
+}
+
+func NewSessionRepository(db *sql.DB) *SessionRepository {
+	return &SessionRepository{db: db}
+}
+
+// CreateSession saves the session and sets its creation time.
+func (r *SessionRepository) CreateSession(ctx context.Context, s *domain.Session) error {
+	row := r.db.QueryRowContext(
+		ctx,
+		"INSERT INTO sessions (id, username) VALUES ($1, $2) RETURNING created_at",
+		s.ID,
+		s.Username,
+	)
+
+	if err := row.Scan(&s.CreatedAt); err != nil {
+		return mapError(fmt.Errorf("error saving session: %w", err), "user", s.Username)
+	}
+
+	return nil
+}
+
+// AddRefreshToken saves the refresh token of the session.
+func (r *SessionRepository) AddRefreshToken(ctx context.Context, t *domain.RefreshToken) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		"INSERT INTO refresh_tokens (hash, session_id, expires_at) VALUES ($1, $2, $3)",
+		t.Hash,
+		t.SessionID,
+		t.ExpiresAt,
+	)
+	if err != nil {
+		return fmt.Errorf("error saving refresh token: %w", err)
+	}
+
+	return nil
+}
+
+// GetRefreshToken returns the refresh token by its hash, or nil if there is no such token.
+func (r *SessionRepository) GetRefreshToken(ctx context.Context, hash string) (*domain.RefreshToken, error) {
+	row := r.db.QueryRowContext(
+		ctx,
+		`SELECT t.hash, t.session_id, s.username, t.expires_at, t.used_at, s.revoked_at IS NOT NULL
+		FROM refresh_tokens t JOIN sessions s ON s.id = t.session_id
+		WHERE t.hash = $1`,
+		hash,
+	)
+
+	var t domain.RefreshToken
+	var usedAt sql.NullTime
+	err := row.Scan(&t.Hash, &t.SessionID, &t.Username, &t.ExpiresAt, &usedAt, &t.Revoked)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("error getting refresh token: %w", err)
+	}
+	if usedAt.Valid {
+		t.UsedAt = &usedAt.Time
+	}
+
+	return &t, nil
+}
+
+// UseRefreshToken marks the refresh token as rotated. It reports false if the token was already used.
+func (r *SessionRepository) UseRefreshToken(ctx context.Context, hash string, usedAt time.Time) (bool, error) {
+	res, err := r.db.ExecContext(
+		ctx,
+		"UPDATE refresh_tokens SET used_at = $2 WHERE hash = $1 AND used_at IS NULL",
+		hash,
+		usedAt,
+	)
+	if err != nil {
+		return false, fmt.Errorf("error using refresh token: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error getting affected rows: %w", err)
+	}
+
+	return affected > 0, nil
+}
+
+// RevokeSession revokes the session together with all its tokens.
+func (r *SessionRepository) RevokeSession(ctx context.Context, id string, revokedAt time.Time) error {
+	res, err := r.db.ExecContext(
+		ctx,
+		"UPDATE sessions SET revoked_at = COALESCE(revoked_at, $2) WHERE id = $1",
+		id,
+		revokedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("error revoking session: %w", err)
+	}
+
+	return checkAffected(res, "session", id)
+}
+
+// RevokeUserSessions revokes all sessions of the user together with their tokens.
+func (r *SessionRepository) RevokeUserSessions(ctx context.Context, username string, revokedAt time.Time) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		"UPDATE sessions SET revoked_at = $2 WHERE username = $1 AND revoked_at IS NULL",
+		username,
+		revokedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("error revoking sessions of user: %w", err)
+	}
+
+	return nil
+}
+
+// IsSessionRevoked reports whether the session is revoked. Unknown sessions are reported as revoked.
+func (r *SessionRepository) IsSessionRevoked(ctx context.Context, id string) (bool, error) {
+	var revoked bool
+	err := r.db.QueryRowContext(
+		ctx,
+		"SELECT revoked_at IS NOT NULL FROM sessions WHERE id = $1",
+		id,
+	).Scan(&revoked)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return true, nil
+		}
+
+		return false, fmt.Errorf("error checking session: %w", err)
+	}
+
+	return revoked, nil
+}
+
+// GetRevocations returns the sessions revoked since the given time, oldest first.
+func (r *SessionRepository) GetRevocations(ctx context.Context, since time.Time) ([]*domain.SessionRevocation, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		"SELECT id, revoked_at FROM sessions WHERE revoked_at >= $1 ORDER BY revoked_at",
+		since,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error getting revoked sessions: %w", err)
+	}
+	defer rows.Close()
+
+	revocations := make([]*domain.SessionRevocation, 0)
+	for rows.Next() {
+		var rev domain.SessionRevocation
+		if err = rows.Scan(&rev.SessionID, &rev.RevokedAt); err != nil {
+			return nil, fmt.Errorf("error scanning revoked session: %w", err)
+		}
+		revocations = append(revocations, &rev)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating revoked sessions: %w", err)
+	}
+
+	return revocations, nil
+}
+
+// DeleteExpired deletes the refresh tokens which expired before the given time, and the sessions
+// left without tokens. Deleted sessions are reported as revoked.
+func (r *SessionRepository) DeleteExpired(ctx context.Context, before time.Time) error {
+	if _, err := r.db.ExecContext(ctx, "DELETE FROM refresh_tokens WHERE expires_at < $1", before); err != nil {
+		return fmt.Errorf("error deleting expired refresh tokens: %w", err)
+	}
+
+	// Sessions just created get their first token right after
+	_, err := r.db.ExecContext(
+		ctx,
+		`DELETE FROM sessions s WHERE s.created_at < $1
+		AND NOT EXISTS (SELECT 1 FROM refresh_tokens t WHERE t.session_id = s.id)`,
+		before,
+	)
+	if err != nil {
+		return fmt.Errorf("error deleting expired sessions: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,61 @@
+package pg
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/lib/pq"
+	"time"
+	"yadro-microservices/internal/core/domain"
+)
+
+// ShadowComparisonRepository stores comparisons of the primary and the shadow search engines.
+type ShadowComparisonRepository struct {
+	db *sql.DB
+}
+
+func NewShadowComparisonRepository(db *sql.DB) *ShadowComparisonRepository {
+	return &ShadowComparisonRepository{db: db}
+}
+
+// Save saves the comparison to the database.
+func (r *ShadowComparisonRepository) Save(ctx context.Context, c *domain.ShadowComparison) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`INSERT INTO shadow_comparisons (
+			query, primary_ids, shadow_ids, jaccard, rank_correlation,
+			primary_latency_ms, shadow_latency_ms, latency_delta_ms, shadow_error, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+		pq.StringArray(append([]string{}, c.Query...)),
+		int64Array(c.PrimaryIDs),
+		int64Array(c.ShadowIDs),
+		c.Jaccard,
+		c.RankCorrelation,
+		milliseconds(c.PrimaryLatency),
+		milliseconds(c.ShadowLatency),
+		milliseconds(c.LatencyDelta),
+		c.ShadowError,
+		c.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("error saving shadow comparison: %w", err)
+	}
+
+	return nil
+}
+
+// milliseconds converts the duration to fractional milliseconds.
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// int64Array converts IDs to a Postgres array. Unlike nil slices, which are saved as NULL,
+// it is never nil, so an empty list is saved as an empty array.
+func int64Array(ids []int) pq.Int64Array {
+	a := make(pq.Int64Array, 0, len(ids))
+	for _, id := range ids {
+		a = append(a, int64(id))
+	}
+
+	return a
+}
@@ -0,0 +1,57 @@
+package pg
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"log"
+	"yadro-microservices/internal/core/domain"
+)
+
+// updateLockKey identifies the advisory lock held while comics are updated, imported or reconciled.
+const updateLockKey = 0x786b6364 // "xkcd"
+
+// UpdateLock is the Postgres advisory lock shared by the server and the command line tool,
+// so that they never change the comics and the search index at the same time.
+type UpdateLock struct {
+	db *sql.DB
+}
+
+func NewUpdateLock(db *sql.DB) *UpdateLock {
+	return &UpdateLock{db: db}
+}
+
+// TryLock acquires the lock without waiting. The lock belongs to the database session,
+// so it is taken on a dedicated connection which is kept until the lock is released.
+func (l *UpdateLock) TryLock(ctx context.Context) (func(), error) {
+	conn, err := l.db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error getting connection: %w", err)
+	}
+
+	var locked bool
+	err = conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", updateLockKey).Scan(&locked)
+	if err != nil || !locked {
+		if closeErr := conn.Close(); closeErr != nil {
+			log.Printf("error closing connection: %v\n", closeErr)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error acquiring update lock: %w", err)
+		}
+
+		return nil, fmt.Errorf("comics are being changed by another process: %w", domain.ErrUpdateInProgress)
+	}
+
+	return func() {
+		if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", updateLockKey); err != nil {
+			log.Printf("error releasing update lock: %v\n", err)
+			// The session still holds the lock, so the connection is discarded instead of going back to the pool
+			_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+			return
+		}
+		if err := conn.Close(); err != nil {
+			log.Printf("error closing connection: %v\n", err)
+		}
+	}, nil
+}
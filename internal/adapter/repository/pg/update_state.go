@@ -0,0 +1,49 @@
+package pg
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// UpdateStateRepository stores the state of comics updates in a single-row table.
+type UpdateStateRepository struct {
+	db *sql.DB
+}
+
+func NewUpdateStateRepository(db *sql.DB) *UpdateStateRepository {
+	return &UpdateStateRepository{db: db}
+}
+
+// GetLastSuccess returns the time of the last successful update, or zero time if there was none.
+func (r *UpdateStateRepository) GetLastSuccess(ctx context.Context) (time.Time, error) {
+	row := r.db.QueryRowContext(ctx, "SELECT last_success_at FROM update_state")
+
+	var lastSuccess time.Time
+	if err := row.Scan(&lastSuccess); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return time.Time{}, nil
+		}
+
+		return time.Time{}, fmt.Errorf("error scanning row: %w", err)
+	}
+
+	return lastSuccess, nil
+}
+
+// SetLastSuccess saves the time of the last successful update.
+func (r *UpdateStateRepository) SetLastSuccess(ctx context.Context, t time.Time) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`INSERT INTO update_state (last_success_at) VALUES ($1)
+		ON CONFLICT (id) DO UPDATE SET last_success_at = EXCLUDED.last_success_at`,
+		t,
+	)
+	if err != nil {
+		return fmt.Errorf("error saving last successful update time: %w", err)
+	}
+
+	return nil
+}
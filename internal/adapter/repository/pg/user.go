@@ -5,6 +5,9 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"github.com/lib/pq"
+	"log"
+	"time"
 	"yadro-microservices/internal/core/domain"
 )
 
@@ -21,21 +24,161 @@ func (r *UserRepository) Save(ctx context.Context, user *domain.User) error {
 		ctx,
 		"INSERT INTO users (username, password, role) VALUES ($1, $2, $3)",
 		user.Username,
-		user.Password,
+		user.PasswordHash,
 		user.Role,
 	)
 
 	if err != nil {
-		return fmt.Errorf("error saving user: %w", err)
+		if unknownRole(err) {
+			return fmt.Errorf("role %s: %w", user.Role, domain.ErrInvalidInput)
+		}
+
+		return mapError(fmt.Errorf("error saving user: %w", err), "user", user.Username)
+	}
+
+	return nil
+}
+
+// unknownRole reports whether the error is a violation of the foreign key of users to roles.
+func unknownRole(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Constraint == "users_role_fkey"
+}
+
+// SavePending saves the pending user together with the token confirming its sign-up. A pending user whose tokens
+// have all expired is replaced, so the username and the email can be claimed again. Emails are unique.
+func (r *UserRepository) SavePending(ctx context.Context, user *domain.User, t *domain.VerificationToken) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer func(tx *sql.Tx) {
+		err := tx.Rollback()
+		if err != nil && !errors.Is(err, sql.ErrTxDone) {
+			log.Printf("error rolling back transaction: %v\n", err)
+		}
+	}(tx)
+
+	_, err = tx.ExecContext(
+		ctx,
+		`DELETE FROM users u WHERE (u.username = $1 OR LOWER(u.email) = LOWER($2)) AND u.pending AND NOT EXISTS (
+			SELECT 1 FROM verification_tokens t WHERE t.username = u.username AND t.expires_at > NOW()
+		)`,
+		user.Username,
+		user.Email,
+	)
+	if err != nil {
+		return fmt.Errorf("error deleting expired pending user: %w", err)
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		"INSERT INTO users (username, email, password, role, pending) VALUES ($1, $2, $3, $4, TRUE)",
+		user.Username,
+		user.Email,
+		user.PasswordHash,
+		user.Role,
+	)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Constraint == "users_email_key" {
+			return fmt.Errorf("email %s: %w", user.Email, domain.ErrAlreadyExists)
+		}
+
+		return mapError(fmt.Errorf("error saving user: %w", err), "user", user.Username)
 	}
 
+	_, err = tx.ExecContext(
+		ctx,
+		"INSERT INTO verification_tokens (hash, username, expires_at) VALUES ($1, $2, $3)",
+		t.Hash,
+		t.Username,
+		t.ExpiresAt,
+	)
+	if err != nil {
+		return fmt.Errorf("error saving verification token: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	user.Pending = true
 	return nil
 }
 
+// DeletePending deletes the user if their account is still pending.
+func (r *UserRepository) DeletePending(ctx context.Context, username string) error {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM users WHERE username = $1 AND pending", username)
+	if err != nil {
+		return fmt.Errorf("error deleting pending user: %w", err)
+	}
+
+	return checkAffected(res, "pending user", username)
+}
+
+// Confirm consumes the verification token which has not expired by now and activates its user.
+// It returns the name of the activated user.
+func (r *UserRepository) Confirm(ctx context.Context, hash string, now time.Time) (string, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer func(tx *sql.Tx) {
+		err := tx.Rollback()
+		if err != nil && !errors.Is(err, sql.ErrTxDone) {
+			log.Printf("error rolling back transaction: %v\n", err)
+		}
+	}(tx)
+
+	var username string
+	err = tx.QueryRowContext(
+		ctx,
+		"DELETE FROM verification_tokens WHERE hash = $1 AND expires_at > $2 RETURNING username",
+		hash,
+		now,
+	).Scan(&username)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", fmt.Errorf("verification token: %w", domain.ErrNotFound)
+		}
+
+		return "", fmt.Errorf("error consuming verification token: %w", err)
+	}
+
+	if _, err = tx.ExecContext(ctx, "UPDATE users SET pending = FALSE WHERE username = $1", username); err != nil {
+		return "", fmt.Errorf("error activating user: %w", err)
+	}
+
+	// Other tokens of the user are not needed anymore
+	if _, err = tx.ExecContext(ctx, "DELETE FROM verification_tokens WHERE username = $1", username); err != nil {
+		return "", fmt.Errorf("error deleting verification tokens: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return "", fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return username, nil
+}
+
 func (r *UserRepository) GetByUsername(ctx context.Context, username string) (*domain.User, error) {
-	row := r.db.QueryRowContext(ctx, "SELECT username, password, role FROM users WHERE username = $1", username)
+	row := r.db.QueryRowContext(
+		ctx,
+		`SELECT username, COALESCE(email, ''), password, role, pending, disabled, history_disabled
+		FROM users WHERE username = $1`,
+		username,
+	)
 	var user domain.User
-	err := row.Scan(&user.Username, &user.Password, &user.Role)
+	err := row.Scan(
+		&user.Username,
+		&user.Email,
+		&user.PasswordHash,
+		&user.Role,
+		&user.Pending,
+		&user.Disabled,
+		&user.HistoryDisabled,
+	)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, nil
@@ -46,3 +189,220 @@ func (r *UserRepository) GetByUsername(ctx context.Context, username string) (*d
 
 	return &user, nil
 }
+
+// SetHistoryDisabled sets whether search history of the user is recorded.
+func (r *UserRepository) SetHistoryDisabled(ctx context.Context, username string, disabled bool) error {
+	res, err := r.db.ExecContext(
+		ctx,
+		"UPDATE users SET history_disabled = $2 WHERE username = $1",
+		username,
+		disabled,
+	)
+	if err != nil {
+		return fmt.Errorf("error updating user settings: %w", err)
+	}
+
+	return checkAffected(res, "user", username)
+}
+
+// List returns the page of user accounts ordered by username, skipping offset accounts.
+func (r *UserRepository) List(ctx context.Context, offset, limit int) (*domain.UserPage, error) {
+	page := &domain.UserPage{Users: make([]*domain.UserAccount, 0, limit)}
+	if err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM users").Scan(&page.Total); err != nil {
+		return nil, fmt.Errorf("error counting users: %w", err)
+	}
+
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT username, COALESCE(email, ''), role, pending, disabled, created_at, last_login
+		FROM users ORDER BY username OFFSET $1 LIMIT $2`,
+		offset,
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error listing users: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var u domain.UserAccount
+		var lastLogin sql.NullTime
+		err = rows.Scan(&u.Username, &u.Email, &u.Role, &u.Pending, &u.Disabled, &u.CreatedAt, &lastLogin)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning user: %w", err)
+		}
+		if lastLogin.Valid {
+			u.LastLogin = &lastLogin.Time
+		}
+		page.Users = append(page.Users, &u)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating users: %w", err)
+	}
+
+	return page, nil
+}
+
+// SetRole changes the role of the user.
+// The change is refused if no active user with the users:manage permission would remain.
+func (r *UserRepository) SetRole(ctx context.Context, username string, role domain.Role) error {
+	return r.keepManager(ctx, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, "UPDATE users SET role = $2 WHERE username = $1", username, role)
+		if err != nil {
+			if unknownRole(err) {
+				return fmt.Errorf("role %s: %w", role, domain.ErrInvalidInput)
+			}
+
+			return fmt.Errorf("error updating user role: %w", err)
+		}
+
+		return checkAffected(res, "user", username)
+	})
+}
+
+// SetDisabled sets whether the user is disabled.
+// The change is refused if no active user with the users:manage permission would remain.
+func (r *UserRepository) SetDisabled(ctx context.Context, username string, disabled bool) error {
+	return r.keepManager(ctx, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, "UPDATE users SET disabled = $2 WHERE username = $1", username, disabled)
+		if err != nil {
+			return fmt.Errorf("error updating user: %w", err)
+		}
+
+		return checkAffected(res, "user", username)
+	})
+}
+
+// SetPassword replaces the password hash of the user.
+func (r *UserRepository) SetPassword(ctx context.Context, username, passwordHash string) error {
+	res, err := r.db.ExecContext(ctx, "UPDATE users SET password = $2 WHERE username = $1", username, passwordHash)
+	if err != nil {
+		return fmt.Errorf("error updating user password: %w", err)
+	}
+
+	return checkAffected(res, "user", username)
+}
+
+// RecordLogin sets the time of the last login of the user.
+func (r *UserRepository) RecordLogin(ctx context.Context, username string, at time.Time) error {
+	res, err := r.db.ExecContext(ctx, "UPDATE users SET last_login = $2 WHERE username = $1", username, at)
+	if err != nil {
+		return fmt.Errorf("error recording user login: %w", err)
+	}
+
+	return checkAffected(res, "user", username)
+}
+
+// Delete deletes the user together with their sessions.
+func (r *UserRepository) Delete(ctx context.Context, username string) error {
+	return r.keepManager(ctx, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, "DELETE FROM users WHERE username = $1", username)
+		if err != nil {
+			return fmt.Errorf("error deleting user: %w", err)
+		}
+
+		return checkAffected(res, "user", username)
+	})
+}
+
+// keepManager runs the change in a transaction and refuses it if it leaves no active user with the users:manage
+// permission while there was one before. The active managers are locked, so concurrent changes cannot remove
+// the last two managers at once.
+func (r *UserRepository) keepManager(ctx context.Context, change func(tx *sql.Tx) error) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer func(tx *sql.Tx) {
+		err := tx.Rollback()
+		if err != nil && !errors.Is(err, sql.ErrTxDone) {
+			log.Printf("error rolling back transaction: %v\n", err)
+		}
+	}(tx)
+
+	before, err := countManagers(ctx, tx, "FOR UPDATE OF u")
+	if err != nil {
+		return err
+	}
+
+	if err = change(tx); err != nil {
+		return err
+	}
+
+	after, err := countManagers(ctx, tx, "")
+	if err != nil {
+		return err
+	}
+
+	if before > 0 && after == 0 {
+		return fmt.Errorf("no active user manager would remain: %w", domain.ErrForbidden)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return nil
+}
+
+// countManagers returns the number of active users with the users:manage permission, locking them if asked.
+func countManagers(ctx context.Context, tx *sql.Tx, lock string) (int, error) {
+	rows, err := tx.QueryContext(
+		ctx,
+		`SELECT u.username FROM users u JOIN role_permissions p ON p.role = u.role
+		WHERE p.permission = $1 AND NOT u.disabled AND NOT u.pending `+lock,
+		domain.PermUsersManage,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("error getting user managers: %w", err)
+	}
+	defer rows.Close()
+
+	count := 0
+	for rows.Next() {
+		count++
+	}
+	if err = rows.Err(); err != nil {
+		return 0, fmt.Errorf("error getting user managers: %w", err)
+	}
+
+	return count, nil
+}
+
+// GetChanges returns the changes of users made since the given time, oldest first.
+func (r *UserRepository) GetChanges(ctx context.Context, since time.Time) ([]*domain.UserChange, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		"SELECT username, changed_at FROM user_changes WHERE changed_at >= $1 ORDER BY changed_at",
+		since,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error getting user changes: %w", err)
+	}
+	defer rows.Close()
+
+	changes := make([]*domain.UserChange, 0)
+	for rows.Next() {
+		var c domain.UserChange
+		if err = rows.Scan(&c.Username, &c.ChangedAt); err != nil {
+			return nil, fmt.Errorf("error scanning user change: %w", err)
+		}
+		changes = append(changes, &c)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating user changes: %w", err)
+	}
+
+	return changes, nil
+}
+
+// DeleteChanges deletes the changes of users made before the given time.
+func (r *UserRepository) DeleteChanges(ctx context.Context, before time.Time) error {
+	if _, err := r.db.ExecContext(ctx, "DELETE FROM user_changes WHERE changed_at < $1", before); err != nil {
+		return fmt.Errorf("error deleting user changes: %w", err)
+	}
+
+	return nil
+}
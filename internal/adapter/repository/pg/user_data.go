@@ -0,0 +1,51 @@
+package pg
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+)
+
+// UserDataRepository deletes the data the xkcd server keeps for users.
+type UserDataRepository struct {
+	db *sql.DB
+}
+
+// NewUserDataRepository creates a new instance of UserDataRepository.
+func NewUserDataRepository(db *sql.DB) *UserDataRepository {
+	return &UserDataRepository{db: db}
+}
+
+// DeleteUserData deletes the favorites, collections, search history and saved searches of the user
+// and removes the user from the logged search queries, which are kept for analytics, in one transaction.
+// Comics of the collections and matches of the saved searches are deleted with them.
+func (r *UserDataRepository) DeleteUserData(ctx context.Context, username string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer func(tx *sql.Tx) {
+		err := tx.Rollback()
+		if err != nil && !errors.Is(err, sql.ErrTxDone) {
+			log.Printf("error rolling back transaction: %v\n", err)
+		}
+	}(tx)
+
+	for _, table := range []string{"favorites", "collections", "search_history", "saved_searches"} {
+		if _, err = tx.ExecContext(ctx, "DELETE FROM "+table+" WHERE username = $1", username); err != nil {
+			return fmt.Errorf("error deleting %s of user: %w", table, err)
+		}
+	}
+
+	if _, err = tx.ExecContext(ctx, "UPDATE search_queries SET username = '' WHERE username = $1", username); err != nil {
+		return fmt.Errorf("error anonymizing search queries of user: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return nil
+}
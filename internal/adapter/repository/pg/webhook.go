@@ -0,0 +1,310 @@
+package pg
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"log"
+	"time"
+	"yadro-microservices/internal/core/domain"
+)
+
+// WebhookRepository stores webhooks and the log of their deliveries.
+type WebhookRepository struct {
+	db *sql.DB
+}
+
+func NewWebhookRepository(db *sql.DB) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+// Create saves the webhook and sets its ID and creation time.
+func (r *WebhookRepository) Create(ctx context.Context, w *domain.Webhook) error {
+	events := make(pq.StringArray, 0, len(w.Events))
+	for _, e := range w.Events {
+		events = append(events, string(e))
+	}
+
+	row := r.db.QueryRowContext(
+		ctx,
+		"INSERT INTO webhooks (url, events, secret) VALUES ($1, $2, $3) RETURNING id, created_at",
+		w.URL,
+		events,
+		w.Secret,
+	)
+
+	if err := row.Scan(&w.ID, &w.CreatedAt); err != nil {
+		return fmt.Errorf("error saving webhook: %w", err)
+	}
+
+	return nil
+}
+
+// GetAll returns all webhooks together with their secrets.
+func (r *WebhookRepository) GetAll(ctx context.Context) ([]*domain.Webhook, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT id, url, events, secret, created_at FROM webhooks ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("error getting webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	webhooks := make([]*domain.Webhook, 0)
+	for rows.Next() {
+		var w domain.Webhook
+		var events []string
+		if err = rows.Scan(&w.ID, &w.URL, pq.Array(&events), &w.Secret, &w.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning row: %w", err)
+		}
+
+		for _, e := range events {
+			w.Events = append(w.Events, domain.EventType(e))
+		}
+		webhooks = append(webhooks, &w)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return webhooks, nil
+}
+
+// Delete deletes the webhook together with its deliveries.
+func (r *WebhookRepository) Delete(ctx context.Context, id int64) error {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM webhooks WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("error deleting webhook: %w", err)
+	}
+
+	return checkAffected(res, "webhook", id)
+}
+
+// Enqueue queues the deliveries, due at their next attempt time, in one transaction.
+func (r *WebhookRepository) Enqueue(ctx context.Context, deliveries []*domain.PendingDelivery) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer func(tx *sql.Tx) {
+		err := tx.Rollback()
+		if err != nil && !errors.Is(err, sql.ErrTxDone) {
+			log.Printf("error rolling back transaction: %v\n", err)
+		}
+	}(tx)
+
+	for _, d := range deliveries {
+		var payload []byte
+		if d.Event != nil {
+			payload, err = json.Marshal(d.Event)
+		} else {
+			payload, err = json.Marshal(d.Notification)
+		}
+		if err != nil {
+			return fmt.Errorf("error encoding payload: %w", err)
+		}
+
+		row := tx.QueryRowContext(
+			ctx,
+			`INSERT INTO webhook_deliveries (webhook_id, saved_search_id, event_id, event_type, attempt, status_code,
+				error, latency_ms, created_at, payload, pending, next_attempt_at)
+			VALUES ($1, $2, $3, $4, $5, 0, '', 0, $6, $7, TRUE, $6) RETURNING id`,
+			nullID(d.WebhookID),
+			nullID(d.SavedSearchID),
+			d.EventID,
+			string(d.EventType),
+			d.Attempt,
+			d.NextAttemptAt,
+			payload,
+		)
+		if err = row.Scan(&d.ID); err != nil {
+			return fmt.Errorf("error queueing webhook delivery: %w", err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return nil
+}
+
+// ClaimDue returns up to limit deliveries due at the given time, the longest waiting first, and postpones them
+// by the lease. Deliveries claimed by other transactions are skipped, so concurrent workers get different ones,
+// and a delivery whose worker stopped before completing it becomes due again after the lease.
+func (r *WebhookRepository) ClaimDue(
+	ctx context.Context,
+	now time.Time,
+	lease time.Duration,
+	limit int,
+) ([]*domain.PendingDelivery, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`WITH due AS (
+			SELECT id FROM webhook_deliveries
+			WHERE pending AND next_attempt_at <= $1
+			ORDER BY next_attempt_at, id
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		), claimed AS (
+			UPDATE webhook_deliveries d SET next_attempt_at = $2 FROM due WHERE d.id = due.id
+			RETURNING d.id, d.webhook_id, d.saved_search_id, d.event_id, d.event_type, d.attempt, d.payload
+		)
+		SELECT c.id, COALESCE(c.webhook_id, 0), COALESCE(c.saved_search_id, 0), COALESCE(w.url, s.webhook_url),
+			COALESCE(w.secret, ''), c.event_id, c.event_type, c.attempt, c.payload
+		FROM claimed c
+		LEFT JOIN webhooks w ON w.id = c.webhook_id
+		LEFT JOIN saved_searches s ON s.id = c.saved_search_id
+		ORDER BY c.id`,
+		now,
+		now.Add(lease),
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error claiming webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := make([]*domain.PendingDelivery, 0)
+	for rows.Next() {
+		d := &domain.PendingDelivery{NextAttemptAt: now.Add(lease)}
+		var payload []byte
+		err = rows.Scan(
+			&d.ID,
+			&d.WebhookID,
+			&d.SavedSearchID,
+			&d.URL,
+			&d.Secret,
+			&d.EventID,
+			&d.EventType,
+			&d.Attempt,
+			&payload,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning row: %w", err)
+		}
+
+		if d.WebhookID != 0 {
+			d.Event = &domain.Event{}
+			err = json.Unmarshal(payload, d.Event)
+		} else {
+			d.Notification = &domain.SavedSearchNotification{}
+			err = json.Unmarshal(payload, d.Notification)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error decoding payload of webhook delivery %d: %w", d.ID, err)
+		}
+
+		deliveries = append(deliveries, d)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// CompleteDelivery logs the result of the claimed delivery attempt. Unless retryAt is zero, the next attempt
+// with the same payload is queued in the same transaction. Nothing is logged if the target was deleted.
+func (r *WebhookRepository) CompleteDelivery(ctx context.Context, d *domain.WebhookDelivery, retryAt time.Time) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer func(tx *sql.Tx) {
+		err := tx.Rollback()
+		if err != nil && !errors.Is(err, sql.ErrTxDone) {
+			log.Printf("error rolling back transaction: %v\n", err)
+		}
+	}(tx)
+
+	_, err = tx.ExecContext(
+		ctx,
+		`UPDATE webhook_deliveries
+		SET pending = FALSE, next_attempt_at = NULL, status_code = $2, error = $3, latency_ms = $4, created_at = $5
+		WHERE id = $1 AND pending`,
+		d.ID,
+		d.StatusCode,
+		d.Error,
+		d.LatencyMs,
+		d.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("error saving webhook delivery: %w", err)
+	}
+
+	if !retryAt.IsZero() {
+		_, err = tx.ExecContext(
+			ctx,
+			`INSERT INTO webhook_deliveries (webhook_id, saved_search_id, event_id, event_type, attempt, status_code,
+				error, latency_ms, created_at, payload, pending, next_attempt_at)
+			SELECT webhook_id, saved_search_id, event_id, event_type, attempt + 1, 0, '', 0, $2, payload, TRUE, $2
+			FROM webhook_deliveries WHERE id = $1`,
+			d.ID,
+			retryAt,
+		)
+		if err != nil {
+			return fmt.Errorf("error queueing webhook delivery retry: %w", err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return nil
+}
+
+// GetDeliveries returns the latest made delivery attempts of the webhook, most recent first.
+func (r *WebhookRepository) GetDeliveries(
+	ctx context.Context,
+	webhookID int64,
+	limit int,
+) ([]*domain.WebhookDelivery, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT id, webhook_id, event_id, event_type, attempt, status_code, error, latency_ms, created_at
+		FROM webhook_deliveries WHERE webhook_id = $1 AND NOT pending ORDER BY created_at DESC, id DESC LIMIT $2`,
+		webhookID,
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error getting webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := make([]*domain.WebhookDelivery, 0)
+	for rows.Next() {
+		var d domain.WebhookDelivery
+		err = rows.Scan(
+			&d.ID,
+			&d.WebhookID,
+			&d.EventID,
+			&d.EventType,
+			&d.Attempt,
+			&d.StatusCode,
+			&d.Error,
+			&d.LatencyMs,
+			&d.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning row: %w", err)
+		}
+
+		deliveries = append(deliveries, &d)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// nullID returns NULL for the zero ID of an optional reference.
+func nullID(id int64) sql.NullInt64 {
+	return sql.NullInt64{Int64: id, Valid: id != 0}
+}
@@ -11,7 +11,16 @@ import (
 	"github.com/go-redis/redis/v8"
 )
 
-// IndexRepository implements the fts.IndexRepository interface.
+// Keys of the set of indexed documents, of the sets of tokens of every document
+// and of the flag set once the tokens of all documents are tracked.
+const (
+	indexedDocumentsKey      = "indexed_documents"
+	documentTokensKeyPrefix  = "document_tokens:"
+	documentTokensTrackedKey = "document_tokens_tracked"
+)
+
+// IndexRepository implements the fts.IndexRepository interface. Every token is a hash of indexes
+// by document ID, and the tokens of every document are kept to remove its indexes on re-indexing.
 type IndexRepository struct {
 	client *redis.Client
 }
@@ -113,9 +122,19 @@ func (r *IndexRepository) Add(
 		}
 	}
 
-	// Add indexed documents
+	// Add indexed documents together with their tokens
+	tokens := make(map[int][]any, len(documents))
+	for word, indexList := range indexes {
+		for _, index := range indexList {
+			tokens[index.ID] = append(tokens[index.ID], word)
+		}
+	}
 	for id := range documents {
-		pipe.SAdd(ctx, "indexed_documents", strconv.Itoa(id))
+		pipe.SAdd(ctx, indexedDocumentsKey, strconv.Itoa(id))
+		pipe.Del(ctx, documentTokensKey(id))
+		if len(tokens[id]) > 0 {
+			pipe.SAdd(ctx, documentTokensKey(id), tokens[id]...)
+		}
 	}
 
 	// Executing the pipeline
@@ -127,12 +146,76 @@ func (r *IndexRepository) Add(
 	return nil
 }
 
+// Remove removes the indexes of the documents with the given IDs by the tokens kept for every document.
+func (r *IndexRepository) Remove(ctx context.Context, ids []int) error {
+	for _, id := range ids {
+		words, err := r.client.SMembers(ctx, documentTokensKey(id)).Result()
+		if err != nil {
+			return fmt.Errorf("failed to get tokens of document %d: %w", id, err)
+		}
+
+		_, err = r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			for _, word := range words {
+				pipe.HDel(ctx, word, strconv.Itoa(id))
+			}
+			pipe.Del(ctx, documentTokensKey(id))
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to remove indexes of document %d: %w", id, err)
+		}
+	}
+
+	return nil
+}
+
+// TrackDocumentTokens saves the tokens of the documents indexed before their tokens were kept,
+// so that Remove finds their indexes. It scans every token once and does nothing on later calls.
+func (r *IndexRepository) TrackDocumentTokens(ctx context.Context) error {
+	tracked, err := r.client.Exists(ctx, documentTokensTrackedKey).Result()
+	if err != nil {
+		return fmt.Errorf("failed to check if tokens of documents are tracked: %w", err)
+	}
+	if tracked > 0 {
+		return nil
+	}
+
+	iter := r.client.ScanType(ctx, 0, "", 1000, "hash").Iterator()
+	for iter.Next(ctx) {
+		word := iter.Val()
+		ids, err := r.client.HKeys(ctx, word).Result()
+		if err != nil {
+			return fmt.Errorf("failed to get documents of token %s: %w", word, err)
+		}
+
+		_, err = r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			for _, id := range ids {
+				pipe.SAdd(ctx, documentTokensKeyPrefix+id, word)
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to track documents of token %s: %w", word, err)
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return fmt.Errorf("failed to scan tokens: %w", err)
+	}
+
+	return r.client.Set(ctx, documentTokensTrackedKey, 1, 0).Err()
+}
+
 // DocumentIsIndexed checks if a document with the given ID is indexed in Redis.
 func (r *IndexRepository) DocumentIsIndexed(ctx context.Context, id int) (bool, error) {
-	return r.client.SIsMember(ctx, "indexed_documents", strconv.Itoa(id)).Result()
+	return r.client.SIsMember(ctx, indexedDocumentsKey, strconv.Itoa(id)).Result()
 }
 
 // MarkDocumentAsIndexed marks a document with the given ID as indexed in Redis.
 func (r *IndexRepository) MarkDocumentAsIndexed(ctx context.Context, id int) error {
-	return r.client.SAdd(ctx, "indexed_documents", strconv.Itoa(id)).Err()
+	return r.client.SAdd(ctx, indexedDocumentsKey, strconv.Itoa(id)).Err()
+}
+
+// documentTokensKey returns the key of the set of tokens of the document.
+func documentTokensKey(id int) string {
+	return documentTokensKeyPrefix + strconv.Itoa(id)
 }
@@ -0,0 +1,32 @@
+package search
+
+import (
+	"context"
+	"yadro-microservices/internal/core/domain"
+	"yadro-microservices/pkg/fts"
+)
+
+// Matcher matches comics against search queries by searching through their keywords directly.
+// It suits small sets of comics, e.g. the ones added by an update, which are not worth an index.
+type Matcher struct {
+	searcher *fts.FullTextSearcher
+}
+
+// NewMatcher creates a new instance of Matcher.
+func NewMatcher() *Matcher {
+	return &Matcher{searcher: &fts.FullTextSearcher{}}
+}
+
+// Match returns the IDs of the comics containing every query token, most relevant first.
+func (m *Matcher) Match(_ context.Context, queryTokens []string, comics domain.Comics) ([]int, error) {
+	if len(queryTokens) == 0 || len(comics) == 0 {
+		return []int{}, nil
+	}
+
+	docs := make([]*fts.Document, 0, len(comics))
+	for id, comic := range comics {
+		docs = append(docs, &fts.Document{ID: id, Tokens: comic.Keywords})
+	}
+
+	return m.searcher.Search(queryTokens, fts.ThroughDocs(docs), fts.ReturnMatchingAll())
+}
@@ -0,0 +1,32 @@
+package search
+
+import (
+	"context"
+	"testing"
+	"yadro-microservices/internal/core/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatcher_Match(t *testing.T) {
+	comics := domain.Comics{
+		1: {Keywords: []string{"comic", "funny"}},
+		2: {Keywords: []string{"comic", "sad", "comic"}},
+		3: {Keywords: []string{"funny", "story"}},
+	}
+
+	matcher := NewMatcher()
+
+	ids, err := matcher.Match(context.Background(), []string{"comic"}, comics)
+	require.NoError(t, err)
+	assert.Equal(t, []int{2, 1}, ids)
+
+	ids, err = matcher.Match(context.Background(), []string{"comic", "funny"}, comics)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1}, ids)
+
+	ids, err = matcher.Match(context.Background(), []string{"missing"}, comics)
+	require.NoError(t, err)
+	assert.Empty(t, ids)
+}
@@ -0,0 +1,145 @@
+package search
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"yadro-microservices/internal/core/domain"
+	"yadro-microservices/pkg/fts"
+)
+
+const (
+	// pgSearchLimit is the number of the most relevant comics returned by PgEngine.
+	pgSearchLimit = 10
+	// pgClickCandidates is the number of the most relevant comics reranked by click-through popularity,
+	// so a popular comic can rise to the results from below the search limit.
+	pgClickCandidates = 100
+)
+
+// PgEngine provides full-text search with Postgres. Comics are searched by the weighted
+// search_vector column of the comics table, which Postgres keeps up to date on save.
+type PgEngine struct {
+	db           *sql.DB
+	clickThrough *fts.ClickThrough
+}
+
+// NewPgEngine creates a new instance of Postgres search engine.
+func NewPgEngine(db *sql.DB) *PgEngine {
+	return &PgEngine{
+		db: db,
+	}
+}
+
+// WithClickThrough makes the engine add click-through popularity of comics to their rank.
+func (pe *PgEngine) WithClickThrough(clickThrough *fts.ClickThrough) *PgEngine {
+	pe.clickThrough = clickThrough
+	return pe
+}
+
+// Search searches for comic IDs by query tokens, ranked by cover density of the tokens.
+// Comics matching any of the tokens are returned, the most relevant first. The query uses
+// the english configuration, like search_vector, so the tokens are stemmed the same way.
+// With click-through, the best candidates are reranked by their rank plus the click boost.
+func (pe *PgEngine) Search(ctx context.Context, queryTokens []string) ([]int, error) {
+	log.Println("Searching with Postgres... Query tokens:", queryTokens)
+	if len(queryTokens) == 0 {
+		return nil, nil
+	}
+
+	limit := pgSearchLimit
+	if pe.clickThrough != nil && pe.clickThrough.Weight != 0 {
+		limit = pgClickCandidates
+	}
+
+	results, err := pe.rank(ctx, queryTokens, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	if limit != pgSearchLimit && len(results) > 0 {
+		boosts, err := pe.clickThrough.Boosts(ctx, queryTokens)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range results {
+			r.rank += boosts[r.id]
+		}
+		// Candidates come ordered by rank and ID, so the stable sort keeps that order for equal scores
+		sort.SliceStable(results, func(i, j int) bool {
+			return results[i].rank > results[j].rank
+		})
+		results = results[:min(len(results), pgSearchLimit)]
+	}
+
+	ids := make([]int, 0, len(results))
+	for _, r := range results {
+		ids = append(ids, r.id)
+	}
+
+	return ids, nil
+}
+
+// rankedComic is a comic found by PgEngine with its rank.
+type rankedComic struct {
+	id   int
+	rank float64
+}
+
+// rank returns up to the limit of the comics matching any of the tokens, the most relevant first.
+func (pe *PgEngine) rank(ctx context.Context, queryTokens []string, limit int) ([]*rankedComic, error) {
+	rows, err := pe.db.QueryContext(
+		ctx,
+		`SELECT id, ts_rank_cd(search_vector, query) AS rank FROM comics, to_tsquery('english', $1) query
+		WHERE search_vector @@ query
+		ORDER BY rank DESC, id
+		LIMIT $2`,
+		tsQuery(queryTokens),
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error executing query: %w", err)
+	}
+	defer rows.Close()
+
+	var results []*rankedComic
+	for rows.Next() {
+		r := &rankedComic{}
+		if err = rows.Scan(&r.id, &r.rank); err != nil {
+			return nil, fmt.Errorf("error scanning row: %w", err)
+		}
+		results = append(results, r)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over rows: %w", err)
+	}
+
+	return results, nil
+}
+
+// CreateIndex does nothing, as search vectors are generated by Postgres when comics are saved.
+func (pe *PgEngine) CreateIndex(_ context.Context, _ domain.Comics) error {
+	return nil
+}
+
+// IndexName returns the name of the engine. Postgres keeps its index up to date by itself,
+// but comics are tracked by it too, so that switching to another engine indexes all of them there.
+func (pe *PgEngine) IndexName() string {
+	return "postgres"
+}
+
+// tsQuery builds a text search query matching any of the tokens.
+// Every token is quoted, so characters with a special meaning in queries are matched literally.
+func tsQuery(tokens []string) string {
+	quoted := make([]string, 0, len(tokens))
+	for _, token := range tokens {
+		token = strings.ReplaceAll(token, `\`, `\\`)
+		token = strings.ReplaceAll(token, `'`, `''`)
+		quoted = append(quoted, "'"+token+"'")
+	}
+
+	return strings.Join(quoted, " | ")
+}
@@ -0,0 +1,32 @@
+package search
+
+import (
+	"context"
+	"testing"
+	"yadro-microservices/internal/core/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTsQuery(t *testing.T) {
+	assert.Equal(t, `'comic' | 'funny'`, tsQuery([]string{"comic", "funny"}))
+	assert.Equal(t, `'o''neil' | 'a\\b' | '!&|'`, tsQuery([]string{"o'neil", `a\b`, "!&|"}))
+}
+
+func TestPgEngine_EmptyQuery(t *testing.T) {
+	engine := NewPgEngine(nil)
+
+	results, err := engine.Search(context.Background(), nil)
+
+	require.NoError(t, err)
+	assert.Empty(t, results)
+}
+
+func TestPgEngine_CreateIndex(t *testing.T) {
+	engine := NewPgEngine(nil)
+
+	err := engine.CreateIndex(context.Background(), domain.Comics{1: {Keywords: []string{"comic"}}})
+
+	require.NoError(t, err)
+}
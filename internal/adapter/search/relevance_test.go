@@ -0,0 +1,73 @@
+package search
+
+import (
+	"context"
+	"testing"
+	"yadro-microservices/internal/adapter/client/jsondir"
+	"yadro-microservices/internal/adapter/judgment"
+	"yadro-microservices/internal/core/port"
+	"yadro-microservices/internal/core/service"
+	"yadro-microservices/pkg/fts"
+	"yadro-microservices/pkg/fts/mock"
+	"yadro-microservices/pkg/words"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Relevance of the search on the fixture corpus must not drop below these scores.
+// Raise them when the search gets better, so the improvement is kept.
+const (
+	minPrecision = 0.2 // Most queries have a single relevant comic, so precision@5 cannot be much higher
+	minRecall    = 0.95
+	minMRR       = 0.95
+	minNDCG      = 0.95
+	relevanceK   = 5
+)
+
+// corpusIndexRepository is an in-memory index repository which, like the Redis one,
+// finds no documents for unknown words instead of failing.
+type corpusIndexRepository struct {
+	*mock.IndexRepository
+}
+
+func (r *corpusIndexRepository) Get(ctx context.Context, word string) ([]*fts.Index, error) {
+	if _, ok := r.Indexes[word]; !ok {
+		return nil, nil
+	}
+
+	return r.IndexRepository.Get(ctx, word)
+}
+
+// TestFtsEngine_Relevance evaluates the full-text search with the text processor on the fixture corpus
+// of testdata/corpus against the judged queries of testdata/judgments.jsonl.
+func TestFtsEngine_Relevance(t *testing.T) {
+	ctx := context.Background()
+	processor := words.NewTextProcessor("en", "../../../config/extended_stopwords_eng.txt")
+
+	comics, err := jsondir.NewComicClient("testdata/corpus", processor).GetComics(ctx, map[int]bool{})
+	require.NoError(t, err)
+
+	indexer := fts.NewInvertedIndexer(&corpusIndexRepository{mock.NewIndexRepository()})
+	var engine port.SearchEngine = NewFtsEngine(indexer, &fts.FullTextSearcher{})
+	require.NoError(t, engine.CreateIndex(ctx, comics))
+
+	queries, err := judgment.ReadFile("testdata/judgments.jsonl")
+	require.NoError(t, err)
+
+	report, err := service.NewEvaluationService(processor, engine).Evaluate(ctx, queries, relevanceK)
+	require.NoError(t, err)
+
+	for _, q := range report.Queries {
+		t.Logf("%-40q results=%v P@%d=%.2f R@%d=%.2f RR=%.2f nDCG=%.2f",
+			q.Query, q.Results, relevanceK, q.Precision, relevanceK, q.Recall, q.ReciprocalRank, q.NDCG)
+	}
+	t.Logf("P@%d=%.3f R@%d=%.3f MRR=%.3f nDCG=%.3f",
+		relevanceK, report.Precision, relevanceK, report.Recall, report.MRR, report.NDCG)
+
+	assert.Zero(t, report.Failed)
+	assert.GreaterOrEqual(t, report.Precision, minPrecision)
+	assert.GreaterOrEqual(t, report.Recall, minRecall)
+	assert.GreaterOrEqual(t, report.MRR, minMRR)
+	assert.GreaterOrEqual(t, report.NDCG, minNDCG)
+}
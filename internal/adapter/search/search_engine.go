@@ -10,8 +10,9 @@ import (
 
 // FtsEngine provides methods for full-text search.
 type FtsEngine struct {
-	searcher *fts.FullTextSearcher
-	indexer  *fts.InvertedIndexer
+	searcher     *fts.FullTextSearcher
+	indexer      *fts.InvertedIndexer
+	clickThrough *fts.ClickThrough
 }
 
 // NewFtsEngine creates a new instance of FTS engine.
@@ -22,11 +23,23 @@ func NewFtsEngine(indexer *fts.InvertedIndexer, searcher *fts.FullTextSearcher)
 	}
 }
 
+// WithClickThrough makes the engine blend click-through popularity of comics into the ranking.
+func (fe *FtsEngine) WithClickThrough(clickThrough *fts.ClickThrough) *FtsEngine {
+	fe.clickThrough = clickThrough
+	return fe
+}
+
 // Search searches for documents ids by query tokens.
 func (fe *FtsEngine) Search(ctx context.Context, queryTokens []string) ([]int, error) {
 	log.Println("Searching... Query tokens:", queryTokens)
 
-	searchResults, err := fe.searcher.Search(queryTokens, fts.ThroughIndexes(ctx, fe.indexer), fts.ReturnMostRelevant(10))
+	modifiers := []fts.SearchModifier{fts.ThroughIndexes(ctx, fe.indexer)}
+	if fe.clickThrough != nil {
+		modifiers = append(modifiers, fts.BoostByClicks(ctx, fe.clickThrough))
+	}
+	modifiers = append(modifiers, fts.ReturnMostRelevant(10))
+
+	searchResults, err := fe.searcher.Search(queryTokens, modifiers...)
 	if err != nil {
 		return nil, fmt.Errorf("error searching for documents: %w", err)
 	}
@@ -34,6 +47,11 @@ func (fe *FtsEngine) Search(ctx context.Context, queryTokens []string) ([]int, e
 	return searchResults, nil
 }
 
+// IndexName returns the name of the Redis index the engine adds comics to.
+func (fe *FtsEngine) IndexName() string {
+	return "redis"
+}
+
 // CreateIndex builds index based on comics.
 func (fe *FtsEngine) CreateIndex(ctx context.Context, comics domain.Comics) error {
 	log.Println("Adding documents to the index...")
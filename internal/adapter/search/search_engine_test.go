@@ -4,6 +4,7 @@ import (
 	"context"
 	"github.com/stretchr/testify/require"
 	"testing"
+	"time"
 	"yadro-microservices/internal/core/domain"
 	"yadro-microservices/pkg/fts"
 	"yadro-microservices/pkg/fts/mock"
@@ -79,3 +80,32 @@ func TestFtsEngine_CreateIndex_ErrorHandling(t *testing.T) {
 	err = engine.CreateIndex(ctx, comics)
 	require.NoError(t, err)
 }
+
+type popularityStub []*fts.Popularity
+
+func (ps popularityStub) GetPopularity(_ context.Context, _ []string) ([]*fts.Popularity, error) {
+	return ps, nil
+}
+
+func TestFtsEngine_Search_ClickThrough(t *testing.T) {
+	indexRepo := mock.NewIndexRepository()
+	indexer := fts.NewInvertedIndexer(indexRepo)
+	searcher := &fts.FullTextSearcher{}
+	engine := NewFtsEngine(indexer, searcher).WithClickThrough(&fts.ClickThrough{
+		Popularity: popularityStub{{Token: "comic", ID: 2, Clicks: 3, UpdatedAt: time.Now()}},
+		Weight:     0.5,
+	})
+
+	comics := domain.Comics{
+		1: {Keywords: []string{"comic", "funny"}},
+		2: {Keywords: []string{"comic", "sad"}},
+	}
+
+	ctx := context.Background()
+	err := engine.CreateIndex(ctx, comics)
+	require.NoError(t, err)
+
+	results, err := engine.Search(ctx, []string{"comic"})
+	require.NoError(t, err)
+	assert.Equal(t, []int{2, 1}, results)
+}
@@ -0,0 +1,131 @@
+package search
+
+import (
+	"context"
+	"log"
+	"sync/atomic"
+	"time"
+	"yadro-microservices/internal/core/domain"
+	"yadro-microservices/internal/core/port"
+	"yadro-microservices/pkg/relevance"
+)
+
+// shadowTimeout limits the time of a shadow query and of saving its comparison.
+const shadowTimeout = 10 * time.Second
+
+// ShadowEngine is a search engine which sends every query to both primary and shadow engines,
+// but returns only the primary results. Results of the engines are compared in the background,
+// and the comparison is saved for later analysis, so the shadow engine never affects the responses.
+type ShadowEngine struct {
+	primary     port.SearchEngine
+	shadow      port.SearchEngine
+	comparisons port.ShadowComparisonRepository
+
+	indexFailures atomic.Int64 // Number of comics the shadow engine failed to index
+}
+
+// NewShadowEngine creates a new instance of shadow search engine.
+func NewShadowEngine(
+	primary port.SearchEngine,
+	shadow port.SearchEngine,
+	comparisons port.ShadowComparisonRepository,
+) *ShadowEngine {
+	return &ShadowEngine{
+		primary:     primary,
+		shadow:      shadow,
+		comparisons: comparisons,
+	}
+}
+
+// shadowResult is the result of a shadow query.
+type shadowResult struct {
+	ids     []int
+	err     error
+	latency time.Duration
+}
+
+// Search searches with both engines concurrently and returns the results of the primary one.
+// The comparison is recorded only if the primary search succeeds.
+func (se *ShadowEngine) Search(ctx context.Context, queryTokens []string) ([]int, error) {
+	shadowDone := make(chan shadowResult, 1)
+	go func() {
+		// The shadow query goes on even if the primary one has already been answered
+		shadowCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), shadowTimeout)
+		defer cancel()
+
+		start := time.Now()
+		ids, err := se.shadow.Search(shadowCtx, queryTokens)
+		shadowDone <- shadowResult{ids: ids, err: err, latency: time.Since(start)}
+	}()
+
+	start := time.Now()
+	ids, err := se.primary.Search(ctx, queryTokens)
+	if err != nil {
+		return nil, err
+	}
+	primaryLatency := time.Since(start)
+
+	go se.compare(queryTokens, ids, primaryLatency, shadowDone)
+
+	return ids, nil
+}
+
+// compare waits for the shadow query and saves its comparison with the primary results.
+func (se *ShadowEngine) compare(
+	queryTokens []string,
+	primaryIDs []int,
+	primaryLatency time.Duration,
+	shadowDone <-chan shadowResult,
+) {
+	shadow := <-shadowDone
+
+	comparison := &domain.ShadowComparison{
+		Query:           queryTokens,
+		PrimaryIDs:      primaryIDs,
+		ShadowIDs:       shadow.ids,
+		Jaccard:         relevance.Jaccard(primaryIDs, shadow.ids),
+		RankCorrelation: relevance.SpearmanCorrelation(primaryIDs, shadow.ids),
+		PrimaryLatency:  primaryLatency,
+		ShadowLatency:   shadow.latency,
+		LatencyDelta:    shadow.latency - primaryLatency,
+		CreatedAt:       time.Now(),
+	}
+	if shadow.err != nil {
+		log.Println("Error searching with shadow engine:", shadow.err)
+		comparison.ShadowError = shadow.err.Error()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), shadowTimeout)
+	defer cancel()
+	if err := se.comparisons.Save(ctx, comparison); err != nil {
+		log.Println("Error saving shadow comparison:", err)
+	}
+}
+
+// CreateIndex adds comics to both engines. Only errors of the primary engine are returned,
+// so a failing shadow engine never fails an update. Comics are marked as indexed by both engines anyway,
+// so comics the shadow engine failed to index are logged and counted instead:
+// the comparisons of queries matching them are skewed.
+func (se *ShadowEngine) CreateIndex(ctx context.Context, comics domain.Comics) error {
+	if err := se.primary.CreateIndex(ctx, comics); err != nil {
+		return err
+	}
+
+	if err := se.shadow.CreateIndex(ctx, comics); err != nil {
+		total := se.indexFailures.Add(int64(len(comics)))
+		log.Printf("Error adding %d comics to shadow search engine, %d missing so far: %v", len(comics), total, err)
+	}
+
+	return nil
+}
+
+// IndexName combines the names of both engines, so that adding a shadow engine indexes all comics again
+// and fills it. Comics are indexed by the primary engine once more, which replaces their old entries.
+func (se *ShadowEngine) IndexName() string {
+	return se.primary.IndexName() + "+" + se.shadow.IndexName()
+}
+
+// IndexFailures returns the number of comics the shadow engine failed to index since start.
+func (se *ShadowEngine) IndexFailures() int64 {
+	return se.indexFailures.Load()
+}
@@ -0,0 +1,116 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"yadro-microservices/internal/core/domain"
+	"yadro-microservices/internal/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShadowEngine_Search(t *testing.T) {
+	primaryMock := new(mocks.SearchEngine)
+	shadowMock := new(mocks.SearchEngine)
+	comparisonsMock := new(mocks.ShadowComparisonRepository)
+	engine := NewShadowEngine(primaryMock, shadowMock, comparisonsMock)
+
+	query := []string{"comic"}
+	saved := make(chan *domain.ShadowComparison, 1)
+	primaryMock.On("Search", mock.Anything, query).Return([]int{1, 2, 3}, nil)
+	shadowMock.On("Search", mock.Anything, query).Return([]int{3, 2, 4}, nil)
+	comparisonsMock.On("Save", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		saved <- args.Get(1).(*domain.ShadowComparison)
+	}).Return(nil)
+
+	ids, err := engine.Search(context.Background(), query)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3}, ids)
+
+	select {
+	case <-time.After(5 * time.Second):
+		t.Fatal("comparison was not saved")
+	case comparison := <-saved:
+		assert.Equal(t, query, comparison.Query)
+		assert.Equal(t, []int{1, 2, 3}, comparison.PrimaryIDs)
+		assert.Equal(t, []int{3, 2, 4}, comparison.ShadowIDs)
+		assert.InDelta(t, 0.5, comparison.Jaccard, 1e-9)
+		assert.InDelta(t, -1, comparison.RankCorrelation, 1e-9)
+		assert.Equal(t, comparison.ShadowLatency-comparison.PrimaryLatency, comparison.LatencyDelta)
+		assert.Empty(t, comparison.ShadowError)
+	}
+}
+
+func TestShadowEngine_Search_ShadowError(t *testing.T) {
+	primaryMock := new(mocks.SearchEngine)
+	shadowMock := new(mocks.SearchEngine)
+	comparisonsMock := new(mocks.ShadowComparisonRepository)
+	engine := NewShadowEngine(primaryMock, shadowMock, comparisonsMock)
+
+	saved := make(chan *domain.ShadowComparison, 1)
+	primaryMock.On("Search", mock.Anything, mock.Anything).Return([]int{1}, nil)
+	shadowMock.On("Search", mock.Anything, mock.Anything).Return(nil, errors.New("shadow is down"))
+	comparisonsMock.On("Save", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		saved <- args.Get(1).(*domain.ShadowComparison)
+	}).Return(nil)
+
+	ids, err := engine.Search(context.Background(), []string{"comic"})
+	require.NoError(t, err)
+	assert.Equal(t, []int{1}, ids)
+
+	select {
+	case <-time.After(5 * time.Second):
+		t.Fatal("comparison was not saved")
+	case comparison := <-saved:
+		assert.Equal(t, "shadow is down", comparison.ShadowError)
+		assert.Zero(t, comparison.Jaccard)
+	}
+}
+
+func TestShadowEngine_Search_PrimaryError(t *testing.T) {
+	primaryMock := new(mocks.SearchEngine)
+	shadowMock := new(mocks.SearchEngine)
+	comparisonsMock := new(mocks.ShadowComparisonRepository)
+	engine := NewShadowEngine(primaryMock, shadowMock, comparisonsMock)
+
+	primaryMock.On("Search", mock.Anything, mock.Anything).Return(nil, errors.New("primary is down"))
+	shadowMock.On("Search", mock.Anything, mock.Anything).Return([]int{1}, nil)
+
+	_, err := engine.Search(context.Background(), []string{"comic"})
+
+	require.Error(t, err)
+	comparisonsMock.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+}
+
+func TestShadowEngine_CreateIndex(t *testing.T) {
+	primaryMock := new(mocks.SearchEngine)
+	shadowMock := new(mocks.SearchEngine)
+	comparisonsMock := new(mocks.ShadowComparisonRepository)
+	engine := NewShadowEngine(primaryMock, shadowMock, comparisonsMock)
+
+	comics := domain.Comics{1: {Keywords: []string{"comic"}}}
+	primaryMock.On("CreateIndex", mock.Anything, comics).Return(nil)
+	shadowMock.On("CreateIndex", mock.Anything, comics).Return(errors.New("shadow is down"))
+
+	err := engine.CreateIndex(context.Background(), comics)
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), engine.IndexFailures())
+	primaryMock.AssertExpectations(t)
+	shadowMock.AssertExpectations(t)
+}
+
+func TestShadowEngine_IndexName(t *testing.T) {
+	primaryMock := new(mocks.SearchEngine)
+	shadowMock := new(mocks.SearchEngine)
+	engine := NewShadowEngine(primaryMock, shadowMock, nil)
+
+	primaryMock.On("IndexName").Return("postgres")
+	shadowMock.On("IndexName").Return("redis")
+
+	assert.Equal(t, "postgres+redis", engine.IndexName())
+}
@@ -0,0 +1,80 @@
+package token
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// minRSABits is the minimum size of RSA keys.
+const minRSABits = 2048
+
+// Key is an asymmetric key identified by its ID (the kid header of the tokens).
+// Keys obtained from a JWKS have no private part and can only verify tokens.
+type Key struct {
+	ID      string
+	Method  jwt.SigningMethod
+	private crypto.Signer
+	public  crypto.PublicKey
+}
+
+// ParseKey parses a PEM-encoded RSA (PKCS#1 or PKCS#8) or Ed25519 (PKCS#8) private key.
+// RSA keys sign tokens with RS256 and Ed25519 keys with EdDSA.
+func ParseKey(id string, data []byte) (*Key, error) {
+	if id == "" {
+		return nil, errors.New("key ID is empty")
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM data found")
+	}
+
+	var private any
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		private, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		private, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error parsing private key: %w", err)
+	}
+
+	switch k := private.(type) {
+	case *rsa.PrivateKey:
+		if k.N.BitLen() < minRSABits {
+			return nil, fmt.Errorf("RSA key must have at least %d bits", minRSABits)
+		}
+		return &Key{ID: id, Method: jwt.SigningMethodRS256, private: k, public: k.Public()}, nil
+	case ed25519.PrivateKey:
+		return &Key{ID: id, Method: jwt.SigningMethodEdDSA, private: k, public: k.Public()}, nil
+	default:
+		return nil, fmt.Errorf("unsupported private key type %T", private)
+	}
+}
+
+// GenerateKey generates a new Ed25519 key.
+func GenerateKey(id string) (*Key, error) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("error generating key: %w", err)
+	}
+
+	return &Key{ID: id, Method: jwt.SigningMethodEdDSA, private: private, public: public}, nil
+}
+
+// CanSign reports whether the key has a private part.
+func (k *Key) CanSign() bool {
+	return k.private != nil
+}
@@ -0,0 +1,159 @@
+package token
+
+import (
+	"errors"
+	"fmt"
+	"yadro-microservices/internal/core/domain"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Issuer and audience of access tokens used when the configuration does not set them.
+const (
+	DefaultIssuer   = "authserver"
+	DefaultAudience = "xkcdserver"
+)
+
+// KeySet signs access tokens with one key and verifies them with any of its keys, picked by the kid header.
+// Keeping the previous keys in the set lets the tokens they signed stay valid after rotation.
+type KeySet struct {
+	signing *Key
+	keys    map[string]*Key
+	order   []*Key
+
+	issuer   string
+	audience string
+}
+
+// claims are the JWT claims of the access token.
+type claims struct {
+	Username        string              `json:"username"`
+	Role            string              `json:"role,omitempty"`
+	Permissions     []domain.Permission `json:"permissions,omitempty"`
+	HistoryDisabled bool                `json:"history_disabled,omitempty"`
+	SessionID       string              `json:"sid,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// NewKeySet creates a key set signing tokens with the key of the given ID.
+// If the ID is empty, the first key signs. The signing key must have a private part.
+func NewKeySet(signingID string, keys ...*Key) (*KeySet, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("no keys")
+	}
+	if signingID == "" {
+		signingID = keys[0].ID
+	}
+
+	ks := &KeySet{keys: make(map[string]*Key, len(keys)), issuer: DefaultIssuer, audience: DefaultAudience}
+	for _, k := range keys {
+		if _, ok := ks.keys[k.ID]; ok {
+			return nil, fmt.Errorf("duplicate key ID %q", k.ID)
+		}
+		ks.keys[k.ID] = k
+		ks.order = append(ks.order, k)
+	}
+
+	signing, ok := ks.keys[signingID]
+	if !ok {
+		return nil, fmt.Errorf("signing key %q not found", signingID)
+	}
+	if !signing.CanSign() {
+		return nil, fmt.Errorf("signing key %q has no private part", signingID)
+	}
+	ks.signing = signing
+
+	return ks, nil
+}
+
+// SetIssuer sets the issuer and the audience put into the signed tokens and required from the verified ones.
+func (ks *KeySet) SetIssuer(issuer, audience string) {
+	ks.issuer = issuer
+	ks.audience = audience
+}
+
+// Sign creates a token with the claims signed by the signing key.
+func (ks *KeySet) Sign(c *domain.TokenClaims) (string, error) {
+	t := jwt.NewWithClaims(ks.signing.Method, &claims{
+		Username:        c.Username,
+		Role:            string(c.Role),
+		Permissions:     c.Permissions,
+		HistoryDisabled: c.HistoryDisabled,
+		SessionID:       c.SessionID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    ks.issuer,
+			Audience:  jwt.ClaimStrings{ks.audience},
+			IssuedAt:  jwt.NewNumericDate(c.IssuedAt),
+			ExpiresAt: jwt.NewNumericDate(c.ExpiresAt),
+		},
+	})
+	t.Header["kid"] = ks.signing.ID
+
+	s, err := t.SignedString(ks.signing.private)
+	if err != nil {
+		return "", fmt.Errorf("error signing token: %w", err)
+	}
+
+	return s, nil
+}
+
+// Verify checks the signature, the expiration, the issuer and the audience of the token and returns its claims.
+func (ks *KeySet) Verify(tokenString string) (*domain.TokenClaims, error) {
+	return verify(tokenString, ks.issuer, ks.audience, func(kid string) (*Key, error) {
+		k, ok := ks.keys[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown key ID %q", kid)
+		}
+		return k, nil
+	})
+}
+
+// verify checks the signature of the token with the key returned by lookup for its kid header,
+// the expiration of the token and that it is issued by issuer for audience, and returns its claims.
+func verify(tokenString, issuer, audience string, lookup func(kid string) (*Key, error)) (*domain.TokenClaims, error) {
+	keyfunc := func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+		k, err := lookup(kid)
+		if err != nil {
+			return nil, err
+		}
+
+		if t.Method.Alg() != k.Method.Alg() {
+			return nil, fmt.Errorf("unexpected signing method %q for key %q", t.Method.Alg(), kid)
+		}
+
+		return k.public, nil
+	}
+
+	var c claims
+	_, err := jwt.ParseWithClaims(
+		tokenString,
+		&c,
+		keyfunc,
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodEdDSA.Alg()}),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuer(issuer),
+		jwt.WithAudience(audience),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse token: %w", err)
+	}
+
+	if c.Username == "" {
+		return nil, errors.New("invalid username in claims")
+	}
+
+	result := &domain.TokenClaims{
+		Username:        c.Username,
+		Role:            domain.Role(c.Role),
+		Permissions:     c.Permissions,
+		HistoryDisabled: c.HistoryDisabled,
+		SessionID:       c.SessionID,
+		ExpiresAt:       c.ExpiresAt.Time,
+	}
+	if c.IssuedAt != nil {
+		result.IssuedAt = c.IssuedAt.Time
+	}
+
+	return result, nil
+}
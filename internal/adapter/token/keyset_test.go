@@ -0,0 +1,302 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+	"yadro-microservices/internal/core/domain"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newClaims(username string, ttl time.Duration) *domain.TokenClaims {
+	now := time.Now().Truncate(time.Second)
+	return &domain.TokenClaims{Username: username, IssuedAt: now, ExpiresAt: now.Add(ttl)}
+}
+
+func generateRSAPEM(t *testing.T, bits int) []byte {
+	t.Helper()
+	k, err := rsa.GenerateKey(rand.Reader, bits)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(k)})
+}
+
+func TestParseKey(t *testing.T) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	require.NoError(t, err)
+
+	edKey, err := ParseKey("ed", pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+	require.NoError(t, err)
+	assert.Equal(t, jwt.SigningMethodEdDSA, edKey.Method)
+
+	rsaKey, err := ParseKey("rsa", generateRSAPEM(t, 2048))
+	require.NoError(t, err)
+	assert.Equal(t, jwt.SigningMethodRS256, rsaKey.Method)
+
+	_, err = ParseKey("weak", generateRSAPEM(t, 1024))
+	assert.Error(t, err)
+	_, err = ParseKey("garbage", []byte("not a key"))
+	assert.Error(t, err)
+	_, err = ParseKey("", generateRSAPEM(t, 2048))
+	assert.Error(t, err)
+}
+
+func TestKeySet_SignVerify(t *testing.T) {
+	rsaKey, err := ParseKey("rsa", generateRSAPEM(t, 2048))
+	require.NoError(t, err)
+	edKey, err := GenerateKey("ed")
+	require.NoError(t, err)
+
+	for _, k := range []*Key{rsaKey, edKey} {
+		t.Run(k.Method.Alg(), func(t *testing.T) {
+			ks, err := NewKeySet("", k)
+			require.NoError(t, err)
+
+			c := newClaims("user", time.Hour)
+			c.Role = domain.ADMIN
+			c.Permissions = []domain.Permission{domain.PermComicsSearch, domain.PermComicsUpdate}
+			c.HistoryDisabled = true
+			s, err := ks.Sign(c)
+			require.NoError(t, err)
+
+			parsed, _, err := jwt.NewParser().ParseUnverified(s, jwt.MapClaims{})
+			require.NoError(t, err)
+			assert.Equal(t, k.ID, parsed.Header["kid"])
+
+			verified, err := ks.Verify(s)
+			require.NoError(t, err)
+			assert.Equal(t, c.Username, verified.Username)
+			assert.Equal(t, domain.ADMIN, verified.Role)
+			assert.Equal(t, c.Permissions, verified.Permissions)
+			assert.True(t, verified.HistoryDisabled)
+			assert.True(t, c.ExpiresAt.Equal(verified.ExpiresAt))
+		})
+	}
+}
+
+func TestKeySet_Rotation(t *testing.T) {
+	oldKey, err := GenerateKey("old")
+	require.NoError(t, err)
+	newKey, err := GenerateKey("new")
+	require.NoError(t, err)
+
+	before, err := NewKeySet("old", oldKey)
+	require.NoError(t, err)
+	oldToken, err := before.Sign(newClaims("user", time.Hour))
+	require.NoError(t, err)
+
+	// The new key signs while the old one still verifies the tokens issued before the rotation
+	after, err := NewKeySet("new", oldKey, newKey)
+	require.NoError(t, err)
+	_, err = after.Verify(oldToken)
+	require.NoError(t, err)
+
+	newToken, err := after.Sign(newClaims("user", time.Hour))
+	require.NoError(t, err)
+	_, err = before.Verify(newToken)
+	assert.Error(t, err)
+
+	// Once the old key is retired, its tokens are rejected
+	retired, err := NewKeySet("", newKey)
+	require.NoError(t, err)
+	_, err = retired.Verify(oldToken)
+	assert.Error(t, err)
+}
+
+func TestKeySet_VerifyRejects(t *testing.T) {
+	k, err := GenerateKey("ed")
+	require.NoError(t, err)
+	ks, err := NewKeySet("", k)
+	require.NoError(t, err)
+
+	expired, err := ks.Sign(newClaims("user", -time.Minute))
+	require.NoError(t, err)
+
+	noUsername, err := ks.Sign(newClaims("", time.Hour))
+	require.NoError(t, err)
+
+	hmac := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"username": "user",
+		"exp":      time.Now().Add(time.Hour).Unix(),
+	})
+	hmac.Header["kid"] = "ed"
+	hmacToken, err := hmac.SignedString([]byte("secret"))
+	require.NoError(t, err)
+
+	other, err := NewKeySet("", k)
+	require.NoError(t, err)
+	other.SetIssuer("other", DefaultAudience)
+	otherIssuer, err := other.Sign(newClaims("user", time.Hour))
+	require.NoError(t, err)
+	other.SetIssuer(DefaultIssuer, "other")
+	otherAudience, err := other.Sign(newClaims("user", time.Hour))
+	require.NoError(t, err)
+
+	tests := map[string]string{
+		"expired":        expired,
+		"no username":    noUsername,
+		"HMAC":           hmacToken,
+		"malformed":      "invalid_token",
+		"other issuer":   otherIssuer,
+		"other audience": otherAudience,
+	}
+	for name, s := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := ks.Verify(s)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestNewKeySet_Invalid(t *testing.T) {
+	k, err := GenerateKey("a")
+	require.NoError(t, err)
+
+	_, err = NewKeySet("")
+	assert.Error(t, err)
+	_, err = NewKeySet("b", k)
+	assert.Error(t, err)
+	_, err = NewKeySet("", k, k)
+	assert.Error(t, err)
+	_, err = NewKeySet("", &Key{ID: "public", Method: jwt.SigningMethodEdDSA, public: k.public})
+	assert.Error(t, err)
+}
+
+func TestKeySet_ServeJWKS(t *testing.T) {
+	edKey, err := GenerateKey("ed")
+	require.NoError(t, err)
+	rsaKey, err := ParseKey("rsa", generateRSAPEM(t, 2048))
+	require.NoError(t, err)
+	ks, err := NewKeySet("ed", edKey, rsaKey)
+	require.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	ks.ServeJWKS(rr, httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+
+	jwks := ks.JWKS()
+	require.Len(t, jwks.Keys, 2)
+	assert.Equal(t, JWK{
+		KeyType:   "OKP",
+		KeyID:     "ed",
+		Use:       "sig",
+		Algorithm: "EdDSA",
+		Curve:     "Ed25519",
+		X:         base64.RawURLEncoding.EncodeToString(edKey.public.(ed25519.PublicKey)),
+	}, jwks.Keys[0])
+	assert.Equal(t, "RSA", jwks.Keys[1].KeyType)
+	assert.Equal(t, "AQAB", jwks.Keys[1].E)
+	assert.NotContains(t, rr.Body.String(), `"d"`)
+}
+
+func TestRemoteKeySet_Verify(t *testing.T) {
+	oldKey, err := GenerateKey("old")
+	require.NoError(t, err)
+	rsaKey, err := ParseKey("rsa", generateRSAPEM(t, 2048))
+	require.NoError(t, err)
+	published, err := NewKeySet("old", oldKey)
+	require.NoError(t, err)
+
+	requests := 0
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		published.ServeJWKS(w, r)
+	}))
+	defer mockServer.Close()
+
+	rs := NewRemoteKeySet(mockServer.URL, time.Hour, time.Second)
+	oldToken, err := published.Sign(newClaims("user", time.Hour))
+	require.NoError(t, err)
+
+	c, err := rs.Verify(oldToken)
+	require.NoError(t, err)
+	assert.Equal(t, "user", c.Username)
+	_, err = rs.Verify(oldToken)
+	require.NoError(t, err)
+	assert.Equal(t, 1, requests)
+
+	// A token signed by a rotated key makes the keys to be fetched again
+	published, err = NewKeySet("rsa", oldKey, rsaKey)
+	require.NoError(t, err)
+	newToken, err := published.Sign(newClaims("user", time.Hour))
+	require.NoError(t, err)
+	rs.triedAt = time.Time{}
+
+	_, err = rs.Verify(newToken)
+	require.NoError(t, err)
+	assert.Equal(t, 2, requests)
+
+	// Unknown keys are not fetched again until the minimum refresh interval passes
+	unknown, err := GenerateKey("unknown")
+	require.NoError(t, err)
+	forged, err := (&KeySet{signing: unknown}).Sign(newClaims("admin", time.Hour))
+	require.NoError(t, err)
+
+	_, err = rs.Verify(forged)
+	assert.Error(t, err)
+	assert.Equal(t, 2, requests)
+}
+
+func TestRemoteKeySet_Unavailable(t *testing.T) {
+	k, err := GenerateKey("k")
+	require.NoError(t, err)
+	ks, err := NewKeySet("", k)
+	require.NoError(t, err)
+	s, err := ks.Sign(newClaims("user", time.Hour))
+	require.NoError(t, err)
+
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer mockServer.Close()
+
+	_, err = NewRemoteKeySet(mockServer.URL, time.Hour, time.Second).Verify(s)
+	assert.Error(t, err)
+}
+
+func TestRemoteKeySet_ConcurrentFetch(t *testing.T) {
+	k, err := GenerateKey("ed")
+	require.NoError(t, err)
+	ks, err := NewKeySet("", k)
+	require.NoError(t, err)
+	s, err := ks.Sign(newClaims("user", time.Hour))
+	require.NoError(t, err)
+
+	var requests atomic.Int32
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		time.Sleep(100 * time.Millisecond)
+		ks.ServeJWKS(w, r)
+	}))
+	defer mockServer.Close()
+
+	// Verifications arriving during a fetch wait for it instead of fetching the keys again
+	rs := NewRemoteKeySet(mockServer.URL, time.Hour, time.Second)
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := rs.Verify(s)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), requests.Load())
+}
@@ -0,0 +1,186 @@
+package token
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+	"yadro-microservices/internal/core/domain"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/sync/singleflight"
+)
+
+// minRefreshInterval limits how often tokens with unknown key IDs cause the keys to be fetched.
+const minRefreshInterval = 30 * time.Second
+
+// RemoteKeySet verifies tokens with the public keys fetched from a JWKS URL.
+// The keys are fetched again once they are older than the refresh interval, or when a token
+// is signed by an unknown key, so keys added by rotation are picked up without a restart.
+// If the keys cannot be fetched, the previously fetched keys keep being used.
+type RemoteKeySet struct {
+	url             string
+	client          *http.Client
+	refreshInterval time.Duration
+	issuer          string
+	audience        string
+
+	fetches singleflight.Group // Verifications needing the keys wait for the same fetch
+
+	mu        sync.Mutex
+	keys      map[string]*Key
+	fetchedAt time.Time
+	triedAt   time.Time
+}
+
+// NewRemoteKeySet creates a new instance of RemoteKeySet. The keys are fetched on the first verification.
+func NewRemoteKeySet(url string, refreshInterval time.Duration, timeout time.Duration) *RemoteKeySet {
+	return &RemoteKeySet{
+		url:             url,
+		client:          &http.Client{Timeout: timeout},
+		refreshInterval: refreshInterval,
+		issuer:          DefaultIssuer,
+		audience:        DefaultAudience,
+	}
+}
+
+// SetIssuer sets the issuer and the audience required from the verified tokens.
+func (rs *RemoteKeySet) SetIssuer(issuer, audience string) {
+	rs.issuer = issuer
+	rs.audience = audience
+}
+
+// Verify checks the signature, the expiration, the issuer and the audience of the token and returns its claims.
+func (rs *RemoteKeySet) Verify(tokenString string) (*domain.TokenClaims, error) {
+	return verify(tokenString, rs.issuer, rs.audience, rs.key)
+}
+
+// key returns the key of the given ID, fetching the keys if it is unknown or they are stale. The keys are fetched
+// without holding the mutex, so verifications with known keys are not blocked by a slow JWKS URL.
+func (rs *RemoteKeySet) key(kid string) (*Key, error) {
+	k, ok, stale := rs.lookup(kid)
+	if !ok || stale {
+		_, _, _ = rs.fetches.Do("", func() (any, error) {
+			rs.refresh()
+			return nil, nil
+		})
+		k, ok, _ = rs.lookup(kid)
+	}
+
+	if !ok {
+		return nil, fmt.Errorf("unknown key ID %q", kid)
+	}
+
+	return k, nil
+}
+
+// lookup returns the key of the given ID and reports whether the keys are stale.
+func (rs *RemoteKeySet) lookup(kid string) (*Key, bool, bool) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	k, ok := rs.keys[kid]
+	return k, ok, time.Since(rs.fetchedAt) > rs.refreshInterval
+}
+
+// refresh fetches the keys unless they were tried to be fetched recently.
+func (rs *RemoteKeySet) refresh() {
+	rs.mu.Lock()
+	if time.Since(rs.triedAt) <= minRefreshInterval {
+		rs.mu.Unlock()
+		return
+	}
+	rs.triedAt = time.Now()
+	rs.mu.Unlock()
+
+	if err := rs.fetch(); err != nil {
+		log.Printf("Error fetching token keys: %v", err)
+	}
+}
+
+// fetch replaces the keys with the ones published at the JWKS URL.
+func (rs *RemoteKeySet) fetch() error {
+	ctx, cancel := context.WithTimeout(context.Background(), rs.client.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rs.url, nil)
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+
+	resp, err := rs.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error sending request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	var set JWKS
+	if err = json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return fmt.Errorf("error decoding JWKS: %w", err)
+	}
+
+	keys := make(map[string]*Key, len(set.Keys))
+	for _, jwk := range set.Keys {
+		k, err := jwk.key()
+		if err != nil {
+			log.Printf("Skipping token key %q: %v", jwk.KeyID, err)
+			continue
+		}
+		keys[k.ID] = k
+	}
+
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	rs.keys = keys
+	rs.fetchedAt = time.Now()
+
+	return nil
+}
+
+// key converts the JWK to a key which can only verify tokens.
+func (jwk JWK) key() (*Key, error) {
+	if jwk.Use != "" && jwk.Use != "sig" {
+		return nil, fmt.Errorf("unsupported key use %q", jwk.Use)
+	}
+
+	switch {
+	case jwk.KeyType == "RSA" && jwk.Algorithm == jwt.SigningMethodRS256.Alg():
+		n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding modulus: %w", err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding exponent: %w", err)
+		}
+
+		public := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		if public.N.BitLen() < minRSABits {
+			return nil, fmt.Errorf("RSA key must have at least %d bits", minRSABits)
+		}
+		return &Key{ID: jwk.KeyID, Method: jwt.SigningMethodRS256, public: public}, nil
+	case jwk.KeyType == "OKP" && jwk.Curve == "Ed25519" && jwk.Algorithm == jwt.SigningMethodEdDSA.Alg():
+		x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding public key: %w", err)
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid Ed25519 public key size %d", len(x))
+		}
+		return &Key{ID: jwk.KeyID, Method: jwt.SigningMethodEdDSA, public: ed25519.PublicKey(x)}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q with algorithm %q", jwk.KeyType, jwk.Algorithm)
+	}
+}
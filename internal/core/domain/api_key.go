@@ -0,0 +1,18 @@
+package domain
+
+import "time"
+
+// APIKey is a long-lived key authenticating scripts as its owner. It is limited to its permissions,
+// which are a subset of the permissions of the owner, and is stored by the hash of its value.
+type APIKey struct {
+	ID          int64        `json:"id"`
+	Username    string       `json:"-"`
+	Name        string       `json:"name"`
+	Prefix      string       `json:"prefix"` // First characters of the key telling the keys apart
+	Hash        string       `json:"-"`
+	Key         string       `json:"key,omitempty"` // Value of the key; returned only on creation
+	Permissions []Permission `json:"permissions"`
+	CreatedAt   time.Time    `json:"created_at"`
+	ExpiresAt   *time.Time   `json:"expires_at,omitempty"` // Never expires if nil
+	LastUsedAt  *time.Time   `json:"last_used_at,omitempty"`
+}
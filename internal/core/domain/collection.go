@@ -0,0 +1,11 @@
+package domain
+
+import "time"
+
+// Collection is a named list of comics organized by a user.
+type Collection struct {
+	ID        int64         `json:"id"`
+	Name      string        `json:"name"`
+	Comics    []*FoundComic `json:"comics"`
+	CreatedAt time.Time     `json:"created_at"`
+}
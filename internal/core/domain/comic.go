@@ -2,8 +2,17 @@ package domain
 
 type Comics map[int]*Comic
 
-// Comic includes the URL of the comic image and a list of keywords associated with the comic.
+// Comic includes the title, the URL of the comic image and a list of keywords associated with the comic.
 type Comic struct {
+	Source   string   `json:"source"`
+	Title    string   `json:"title"`
 	Img      string   `json:"url"`
 	Keywords []string `json:"keywords"`
 }
+
+// SaveStats reports how many comics were inserted and how many existing comics were updated on save.
+type SaveStats struct {
+	Inserted    int   `json:"inserted"`
+	Updated     int   `json:"updated"`
+	InsertedIDs []int `json:"-"`
+}
@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrNotFound is returned when the requested entity does not exist.
+	ErrNotFound = errors.New("not found")
+	// ErrAlreadyExists is returned when the entity to create conflicts with an existing one.
+	ErrAlreadyExists = errors.New("already exists")
+	// ErrInvalidInput is returned when the request data does not pass validation.
+	ErrInvalidInput = errors.New("invalid input")
+	// ErrInvalidToken is returned when the token is malformed, expired, or revoked.
+	ErrInvalidToken = errors.New("invalid token")
+	// ErrInvalidCredentials is returned when the username or the password is wrong; it does not tell which.
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	// ErrTooManyAttempts is returned when logins are throttled after repeated failures.
+	ErrTooManyAttempts = errors.New("too many failed login attempts")
+	// ErrForbidden is returned when the operation is not allowed.
+	ErrForbidden = errors.New("forbidden")
+	// ErrUpdateInProgress is returned when a comics update is requested while another one is running.
+	ErrUpdateInProgress = errors.New("update is already in progress")
+)
+
+// ThrottledError is returned when a login is rejected without checking the password because of
+// the recent failed attempts. It tells when the next attempt is allowed.
+type ThrottledError struct {
+	RetryAfter time.Duration
+}
+
+func (e *ThrottledError) Error() string {
+	return fmt.Sprintf("%v, retry after %v", ErrTooManyAttempts, e.RetryAfter)
+}
+
+func (e *ThrottledError) Unwrap() error {
+	return ErrTooManyAttempts
+}
+
+// FieldViolation is a reason why a field of the request is invalid.
+type FieldViolation struct {
+	Field       string `json:"field"`
+	Code        string `json:"code"` // Stable identifier of the rule, e.g. too_short
+	Description string `json:"description"`
+}
+
+// ValidationError is returned when fields of the request break rules, such as the password policy.
+// It lists every violation and wraps ErrInvalidInput.
+type ValidationError struct {
+	Violations []FieldViolation
+}
+
+func (e *ValidationError) Error() string {
+	descriptions := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		descriptions[i] = v.Field + ": " + v.Description
+	}
+
+	return fmt.Sprintf("%v: %s", ErrInvalidInput, strings.Join(descriptions, "; "))
+}
+
+func (e *ValidationError) Unwrap() error {
+	return ErrInvalidInput
+}
@@ -0,0 +1,30 @@
+package domain
+
+// JudgedQuery is a search query together with the IDs of comics judged relevant to it.
+type JudgedQuery struct {
+	Query    string `json:"query"`
+	Relevant []int  `json:"relevant"`
+}
+
+// QueryEvaluation contains relevance metrics of the search results for a single judged query.
+type QueryEvaluation struct {
+	Query          string  `json:"query"`
+	Results        []int   `json:"results"`
+	Precision      float64 `json:"precision"`
+	Recall         float64 `json:"recall"`
+	ReciprocalRank float64 `json:"reciprocal_rank"`
+	NDCG           float64 `json:"ndcg"`
+	Error          string  `json:"error,omitempty"`
+}
+
+// EvaluationReport contains relevance metrics of a search engine averaged over a set of judged queries.
+// Metrics are calculated for the first K results.
+type EvaluationReport struct {
+	K         int                `json:"k"`
+	Precision float64            `json:"precision"`
+	Recall    float64            `json:"recall"`
+	MRR       float64            `json:"mrr"`
+	NDCG      float64            `json:"ndcg"`
+	Failed    int                `json:"failed"` // Number of queries which could not be searched
+	Queries   []*QueryEvaluation `json:"queries"`
+}
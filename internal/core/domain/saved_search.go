@@ -0,0 +1,30 @@
+package domain
+
+import "time"
+
+// SavedSearch is a search query saved by a user to be notified about new comics matching it.
+type SavedSearch struct {
+	ID         int64     `json:"id"`
+	Username   string    `json:"-"`
+	Query      string    `json:"query"`
+	Tokens     []string  `json:"-"`                     // Normalized query tokens
+	WebhookURL string    `json:"webhook_url,omitempty"` // URL notified about new matches, if any
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// InboxItem is a new comic matching a saved search of the user.
+type InboxItem struct {
+	ID            int64      `json:"id"`
+	SavedSearchID int64      `json:"saved_search_id"`
+	Query         string     `json:"query"`
+	Comic         FoundComic `json:"comic"`
+	CreatedAt     time.Time  `json:"created_at"`
+	ReadAt        *time.Time `json:"read_at,omitempty"`
+}
+
+// SavedSearchNotification is sent to the webhook of the saved search when new comics match it.
+type SavedSearchNotification struct {
+	SavedSearchID int64         `json:"saved_search_id"`
+	Query         string        `json:"query"`
+	Comics        []*FoundComic `json:"comics"`
+}
@@ -0,0 +1,72 @@
+package domain
+
+import "time"
+
+// SearchQuery is a search query logged for analytics.
+type SearchQuery struct {
+	ID             int64
+	Query          string   // Query as typed by the user
+	Tokens         []string // Normalized query tokens
+	Username       string
+	ResultCount    int
+	ResultIDs      []int // Comics returned to the user, the only ones a click can be reported on
+	Latency        time.Duration
+	ClickedComicID *int // Comic opened from the results, if reported back
+	CreatedAt      time.Time
+}
+
+// SearchResult is the result of a search query.
+type SearchResult struct {
+	QueryID int64 // ID of the logged query, or 0 if it was not logged
+	Comics  []*FoundComic
+}
+
+// FoundComic is a comic found by a search query.
+type FoundComic struct {
+	ID  int    `json:"id"`
+	URL string `json:"url"`
+}
+
+// URLs returns the image URLs of the found comics.
+func (r *SearchResult) URLs() []string {
+	urls := make([]string, 0, len(r.Comics))
+	for _, c := range r.Comics {
+		urls = append(urls, c.URL)
+	}
+
+	return urls
+}
+
+// HistoryEntry is a search query recorded in the history of the user who made it.
+type HistoryEntry struct {
+	ID          int64     `json:"id"`
+	Query       string    `json:"query"`
+	ResultCount int       `json:"result_count"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// QueryStats contains statistics of a normalized search query.
+type QueryStats struct {
+	Query      string  `json:"query"`
+	Count      int     `json:"count"`
+	AvgResults float64 `json:"avg_results"`
+	Clicks     int     `json:"clicks"`
+}
+
+// DailySearchStats contains statistics of search queries made during a day (UTC).
+type DailySearchStats struct {
+	Day          string  `json:"day"`
+	Queries      int     `json:"queries"`
+	ZeroResults  int     `json:"zero_results"`
+	Users        int     `json:"users"`
+	Clicks       int     `json:"clicks"`
+	AvgLatencyMs float64 `json:"avg_latency_ms"`
+}
+
+// SearchAnalytics summarizes search queries made since the given time.
+type SearchAnalytics struct {
+	Since             time.Time          `json:"since"`
+	TopQueries        []QueryStats       `json:"top_queries"`
+	ZeroResultQueries []QueryStats       `json:"zero_result_queries"`
+	Days              []DailySearchStats `json:"days"`
+}
@@ -0,0 +1,17 @@
+package domain
+
+import "time"
+
+// ShadowComparison compares results of the primary and the shadow search engines for a single query.
+type ShadowComparison struct {
+	Query           []string
+	PrimaryIDs      []int
+	ShadowIDs       []int
+	Jaccard         float64 // Jaccard similarity of the result sets
+	RankCorrelation float64 // Spearman's rank correlation of the common results
+	PrimaryLatency  time.Duration
+	ShadowLatency   time.Duration
+	LatencyDelta    time.Duration // Shadow latency minus primary latency
+	ShadowError     string
+	CreatedAt       time.Time
+}
@@ -0,0 +1,92 @@
+package domain
+
+import "time"
+
+// TokenClaims are the claims carried by an access token.
+type TokenClaims struct {
+	Username        string
+	Role            Role
+	Permissions     []Permission
+	HistoryDisabled bool   // Settings of the user at the time the token was issued
+	SessionID       string // Login session revoked by logout or by reuse of its refresh token
+	IssuedAt        time.Time
+	ExpiresAt       time.Time
+}
+
+// TokenPair is a short-lived access token with the refresh token exchanging it for a new pair.
+type TokenPair struct {
+	AccessToken  string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// Session is a login of the user. Every refresh rotates its refresh token, and using a rotated token again
+// revokes the session together with all its tokens.
+type Session struct {
+	ID        string
+	Username  string
+	CreatedAt time.Time
+	RevokedAt *time.Time
+}
+
+// RefreshToken is a refresh token of the session, stored by the hash of its value.
+type RefreshToken struct {
+	Hash      string
+	SessionID string
+	Username  string
+	ExpiresAt time.Time
+	UsedAt    *time.Time // Time the token was rotated
+	Revoked   bool       // Whether its session is revoked
+}
+
+// UserChange is a change of the role, the settings or the state of a user, or their deletion.
+// Tokens issued to the user before it no longer describe them.
+type UserChange struct {
+	Username  string
+	ChangedAt time.Time
+}
+
+// SessionRevocation is a revoked session, whose access tokens are no longer valid.
+type SessionRevocation struct {
+	SessionID string
+	RevokedAt time.Time
+}
+
+// Changes are the changes of users and the sessions revoked since a time,
+// fetched by the services verifying tokens locally.
+type Changes struct {
+	Users        []*UserChange
+	Sessions     []*SessionRevocation
+	Now          time.Time     // Time the changes were collected at; the next changes are fetched since it
+	TokenMaxTime time.Duration // Tokens issued before a change older than it have all expired
+}
+
+// LoginFailureReason tells why a login failed.
+type LoginFailureReason string
+
+const (
+	LoginUnknownUser   LoginFailureReason = "unknown_user"
+	LoginWrongPassword LoginFailureReason = "wrong_password"
+	LoginPending       LoginFailureReason = "pending"
+	LoginDisabled      LoginFailureReason = "disabled"
+	LoginThrottled     LoginFailureReason = "throttled" // Rejected without checking the password
+	LoginAttempted     LoginFailureReason = "attempted" // Still in progress; counts as a failure until resolved
+)
+
+// LoginFailure is an audit record of a failed login. With throttling, every login is recorded
+// as attempted before it is checked, and the record is dropped if the login succeeds.
+type LoginFailure struct {
+	ID        int64
+	Username  string
+	ClientIP  string // Empty if unknown
+	Reason    LoginFailureReason
+	CreatedAt time.Time
+}
+
+// LoginFailureStats are the recent failed logins of a username and of a client IP which count
+// towards throttling. Throttled attempts do not count.
+type LoginFailureStats struct {
+	UserFailures    int
+	UserLastFailure time.Time
+	IPFailures      int
+	IPLastFailure   time.Time
+}
@@ -0,0 +1,38 @@
+package domain
+
+import "time"
+
+type UpdateStatus string
+
+const (
+	UpdateRunning   UpdateStatus = "running"
+	UpdateSucceeded UpdateStatus = "succeeded"
+	UpdateFailed    UpdateStatus = "failed"
+)
+
+// UpdateJob describes a single run of the comics update and its progress.
+type UpdateJob struct {
+	ID         int          `json:"id"`
+	Status     UpdateStatus `json:"status"`
+	StartedAt  time.Time    `json:"started_at"`
+	FinishedAt *time.Time   `json:"finished_at,omitempty"`
+	Fetched    int          `json:"fetched"`  // Number of new comics retrieved from the sources
+	Saved      int          `json:"saved"`    // Number of comics saved to the database
+	Inserted   int          `json:"inserted"` // Number of saved comics which were not stored before
+	Updated    int          `json:"updated"`  // Number of saved comics which replaced the stored ones
+	Indexed    int          `json:"indexed"`  // Number of comics added to the search engine
+	Total      int          `json:"total"`    // Total number of comics after the update has finished
+	Errors     []string     `json:"errors,omitempty"`
+}
+
+// Copy returns a deep copy of the job.
+func (j *UpdateJob) Copy() *UpdateJob {
+	c := *j
+	if j.FinishedAt != nil {
+		finishedAt := *j.FinishedAt
+		c.FinishedAt = &finishedAt
+	}
+	c.Errors = append([]string(nil), j.Errors...)
+
+	return &c
+}
@@ -0,0 +1,22 @@
+package domain
+
+import "time"
+
+// UpdateSchedule configures when comics updates are run.
+type UpdateSchedule struct {
+	Cron         string         // Cron expression in the standard five-field format
+	Location     *time.Location // Time zone the cron expression is evaluated in
+	RunOnStartup bool           // Run the update as soon as the schedule starts
+	CatchUp      bool           // Run the update as soon as the schedule starts if a scheduled run was missed
+}
+
+// UpdateScheduleStatus describes the configured update schedule and its state.
+type UpdateScheduleStatus struct {
+	Cron         string     `json:"cron"`
+	Timezone     string     `json:"timezone"`
+	RunOnStartup bool       `json:"run_on_startup"`
+	CatchUp      bool       `json:"catch_up"`
+	LastSuccess  *time.Time `json:"last_success,omitempty"`
+	NextRun      *time.Time `json:"next_run,omitempty"`
+	RunningJobID int        `json:"running_job_id,omitempty"`
+}
@@ -1,5 +1,7 @@
 package domain
 
+import "time"
+
 type Role string
 
 const (
@@ -8,18 +10,85 @@ const (
 	ADMIN     Role = "admin"
 )
 
-// User represents a user of the system.
+// Permission is an action a principal is allowed to perform. Permissions are granted to roles by the auth server.
+type Permission string
+
+const (
+	PermComicsSearch   Permission = "comics:search"   // Search comics and report clicks
+	PermComicsUpdate   Permission = "comics:update"   // Update and import comics
+	PermAnalyticsRead  Permission = "analytics:read"  // View search analytics
+	PermWebhooksManage Permission = "webhooks:manage" // Manage outbound webhooks
+	PermUsersRegister  Permission = "users:register"  // Register users with any role
+	PermUsersManage    Permission = "users:manage"    // List, disable, delete users and change their roles
+	PermAccountManage  Permission = "account:manage"  // Manage own settings, history, collections and searches
+)
+
+// User is a user as stored by the auth server. It holds the password hash
+// and must never leave the auth server; other services see a Principal.
 type User struct {
+	Username     string
+	Email        string // Set for the users who signed up themselves
+	PasswordHash string
+	Role         Role
+	Pending      bool // Signed up, but not confirmed yet; pending users cannot log in
+	Disabled     bool // Disabled by an administrator; disabled users cannot log in
+
+	HistoryDisabled bool // Search history of the user is not recorded
+}
+
+// UserAccount describes the account of a user to administrators. It carries no credentials.
+type UserAccount struct {
+	Username  string     `json:"username"`
+	Email     string     `json:"email,omitempty"`
+	Role      Role       `json:"role"`
+	Pending   bool       `json:"pending"`
+	Disabled  bool       `json:"disabled"`
+	CreatedAt time.Time  `json:"created_at"`
+	LastLogin *time.Time `json:"last_login,omitempty"`
+}
+
+// UserPage is a page of user accounts ordered by username.
+type UserPage struct {
+	Users []*UserAccount `json:"users"`
+	Total int            `json:"total"` // Number of all users
+}
+
+// VerificationToken confirms the sign-up of a pending user. It is stored by the hash of its value.
+type VerificationToken struct {
+	Hash      string
+	Username  string
+	ExpiresAt time.Time
+}
+
+// Notification is a message delivered to a user by a notifier.
+type Notification struct {
 	Username string
-	Password string
-	Role     Role
+	Email    string
+	Subject  string
+	Text     string
+}
+
+// Principal is the authenticated user of a request. It carries no credentials.
+type Principal struct {
+	Username    string
+	Role        Role
+	Permissions []Permission
+	ExpiresAt   time.Time // Expiration of the token or API key the principal was authenticated with; zero if none
+
+	HistoryDisabled bool // Search history of the user is not recorded
 }
 
-// NewUser creates a new user with the given username, password, and role.
-func NewUser(username, password string, role Role) *User {
-	return &User{
-		Username: username,
-		Password: password,
-		Role:     role,
+// Can reports whether the principal has the permission.
+func (p *Principal) Can(perm Permission) bool {
+	if p == nil {
+		return false
 	}
+
+	for _, granted := range p.Permissions {
+		if granted == perm {
+			return true
+		}
+	}
+
+	return false
 }
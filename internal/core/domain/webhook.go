@@ -0,0 +1,84 @@
+package domain
+
+import "time"
+
+// EventType is the type of the event delivered to webhooks.
+type EventType string
+
+const (
+	EventComicAdded      EventType = "comic.added"
+	EventUpdateCompleted EventType = "update.completed"
+	EventUpdateFailed    EventType = "update.failed"
+
+	// EventSavedSearchMatched is only delivered to the webhooks of saved searches, which cannot subscribe to it
+	EventSavedSearchMatched EventType = "saved_search.matched"
+)
+
+// Valid reports whether the event type is known.
+func (t EventType) Valid() bool {
+	switch t {
+	case EventComicAdded, EventUpdateCompleted, EventUpdateFailed:
+		return true
+	default:
+		return false
+	}
+}
+
+// Event is a notification about comics ingestion delivered to the webhooks subscribed to its type.
+type Event struct {
+	ID        string    `json:"id"`
+	Type      EventType `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      any       `json:"data"`
+}
+
+// AddedComics is the data of the comic.added event: all comics added by one update.
+type AddedComics struct {
+	Comics []*AddedComic `json:"comics"`
+}
+
+// AddedComic is a comic added by an update.
+type AddedComic struct {
+	ID     int    `json:"id"`
+	Source string `json:"source"`
+	Title  string `json:"title"`
+	URL    string `json:"url"`
+}
+
+// Webhook is a target URL receiving the events of the subscribed types.
+type Webhook struct {
+	ID        int64       `json:"id"`
+	URL       string      `json:"url"`
+	Events    []EventType `json:"events"`
+	Secret    string      `json:"secret,omitempty"` // Key signing the deliveries, only shown on creation
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+// WebhookDelivery is an attempt to deliver the event to the webhook.
+type WebhookDelivery struct {
+	ID         int64     `json:"id"`
+	WebhookID  int64     `json:"webhook_id"`
+	EventID    string    `json:"event_id"`
+	EventType  EventType `json:"event_type"`
+	Attempt    int       `json:"attempt"`
+	StatusCode int       `json:"status_code,omitempty"` // Zero if no response was received
+	Error      string    `json:"error,omitempty"`
+	LatencyMs  float64   `json:"latency_ms"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// PendingDelivery is a delivery attempt waiting in the queue. It carries either an event for a webhook
+// or a notification for the webhook of a saved search.
+type PendingDelivery struct {
+	ID            int64
+	WebhookID     int64 // Zero for notifications of saved searches
+	SavedSearchID int64 // Zero for events
+	URL           string
+	Secret        string
+	EventID       string
+	EventType     EventType
+	Event         *Event
+	Notification  *SavedSearchNotification
+	Attempt       int
+	NextAttemptAt time.Time
+}
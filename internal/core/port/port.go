@@ -2,16 +2,31 @@ package port
 
 import (
 	"context"
+	"time"
 	"yadro-microservices/internal/core/domain"
 )
 
 // ComicRepository defines the interface for saving comic data to the database.
 type ComicRepository interface {
-	Save(ctx context.Context, c domain.Comics) error
+	Save(ctx context.Context, c domain.Comics) (*domain.SaveStats, error)
 	GetAll(ctx context.Context) (domain.Comics, error)
 	GetAllIDs(ctx context.Context) (map[int]bool, error)
 	GetByID(ctx context.Context, id int) (*domain.Comic, error)
 	GetTotalComics(ctx context.Context) (int, error)
+	GetUnindexed(ctx context.Context, index string, limit int) (domain.Comics, error)
+	MarkIndexed(ctx context.Context, index string, ids []int) error
+}
+
+// UpdateStateRepository defines the interface for storing the state of comics updates.
+type UpdateStateRepository interface {
+	GetLastSuccess(ctx context.Context) (time.Time, error)
+	SetLastSuccess(ctx context.Context, t time.Time) error
+}
+
+// UpdateLock defines the interface for the lock shared by all processes changing the comics and their index.
+// TryLock fails with domain.ErrUpdateInProgress if the lock is held, otherwise it returns the function releasing it.
+type UpdateLock interface {
+	TryLock(ctx context.Context) (unlock func(), err error)
 }
 
 // ComicProcessor defines the interface for processing text of the comic.
@@ -20,19 +35,162 @@ type ComicProcessor interface {
 }
 
 // SearchEngine defines the interface for a search engine.
+// IndexName identifies the index CreateIndex adds comics to, so that comics are tracked by every index.
 type SearchEngine interface {
 	Search(ctx context.Context, queryTokens []string) ([]int, error)
 	CreateIndex(ctx context.Context, comics domain.Comics) error
+	IndexName() string
+}
+
+// ShadowComparisonRepository defines the interface for storing comparisons of search engines.
+type ShadowComparisonRepository interface {
+	Save(ctx context.Context, c *domain.ShadowComparison) error
+}
+
+// SearchQueryRepository defines the interface for logging search queries and analyzing them.
+type SearchQueryRepository interface {
+	Save(ctx context.Context, q *domain.SearchQuery) (int64, error)
+	GetAnalytics(ctx context.Context, since time.Time, limit int) (*domain.SearchAnalytics, error)
+	Anonymize(ctx context.Context, username string) error
+}
+
+// SearchHistoryRepository defines the interface for storing search history of users.
+type SearchHistoryRepository interface {
+	Add(ctx context.Context, username string, e *domain.HistoryEntry) error
+	Get(ctx context.Context, username string, limit int) ([]*domain.HistoryEntry, error)
+	Clear(ctx context.Context, username string) error
+}
+
+// ClickRepository defines the interface for recording clicks on search results and counting them
+// per query token.
+type ClickRepository interface {
+	AddClick(ctx context.Context, queryID int64, username string, comicID int, at time.Time) error
+}
+
+// CollectionRepository defines the interface for storing favorite comics and comic collections of users.
+type CollectionRepository interface {
+	AddFavorite(ctx context.Context, username string, comicID int) error
+	RemoveFavorite(ctx context.Context, username string, comicID int) error
+	GetFavorites(ctx context.Context, username string) ([]*domain.FoundComic, error)
+	CreateCollection(ctx context.Context, username, name string) (*domain.Collection, error)
+	DeleteCollection(ctx context.Context, username string, id int64) error
+	GetCollections(ctx context.Context, username string) ([]*domain.Collection, error)
+	AddToCollection(ctx context.Context, username string, id int64, comicID int) error
+	RemoveFromCollection(ctx context.Context, username string, id int64, comicID int) error
+}
+
+// SavedSearchRepository defines the interface for storing saved searches of users and their matches.
+type SavedSearchRepository interface {
+	Create(ctx context.Context, s *domain.SavedSearch) error
+	CountByUsername(ctx context.Context, username string) (int, error)
+	GetByUsername(ctx context.Context, username string) ([]*domain.SavedSearch, error)
+	GetAll(ctx context.Context) ([]*domain.SavedSearch, error)
+	Delete(ctx context.Context, username string, id int64) error
+	AddMatches(ctx context.Context, s *domain.SavedSearch, comicIDs []int) ([]int, error)
+	GetInbox(ctx context.Context, username string, unreadOnly bool, limit int) ([]*domain.InboxItem, error)
+	MarkRead(ctx context.Context, username string, id int64, at time.Time) error
+}
+
+// UserDataRepository defines the interface for deleting the favorites, collections, search history
+// and saved searches of users.
+type UserDataRepository interface {
+	DeleteUserData(ctx context.Context, username string) error
+}
+
+// ComicMatcher defines the interface for matching comics against a search query without the search index.
+type ComicMatcher interface {
+	Match(ctx context.Context, queryTokens []string, comics domain.Comics) ([]int, error)
+}
+
+// SavedSearchNotifier defines the interface for notifying webhooks of saved searches about new matches.
+// Notifications are queued, so NotifySavedSearch does not wait for the webhook.
+type SavedSearchNotifier interface {
+	CheckURL(ctx context.Context, url string) error
+	NotifySavedSearch(ctx context.Context, s *domain.SavedSearch, n *domain.SavedSearchNotification) error
+}
+
+// WebhookRepository defines the interface for storing webhooks, the queue of their pending deliveries
+// and the log of the made ones. ClaimDue hides the claimed deliveries from other claims for the lease.
+// CompleteDelivery logs the attempt and queues the next one unless retryAt is zero.
+type WebhookRepository interface {
+	Create(ctx context.Context, w *domain.Webhook) error
+	GetAll(ctx context.Context) ([]*domain.Webhook, error)
+	Delete(ctx context.Context, id int64) error
+	Enqueue(ctx context.Context, deliveries []*domain.PendingDelivery) error
+	ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*domain.PendingDelivery, error)
+	CompleteDelivery(ctx context.Context, d *domain.WebhookDelivery, retryAt time.Time) error
+	GetDeliveries(ctx context.Context, webhookID int64, limit int) ([]*domain.WebhookDelivery, error)
+}
+
+// WebhookSender defines the interface for sending signed events and saved search notifications to webhooks.
+// It returns the status code of the response, or zero if no response was received.
+// CheckURL returns an error if the sender refuses to deliver to the URL, e.g. to an internal address.
+type WebhookSender interface {
+	CheckURL(ctx context.Context, url string) error
+	Send(ctx context.Context, url, secret string, e *domain.Event) (int, error)
+	Notify(ctx context.Context, url string, n *domain.SavedSearchNotification) (int, error)
+}
+
+// ComicsListener defines the interface for reacting to comics added by an update.
+type ComicsListener interface {
+	ComicsAdded(ctx context.Context, comics domain.Comics) error
+}
+
+// UpdateListener defines the interface for reacting to finished updates.
+type UpdateListener interface {
+	UpdateFinished(ctx context.Context, job *domain.UpdateJob)
 }
 
 // ComicService defines the interface for the comic service.
 type ComicService interface {
-	UpdateComics(ctx context.Context) error
-	Search(ctx context.Context, query string) ([]string, error)
+	StartUpdate(ctx context.Context) (*domain.UpdateJob, error)
+	GetUpdateJob(ctx context.Context, id int) (*domain.UpdateJob, error)
+	GetScheduleStatus(ctx context.Context) (*domain.UpdateScheduleStatus, error)
+	ImportComics(ctx context.Context, comics domain.Comics) (int, error)
+	Search(ctx context.Context, query string, user *domain.Principal) (*domain.SearchResult, error)
+	ReportClick(ctx context.Context, queryID int64, user *domain.Principal, comicID int) error
+	GetSearchAnalytics(ctx context.Context, since time.Time, limit int) (*domain.SearchAnalytics, error)
+	GetHistory(ctx context.Context, username string, limit int) ([]*domain.HistoryEntry, error)
+	ClearHistory(ctx context.Context, username string) error
 	GetNumberOfComics(ctx context.Context) (int, error)
 }
 
+// CollectionService defines the interface for managing favorite comics and comic collections of users.
+type CollectionService interface {
+	AddFavorite(ctx context.Context, username string, comicID int) error
+	RemoveFavorite(ctx context.Context, username string, comicID int) error
+	GetFavorites(ctx context.Context, username string) ([]*domain.FoundComic, error)
+	CreateCollection(ctx context.Context, username, name string) (*domain.Collection, error)
+	DeleteCollection(ctx context.Context, username string, id int64) error
+	GetCollections(ctx context.Context, username string) ([]*domain.Collection, error)
+	AddToCollection(ctx context.Context, username string, id int64, comicID int) error
+	RemoveFromCollection(ctx context.Context, username string, id int64, comicID int) error
+}
+
+// SavedSearchService defines the interface for managing saved searches of users and their inbox.
+type SavedSearchService interface {
+	Create(ctx context.Context, username, query, webhookURL string) (*domain.SavedSearch, error)
+	Get(ctx context.Context, username string) ([]*domain.SavedSearch, error)
+	Delete(ctx context.Context, username string, id int64) error
+	GetInbox(ctx context.Context, username string, unreadOnly bool, limit int) ([]*domain.InboxItem, error)
+	MarkRead(ctx context.Context, username string, id int64) error
+}
+
+// WebhookService defines the interface for managing webhooks notified about comics ingestion events.
+type WebhookService interface {
+	Create(ctx context.Context, url string, events []domain.EventType) (*domain.Webhook, error)
+	List(ctx context.Context) ([]*domain.Webhook, error)
+	Delete(ctx context.Context, id int64) error
+	GetDeliveries(ctx context.Context, webhookID int64, limit int) ([]*domain.WebhookDelivery, error)
+}
+
+// UserService defines the interface for deleting users together with their data kept by the xkcd server.
+type UserService interface {
+	DeleteUser(ctx context.Context, username string) error
+}
+
 // ComicClient defines the interface for the comic client.
+// If only some comics could be retrieved, GetComics returns them together with the error.
 type ComicClient interface {
 	GetComics(ctx context.Context, existingIDs map[int]bool) (domain.Comics, error)
 }
@@ -40,19 +198,128 @@ type ComicClient interface {
 // UserRepository defines the interface for the user repository. It is used to store and retrieve user data.
 type UserRepository interface {
 	Save(ctx context.Context, user *domain.User) error
+	SavePending(ctx context.Context, user *domain.User, t *domain.VerificationToken) error
+	DeletePending(ctx context.Context, username string) error
+	Confirm(ctx context.Context, hash string, now time.Time) (string, error)
 	GetByUsername(ctx context.Context, username string) (*domain.User, error)
+	List(ctx context.Context, offset, limit int) (*domain.UserPage, error)
+	SetHistoryDisabled(ctx context.Context, username string, disabled bool) error
+	SetRole(ctx context.Context, username string, role domain.Role) error
+	SetDisabled(ctx context.Context, username string, disabled bool) error
+	SetPassword(ctx context.Context, username, passwordHash string) error
+	RecordLogin(ctx context.Context, username string, at time.Time) error
+	Delete(ctx context.Context, username string) error
+	GetChanges(ctx context.Context, since time.Time) ([]*domain.UserChange, error)
+	DeleteChanges(ctx context.Context, before time.Time) error
+}
+
+// Notifier defines the interface for delivering notifications to users, such as sign-up confirmations.
+type Notifier interface {
+	Notify(ctx context.Context, msg *domain.Notification) error
+}
+
+// RoleRepository defines the interface for retrieving the permissions granted to roles.
+type RoleRepository interface {
+	GetPermissions(ctx context.Context, role domain.Role) ([]domain.Permission, error)
+}
+
+// SessionRepository defines the interface for storing login sessions and their refresh tokens.
+type SessionRepository interface {
+	CreateSession(ctx context.Context, s *domain.Session) error
+	AddRefreshToken(ctx context.Context, t *domain.RefreshToken) error
+	GetRefreshToken(ctx context.Context, hash string) (*domain.RefreshToken, error)
+	UseRefreshToken(ctx context.Context, hash string, usedAt time.Time) (bool, error)
+	RevokeSession(ctx context.Context, id string, revokedAt time.Time) error
+	RevokeUserSessions(ctx context.Context, username string, revokedAt time.Time) error
+	IsSessionRevoked(ctx context.Context, id string) (bool, error)
+	GetRevocations(ctx context.Context, since time.Time) ([]*domain.SessionRevocation, error)
+	DeleteExpired(ctx context.Context, before time.Time) error
+}
+
+// LoginFailureRepository defines the interface for the audit records of failed logins, which throttle logins.
+type LoginFailureRepository interface {
+	Save(ctx context.Context, f *domain.LoginFailure) error
+	SetReason(ctx context.Context, id int64, reason domain.LoginFailureReason) error
+	Delete(ctx context.Context, id int64) error
+	DeleteBefore(ctx context.Context, before time.Time) error
+	GetStats(
+		ctx context.Context,
+		username, clientIP string,
+		since time.Time,
+		beforeID int64,
+	) (*domain.LoginFailureStats, error)
+}
+
+// APIKeyRepository defines the interface for storing API keys of users.
+type APIKeyRepository interface {
+	Create(ctx context.Context, k *domain.APIKey, maxKeys int) error
+	GetByHash(ctx context.Context, hash string) (*domain.APIKey, error)
+	List(ctx context.Context, username string) ([]*domain.APIKey, error)
+	Delete(ctx context.Context, username string, id int64) error
+	RecordUse(ctx context.Context, id int64, at time.Time) error
+}
+
+// TokenVerifier defines the interface for verifying access tokens.
+type TokenVerifier interface {
+	Verify(token string) (*domain.TokenClaims, error)
+}
+
+// TokenSigner defines the interface for issuing and verifying access tokens.
+type TokenSigner interface {
+	TokenVerifier
+	Sign(claims *domain.TokenClaims) (string, error)
 }
 
 // AuthService defines the interface for the auth service.
 type AuthService interface {
-	Login(ctx context.Context, username, password string) (string, error)
-	Register(ctx context.Context, newUser *domain.User) error
-	ValidateToken(ctx context.Context, tokenString string) (*domain.User, error)
+	Login(ctx context.Context, username, password, clientIP string) (*domain.TokenPair, error)
+	Refresh(ctx context.Context, refreshToken string) (*domain.TokenPair, error)
+	Logout(ctx context.Context, token string) error
+	Register(ctx context.Context, username, password string, role domain.Role) error
+	SignUp(ctx context.Context, username, email, password string) error
+	ConfirmSignUp(ctx context.Context, token string) error
+	ValidateToken(ctx context.Context, tokenString string) (*domain.Principal, error)
+	SetHistoryDisabled(ctx context.Context, username string, disabled bool) error
+	ListUsers(ctx context.Context, offset, limit int) (*domain.UserPage, error)
+	SetRole(ctx context.Context, username string, role domain.Role) error
+	SetDisabled(ctx context.Context, username string, disabled bool) error
+	DeleteUser(ctx context.Context, username string) error
+	ChangePassword(ctx context.Context, username, oldPassword, newPassword, clientIP string) error
+	CreateAPIKey(
+		ctx context.Context,
+		username, name string,
+		permissions []domain.Permission,
+		ttl time.Duration,
+	) (*domain.APIKey, error)
+	ListAPIKeys(ctx context.Context, username string) ([]*domain.APIKey, error)
+	RevokeAPIKey(ctx context.Context, username string, id int64) error
+	ValidateAPIKey(ctx context.Context, key string) (*domain.Principal, error)
+	GetChanges(ctx context.Context, since time.Time) (*domain.Changes, error)
 }
 
 // AuthClient defines the interface for the auth client. It is used to communicate with the auth server.
 type AuthClient interface {
 	Register(ctx context.Context, username, password string, role domain.Role) error
-	Login(ctx context.Context, username, password string) (string, error)
-	ValidateToken(ctx context.Context, token string) (*domain.User, error)
+	SignUp(ctx context.Context, username, email, password string) error
+	ConfirmSignUp(ctx context.Context, token string) error
+	Login(ctx context.Context, username, password, clientIP string) (*domain.TokenPair, error)
+	Refresh(ctx context.Context, refreshToken string) (*domain.TokenPair, error)
+	Logout(ctx context.Context, token string) error
+	ValidateToken(ctx context.Context, token string) (*domain.Principal, error)
+	SetHistoryDisabled(ctx context.Context, username string, disabled bool) error
+	ListUsers(ctx context.Context, offset, limit int) (*domain.UserPage, error)
+	SetRole(ctx context.Context, username string, role domain.Role) error
+	SetDisabled(ctx context.Context, username string, disabled bool) error
+	DeleteUser(ctx context.Context, username string) error
+	ChangePassword(ctx context.Context, username, oldPassword, newPassword, clientIP string) error
+	CreateAPIKey(
+		ctx context.Context,
+		username, name string,
+		permissions []domain.Permission,
+		ttl time.Duration,
+	) (*domain.APIKey, error)
+	ListAPIKeys(ctx context.Context, username string) ([]*domain.APIKey, error)
+	RevokeAPIKey(ctx context.Context, username string, id int64) error
+	ValidateAPIKey(ctx context.Context, key string) (*domain.Principal, error)
+	GetChanges(ctx context.Context, since time.Time) (*domain.Changes, error)
 }
@@ -2,110 +2,933 @@ package service
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"log"
+	"runtime"
+	"slices"
+	"sync"
 	"time"
 	"yadro-microservices/internal/core/domain"
 	"yadro-microservices/internal/core/port"
+)
+
+// Sizes of the random session IDs and tokens.
+const (
+	sessionIDBytes         = 16
+	refreshTokenBytes      = 32
+	verificationTokenBytes = 32
+	apiKeyBytes            = 32
+)
 
-	"github.com/golang-jwt/jwt/v5"
-	"golang.org/x/crypto/bcrypt"
+// Format and limits of API keys.
+const (
+	apiKeyPrefix        = "xk_"
+	apiKeyVisibleLength = len(apiKeyPrefix) + 8 // Characters of the key stored to tell keys apart
+	apiKeyMaxNameLength = 64
+	maxAPIKeys          = 20          // Keys of a user which have not expired
+	apiKeyUseInterval   = time.Minute // Uses of a key are recorded at most this often
 )
 
 // AuthService is the service for authentication.
 type AuthService struct {
-	authRep      port.UserRepository
-	tokenMaxTime time.Duration
+	authRep         port.UserRepository
+	roleRep         port.RoleRepository
+	sessionRep      port.SessionRepository
+	failureRep      port.LoginFailureRepository
+	apiKeyRep       port.APIKeyRepository
+	tokens          port.TokenSigner
+	tokenMaxTime    time.Duration
+	refreshTokenTTL time.Duration
+
+	notifier       port.Notifier // Sends sign-up confirmations; sign-up is disabled if nil
+	signUpTokenTTL time.Duration
+
+	throttle         LoginThrottle
+	failureRetention time.Duration // Failed logins are kept as the audit record for this long; forever if zero
+
+	hashing   PasswordHashing
+	policy    PasswordPolicy
+	dummies   func() dummyHashes // Verified in place of missing hashes to take as long as a wrong password
+	hashSlots chan struct{}      // Limits passwords hashed at once, as every argon2id hash takes its memory
 }
 
-// NewAuthService creates a new AuthService.
-func NewAuthService(authRep port.UserRepository, tokenMaxTime time.Duration) *AuthService {
-	return &AuthService{
-		authRep:      authRep,
-		tokenMaxTime: tokenMaxTime,
+// NewAuthService creates a new AuthService. Access tokens are valid for tokenMaxTime
+// and refresh tokens for refreshTokenTTL.
+func NewAuthService(
+	authRep port.UserRepository,
+	roleRep port.RoleRepository,
+	sessionRep port.SessionRepository,
+	failureRep port.LoginFailureRepository,
+	apiKeyRep port.APIKeyRepository,
+	tokens port.TokenSigner,
+	tokenMaxTime time.Duration,
+	refreshTokenTTL time.Duration,
+) *AuthService {
+	a := &AuthService{
+		authRep:         authRep,
+		roleRep:         roleRep,
+		sessionRep:      sessionRep,
+		failureRep:      failureRep,
+		apiKeyRep:       apiKeyRep,
+		tokens:          tokens,
+		tokenMaxTime:    tokenMaxTime,
+		refreshTokenTTL: refreshTokenTTL,
+		hashing:         DefaultPasswordHashing,
 	}
+	a.dummies = sync.OnceValue(func() dummyHashes {
+		d, err := a.hashing.dummies()
+		if err != nil {
+			log.Println("Error hashing dummy password:", err)
+		}
+
+		return d
+	})
+	a.SetHashConcurrency(runtime.NumCPU())
+
+	return a
 }
 
-// Login logs in the user and returns a token.
-func (a *AuthService) Login(ctx context.Context, username, password string) (string, error) {
+// SetPasswordHashing selects how new passwords are hashed. Passwords hashed otherwise are rehashed at login.
+func (a *AuthService) SetPasswordHashing(hashing PasswordHashing) {
+	a.hashing = hashing
+}
+
+// SetHashConcurrency limits the number of passwords hashed or verified at once to n; further requests wait.
+// Every argon2id hash takes its memory, so the limit bounds the memory logins take.
+func (a *AuthService) SetHashConcurrency(n int) {
+	a.hashSlots = make(chan struct{}, max(n, 1))
+}
+
+// SetPasswordPolicy sets the rules new passwords have to follow.
+func (a *AuthService) SetPasswordPolicy(policy PasswordPolicy) {
+	a.policy = policy
+}
+
+// EnableSignUp allows users to sign up themselves. The tokens confirming their accounts
+// are sent by the notifier and are valid for tokenTTL.
+func (a *AuthService) EnableSignUp(notifier port.Notifier, tokenTTL time.Duration) {
+	a.notifier = notifier
+	a.signUpTokenTTL = tokenTTL
+}
+
+// ThrottleLogins limits failed logins. Without it failed logins are only recorded.
+func (a *AuthService) ThrottleLogins(throttle LoginThrottle) {
+	a.throttle = throttle
+}
+
+// SetLoginFailureRetention sets how long failed logins are kept as the audit record. They are kept forever
+// unless it is set. The retention is independent of the throttling window, which only limits the counted failures.
+func (a *AuthService) SetLoginFailureRetention(retention time.Duration) {
+	a.failureRetention = retention
+}
+
+// Login logs in the user, starting a new session, and returns its access and refresh tokens.
+// An unknown username and a wrong password both fail with ErrInvalidCredentials. Every failure is recorded
+// with the client IP, and after repeated failures attempts fail with ThrottledError without checking the password.
+func (a *AuthService) Login(ctx context.Context, username, password, clientIP string) (*domain.TokenPair, error) {
+	attempt := &domain.LoginFailure{
+		Username:  username,
+		ClientIP:  clientIP,
+		Reason:    domain.LoginAttempted,
+		CreatedAt: time.Now(),
+	}
+	defer a.dropAttempt(ctx, attempt)
+
+	if err := a.checkThrottle(ctx, attempt); err != nil {
+		return nil, err
+	}
+
 	user, err := a.authRep.GetByUsername(ctx, username)
 	if err != nil {
-		return "", fmt.Errorf("failed to get user: %w", err)
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	// An unknown user takes as long as a wrong password, whatever algorithm hashed the passwords of others
+	var hash string
+	if user != nil {
+		hash = user.PasswordHash
+	}
+
+	ok, rehash, err := a.verifyPassword(ctx, hash, password)
+	if err != nil {
+		return nil, err
 	}
 
 	if user == nil {
-		return "", errors.New("user not found")
+		a.recordFailure(ctx, attempt, domain.LoginUnknownUser)
+		return nil, domain.ErrInvalidCredentials
+	}
+
+	if !ok {
+		a.recordFailure(ctx, attempt, domain.LoginWrongPassword)
+		return nil, domain.ErrInvalidCredentials
+	}
+
+	// Only those who know the password learn the state of the account
+	if user.Pending {
+		a.recordFailure(ctx, attempt, domain.LoginPending)
+		return nil, fmt.Errorf("account is not confirmed: %w", domain.ErrForbidden)
+	}
+
+	if user.Disabled {
+		a.recordFailure(ctx, attempt, domain.LoginDisabled)
+		return nil, fmt.Errorf("account is disabled: %w", domain.ErrForbidden)
+	}
+
+	sessionID, err := randomHex(sessionIDBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate session ID: %w", err)
+	}
+
+	if err = a.sessionRep.CreateSession(ctx, &domain.Session{ID: sessionID, Username: user.Username}); err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
 	}
 
-	if err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
-		return "", errors.New("invalid password")
+	if err = a.authRep.RecordLogin(ctx, user.Username, time.Now()); err != nil {
+		log.Printf("Error recording login of user %s: %v", user.Username, err)
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"username": username,
-		"exp":      time.Now().Add(a.tokenMaxTime).Unix(),
+	if rehash {
+		a.rehash(ctx, user.Username, password)
+	}
+
+	return a.issue(ctx, user, sessionID, time.Now().Add(a.refreshTokenTTL))
+}
+
+// verifyPassword verifies the password against the hash once a hashing slot is free. Every verification
+// runs both algorithms, and an empty hash never matches. See PasswordHashing.verifyEvenly.
+func (a *AuthService) verifyPassword(ctx context.Context, hash, password string) (ok, rehash bool, err error) {
+	release, err := a.acquireHashing(ctx)
+	if err != nil {
+		return false, false, err
+	}
+	defer release()
+
+	ok, rehash = a.hashing.verifyEvenly(hash, password, a.dummies())
+	return ok, rehash, nil
+}
+
+// hashPassword hashes the password with the current hashing once a hashing slot is free.
+func (a *AuthService) hashPassword(ctx context.Context, password string) (string, error) {
+	release, err := a.acquireHashing(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+
+	return a.hashing.hash(password)
+}
+
+// acquireHashing waits for a free slot to hash a password and returns the function releasing it,
+// or the error of the context if it is done first.
+func (a *AuthService) acquireHashing(ctx context.Context) (func(), error) {
+	select {
+	case a.hashSlots <- struct{}{}:
+		return func() { <-a.hashSlots }, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("failed to wait for password hashing: %w", ctx.Err())
+	}
+}
+
+// rehash replaces the password hash of the user by one made with the current hashing. Errors are only logged,
+// as the old hash still verifies the password.
+func (a *AuthService) rehash(ctx context.Context, username, password string) {
+	hash, err := a.hashPassword(ctx, password)
+	if err == nil {
+		err = a.authRep.SetPassword(ctx, username, hash)
+	}
+	if err != nil {
+		log.Printf("Error rehashing password of user %s: %v", username, err)
+		return
+	}
+
+	log.Printf("Rehashed password of user %s with %s", username, a.hashing.Algorithm)
+}
+
+// checkThrottle returns ThrottledError if the login has to wait because of the recent failures
+// of the username or from the client IP. The attempt is recorded before the failures are counted,
+// so of concurrent attempts every later one counts the earlier ones. The rejected attempt stays recorded.
+func (a *AuthService) checkThrottle(ctx context.Context, attempt *domain.LoginFailure) error {
+	if a.throttle.Window <= 0 {
+		return nil
+	}
+
+	if err := a.failureRep.Save(ctx, attempt); err != nil {
+		return fmt.Errorf("failed to record login attempt: %w", err)
+	}
+
+	stats, err := a.failureRep.GetStats(
+		ctx,
+		attempt.Username,
+		attempt.ClientIP,
+		attempt.CreatedAt.Add(-a.throttle.Window),
+		attempt.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to get login failures: %w", err)
+	}
+
+	retryAfter := a.throttle.retryAfter(stats, time.Now())
+	if retryAfter <= 0 {
+		return nil
+	}
+
+	a.recordFailure(ctx, attempt, domain.LoginThrottled)
+	return &domain.ThrottledError{RetryAfter: retryAfter}
+}
+
+// recordFailure saves the audit record of the failed login, or sets the reason of its recorded attempt.
+// Errors are only logged.
+func (a *AuthService) recordFailure(
+	ctx context.Context,
+	attempt *domain.LoginFailure,
+	reason domain.LoginFailureReason,
+) {
+	log.Printf("Failed login of user %q from %q: %s", attempt.Username, attempt.ClientIP, reason)
+	attempt.Reason = reason
+
+	var err error
+	if attempt.ID != 0 {
+		err = a.failureRep.SetReason(ctx, attempt.ID, reason)
+	} else {
+		err = a.failureRep.Save(ctx, attempt)
+	}
+	if err != nil {
+		log.Printf("Error recording failed login of user %q: %v", attempt.Username, err)
+	}
+}
+
+// dropAttempt deletes the recorded login attempt unless it has failed, so successful logins and logins
+// broken by an error do not count as failures. Errors are only logged.
+func (a *AuthService) dropAttempt(ctx context.Context, attempt *domain.LoginFailure) {
+	if attempt.ID == 0 || attempt.Reason != domain.LoginAttempted {
+		return
+	}
+
+	if err := a.failureRep.Delete(context.WithoutCancel(ctx), attempt.ID); err != nil {
+		log.Printf("Error deleting login attempt of user %q: %v", attempt.Username, err)
+	}
+}
+
+// Refresh exchanges the refresh token for a new pair of tokens of the same session.
+// The refresh token can be used only once: using it again means it has leaked,
+// so the whole session is revoked.
+func (a *AuthService) Refresh(ctx context.Context, refreshToken string) (*domain.TokenPair, error) {
+	hash := hashToken(refreshToken)
+	t, err := a.sessionRep.GetRefreshToken(ctx, hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get refresh token: %w", err)
+	}
+
+	now := time.Now()
+	switch {
+	case t == nil || t.Revoked:
+		return nil, domain.ErrInvalidToken
+	case t.UsedAt != nil:
+		return nil, a.revokeReused(ctx, t)
+	case now.After(t.ExpiresAt):
+		return nil, fmt.Errorf("refresh token expired: %w", domain.ErrInvalidToken)
+	}
+
+	ok, err := a.sessionRep.UseRefreshToken(ctx, hash, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rotate refresh token: %w", err)
+	}
+	if !ok {
+		// Another request has just used the token
+		return nil, a.revokeReused(ctx, t)
+	}
+
+	user, err := a.authRep.GetByUsername(ctx, t.Username)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if user == nil || user.Disabled {
+		return nil, domain.ErrInvalidToken
+	}
+
+	// The session expires at the same time however often it is refreshed
+	return a.issue(ctx, user, t.SessionID, t.ExpiresAt)
+}
+
+// Logout revokes the session of the access token together with all its tokens.
+func (a *AuthService) Logout(ctx context.Context, token string) error {
+	claims, err := a.tokens.Verify(token)
+	if err != nil {
+		return fmt.Errorf("%w: %w", domain.ErrInvalidToken, err)
+	}
+
+	if claims.SessionID == "" {
+		return domain.ErrInvalidToken
+	}
+
+	if err = a.sessionRep.RevokeSession(ctx, claims.SessionID, time.Now()); err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+
+	return nil
+}
+
+// issue creates a new pair of tokens of the session expiring at sessionExpiresAt.
+// Neither of the tokens is valid after the session expires.
+func (a *AuthService) issue(
+	ctx context.Context,
+	user *domain.User,
+	sessionID string,
+	sessionExpiresAt time.Time,
+) (*domain.TokenPair, error) {
+	permissions, err := a.roleRep.GetPermissions(ctx, user.Role)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get permissions: %w", err)
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(a.tokenMaxTime)
+	if sessionExpiresAt.Before(expiresAt) {
+		expiresAt = sessionExpiresAt
+	}
+
+	accessToken, err := a.tokens.Sign(&domain.TokenClaims{
+		Username:        user.Username,
+		Role:            user.Role,
+		Permissions:     permissions,
+		HistoryDisabled: user.HistoryDisabled,
+		SessionID:       sessionID,
+		IssuedAt:        now,
+		ExpiresAt:       expiresAt,
 	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign token: %w", err)
+	}
+
+	refreshToken, err := randomHex(refreshTokenBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+
+	err = a.sessionRep.AddRefreshToken(ctx, &domain.RefreshToken{
+		Hash:      hashToken(refreshToken),
+		SessionID: sessionID,
+		ExpiresAt: sessionExpiresAt,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to save refresh token: %w", err)
+	}
+
+	return &domain.TokenPair{AccessToken: accessToken, RefreshToken: refreshToken}, nil
+}
+
+// revokeReused revokes the session of the refresh token which was used more than once.
+func (a *AuthService) revokeReused(ctx context.Context, t *domain.RefreshToken) error {
+	log.Printf("Refresh token of session %s of user %s was reused, revoking the session", t.SessionID, t.Username)
+	if err := a.sessionRep.RevokeSession(ctx, t.SessionID, time.Now()); err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+
+	return fmt.Errorf("refresh token reused: %w", domain.ErrInvalidToken)
+}
+
+// hashToken returns the hex-encoded SHA-256 of the token, under which it is stored.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Register registers a new user with the specified username, password, and role.
+func (a *AuthService) Register(ctx context.Context, username, password string, role domain.Role) error {
+	hashedPassword, err := a.newPasswordHash(ctx, "password", username, password)
+	if err != nil {
+		return err
+	}
+
+	err = a.authRep.Save(ctx, &domain.User{
+		Username:     username,
+		PasswordHash: hashedPassword,
+		Role:         role,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save user: %w", err)
+	}
+
+	return nil
+}
+
+// newPasswordHash checks the new password of the user against the password policy and hashes it.
+// Violations of the policy are returned as ValidationError reported for the field.
+func (a *AuthService) newPasswordHash(ctx context.Context, field, username, password string) (string, error) {
+	violations := a.policy.check(field, username, password)
+	if a.hashing.Algorithm == HashBcrypt && len(password) > bcryptMaxLength {
+		violations = append(violations, domain.FieldViolation{
+			Field:       field,
+			Code:        "too_long",
+			Description: fmt.Sprintf("must be at most %d bytes long", bcryptMaxLength),
+		})
+	}
+	if len(violations) > 0 {
+		return "", &domain.ValidationError{Violations: violations}
+	}
 
-	tokenString, err := token.SignedString([]byte("secret")) // For demonstration purposes only. Should be hidden.
+	hash, err := a.hashPassword(ctx, password)
 	if err != nil {
-		return "", fmt.Errorf("failed to sign token: %w", err)
+		return "", fmt.Errorf("failed to hash password: %w", err)
 	}
 
-	return tokenString, nil
+	return hash, nil
 }
 
-// Register registers a new user.
-func (a *AuthService) Register(ctx context.Context, newUser *domain.User) error {
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newUser.Password), bcrypt.DefaultCost)
+// SignUp creates a pending account of a user with the USER role and sends them the token confirming it.
+// A pending user cannot log in until the account is confirmed. Sign-up fails with ErrForbidden unless enabled.
+func (a *AuthService) SignUp(ctx context.Context, username, email, password string) error {
+	if a.notifier == nil {
+		return fmt.Errorf("sign-up is disabled: %w", domain.ErrForbidden)
+	}
+
+	hashedPassword, err := a.newPasswordHash(ctx, "password", username, password)
 	if err != nil {
-		return fmt.Errorf("failed to hash password: %w", err)
+		return err
 	}
 
-	newUser.Password = string(hashedPassword)
+	token, err := randomHex(verificationTokenBytes)
+	if err != nil {
+		return fmt.Errorf("failed to generate verification token: %w", err)
+	}
 
-	err = a.authRep.Save(ctx, newUser)
+	expiresAt := time.Now().Add(a.signUpTokenTTL)
+	user := &domain.User{
+		Username:     username,
+		Email:        email,
+		PasswordHash: hashedPassword,
+		Role:         domain.USER,
+	}
+	err = a.authRep.SavePending(ctx, user, &domain.VerificationToken{
+		Hash:      hashToken(token),
+		Username:  username,
+		ExpiresAt: expiresAt,
+	})
 	if err != nil {
 		return fmt.Errorf("failed to save user: %w", err)
 	}
 
+	err = a.notifier.Notify(ctx, &domain.Notification{
+		Username: username,
+		Email:    email,
+		Subject:  "Confirm your account",
+		Text: fmt.Sprintf(
+			"Your confirmation token is %s. It expires at %s.",
+			token,
+			expiresAt.UTC().Format(time.RFC3339),
+		),
+	})
+	if err != nil {
+		// Without the token the account could never be confirmed, so the user can sign up again at once
+		if err := a.authRep.DeletePending(context.WithoutCancel(ctx), username); err != nil {
+			log.Printf("Error deleting pending user %s: %v", username, err)
+		}
+		return fmt.Errorf("failed to send confirmation: %w", err)
+	}
+
 	return nil
 }
 
-// ValidateToken validates the token and returns the user associated with it.
-func (a *AuthService) ValidateToken(ctx context.Context, tokenString string) (*domain.User, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+// ConfirmSignUp activates the pending account confirmed by the token. The token can be used once.
+func (a *AuthService) ConfirmSignUp(ctx context.Context, token string) error {
+	username, err := a.authRep.Confirm(ctx, hashToken(token), time.Now())
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return fmt.Errorf("unknown or expired verification token: %w", domain.ErrInvalidToken)
 		}
-		return []byte("secret"), nil // For demonstration purposes only. Should be hidden.
-	})
 
+		return fmt.Errorf("failed to confirm user: %w", err)
+	}
+
+	log.Printf("User %s confirmed their account", username)
+	return nil
+}
+
+// ValidateToken validates the token, checking that its session is not revoked, and returns
+// the principal authenticated by it with the current role and settings of the user.
+func (a *AuthService) ValidateToken(ctx context.Context, tokenString string) (*domain.Principal, error) {
+	claims, err := a.tokens.Verify(tokenString)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse token: %w", err)
+		return nil, err
 	}
 
-	if !token.Valid {
-		return nil, errors.New("invalid token")
+	if claims.SessionID == "" {
+		return nil, errors.New("token has no session")
 	}
 
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok {
-		return nil, errors.New("invalid claims")
+	revoked, err := a.sessionRep.IsSessionRevoked(ctx, claims.SessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check session: %w", err)
+	}
+
+	if revoked {
+		return nil, fmt.Errorf("session revoked: %w", domain.ErrInvalidToken)
+	}
+
+	user, err := a.authRep.GetByUsername(ctx, claims.Username)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if user == nil {
+		return nil, errors.New("user not found")
+	}
+
+	if user.Disabled {
+		return nil, fmt.Errorf("account is disabled: %w", domain.ErrInvalidToken)
+	}
+
+	permissions, err := a.roleRep.GetPermissions(ctx, user.Role)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get permissions: %w", err)
+	}
+
+	return &domain.Principal{
+		Username:        user.Username,
+		Role:            user.Role,
+		Permissions:     permissions,
+		ExpiresAt:       claims.ExpiresAt,
+		HistoryDisabled: user.HistoryDisabled,
+	}, nil
+}
+
+// SetHistoryDisabled sets whether search history of the user is recorded.
+func (a *AuthService) SetHistoryDisabled(ctx context.Context, username string, disabled bool) error {
+	if err := a.authRep.SetHistoryDisabled(ctx, username, disabled); err != nil {
+		return fmt.Errorf("failed to update settings: %w", err)
+	}
+
+	return nil
+}
+
+// GetChanges returns the changes of users made and the sessions revoked since the given time
+// for the services verifying tokens locally.
+// Changes older than the lifetime of access tokens outdate no valid tokens, so they are deleted instead.
+func (a *AuthService) GetChanges(ctx context.Context, since time.Time) (*domain.Changes, error) {
+	now := time.Now()
+	oldest := now.Add(-a.tokenMaxTime)
+	if err := a.authRep.DeleteChanges(ctx, oldest); err != nil {
+		return nil, fmt.Errorf("failed to delete old changes: %w", err)
+	}
+
+	if since.Before(oldest) {
+		since = oldest
+	}
+
+	users, err := a.authRep.GetChanges(ctx, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get changes: %w", err)
+	}
+
+	sessions, err := a.sessionRep.GetRevocations(ctx, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get revoked sessions: %w", err)
+	}
+
+	return &domain.Changes{Users: users, Sessions: sessions, Now: now, TokenMaxTime: a.tokenMaxTime}, nil
+}
+
+// PurgeExpired deletes the expired refresh tokens and the sessions left without them.
+// Access tokens never outlive their session, so no valid token refers to a deleted session.
+// With the login failure retention set, the failed logins older than it are deleted too.
+func (a *AuthService) PurgeExpired(ctx context.Context) error {
+	if err := a.sessionRep.DeleteExpired(ctx, time.Now()); err != nil {
+		return fmt.Errorf("failed to delete expired sessions: %w", err)
+	}
+
+	if a.failureRetention > 0 {
+		if err := a.failureRep.DeleteBefore(ctx, time.Now().Add(-a.failureRetention)); err != nil {
+			return fmt.Errorf("failed to delete login failures: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// ListUsers returns the page of user accounts ordered by username, skipping offset accounts.
+func (a *AuthService) ListUsers(ctx context.Context, offset, limit int) (*domain.UserPage, error) {
+	if offset < 0 || limit < 0 {
+		return nil, fmt.Errorf("offset and limit must not be negative: %w", domain.ErrInvalidInput)
+	}
+
+	page, err := a.authRep.List(ctx, offset, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+
+	return page, nil
+}
+
+// SetRole changes the role of the user. Tokens issued before carry the old role until they are refreshed.
+// The change is refused with ErrForbidden if no active user manager would remain.
+func (a *AuthService) SetRole(ctx context.Context, username string, role domain.Role) error {
+	if err := a.authRep.SetRole(ctx, username, role); err != nil {
+		return fmt.Errorf("failed to set role: %w", err)
+	}
+
+	return nil
+}
+
+// SetDisabled disables or enables the user. Disabling the user also revokes all their sessions.
+// Disabling the last active user manager is refused with ErrForbidden.
+func (a *AuthService) SetDisabled(ctx context.Context, username string, disabled bool) error {
+	if err := a.authRep.SetDisabled(ctx, username, disabled); err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+
+	if disabled {
+		if err := a.sessionRep.RevokeUserSessions(ctx, username, time.Now()); err != nil {
+			return fmt.Errorf("failed to revoke sessions: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// DeleteUser deletes the user together with their sessions.
+// Deleting the last active user manager is refused with ErrForbidden.
+func (a *AuthService) DeleteUser(ctx context.Context, username string) error {
+	if err := a.authRep.Delete(ctx, username); err != nil {
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+
+	return nil
+}
+
+// ChangePassword replaces the password of the user, who has to know the old one.
+// All sessions of the user are revoked, so they have to log in again with the new password.
+// The old password is checked like the password of a login: a wrong one is recorded as a failed login,
+// and the change is throttled by the recent failures of the username and of the client IP.
+func (a *AuthService) ChangePassword(ctx context.Context, username, oldPassword, newPassword, clientIP string) error {
+	attempt := &domain.LoginFailure{
+		Username:  username,
+		ClientIP:  clientIP,
+		Reason:    domain.LoginAttempted,
+		CreatedAt: time.Now(),
+	}
+	defer a.dropAttempt(ctx, attempt)
+
+	if err := a.checkThrottle(ctx, attempt); err != nil {
+		return err
+	}
+
+	user, err := a.authRep.GetByUsername(ctx, username)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if user == nil {
+		return fmt.Errorf("user %s: %w", username, domain.ErrNotFound)
+	}
+
+	ok, _, err := a.verifyPassword(ctx, user.PasswordHash, oldPassword)
+	if err != nil {
+		return err
 	}
 
-	username, ok := claims["username"].(string)
 	if !ok {
-		return nil, errors.New("invalid username in claims")
+		a.recordFailure(ctx, attempt, domain.LoginWrongPassword)
+		return fmt.Errorf("old password is incorrect: %w", domain.ErrInvalidInput)
 	}
 
+	hashedPassword, err := a.newPasswordHash(ctx, "new_password", username, newPassword)
+	if err != nil {
+		return err
+	}
+
+	if err = a.authRep.SetPassword(ctx, username, hashedPassword); err != nil {
+		return fmt.Errorf("failed to set password: %w", err)
+	}
+
+	if err = a.sessionRep.RevokeUserSessions(ctx, username, time.Now()); err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	return nil
+}
+
+// CreateAPIKey creates an API key of the user limited to the permissions, which the user must have.
+// The key expires after ttl unless it is zero. The returned key contains its value, which is not shown afterwards.
+// A user can have at most maxAPIKeys keys which have not expired.
+func (a *AuthService) CreateAPIKey(
+	ctx context.Context,
+	username, name string,
+	permissions []domain.Permission,
+	ttl time.Duration,
+) (*domain.APIKey, error) {
 	user, err := a.authRep.GetByUsername(ctx, username)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
 
 	if user == nil {
-		return nil, errors.New("user not found")
+		return nil, fmt.Errorf("user %s: %w", username, domain.ErrNotFound)
+	}
+
+	granted, err := a.roleRep.GetPermissions(ctx, user.Role)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get permissions: %w", err)
+	}
+
+	unique, violations := checkAPIKey(name, permissions, granted, ttl)
+	if len(violations) > 0 {
+		return nil, &domain.ValidationError{Violations: violations}
+	}
+
+	secret, err := randomHex(apiKeyBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate API key: %w", err)
+	}
+
+	key := apiKeyPrefix + secret
+	k := &domain.APIKey{
+		Username:    username,
+		Name:        name,
+		Prefix:      key[:apiKeyVisibleLength],
+		Hash:        hashToken(key),
+		Permissions: unique,
+	}
+	if ttl > 0 {
+		expiresAt := time.Now().Add(ttl)
+		k.ExpiresAt = &expiresAt
+	}
+
+	if err = a.apiKeyRep.Create(ctx, k, maxAPIKeys); err != nil {
+		return nil, fmt.Errorf("failed to save API key: %w", err)
+	}
+
+	k.Key = key
+	return k, nil
+}
+
+// checkAPIKey returns the permissions of the new API key without duplicates
+// and the violations of the rules of API keys.
+func checkAPIKey(
+	name string,
+	permissions, granted []domain.Permission,
+	ttl time.Duration,
+) ([]domain.Permission, []domain.FieldViolation) {
+	var violations []domain.FieldViolation
+	if name == "" {
+		violations = append(violations, domain.FieldViolation{Field: "name", Code: "required", Description: "is required"})
+	}
+	if len([]rune(name)) > apiKeyMaxNameLength {
+		violations = append(violations, domain.FieldViolation{
+			Field:       "name",
+			Code:        "too_long",
+			Description: fmt.Sprintf("must be at most %d characters long", apiKeyMaxNameLength),
+		})
+	}
+	if ttl < 0 {
+		violations = append(violations, domain.FieldViolation{
+			Field:       "ttl",
+			Code:        "negative",
+			Description: "must not be negative",
+		})
+	}
+	if len(permissions) == 0 {
+		violations = append(violations, domain.FieldViolation{
+			Field:       "permissions",
+			Code:        "required",
+			Description: "at least one permission is required",
+		})
+	}
+
+	unique := make([]domain.Permission, 0, len(permissions))
+	for _, perm := range permissions {
+		if slices.Contains(unique, perm) {
+			continue
+		}
+
+		if !slices.Contains(granted, perm) {
+			violations = append(violations, domain.FieldViolation{
+				Field:       "permissions",
+				Code:        "not_granted",
+				Description: fmt.Sprintf("permission %q is not granted to the user", perm),
+			})
+		}
+		unique = append(unique, perm)
+	}
+
+	return unique, violations
+}
+
+// ListAPIKeys returns the API keys of the user without their values.
+func (a *AuthService) ListAPIKeys(ctx context.Context, username string) ([]*domain.APIKey, error) {
+	keys, err := a.apiKeyRep.List(ctx, username)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list API keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+// RevokeAPIKey deletes the API key of the user, so it cannot be used anymore.
+func (a *AuthService) RevokeAPIKey(ctx context.Context, username string, id int64) error {
+	if err := a.apiKeyRep.Delete(ctx, username, id); err != nil {
+		return fmt.Errorf("failed to revoke API key: %w", err)
+	}
+
+	return nil
+}
+
+// ValidateAPIKey validates the API key and returns the principal authenticated by it: the owner
+// of the key limited to the permissions of the key which the owner still has.
+func (a *AuthService) ValidateAPIKey(ctx context.Context, key string) (*domain.Principal, error) {
+	k, err := a.apiKeyRep.GetByHash(ctx, hashToken(key))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get API key: %w", err)
+	}
+
+	now := time.Now()
+	switch {
+	case k == nil:
+		return nil, fmt.Errorf("unknown API key: %w", domain.ErrInvalidToken)
+	case k.ExpiresAt != nil && now.After(*k.ExpiresAt):
+		return nil, fmt.Errorf("API key expired: %w", domain.ErrInvalidToken)
+	}
+
+	user, err := a.authRep.GetByUsername(ctx, k.Username)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if user == nil || user.Disabled {
+		return nil, fmt.Errorf("owner of API key is disabled: %w", domain.ErrInvalidToken)
+	}
+
+	granted, err := a.roleRep.GetPermissions(ctx, user.Role)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get permissions: %w", err)
+	}
+
+	permissions := make([]domain.Permission, 0, len(k.Permissions))
+	for _, perm := range k.Permissions {
+		if slices.Contains(granted, perm) {
+			permissions = append(permissions, perm)
+		}
+	}
+
+	if k.LastUsedAt == nil || now.Sub(*k.LastUsedAt) >= apiKeyUseInterval {
+		if err = a.apiKeyRep.RecordUse(ctx, k.ID, now); err != nil {
+			log.Printf("Error recording use of API key %d: %v", k.ID, err)
+		}
+	}
+
+	p := &domain.Principal{
+		Username:        user.Username,
+		Role:            user.Role,
+		Permissions:     permissions,
+		HistoryDisabled: user.HistoryDisabled,
+	}
+	if k.ExpiresAt != nil {
+		p.ExpiresAt = *k.ExpiresAt
 	}
 
-	return user, nil
+	return p, nil
 }
@@ -3,8 +3,9 @@ package service
 import (
 	"context"
 	"errors"
-	"github.com/golang-jwt/jwt/v5"
+	"fmt"
 	"github.com/stretchr/testify/require"
+	"slices"
 	"testing"
 	"time"
 	"yadro-microservices/internal/core/domain"
@@ -12,113 +13,321 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
 )
 
 func TestAuthService_Login(t *testing.T) {
 	userRepo := new(mocks.UserRepository)
 	userRepo.On("GetByUsername", mock.Anything, "valid_user").Return(
-		&domain.User{Password: "$2a$10$/md3ztppcKhB9sjDb/GMZuYlb9o3bxvPnwO2v3up3/KlHCjMOskcG"},
+		&domain.User{
+			Username:     "valid_user",
+			PasswordHash: "$2a$10$/md3ztppcKhB9sjDb/GMZuYlb9o3bxvPnwO2v3up3/KlHCjMOskcG",
+			Role:         domain.ADMIN,
+		},
 		nil,
 	).Once()
+	userRepo.On("RecordLogin", mock.Anything, "valid_user", mock.Anything).Return(nil).Once()
+	sessionRepo := new(mocks.SessionRepository)
+	var sessionID string
+	sessionRepo.On("CreateSession", mock.Anything, mock.MatchedBy(func(s *domain.Session) bool {
+		sessionID = s.ID
+		return s.Username == "valid_user" && s.ID != ""
+	})).Return(nil).Once()
+	var refreshHash string
+	sessionRepo.On("AddRefreshToken", mock.Anything, mock.MatchedBy(func(rt *domain.RefreshToken) bool {
+		refreshHash = rt.Hash
+		return rt.SessionID == sessionID && time.Until(rt.ExpiresAt) > 23*time.Hour
+	})).Return(nil).Once()
+	tokens := new(mocks.TokenSigner)
+	tokens.On("Sign", mock.MatchedBy(func(c *domain.TokenClaims) bool {
+		return c.Username == "valid_user" && c.Role == domain.ADMIN && c.SessionID == sessionID &&
+			c.ExpiresAt.Sub(c.IssuedAt) == time.Hour &&
+			slices.Equal(c.Permissions, []domain.Permission{domain.PermComicsSearch, domain.PermComicsUpdate})
+	})).Return("token", nil).Once()
 
-	authService := NewAuthService(userRepo, 3600)
-	token, err := authService.Login(context.Background(), "valid_user", "password")
+	roleRepo := new(mocks.RoleRepository)
+	roleRepo.On("GetPermissions", mock.Anything, domain.ADMIN).Return(
+		[]domain.Permission{domain.PermComicsSearch, domain.PermComicsUpdate},
+		nil,
+	).Once()
+
+	authService := NewAuthService(userRepo, roleRepo, sessionRepo, nil, nil, tokens, time.Hour, 24*time.Hour)
+	pair, err := authService.Login(context.Background(), "valid_user", "password", "10.0.0.1")
 
 	require.NoError(t, err)
-	assert.NotEmpty(t, token)
+	assert.Equal(t, "token", pair.AccessToken)
+	assert.NotEmpty(t, pair.RefreshToken)
+	assert.Equal(t, hashToken(pair.RefreshToken), refreshHash, "only the hash of the refresh token is stored")
 	userRepo.AssertExpectations(t)
+	sessionRepo.AssertExpectations(t)
+	tokens.AssertExpectations(t)
 }
 
 func TestAuthService_LoginInvalidPassword(t *testing.T) {
 	userRepo := new(mocks.UserRepository)
 	userRepo.On("GetByUsername", mock.Anything, "valid_user").Return(
-		&domain.User{Password: "$2a$10$N9qo8uLOickgx2ZMRZoHKuGnK.y39JZjiujDtJZN.gR7Oy.fXx8aG"},
+		&domain.User{PasswordHash: "$2a$10$N9qo8uLOickgx2ZMRZoHKuGnK.y39JZjiujDtJZN.gR7Oy.fXx8aG"},
 		nil,
 	).Once()
+	tokens := new(mocks.TokenSigner)
+	sessionRepo := new(mocks.SessionRepository)
+	failureRep := new(mocks.LoginFailureRepository)
+	failureRep.On("Save", mock.Anything, mock.MatchedBy(func(f *domain.LoginFailure) bool {
+		return f.Username == "valid_user" && f.ClientIP == "10.0.0.1" && f.Reason == domain.LoginWrongPassword
+	})).Return(nil).Once()
 
-	authService := NewAuthService(userRepo, 3600)
-	_, err := authService.Login(context.Background(), "valid_user", "invalid_password")
+	authService := NewAuthService(userRepo, nil, sessionRepo, failureRep, nil, tokens, time.Hour, 24*time.Hour)
+	_, err := authService.Login(context.Background(), "valid_user", "invalid_password", "10.0.0.1")
 
-	require.Error(t, err)
+	require.ErrorIs(t, err, domain.ErrInvalidCredentials)
 	userRepo.AssertExpectations(t)
+	failureRep.AssertExpectations(t)
+	tokens.AssertNotCalled(t, "Sign", mock.Anything)
 }
 
-func TestAuthService_Register(t *testing.T) {
+func TestAuthService_LoginUnknownUser(t *testing.T) {
 	userRepo := new(mocks.UserRepository)
-	userRepo.On("Save", mock.Anything, mock.Anything).Return(nil).Once()
+	userRepo.On("GetByUsername", mock.Anything, "unknown_user").Return(nil, nil).Once()
+	failureRep := new(mocks.LoginFailureRepository)
+	failureRep.On("Save", mock.Anything, mock.MatchedBy(func(f *domain.LoginFailure) bool {
+		return f.Username == "unknown_user" && f.Reason == domain.LoginUnknownUser
+	})).Return(nil).Once()
 
-	authService := NewAuthService(userRepo, 3600)
-	err := authService.Register(context.Background(), &domain.User{
-		Username: "new_user",
-		Password: "password",
-		Role:     domain.USER,
-	})
+	authService := NewAuthService(userRepo, nil, nil, failureRep, nil, nil, time.Hour, 24*time.Hour)
+	_, err := authService.Login(context.Background(), "unknown_user", "password", "10.0.0.1")
 
-	require.NoError(t, err)
+	require.ErrorIs(t, err, domain.ErrInvalidCredentials)
+	assert.Equal(t, domain.ErrInvalidCredentials.Error(), err.Error(), "the error must not tell the user is unknown")
+	failureRep.AssertExpectations(t)
+}
+
+func TestAuthService_LoginWaitsForHashing(t *testing.T) {
+	userRepo := new(mocks.UserRepository)
+	userRepo.On("GetByUsername", mock.Anything, "unknown_user").Return(nil, nil).Once()
+
+	authService := NewAuthService(userRepo, nil, nil, nil, nil, nil, time.Hour, 24*time.Hour)
+	authService.SetHashConcurrency(1)
+	authService.hashSlots <- struct{}{}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := authService.Login(ctx, "unknown_user", "password", "10.0.0.1")
+
+	require.ErrorIs(t, err, context.DeadlineExceeded)
 	userRepo.AssertExpectations(t)
 }
 
-func TestAuthService_ValidateToken(t *testing.T) {
+func TestAuthService_LoginFailureNotRecorded(t *testing.T) {
 	userRepo := new(mocks.UserRepository)
-	userRepo.On("Save", mock.Anything, mock.Anything).Return(nil).Once()
-	userRepo.On("GetByUsername", mock.Anything, "valid_user").Return(domain.NewUser(
-		"valid_user",
-		"$2a$10$/md3ztppcKhB9sjDb/GMZuYlb9o3bxvPnwO2v3up3/KlHCjMOskcG",
-		domain.USER), nil).Twice()
+	userRepo.On("GetByUsername", mock.Anything, "unknown_user").Return(nil, nil).Once()
+	failureRep := new(mocks.LoginFailureRepository)
+	failureRep.On("Save", mock.Anything, mock.Anything).Return(errors.New("db error")).Once()
 
-	authService := NewAuthService(userRepo, 1*time.Hour)
-	err := authService.Register(context.Background(), domain.NewUser(
-		"valid_user",
-		"password",
-		domain.USER))
+	authService := NewAuthService(userRepo, nil, nil, failureRep, nil, nil, time.Hour, 24*time.Hour)
+	_, err := authService.Login(context.Background(), "unknown_user", "password", "10.0.0.1")
+
+	require.ErrorIs(t, err, domain.ErrInvalidCredentials)
+}
+
+func TestAuthService_LoginLockedOut(t *testing.T) {
+	userRepo := new(mocks.UserRepository)
+	failureRep := new(mocks.LoginFailureRepository)
+	// The attempt is recorded first, and only the failures recorded before it are counted
+	failureRep.On("Save", mock.Anything, mock.MatchedBy(func(f *domain.LoginFailure) bool {
+		return f.Username == "valid_user" && f.Reason == domain.LoginAttempted
+	})).Run(func(args mock.Arguments) {
+		args.Get(1).(*domain.LoginFailure).ID = 42
+	}).Return(nil).Once()
+	failureRep.On("GetStats", mock.Anything, "valid_user", "10.0.0.1", mock.Anything, int64(42)).Return(
+		&domain.LoginFailureStats{UserFailures: 5, UserLastFailure: time.Now()},
+		nil,
+	).Once()
+	failureRep.On("SetReason", mock.Anything, int64(42), domain.LoginThrottled).Return(nil).Once()
+
+	authService := NewAuthService(userRepo, nil, nil, failureRep, nil, nil, time.Hour, 24*time.Hour)
+	authService.ThrottleLogins(LoginThrottle{
+		Window:      15 * time.Minute,
+		LockoutTime: 15 * time.Minute,
+		User:        AttemptLimit{Lockout: 5},
+	})
+	_, err := authService.Login(context.Background(), "valid_user", "password", "10.0.0.1")
+
+	var throttled *domain.ThrottledError
+	require.ErrorAs(t, err, &throttled)
+	require.ErrorIs(t, err, domain.ErrTooManyAttempts)
+	assert.Greater(t, throttled.RetryAfter, 14*time.Minute)
+	userRepo.AssertNotCalled(t, "GetByUsername", mock.Anything, mock.Anything)
+	failureRep.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+	failureRep.AssertExpectations(t)
+}
+
+func TestAuthService_LoginAttemptResolved(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("valid_pass"), bcrypt.MinCost)
 	require.NoError(t, err)
+	userRepo := new(mocks.UserRepository)
+	userRepo.On("GetByUsername", mock.Anything, "valid_user").Return(&domain.User{PasswordHash: string(hash)}, nil).Twice()
+	failureRep := new(mocks.LoginFailureRepository)
+	failureRep.On("Save", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		args.Get(1).(*domain.LoginFailure).ID = 7
+	}).Return(nil).Twice()
+	failureRep.On("GetStats", mock.Anything, "valid_user", "10.0.0.1", mock.Anything, int64(7)).
+		Return(&domain.LoginFailureStats{}, nil).Twice()
+	// A failed attempt keeps its record with the reason, an attempt broken by an error is dropped
+	failureRep.On("SetReason", mock.Anything, int64(7), domain.LoginWrongPassword).Return(nil).Once()
+	failureRep.On("Delete", mock.Anything, int64(7)).Return(nil).Once()
+	sessionRepo := new(mocks.SessionRepository)
+	sessionRepo.On("CreateSession", mock.Anything, mock.Anything).Return(errors.New("db error")).Once()
+
+	authService := NewAuthService(userRepo, nil, sessionRepo, failureRep, nil, nil, time.Hour, 24*time.Hour)
+	authService.ThrottleLogins(LoginThrottle{Window: 15 * time.Minute, User: AttemptLimit{Lockout: 5}})
+
+	_, err = authService.Login(context.Background(), "valid_user", "invalid_password", "10.0.0.1")
+	require.ErrorIs(t, err, domain.ErrInvalidCredentials)
+	_, err = authService.Login(context.Background(), "valid_user", "valid_pass", "10.0.0.1")
+	require.Error(t, err)
+	failureRep.AssertExpectations(t)
+}
+
+func TestAuthService_Register(t *testing.T) {
+	userRepo := new(mocks.UserRepository)
+	userRepo.On("Save", mock.Anything, mock.MatchedBy(func(u *domain.User) bool {
+		return u.Username == "new_user" && u.Role == domain.USER &&
+			bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte("password")) == nil
+	})).Return(nil).Once()
+
+	authService := NewAuthService(userRepo, nil, nil, nil, nil, nil, time.Hour, 24*time.Hour)
+	err := authService.Register(context.Background(), "new_user", "password", domain.USER)
 
-	tokenString, err := authService.Login(context.Background(), "valid_user", "password")
 	require.NoError(t, err)
+	userRepo.AssertExpectations(t)
+}
+
+func TestAuthService_RegisterPolicyViolation(t *testing.T) {
+	userRepo := new(mocks.UserRepository)
+
+	authService := NewAuthService(userRepo, nil, nil, nil, nil, nil, time.Hour, 24*time.Hour)
+	authService.SetPasswordPolicy(NewPasswordPolicy(8, 0, 2, []string{"password"}))
+	err := authService.Register(context.Background(), "new_user", "password", domain.USER)
+
+	var invalid *domain.ValidationError
+	require.ErrorAs(t, err, &invalid)
+	require.ErrorIs(t, err, domain.ErrInvalidInput)
+	require.Len(t, invalid.Violations, 2)
+	assert.Equal(t, "too_few_classes", invalid.Violations[0].Code)
+	assert.Equal(t, "too_common", invalid.Violations[1].Code)
+	userRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+}
 
-	user, err := authService.ValidateToken(context.Background(), tokenString)
+func TestAuthService_RegisterArgon2id(t *testing.T) {
+	hashing := PasswordHashing{Algorithm: HashArgon2id, Argon2: testArgon2Params}
+	userRepo := new(mocks.UserRepository)
+	userRepo.On("Save", mock.Anything, mock.MatchedBy(func(u *domain.User) bool {
+		ok, rehash := hashing.verify(u.PasswordHash, "Correct horse 1")
+		return ok && !rehash
+	})).Return(nil).Once()
+
+	authService := NewAuthService(userRepo, nil, nil, nil, nil, nil, time.Hour, 24*time.Hour)
+	authService.SetPasswordHashing(hashing)
+	err := authService.Register(context.Background(), "new_user", "Correct horse 1", domain.USER)
 
 	require.NoError(t, err)
-	assert.NotNil(t, user)
 	userRepo.AssertExpectations(t)
 }
 
-func TestAuthService_ValidateTokenInvalidFormat(t *testing.T) {
+func TestAuthService_LoginRehash(t *testing.T) {
+	hashing := PasswordHashing{Algorithm: HashArgon2id, Argon2: testArgon2Params}
 	userRepo := new(mocks.UserRepository)
+	userRepo.On("GetByUsername", mock.Anything, "valid_user").Return(
+		&domain.User{
+			Username:     "valid_user",
+			PasswordHash: "$2a$10$/md3ztppcKhB9sjDb/GMZuYlb9o3bxvPnwO2v3up3/KlHCjMOskcG",
+			Role:         domain.USER,
+		},
+		nil,
+	).Once()
+	userRepo.On("RecordLogin", mock.Anything, "valid_user", mock.Anything).Return(nil).Once()
+	userRepo.On("SetPassword", mock.Anything, "valid_user", mock.MatchedBy(func(hash string) bool {
+		ok, rehash := hashing.verify(hash, "password")
+		return ok && !rehash
+	})).Return(nil).Once()
+	sessionRepo := new(mocks.SessionRepository)
+	sessionRepo.On("CreateSession", mock.Anything, mock.Anything).Return(nil).Once()
+	sessionRepo.On("AddRefreshToken", mock.Anything, mock.Anything).Return(nil).Once()
+	roleRepo := new(mocks.RoleRepository)
+	roleRepo.On("GetPermissions", mock.Anything, domain.USER).Return([]domain.Permission{}, nil).Once()
+	tokens := new(mocks.TokenSigner)
+	tokens.On("Sign", mock.Anything).Return("token", nil).Once()
 
-	authService := NewAuthService(userRepo, 3600)
-	_, err := authService.ValidateToken(context.Background(), "invalid_token")
+	authService := NewAuthService(userRepo, roleRepo, sessionRepo, nil, nil, tokens, time.Hour, 24*time.Hour)
+	authService.SetPasswordHashing(hashing)
+	_, err := authService.Login(context.Background(), "valid_user", "password", "10.0.0.1")
 
-	require.Error(t, err)
+	require.NoError(t, err)
+	userRepo.AssertExpectations(t)
 }
 
-func TestAuthService_ValidateUserNotFound(t *testing.T) {
+func TestAuthService_ValidateToken(t *testing.T) {
 	userRepo := new(mocks.UserRepository)
-	userRepo.On("GetByUsername", mock.Anything, mock.Anything).Return(nil, nil)
+	userRepo.On("GetByUsername", mock.Anything, "valid_user").Return(&domain.User{
+		Username:     "valid_user",
+		PasswordHash: "$2a$10$/md3ztppcKhB9sjDb/GMZuYlb9o3bxvPnwO2v3up3/KlHCjMOskcG",
+		Role:         domain.USER,
+	}, nil).Once()
+	expiresAt := time.Now().Add(time.Hour)
+	tokens := new(mocks.TokenSigner)
+	tokens.On("Verify", "token").Return(&domain.TokenClaims{
+		Username:  "valid_user",
+		SessionID: "s1",
+		ExpiresAt: expiresAt,
+	}, nil).Once()
+	sessionRepo := new(mocks.SessionRepository)
+	sessionRepo.On("IsSessionRevoked", mock.Anything, "s1").Return(false, nil).Once()
+
+	roleRepo := new(mocks.RoleRepository)
+	roleRepo.On("GetPermissions", mock.Anything, domain.USER).Return(
+		[]domain.Permission{domain.PermComicsSearch},
+		nil,
+	).Once()
+
+	authService := NewAuthService(userRepo, roleRepo, sessionRepo, nil, nil, tokens, time.Hour, 24*time.Hour)
+	p, err := authService.ValidateToken(context.Background(), "token")
 
-	authService := NewAuthService(userRepo, 3600)
-	tokenString, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"username": "valid_user",
-		"exp":      time.Now().Add(time.Hour).Unix(),
-	}).SignedString([]byte("secret"))
 	require.NoError(t, err)
-	_, err = authService.ValidateToken(context.Background(), tokenString)
+	assert.Equal(t, &domain.Principal{
+		Username:    "valid_user",
+		Role:        domain.USER,
+		Permissions: []domain.Permission{domain.PermComicsSearch},
+		ExpiresAt:   expiresAt,
+	}, p)
+	userRepo.AssertExpectations(t)
+	tokens.AssertExpectations(t)
+}
+
+func TestAuthService_ValidateTokenInvalid(t *testing.T) {
+	userRepo := new(mocks.UserRepository)
+	tokens := new(mocks.TokenSigner)
+	tokens.On("Verify", "invalid_token").Return(nil, errors.New("failed to parse token")).Once()
+	sessionRepo := new(mocks.SessionRepository)
+
+	authService := NewAuthService(userRepo, nil, sessionRepo, nil, nil, tokens, time.Hour, 24*time.Hour)
+	_, err := authService.ValidateToken(context.Background(), "invalid_token")
 
 	require.Error(t, err)
+	userRepo.AssertNotCalled(t, "GetByUsername", mock.Anything, mock.Anything)
 }
 
-func TestAuthService_ValidateTokenInvalidClaims(t *testing.T) {
+func TestAuthService_ValidateUserNotFound(t *testing.T) {
 	userRepo := new(mocks.UserRepository)
+	userRepo.On("GetByUsername", mock.Anything, mock.Anything).Return(nil, nil)
+	tokens := new(mocks.TokenSigner)
+	tokens.On("Verify", "token").Return(&domain.TokenClaims{Username: "valid_user", SessionID: "s1"}, nil)
+	sessionRepo := new(mocks.SessionRepository)
+	sessionRepo.On("IsSessionRevoked", mock.Anything, "s1").Return(false, nil)
 
-	authService := NewAuthService(userRepo, 3600*time.Second)
-	tokenString, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"exp": time.Now().Add(time.Hour).Unix(),
-	}).SignedString([]byte("secret"))
-	require.NoError(t, err)
+	authService := NewAuthService(userRepo, nil, sessionRepo, nil, nil, tokens, time.Hour, 24*time.Hour)
+	_, err := authService.ValidateToken(context.Background(), "token")
 
-	_, err = authService.ValidateToken(context.Background(), tokenString)
 	require.Error(t, err)
-	assert.Equal(t, "invalid username in claims", err.Error())
 }
 
 func TestAuthService_ValidateTokenGetByUsernameError(t *testing.T) {
@@ -128,17 +337,628 @@ func TestAuthService_ValidateTokenGetByUsernameError(t *testing.T) {
 		mock.Anything,
 		"valid_user",
 	).Return(nil, errors.New("db error")).Once()
+	tokens := new(mocks.TokenSigner)
+	tokens.On("Verify", "token").Return(&domain.TokenClaims{Username: "valid_user", SessionID: "s1"}, nil)
+	sessionRepo := new(mocks.SessionRepository)
+	sessionRepo.On("IsSessionRevoked", mock.Anything, "s1").Return(false, nil)
+
+	authService := NewAuthService(userRepo, nil, sessionRepo, nil, nil, tokens, time.Hour, 24*time.Hour)
+	_, err := authService.ValidateToken(context.Background(), "token")
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to get user")
+	userRepo.AssertExpectations(t)
+}
+
+func TestAuthService_ValidateTokenRevokedSession(t *testing.T) {
+	userRepo := new(mocks.UserRepository)
+	tokens := new(mocks.TokenSigner)
+	tokens.On("Verify", "token").Return(&domain.TokenClaims{Username: "valid_user", SessionID: "s1"}, nil)
+	tokens.On("Verify", "no_session").Return(&domain.TokenClaims{Username: "valid_user"}, nil)
+	sessionRepo := new(mocks.SessionRepository)
+	sessionRepo.On("IsSessionRevoked", mock.Anything, "s1").Return(true, nil).Once()
+
+	authService := NewAuthService(userRepo, nil, sessionRepo, nil, nil, tokens, time.Hour, 24*time.Hour)
+	_, err := authService.ValidateToken(context.Background(), "token")
+	require.ErrorIs(t, err, domain.ErrInvalidToken)
+
+	_, err = authService.ValidateToken(context.Background(), "no_session")
+	require.Error(t, err)
+	userRepo.AssertNotCalled(t, "GetByUsername", mock.Anything, mock.Anything)
+	sessionRepo.AssertExpectations(t)
+}
+
+func TestAuthService_Refresh(t *testing.T) {
+	// The session expires sooner than a new access token would
+	sessionExpiresAt := time.Now().Add(10 * time.Minute)
+	userRepo := new(mocks.UserRepository)
+	userRepo.On("GetByUsername", mock.Anything, "valid_user").Return(
+		&domain.User{Username: "valid_user", Role: domain.USER},
+		nil,
+	).Once()
+	tokens := new(mocks.TokenSigner)
+	tokens.On("Sign", mock.MatchedBy(func(c *domain.TokenClaims) bool {
+		return c.Username == "valid_user" && c.SessionID == "s1" && c.ExpiresAt.Equal(sessionExpiresAt)
+	})).Return("new_token", nil).Once()
+	sessionRepo := new(mocks.SessionRepository)
+	sessionRepo.On("GetRefreshToken", mock.Anything, hashToken("refresh")).Return(&domain.RefreshToken{
+		Hash:      hashToken("refresh"),
+		SessionID: "s1",
+		Username:  "valid_user",
+		ExpiresAt: sessionExpiresAt,
+	}, nil).Once()
+	sessionRepo.On("UseRefreshToken", mock.Anything, hashToken("refresh"), mock.Anything).Return(true, nil).Once()
+	// The rotated refresh token keeps the expiration of the session
+	sessionRepo.On("AddRefreshToken", mock.Anything, mock.MatchedBy(func(rt *domain.RefreshToken) bool {
+		return rt.SessionID == "s1" && rt.Hash != hashToken("refresh") && rt.ExpiresAt.Equal(sessionExpiresAt)
+	})).Return(nil).Once()
+
+	roleRepo := new(mocks.RoleRepository)
+	roleRepo.On("GetPermissions", mock.Anything, domain.USER).Return(
+		[]domain.Permission{domain.PermComicsSearch},
+		nil,
+	).Once()
+
+	authService := NewAuthService(userRepo, roleRepo, sessionRepo, nil, nil, tokens, time.Hour, 24*time.Hour)
+	pair, err := authService.Refresh(context.Background(), "refresh")
+
+	require.NoError(t, err)
+	assert.Equal(t, "new_token", pair.AccessToken)
+	assert.NotEqual(t, "refresh", pair.RefreshToken)
+	userRepo.AssertExpectations(t)
+	tokens.AssertExpectations(t)
+	sessionRepo.AssertExpectations(t)
+}
+
+func TestAuthService_RefreshReuseRevokesSession(t *testing.T) {
+	usedAt := time.Now().Add(-time.Minute)
+	sessionRepo := new(mocks.SessionRepository)
+	sessionRepo.On("GetRefreshToken", mock.Anything, hashToken("used")).Return(&domain.RefreshToken{
+		SessionID: "s1",
+		Username:  "valid_user",
+		ExpiresAt: time.Now().Add(time.Hour),
+		UsedAt:    &usedAt,
+	}, nil).Once()
+	sessionRepo.On("GetRefreshToken", mock.Anything, hashToken("raced")).Return(&domain.RefreshToken{
+		SessionID: "s2",
+		Username:  "valid_user",
+		ExpiresAt: time.Now().Add(time.Hour),
+	}, nil).Once()
+	sessionRepo.On("UseRefreshToken", mock.Anything, hashToken("raced"), mock.Anything).Return(false, nil).Once()
+	sessionRepo.On("RevokeSession", mock.Anything, "s1", mock.Anything).Return(nil).Once()
+	sessionRepo.On("RevokeSession", mock.Anything, "s2", mock.Anything).Return(nil).Once()
+
+	authService := NewAuthService(nil, nil, sessionRepo, nil, nil, nil, time.Hour, 24*time.Hour)
+
+	_, err := authService.Refresh(context.Background(), "used")
+	require.ErrorIs(t, err, domain.ErrInvalidToken)
+	_, err = authService.Refresh(context.Background(), "raced")
+	require.ErrorIs(t, err, domain.ErrInvalidToken)
+	sessionRepo.AssertExpectations(t)
+}
+
+func TestAuthService_RefreshInvalid(t *testing.T) {
+	sessionRepo := new(mocks.SessionRepository)
+	sessionRepo.On("GetRefreshToken", mock.Anything, hashToken("unknown")).Return(nil, nil).Once()
+	sessionRepo.On("GetRefreshToken", mock.Anything, hashToken("revoked")).Return(&domain.RefreshToken{
+		SessionID: "s1",
+		ExpiresAt: time.Now().Add(time.Hour),
+		Revoked:   true,
+	}, nil).Once()
+	sessionRepo.On("GetRefreshToken", mock.Anything, hashToken("expired")).Return(&domain.RefreshToken{
+		SessionID: "s1",
+		ExpiresAt: time.Now().Add(-time.Minute),
+	}, nil).Once()
+
+	authService := NewAuthService(nil, nil, sessionRepo, nil, nil, nil, time.Hour, 24*time.Hour)
+
+	for _, token := range []string{"unknown", "revoked", "expired"} {
+		_, err := authService.Refresh(context.Background(), token)
+		require.ErrorIs(t, err, domain.ErrInvalidToken, token)
+	}
+	sessionRepo.AssertExpectations(t)
+	sessionRepo.AssertNotCalled(t, "UseRefreshToken", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestAuthService_Logout(t *testing.T) {
+	tokens := new(mocks.TokenSigner)
+	tokens.On("Verify", "token").Return(&domain.TokenClaims{Username: "valid_user", SessionID: "s1"}, nil).Once()
+	tokens.On("Verify", "invalid").Return(nil, errors.New("token is expired")).Once()
+	sessionRepo := new(mocks.SessionRepository)
+	sessionRepo.On("RevokeSession", mock.Anything, "s1", mock.Anything).Return(nil).Once()
+
+	authService := NewAuthService(nil, nil, sessionRepo, nil, nil, tokens, time.Hour, 24*time.Hour)
+
+	require.NoError(t, authService.Logout(context.Background(), "token"))
+	require.ErrorIs(t, authService.Logout(context.Background(), "invalid"), domain.ErrInvalidToken)
+	sessionRepo.AssertExpectations(t)
+}
+
+func TestAuthService_SetHistoryDisabled(t *testing.T) {
+	userRepo := new(mocks.UserRepository)
+	userRepo.On("SetHistoryDisabled", mock.Anything, "valid_user", true).Return(nil).Once()
+	userRepo.On("SetHistoryDisabled", mock.Anything, "missing_user", true).Return(domain.ErrNotFound).Once()
+
+	authService := NewAuthService(userRepo, nil, nil, nil, nil, nil, time.Hour, 24*time.Hour)
+
+	require.NoError(t, authService.SetHistoryDisabled(context.Background(), "valid_user", true))
+	require.ErrorIs(t, authService.SetHistoryDisabled(context.Background(), "missing_user", true), domain.ErrNotFound)
+	userRepo.AssertExpectations(t)
+}
+
+func TestAuthService_SignUp(t *testing.T) {
+	userRepo := new(mocks.UserRepository)
+	var tokenHash string
+	userRepo.On("SavePending", mock.Anything, mock.MatchedBy(func(u *domain.User) bool {
+		return u.Username == "new_user" && u.Email == "new@example.com" && u.Role == domain.USER &&
+			bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte("password")) == nil
+	}), mock.MatchedBy(func(vt *domain.VerificationToken) bool {
+		tokenHash = vt.Hash
+		return vt.Username == "new_user" && time.Until(vt.ExpiresAt) > 47*time.Hour
+	})).Return(nil).Once()
+	notifier := new(mocks.Notifier)
+	var token string
+	notifier.On("Notify", mock.Anything, mock.MatchedBy(func(n *domain.Notification) bool {
+		_, err := fmt.Sscanf(n.Text, "Your confirmation token is %64s", &token)
+		return err == nil && n.Username == "new_user" && n.Email == "new@example.com"
+	})).Return(nil).Once()
+
+	authService := NewAuthService(userRepo, nil, nil, nil, nil, nil, time.Hour, 24*time.Hour)
+	authService.EnableSignUp(notifier, 48*time.Hour)
+	err := authService.SignUp(context.Background(), "new_user", "new@example.com", "password")
 
-	authService := NewAuthService(userRepo, 3600*time.Second)
-	tokenString, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"username": "valid_user",
-		"exp":      time.Now().Add(time.Hour).Unix(),
-	}).SignedString([]byte("secret"))
 	require.NoError(t, err)
+	assert.Equal(t, hashToken(token), tokenHash, "only the hash of the sent token is stored")
+	userRepo.AssertExpectations(t)
+	notifier.AssertExpectations(t)
+}
 
-	_, err = authService.ValidateToken(context.Background(), tokenString)
+func TestAuthService_SignUpDisabled(t *testing.T) {
+	userRepo := new(mocks.UserRepository)
+
+	authService := NewAuthService(userRepo, nil, nil, nil, nil, nil, time.Hour, 24*time.Hour)
+	err := authService.SignUp(context.Background(), "new_user", "new@example.com", "password")
+
+	require.ErrorIs(t, err, domain.ErrForbidden)
+	userRepo.AssertNotCalled(t, "SavePending", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestAuthService_SignUpTaken(t *testing.T) {
+	userRepo := new(mocks.UserRepository)
+	userRepo.On("SavePending", mock.Anything, mock.Anything, mock.Anything).Return(domain.ErrAlreadyExists).Once()
+	notifier := new(mocks.Notifier)
+
+	authService := NewAuthService(userRepo, nil, nil, nil, nil, nil, time.Hour, 24*time.Hour)
+	authService.EnableSignUp(notifier, time.Hour)
+	err := authService.SignUp(context.Background(), "admin", "admin@example.com", "password")
+
+	require.ErrorIs(t, err, domain.ErrAlreadyExists)
+	notifier.AssertNotCalled(t, "Notify", mock.Anything, mock.Anything)
+}
+
+func TestAuthService_SignUpNotifyFailed(t *testing.T) {
+	userRepo := new(mocks.UserRepository)
+	userRepo.On("SavePending", mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
+	userRepo.On("DeletePending", mock.Anything, "new_user").Return(nil).Once()
+	notifier := new(mocks.Notifier)
+	notifier.On("Notify", mock.Anything, mock.Anything).Return(errors.New("mail is down")).Once()
+
+	// The user who never got the token is deleted, so they can sign up again
+	authService := NewAuthService(userRepo, nil, nil, nil, nil, nil, time.Hour, 24*time.Hour)
+	authService.EnableSignUp(notifier, time.Hour)
+	err := authService.SignUp(context.Background(), "new_user", "new@example.com", "password")
 
 	require.Error(t, err)
-	assert.Contains(t, err.Error(), "failed to get user")
+	userRepo.AssertExpectations(t)
+	notifier.AssertExpectations(t)
+}
+
+func TestAuthService_ConfirmSignUp(t *testing.T) {
+	userRepo := new(mocks.UserRepository)
+	userRepo.On("Confirm", mock.Anything, hashToken("token"), mock.Anything).Return("new_user", nil).Once()
+	userRepo.On("Confirm", mock.Anything, hashToken("expired"), mock.Anything).Return("", domain.ErrNotFound).Once()
+
+	authService := NewAuthService(userRepo, nil, nil, nil, nil, nil, time.Hour, 24*time.Hour)
+
+	require.NoError(t, authService.ConfirmSignUp(context.Background(), "token"))
+	require.ErrorIs(t, authService.ConfirmSignUp(context.Background(), "expired"), domain.ErrInvalidToken)
+	userRepo.AssertExpectations(t)
+}
+
+func TestAuthService_LoginPending(t *testing.T) {
+	userRepo := new(mocks.UserRepository)
+	userRepo.On("GetByUsername", mock.Anything, "new_user").Return(
+		&domain.User{
+			Username:     "new_user",
+			PasswordHash: "$2a$10$/md3ztppcKhB9sjDb/GMZuYlb9o3bxvPnwO2v3up3/KlHCjMOskcG",
+			Role:         domain.USER,
+			Pending:      true,
+		},
+		nil,
+	).Once()
+	sessionRepo := new(mocks.SessionRepository)
+	failureRep := new(mocks.LoginFailureRepository)
+	failureRep.On("Save", mock.Anything, mock.MatchedBy(func(f *domain.LoginFailure) bool {
+		return f.Reason == domain.LoginPending
+	})).Return(nil).Once()
+
+	authService := NewAuthService(userRepo, nil, sessionRepo, failureRep, nil, nil, time.Hour, 24*time.Hour)
+	_, err := authService.Login(context.Background(), "new_user", "password", "10.0.0.1")
+
+	require.ErrorIs(t, err, domain.ErrForbidden)
+	failureRep.AssertExpectations(t)
+	sessionRepo.AssertNotCalled(t, "CreateSession", mock.Anything, mock.Anything)
+}
+
+func TestAuthService_LoginDisabled(t *testing.T) {
+	userRepo := new(mocks.UserRepository)
+	userRepo.On("GetByUsername", mock.Anything, "valid_user").Return(
+		&domain.User{
+			Username:     "valid_user",
+			PasswordHash: "$2a$10$/md3ztppcKhB9sjDb/GMZuYlb9o3bxvPnwO2v3up3/KlHCjMOskcG",
+			Role:         domain.USER,
+			Disabled:     true,
+		},
+		nil,
+	).Once()
+	sessionRepo := new(mocks.SessionRepository)
+	failureRep := new(mocks.LoginFailureRepository)
+	failureRep.On("Save", mock.Anything, mock.MatchedBy(func(f *domain.LoginFailure) bool {
+		return f.Reason == domain.LoginDisabled
+	})).Return(nil).Once()
+
+	authService := NewAuthService(userRepo, nil, sessionRepo, failureRep, nil, nil, time.Hour, 24*time.Hour)
+	_, err := authService.Login(context.Background(), "valid_user", "password", "10.0.0.1")
+
+	require.ErrorIs(t, err, domain.ErrForbidden)
+	failureRep.AssertExpectations(t)
+	sessionRepo.AssertNotCalled(t, "CreateSession", mock.Anything, mock.Anything)
+}
+
+func TestAuthService_ValidateTokenDisabled(t *testing.T) {
+	userRepo := new(mocks.UserRepository)
+	userRepo.On("GetByUsername", mock.Anything, "valid_user").Return(
+		&domain.User{Username: "valid_user", Role: domain.USER, Disabled: true},
+		nil,
+	).Once()
+	tokens := new(mocks.TokenSigner)
+	tokens.On("Verify", "token").Return(&domain.TokenClaims{Username: "valid_user", SessionID: "s1"}, nil).Once()
+	sessionRepo := new(mocks.SessionRepository)
+	sessionRepo.On("IsSessionRevoked", mock.Anything, "s1").Return(false, nil).Once()
+
+	authService := NewAuthService(userRepo, nil, sessionRepo, nil, nil, tokens, time.Hour, 24*time.Hour)
+	_, err := authService.ValidateToken(context.Background(), "token")
+
+	require.ErrorIs(t, err, domain.ErrInvalidToken)
+}
+
+func TestAuthService_ListUsers(t *testing.T) {
+	page := &domain.UserPage{Users: []*domain.UserAccount{{Username: "admin", Role: domain.ADMIN}}, Total: 3}
+	userRepo := new(mocks.UserRepository)
+	userRepo.On("List", mock.Anything, 0, 1).Return(page, nil).Once()
+
+	authService := NewAuthService(userRepo, nil, nil, nil, nil, nil, time.Hour, 24*time.Hour)
+	result, err := authService.ListUsers(context.Background(), 0, 1)
+
+	require.NoError(t, err)
+	assert.Equal(t, page, result)
+	userRepo.AssertExpectations(t)
+}
+
+func TestAuthService_ListUsersNegative(t *testing.T) {
+	authService := NewAuthService(nil, nil, nil, nil, nil, nil, time.Hour, 24*time.Hour)
+
+	_, err := authService.ListUsers(context.Background(), -1, 10)
+	require.ErrorIs(t, err, domain.ErrInvalidInput)
+	_, err = authService.ListUsers(context.Background(), 0, -1)
+	require.ErrorIs(t, err, domain.ErrInvalidInput)
+}
+
+func TestAuthService_GetChanges(t *testing.T) {
+	changes := []*domain.UserChange{{Username: "user", ChangedAt: time.Now()}}
+	userRepo := new(mocks.UserRepository)
+	userRepo.On("DeleteChanges", mock.Anything, mock.Anything).Return(nil).Once()
+	// Changes older than the lifetime of tokens are not asked for
+	userRepo.On("GetChanges", mock.Anything, mock.MatchedBy(func(since time.Time) bool {
+		return time.Since(since) < time.Hour+time.Minute
+	})).Return(changes, nil).Once()
+	revocations := []*domain.SessionRevocation{{SessionID: "s1", RevokedAt: time.Now()}}
+	sessionRepo := new(mocks.SessionRepository)
+	sessionRepo.On("GetRevocations", mock.Anything, mock.Anything).Return(revocations, nil).Once()
+
+	authService := NewAuthService(userRepo, nil, sessionRepo, nil, nil, nil, time.Hour, 24*time.Hour)
+	result, err := authService.GetChanges(context.Background(), time.Time{})
+
+	require.NoError(t, err)
+	assert.Equal(t, changes, result.Users)
+	assert.Equal(t, revocations, result.Sessions)
+	assert.Equal(t, time.Hour, result.TokenMaxTime)
+	userRepo.AssertExpectations(t)
+	sessionRepo.AssertExpectations(t)
+}
+
+func TestAuthService_PurgeExpired(t *testing.T) {
+	sessionRepo := new(mocks.SessionRepository)
+	sessionRepo.On("DeleteExpired", mock.Anything, mock.Anything).Return(nil).Once()
+
+	authService := NewAuthService(nil, nil, sessionRepo, nil, nil, nil, time.Hour, 24*time.Hour)
+
+	require.NoError(t, authService.PurgeExpired(context.Background()))
+	sessionRepo.AssertExpectations(t)
+}
+
+func TestAuthService_PurgeExpiredLoginFailures(t *testing.T) {
+	sessionRepo := new(mocks.SessionRepository)
+	sessionRepo.On("DeleteExpired", mock.Anything, mock.Anything).Return(nil).Once()
+	failureRep := new(mocks.LoginFailureRepository)
+	// Failures are kept for the retention period, long after the throttling window
+	failureRep.On("DeleteBefore", mock.Anything, mock.MatchedBy(func(before time.Time) bool {
+		return time.Since(before) >= 90*24*time.Hour && time.Since(before) < 90*24*time.Hour+time.Minute
+	})).Return(nil).Once()
+
+	authService := NewAuthService(nil, nil, sessionRepo, failureRep, nil, nil, time.Hour, 24*time.Hour)
+	authService.ThrottleLogins(LoginThrottle{Window: 15 * time.Minute})
+	authService.SetLoginFailureRetention(90 * 24 * time.Hour)
+
+	require.NoError(t, authService.PurgeExpired(context.Background()))
+	sessionRepo.AssertExpectations(t)
+	failureRep.AssertExpectations(t)
+}
+
+func TestAuthService_PurgeExpiredKeepsLoginFailures(t *testing.T) {
+	sessionRepo := new(mocks.SessionRepository)
+	sessionRepo.On("DeleteExpired", mock.Anything, mock.Anything).Return(nil).Once()
+	failureRep := new(mocks.LoginFailureRepository)
+
+	authService := NewAuthService(nil, nil, sessionRepo, failureRep, nil, nil, time.Hour, 24*time.Hour)
+	authService.ThrottleLogins(LoginThrottle{Window: 15 * time.Minute})
+
+	require.NoError(t, authService.PurgeExpired(context.Background()))
+	failureRep.AssertNotCalled(t, "DeleteBefore", mock.Anything, mock.Anything)
+}
+
+func TestAuthService_SetDisabled(t *testing.T) {
+	userRepo := new(mocks.UserRepository)
+	userRepo.On("SetDisabled", mock.Anything, "user", true).Return(nil).Once()
+	userRepo.On("SetDisabled", mock.Anything, "user", false).Return(nil).Once()
+	userRepo.On("SetDisabled", mock.Anything, "missing", true).Return(domain.ErrNotFound).Once()
+	sessionRepo := new(mocks.SessionRepository)
+	sessionRepo.On("RevokeUserSessions", mock.Anything, "user", mock.Anything).Return(nil).Once()
+
+	authService := NewAuthService(userRepo, nil, sessionRepo, nil, nil, nil, time.Hour, 24*time.Hour)
+
+	require.NoError(t, authService.SetDisabled(context.Background(), "user", true))
+	require.NoError(t, authService.SetDisabled(context.Background(), "user", false))
+	require.ErrorIs(t, authService.SetDisabled(context.Background(), "missing", true), domain.ErrNotFound)
+	userRepo.AssertExpectations(t)
+	sessionRepo.AssertExpectations(t)
+}
+
+func TestAuthService_SetRoleAndDelete(t *testing.T) {
+	userRepo := new(mocks.UserRepository)
+	userRepo.On("SetRole", mock.Anything, "user", domain.ADMIN).Return(nil).Once()
+	userRepo.On("Delete", mock.Anything, "user").Return(nil).Once()
+	userRepo.On("Delete", mock.Anything, "missing").Return(domain.ErrNotFound).Once()
+
+	authService := NewAuthService(userRepo, nil, nil, nil, nil, nil, time.Hour, 24*time.Hour)
+
+	require.NoError(t, authService.SetRole(context.Background(), "user", domain.ADMIN))
+	require.NoError(t, authService.DeleteUser(context.Background(), "user"))
+	require.ErrorIs(t, authService.DeleteUser(context.Background(), "missing"), domain.ErrNotFound)
+	userRepo.AssertExpectations(t)
+}
+
+func TestAuthService_LastManager(t *testing.T) {
+	userRepo := new(mocks.UserRepository)
+	userRepo.On("SetRole", mock.Anything, "admin", domain.USER).Return(domain.ErrForbidden).Once()
+	userRepo.On("SetDisabled", mock.Anything, "admin", true).Return(domain.ErrForbidden).Once()
+	userRepo.On("Delete", mock.Anything, "admin").Return(domain.ErrForbidden).Once()
+
+	authService := NewAuthService(userRepo, nil, nil, nil, nil, nil, time.Hour, 24*time.Hour)
+
+	require.ErrorIs(t, authService.SetRole(context.Background(), "admin", domain.USER), domain.ErrForbidden)
+	require.ErrorIs(t, authService.SetDisabled(context.Background(), "admin", true), domain.ErrForbidden)
+	require.ErrorIs(t, authService.DeleteUser(context.Background(), "admin"), domain.ErrForbidden)
+	userRepo.AssertExpectations(t)
+}
+
+func TestAuthService_ChangePassword(t *testing.T) {
+	user := &domain.User{
+		Username:     "user",
+		PasswordHash: "$2a$10$/md3ztppcKhB9sjDb/GMZuYlb9o3bxvPnwO2v3up3/KlHCjMOskcG",
+		Role:         domain.USER,
+	}
+	userRepo := new(mocks.UserRepository)
+	userRepo.On("GetByUsername", mock.Anything, "user").Return(user, nil).Twice()
+	userRepo.On("SetPassword", mock.Anything, "user", mock.MatchedBy(func(hash string) bool {
+		return bcrypt.CompareHashAndPassword([]byte(hash), []byte("new_password")) == nil
+	})).Return(nil).Once()
+	sessionRepo := new(mocks.SessionRepository)
+	sessionRepo.On("RevokeUserSessions", mock.Anything, "user", mock.Anything).Return(nil).Once()
+	failureRep := new(mocks.LoginFailureRepository)
+	failureRep.On("Save", mock.Anything, mock.MatchedBy(func(f *domain.LoginFailure) bool {
+		return f.Username == "user" && f.ClientIP == "10.0.0.1" && f.Reason == domain.LoginWrongPassword
+	})).Return(nil).Once()
+
+	authService := NewAuthService(userRepo, nil, sessionRepo, failureRep, nil, nil, time.Hour, 24*time.Hour)
+
+	err := authService.ChangePassword(context.Background(), "user", "wrong_password", "new_password", "10.0.0.1")
+	require.ErrorIs(t, err, domain.ErrInvalidInput)
+	require.NoError(t, authService.ChangePassword(context.Background(), "user", "password", "new_password", "10.0.0.1"))
+	userRepo.AssertExpectations(t)
+	sessionRepo.AssertExpectations(t)
+	failureRep.AssertExpectations(t)
+}
+
+func TestAuthService_ChangePasswordThrottled(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	require.NoError(t, err)
+	userRepo := new(mocks.UserRepository)
+	userRepo.On("GetByUsername", mock.Anything, "user").Return(&domain.User{PasswordHash: string(hash)}, nil).Once()
+	failureRep := new(mocks.LoginFailureRepository)
+	failureRep.On("Save", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		args.Get(1).(*domain.LoginFailure).ID = 7
+	}).Return(nil).Twice()
+	failureRep.On("GetStats", mock.Anything, "user", "10.0.0.1", mock.Anything, int64(7)).
+		Return(&domain.LoginFailureStats{}, nil).Once()
+	failureRep.On("GetStats", mock.Anything, "user", "10.0.0.1", mock.Anything, int64(7)).
+		Return(&domain.LoginFailureStats{UserFailures: 5, UserLastFailure: time.Now()}, nil).Once()
+	// A wrong old password is recorded as a failed login, and too many of them throttle the change
+	failureRep.On("SetReason", mock.Anything, int64(7), domain.LoginWrongPassword).Return(nil).Once()
+	failureRep.On("SetReason", mock.Anything, int64(7), domain.LoginThrottled).Return(nil).Once()
+
+	authService := NewAuthService(userRepo, nil, nil, failureRep, nil, nil, time.Hour, 24*time.Hour)
+	authService.ThrottleLogins(LoginThrottle{
+		Window:      15 * time.Minute,
+		LockoutTime: 15 * time.Minute,
+		User:        AttemptLimit{Lockout: 5},
+	})
+
+	err = authService.ChangePassword(context.Background(), "user", "wrong_password", "new_password", "10.0.0.1")
+	require.ErrorIs(t, err, domain.ErrInvalidInput)
+	err = authService.ChangePassword(context.Background(), "user", "password", "new_password", "10.0.0.1")
+	var throttled *domain.ThrottledError
+	require.ErrorAs(t, err, &throttled)
+	userRepo.AssertExpectations(t)
+	userRepo.AssertNotCalled(t, "SetPassword", mock.Anything, mock.Anything, mock.Anything)
+	failureRep.AssertExpectations(t)
+}
+
+func TestAuthService_CreateAPIKey(t *testing.T) {
+	userRepo := new(mocks.UserRepository)
+	userRepo.On("GetByUsername", mock.Anything, "admin").Return(&domain.User{Username: "admin", Role: domain.ADMIN}, nil)
+	roleRepo := new(mocks.RoleRepository)
+	roleRepo.On("GetPermissions", mock.Anything, domain.ADMIN).Return(
+		[]domain.Permission{domain.PermComicsUpdate, domain.PermComicsSearch},
+		nil,
+	)
+	apiKeyRepo := new(mocks.APIKeyRepository)
+	var saved *domain.APIKey
+	apiKeyRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.APIKey"), maxAPIKeys).
+		Run(func(args mock.Arguments) {
+			saved = args.Get(1).(*domain.APIKey)
+			saved.ID = 1
+		}).Return(nil).Once()
+	apiKeyRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.APIKey"), maxAPIKeys).
+		Return(domain.ErrInvalidInput).Once()
+
+	authService := NewAuthService(userRepo, roleRepo, nil, nil, apiKeyRepo, nil, time.Hour, 24*time.Hour)
+	k, err := authService.CreateAPIKey(
+		context.Background(),
+		"admin",
+		"cron",
+		[]domain.Permission{domain.PermComicsUpdate, domain.PermComicsUpdate},
+		time.Hour,
+	)
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), k.ID)
+	assert.Regexp(t, `^xk_[0-9a-f]{64}$`, k.Key)
+	assert.Equal(t, k.Key[:len(k.Prefix)], k.Prefix)
+	assert.Equal(t, hashToken(k.Key), saved.Hash)
+	assert.Equal(t, []domain.Permission{domain.PermComicsUpdate}, saved.Permissions)
+	require.NotNil(t, saved.ExpiresAt)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), *saved.ExpiresAt, time.Minute)
+
+	// Keys beyond the limit are refused
+	more := []domain.Permission{domain.PermComicsSearch}
+	_, err = authService.CreateAPIKey(context.Background(), "admin", "more", more, 0)
+	require.ErrorIs(t, err, domain.ErrInvalidInput)
+
+	_, err = authService.CreateAPIKey(
+		context.Background(),
+		"admin",
+		"",
+		[]domain.Permission{domain.PermUsersManage},
+		-time.Hour,
+	)
+	var invalid *domain.ValidationError
+	require.ErrorAs(t, err, &invalid)
+	require.ErrorIs(t, err, domain.ErrInvalidInput)
+	var codes []string
+	for _, v := range invalid.Violations {
+		codes = append(codes, v.Field+":"+v.Code)
+	}
+	assert.Equal(t, []string{"name:required", "ttl:negative", "permissions:not_granted"}, codes)
+	apiKeyRepo.AssertExpectations(t)
+}
+
+func TestAuthService_ValidateAPIKey(t *testing.T) {
+	lastUsed := time.Now().Add(-time.Hour)
+	apiKeyRepo := new(mocks.APIKeyRepository)
+	apiKeyRepo.On("GetByHash", mock.Anything, hashToken("xk_key")).Return(&domain.APIKey{
+		ID:          1,
+		Username:    "user",
+		Permissions: []domain.Permission{domain.PermComicsSearch, domain.PermComicsUpdate},
+		LastUsedAt:  &lastUsed,
+	}, nil).Once()
+	apiKeyRepo.On("RecordUse", mock.Anything, int64(1), mock.AnythingOfType("time.Time")).Return(nil).Once()
+	userRepo := new(mocks.UserRepository)
+	userRepo.On("GetByUsername", mock.Anything, "user").Return(&domain.User{Username: "user", Role: domain.USER}, nil).Once()
+	roleRepo := new(mocks.RoleRepository)
+	roleRepo.On("GetPermissions", mock.Anything, domain.USER).Return(
+		[]domain.Permission{domain.PermComicsSearch, domain.PermAccountManage},
+		nil,
+	).Once()
+
+	authService := NewAuthService(userRepo, roleRepo, nil, nil, apiKeyRepo, nil, time.Hour, 24*time.Hour)
+	p, err := authService.ValidateAPIKey(context.Background(), "xk_key")
+
+	require.NoError(t, err)
+	assert.Equal(t, &domain.Principal{
+		Username:    "user",
+		Role:        domain.USER,
+		Permissions: []domain.Permission{domain.PermComicsSearch},
+	}, p)
+	apiKeyRepo.AssertExpectations(t)
+}
+
+func TestAuthService_ValidateAPIKeyRecentlyUsed(t *testing.T) {
+	lastUsed := time.Now()
+	apiKeyRepo := new(mocks.APIKeyRepository)
+	apiKeyRepo.On("GetByHash", mock.Anything, hashToken("xk_key")).Return(&domain.APIKey{
+		ID:          1,
+		Username:    "user",
+		Permissions: []domain.Permission{domain.PermComicsSearch},
+		LastUsedAt:  &lastUsed,
+	}, nil).Once()
+	userRepo := new(mocks.UserRepository)
+	userRepo.On("GetByUsername", mock.Anything, "user").Return(&domain.User{Username: "user", Role: domain.USER}, nil).Once()
+	roleRepo := new(mocks.RoleRepository)
+	roleRepo.On("GetPermissions", mock.Anything, domain.USER).Return([]domain.Permission{domain.PermComicsSearch}, nil).Once()
+
+	authService := NewAuthService(userRepo, roleRepo, nil, nil, apiKeyRepo, nil, time.Hour, 24*time.Hour)
+	_, err := authService.ValidateAPIKey(context.Background(), "xk_key")
+
+	require.NoError(t, err)
+	apiKeyRepo.AssertNotCalled(t, "RecordUse", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestAuthService_ValidateAPIKeyInvalid(t *testing.T) {
+	expired := time.Now().Add(-time.Minute)
+	apiKeyRepo := new(mocks.APIKeyRepository)
+	apiKeyRepo.On("GetByHash", mock.Anything, hashToken("xk_unknown")).Return(nil, nil).Once()
+	apiKeyRepo.On("GetByHash", mock.Anything, hashToken("xk_expired")).Return(&domain.APIKey{
+		ID:        1,
+		Username:  "user",
+		ExpiresAt: &expired,
+	}, nil).Once()
+	apiKeyRepo.On("GetByHash", mock.Anything, hashToken("xk_disabled")).Return(&domain.APIKey{
+		ID:       2,
+		Username: "disabled",
+	}, nil).Once()
+	userRepo := new(mocks.UserRepository)
+	userRepo.On("GetByUsername", mock.Anything, "disabled").Return(&domain.User{
+		Username: "disabled",
+		Role:     domain.USER,
+		Disabled: true,
+	}, nil).Once()
+
+	authService := NewAuthService(userRepo, nil, nil, nil, apiKeyRepo, nil, time.Hour, 24*time.Hour)
+	for _, key := range []string{"xk_unknown", "xk_expired", "xk_disabled"} {
+		_, err := authService.ValidateAPIKey(context.Background(), key)
+		require.ErrorIs(t, err, domain.ErrInvalidToken, key)
+	}
+	apiKeyRepo.AssertExpectations(t)
 	userRepo.AssertExpectations(t)
 }
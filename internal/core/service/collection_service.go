@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+	"yadro-microservices/internal/core/domain"
+	"yadro-microservices/internal/core/port"
+)
+
+// maxCollectionNameLength is the maximum number of characters in a collection name.
+const maxCollectionNameLength = 100
+
+// CollectionService provides methods for managing favorite comics and comic collections of users.
+type CollectionService struct {
+	collectionRep port.CollectionRepository
+}
+
+// NewCollectionService creates a new instance of collection service.
+func NewCollectionService(collectionRep port.CollectionRepository) *CollectionService {
+	return &CollectionService{collectionRep: collectionRep}
+}
+
+// AddFavorite adds the comic to the favorites of the user.
+func (cs *CollectionService) AddFavorite(ctx context.Context, username string, comicID int) error {
+	if err := cs.collectionRep.AddFavorite(ctx, username, comicID); err != nil {
+		return fmt.Errorf("error adding favorite: %w", err)
+	}
+
+	return nil
+}
+
+// RemoveFavorite removes the comic from the favorites of the user.
+func (cs *CollectionService) RemoveFavorite(ctx context.Context, username string, comicID int) error {
+	if err := cs.collectionRep.RemoveFavorite(ctx, username, comicID); err != nil {
+		return fmt.Errorf("error removing favorite: %w", err)
+	}
+
+	return nil
+}
+
+// GetFavorites returns the favorite comics of the user.
+func (cs *CollectionService) GetFavorites(ctx context.Context, username string) ([]*domain.FoundComic, error) {
+	favorites, err := cs.collectionRep.GetFavorites(ctx, username)
+	if err != nil {
+		return nil, fmt.Errorf("error getting favorites: %w", err)
+	}
+
+	return favorites, nil
+}
+
+// CreateCollection creates an empty collection of the user.
+// The name is trimmed and must be unique among the collections of the user.
+func (cs *CollectionService) CreateCollection(
+	ctx context.Context,
+	username, name string,
+) (*domain.Collection, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxCollectionNameLength {
+		return nil, fmt.Errorf(
+			"collection name must be from 1 to %d characters: %w",
+			maxCollectionNameLength,
+			domain.ErrInvalidInput,
+		)
+	}
+
+	collection, err := cs.collectionRep.CreateCollection(ctx, username, name)
+	if err != nil {
+		return nil, fmt.Errorf("error creating collection: %w", err)
+	}
+
+	return collection, nil
+}
+
+// DeleteCollection deletes the collection of the user.
+func (cs *CollectionService) DeleteCollection(ctx context.Context, username string, id int64) error {
+	if err := cs.collectionRep.DeleteCollection(ctx, username, id); err != nil {
+		return fmt.Errorf("error deleting collection: %w", err)
+	}
+
+	return nil
+}
+
+// GetCollections returns the collections of the user with their comics.
+func (cs *CollectionService) GetCollections(ctx context.Context, username string) ([]*domain.Collection, error) {
+	collections, err := cs.collectionRep.GetCollections(ctx, username)
+	if err != nil {
+		return nil, fmt.Errorf("error getting collections: %w", err)
+	}
+
+	return collections, nil
+}
+
+// AddToCollection adds the comic to the collection of the user.
+func (cs *CollectionService) AddToCollection(ctx context.Context, username string, id int64, comicID int) error {
+	if err := cs.collectionRep.AddToCollection(ctx, username, id, comicID); err != nil {
+		return fmt.Errorf("error adding comic to collection: %w", err)
+	}
+
+	return nil
+}
+
+// RemoveFromCollection removes the comic from the collection of the user.
+func (cs *CollectionService) RemoveFromCollection(
+	ctx context.Context,
+	username string,
+	id int64,
+	comicID int,
+) error {
+	if err := cs.collectionRep.RemoveFromCollection(ctx, username, id, comicID); err != nil {
+		return fmt.Errorf("error removing comic from collection: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"yadro-microservices/internal/core/domain"
+	"yadro-microservices/internal/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateCollection(t *testing.T) {
+	ctx := context.Background()
+
+	collectionRepMock := new(mocks.CollectionRepository)
+	service := NewCollectionService(collectionRepMock)
+
+	collection := &domain.Collection{ID: 1, Name: "Physics"}
+	collectionRepMock.On("CreateCollection", ctx, "user", "Physics").Return(collection, nil).Once()
+	collectionRepMock.On("CreateCollection", ctx, "user", "Math").Return(nil, domain.ErrAlreadyExists).Once()
+
+	created, err := service.CreateCollection(ctx, "user", "  Physics ")
+	require.NoError(t, err)
+	assert.Equal(t, collection, created)
+
+	_, err = service.CreateCollection(ctx, "user", "Math")
+	require.ErrorIs(t, err, domain.ErrAlreadyExists)
+	collectionRepMock.AssertExpectations(t)
+}
+
+func TestCreateCollection_InvalidName(t *testing.T) {
+	service := NewCollectionService(nil)
+
+	_, err := service.CreateCollection(context.Background(), "user", "   ")
+	require.ErrorIs(t, err, domain.ErrInvalidInput)
+
+	_, err = service.CreateCollection(context.Background(), "user", strings.Repeat("x", maxCollectionNameLength+1))
+	require.ErrorIs(t, err, domain.ErrInvalidInput)
+}
+
+func TestAddFavorite(t *testing.T) {
+	ctx := context.Background()
+
+	collectionRepMock := new(mocks.CollectionRepository)
+	service := NewCollectionService(collectionRepMock)
+
+	collectionRepMock.On("AddFavorite", ctx, "user", 353).Return(nil).Once()
+	collectionRepMock.On("AddFavorite", ctx, "user", 9999).Return(domain.ErrNotFound).Once()
+
+	require.NoError(t, service.AddFavorite(ctx, "user", 353))
+	require.ErrorIs(t, service.AddFavorite(ctx, "user", 9999), domain.ErrNotFound)
+	collectionRepMock.AssertExpectations(t)
+}
+
+func TestAddToCollection(t *testing.T) {
+	ctx := context.Background()
+
+	collectionRepMock := new(mocks.CollectionRepository)
+	service := NewCollectionService(collectionRepMock)
+
+	collectionRepMock.On("AddToCollection", ctx, "user", int64(1), 353).Return(nil).Once()
+	collectionRepMock.On("AddToCollection", ctx, "other", int64(1), 353).Return(domain.ErrNotFound).Once()
+
+	require.NoError(t, service.AddToCollection(ctx, "user", 1, 353))
+	require.ErrorIs(t, service.AddToCollection(ctx, "other", 1, 353), domain.ErrNotFound)
+	collectionRepMock.AssertExpectations(t)
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"yadro-microservices/internal/core/domain"
+	"yadro-microservices/internal/core/port"
+	"yadro-microservices/pkg/relevance"
+)
+
+// EvaluationService evaluates the relevance of search results against judged queries.
+type EvaluationService struct {
+	processor    port.ComicProcessor
+	searchEngine port.SearchEngine
+}
+
+// NewEvaluationService creates a new instance of evaluation service.
+func NewEvaluationService(processor port.ComicProcessor, searchEngine port.SearchEngine) *EvaluationService {
+	return &EvaluationService{
+		processor:    processor,
+		searchEngine: searchEngine,
+	}
+}
+
+// Evaluate runs the judged queries against the search engine and calculates precision@k, recall@k,
+// MRR and nDCG@k. Queries are processed the same way as user queries. A query which fails is counted
+// as one with no results, so a broken engine gets low scores instead of being skipped.
+func (es *EvaluationService) Evaluate(
+	ctx context.Context,
+	queries []domain.JudgedQuery,
+	k int,
+) (*domain.EvaluationReport, error) {
+	if k <= 0 {
+		return nil, errors.New("k must be positive")
+	}
+	if len(queries) == 0 {
+		return nil, errors.New("no judged queries")
+	}
+
+	report := &domain.EvaluationReport{K: k}
+	for _, q := range queries {
+		relevant := make(map[int]bool, len(q.Relevant))
+		for _, id := range q.Relevant {
+			relevant[id] = true
+		}
+
+		evaluation := &domain.QueryEvaluation{Query: q.Query}
+		results, err := es.search(ctx, q.Query)
+		if err != nil {
+			log.Printf("Error searching judged query %q: %v", q.Query, err)
+			evaluation.Error = err.Error()
+			report.Failed++
+		}
+
+		evaluation.Results = results
+		evaluation.Precision = relevance.PrecisionAtK(results, relevant, k)
+		evaluation.Recall = relevance.RecallAtK(results, relevant, k)
+		evaluation.ReciprocalRank = relevance.ReciprocalRankAtK(results, relevant, k)
+		evaluation.NDCG = relevance.NDCGAtK(results, relevant, k)
+		report.Queries = append(report.Queries, evaluation)
+
+		report.Precision += evaluation.Precision
+		report.Recall += evaluation.Recall
+		report.MRR += evaluation.ReciprocalRank
+		report.NDCG += evaluation.NDCG
+	}
+
+	n := float64(len(queries))
+	report.Precision /= n
+	report.Recall /= n
+	report.MRR /= n
+	report.NDCG /= n
+
+	return report, nil
+}
+
+// search processes the query and searches for the comic IDs.
+func (es *EvaluationService) search(ctx context.Context, query string) ([]int, error) {
+	queryTokens, err := es.processor.FullProcess(query)
+	if err != nil {
+		return nil, fmt.Errorf("error processing query: %w", err)
+	}
+
+	ids, err := es.searchEngine.Search(ctx, queryTokens)
+	if err != nil {
+		return nil, fmt.Errorf("error searching comics: %w", err)
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"yadro-microservices/internal/core/domain"
+	"yadro-microservices/internal/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluate(t *testing.T) {
+	processorMock := new(mocks.ComicProcessor)
+	searchEngineMock := new(mocks.SearchEngine)
+
+	service := NewEvaluationService(processorMock, searchEngineMock)
+
+	processorMock.On("FullProcess", "apple doctor").Return([]string{"appl", "doctor"}, nil)
+	processorMock.On("FullProcess", "barrel").Return([]string{"barrel"}, nil)
+	searchEngineMock.On("Search", mock.Anything, []string{"appl", "doctor"}).Return([]int{1, 2}, nil)
+	searchEngineMock.On("Search", mock.Anything, []string{"barrel"}).Return([]int{5, 3}, nil)
+
+	report, err := service.Evaluate(context.Background(), []domain.JudgedQuery{
+		{Query: "apple doctor", Relevant: []int{1, 2}},
+		{Query: "barrel", Relevant: []int{3}},
+	}, 2)
+
+	require.NoError(t, err)
+	require.Len(t, report.Queries, 2)
+	assert.Equal(t, 2, report.K)
+	assert.Zero(t, report.Failed)
+	assert.Equal(t, []int{1, 2}, report.Queries[0].Results)
+	assert.InDelta(t, 1, report.Queries[0].NDCG, 1e-9)
+	assert.InDelta(t, 0.5, report.Queries[1].Precision, 1e-9)
+	assert.InDelta(t, 0.75, report.Precision, 1e-9)
+	assert.InDelta(t, 1, report.Recall, 1e-9)
+	assert.InDelta(t, 0.75, report.MRR, 1e-9)
+}
+
+func TestEvaluate_FailedQuery(t *testing.T) {
+	processorMock := new(mocks.ComicProcessor)
+	searchEngineMock := new(mocks.SearchEngine)
+
+	service := NewEvaluationService(processorMock, searchEngineMock)
+
+	processorMock.On("FullProcess", "barrel").Return([]string{"barrel"}, nil)
+	searchEngineMock.On("Search", mock.Anything, mock.Anything).Return(nil, errors.New("engine is down"))
+
+	report, err := service.Evaluate(context.Background(), []domain.JudgedQuery{
+		{Query: "barrel", Relevant: []int{3}},
+	}, 10)
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Failed)
+	assert.NotEmpty(t, report.Queries[0].Error)
+	assert.Zero(t, report.NDCG)
+}
+
+func TestEvaluate_InvalidArguments(t *testing.T) {
+	service := NewEvaluationService(nil, nil)
+
+	_, err := service.Evaluate(context.Background(), []domain.JudgedQuery{{Query: "barrel"}}, 0)
+	require.Error(t, err)
+
+	_, err = service.Evaluate(context.Background(), nil, 10)
+	require.Error(t, err)
+}
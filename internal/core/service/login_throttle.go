@@ -0,0 +1,53 @@
+package service
+
+import (
+	"time"
+	"yadro-microservices/internal/core/domain"
+)
+
+// LoginThrottle limits failed logins by username and by client IP. After the free failures every next
+// attempt is delayed, the delay doubling with each failure, and after the lockout failures attempts are
+// rejected for the lockout time. Failures are forgotten after the window, and failures of a username
+// also after its successful login. Logins are not throttled if the window is zero.
+type LoginThrottle struct {
+	Window      time.Duration
+	BaseDelay   time.Duration // Delay after the first failure beyond the free ones
+	MaxDelay    time.Duration
+	LockoutTime time.Duration
+	User        AttemptLimit
+	IP          AttemptLimit
+}
+
+// AttemptLimit is the number of failures after which logins are delayed or locked out; zero disables the limit.
+type AttemptLimit struct {
+	Free    int
+	Lockout int
+}
+
+// retryAfter returns how long the next attempt has to wait given the recent failures, or zero if it is allowed.
+func (t LoginThrottle) retryAfter(stats *domain.LoginFailureStats, now time.Time) time.Duration {
+	return max(
+		t.wait(t.User, stats.UserFailures, stats.UserLastFailure, now),
+		t.wait(t.IP, stats.IPFailures, stats.IPLastFailure, now),
+		0,
+	)
+}
+
+// wait returns the time left until the next attempt after the failures under the limit.
+func (t LoginThrottle) wait(limit AttemptLimit, failures int, last, now time.Time) time.Duration {
+	var delay time.Duration
+	switch {
+	case limit.Lockout > 0 && failures >= limit.Lockout:
+		delay = t.LockoutTime
+	case limit.Free > 0 && failures > limit.Free:
+		delay = t.BaseDelay
+		for i := limit.Free + 1; i < failures && delay < t.MaxDelay; i++ {
+			delay *= 2
+		}
+		delay = min(delay, t.MaxDelay)
+	default:
+		return 0
+	}
+
+	return last.Add(delay).Sub(now)
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"testing"
+	"time"
+	"yadro-microservices/internal/core/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoginThrottle_RetryAfter(t *testing.T) {
+	now := time.Now()
+	throttle := LoginThrottle{
+		Window:      15 * time.Minute,
+		BaseDelay:   time.Second,
+		MaxDelay:    10 * time.Second,
+		LockoutTime: 15 * time.Minute,
+		User:        AttemptLimit{Free: 3, Lockout: 10},
+		IP:          AttemptLimit{Free: 20, Lockout: 100},
+	}
+
+	tests := []struct {
+		name       string
+		stats      domain.LoginFailureStats
+		retryAfter time.Duration // Zero if the login is allowed
+	}{
+		{
+			name:  "free failures",
+			stats: domain.LoginFailureStats{UserFailures: 3, UserLastFailure: now},
+		},
+		{
+			name:       "first delay",
+			stats:      domain.LoginFailureStats{UserFailures: 4, UserLastFailure: now},
+			retryAfter: time.Second,
+		},
+		{
+			name:       "doubled delay",
+			stats:      domain.LoginFailureStats{UserFailures: 6, UserLastFailure: now},
+			retryAfter: 4 * time.Second,
+		},
+		{
+			name:       "max delay",
+			stats:      domain.LoginFailureStats{UserFailures: 9, UserLastFailure: now},
+			retryAfter: 10 * time.Second,
+		},
+		{
+			name:  "delay passed",
+			stats: domain.LoginFailureStats{UserFailures: 4, UserLastFailure: now.Add(-2 * time.Second)},
+		},
+		{
+			name:       "user locked out",
+			stats:      domain.LoginFailureStats{UserFailures: 10, UserLastFailure: now},
+			retryAfter: 15 * time.Minute,
+		},
+		{
+			name:       "IP locked out",
+			stats:      domain.LoginFailureStats{IPFailures: 100, IPLastFailure: now},
+			retryAfter: 15 * time.Minute,
+		},
+		{
+			name:  "lockout passed",
+			stats: domain.LoginFailureStats{UserFailures: 10, UserLastFailure: now.Add(-16 * time.Minute)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.InDelta(t, tt.retryAfter, throttle.retryAfter(&tt.stats, now), float64(time.Millisecond))
+		})
+	}
+}
@@ -0,0 +1,285 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"yadro-microservices/internal/core/domain"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Algorithms hashing passwords.
+const (
+	HashBcrypt   = "bcrypt"
+	HashArgon2id = "argon2id"
+)
+
+// bcryptMaxLength is the number of bytes of a password bcrypt can hash.
+const bcryptMaxLength = 72
+
+// PasswordHashing selects how new passwords are hashed. Hashes of both algorithms are verified,
+// and a hash made by another algorithm or with other parameters is replaced at the next login.
+type PasswordHashing struct {
+	Algorithm  string
+	BcryptCost int
+	Argon2     Argon2Params
+}
+
+// Argon2Params are the parameters of argon2id.
+type Argon2Params struct {
+	Time       uint32
+	Memory     uint32 // KiB
+	Threads    uint8
+	SaltLength uint32
+	KeyLength  uint32
+}
+
+// DefaultPasswordHashing hashes passwords by bcrypt with the default cost.
+var DefaultPasswordHashing = PasswordHashing{Algorithm: HashBcrypt, BcryptCost: bcrypt.DefaultCost}
+
+// DefaultArgon2Params are the argon2id parameters recommended by RFC 9106 for memory-constrained environments.
+var DefaultArgon2Params = Argon2Params{Time: 3, Memory: 64 * 1024, Threads: 4, SaltLength: 16, KeyLength: 32}
+
+// Validate checks that the algorithm is known and its parameters are usable.
+func (h PasswordHashing) Validate() error {
+	switch h.Algorithm {
+	case HashBcrypt:
+		if h.BcryptCost < bcrypt.MinCost || h.BcryptCost > bcrypt.MaxCost {
+			return fmt.Errorf("bcrypt cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+		}
+	case HashArgon2id:
+		p := h.Argon2
+		if p.Time == 0 || p.Memory == 0 || p.Threads == 0 || p.SaltLength == 0 || p.KeyLength == 0 {
+			return errors.New("argon2id parameters must be positive")
+		}
+	default:
+		return fmt.Errorf("unknown password hashing algorithm %q", h.Algorithm)
+	}
+
+	return nil
+}
+
+// hash hashes the password with the selected algorithm.
+func (h PasswordHashing) hash(password string) (string, error) {
+	if h.Algorithm != HashArgon2id {
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), h.BcryptCost)
+		return string(hash), err
+	}
+
+	p := h.Argon2
+	salt := make([]byte, p.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, p.Time, p.Memory, p.Threads, p.KeyLength)
+	return fmt.Sprintf(
+		"$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version,
+		p.Memory,
+		p.Time,
+		p.Threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// verify reports whether the password matches the hash, and if so, whether the hash
+// has to be replaced because it was made by another algorithm or with other parameters.
+func (h PasswordHashing) verify(hash, password string) (ok, rehash bool) {
+	if !strings.HasPrefix(hash, "$argon2id$") {
+		if bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) != nil {
+			return false, false
+		}
+
+		cost, err := bcrypt.Cost([]byte(hash))
+		return true, h.Algorithm != HashBcrypt || err != nil || cost != h.BcryptCost
+	}
+
+	p, salt, key, err := parseArgon2id(hash)
+	if err != nil {
+		return false, false
+	}
+
+	actual := argon2.IDKey([]byte(password), salt, p.Time, p.Memory, p.Threads, p.KeyLength)
+	if subtle.ConstantTimeCompare(actual, key) != 1 {
+		return false, false
+	}
+
+	return true, h.Algorithm != HashArgon2id || p != h.Argon2
+}
+
+// dummyHashes are hashes of a dummy password by both algorithms, verified in place of the hash of an unknown user
+// and of the algorithm the hash of a user was not made by.
+type dummyHashes struct {
+	bcrypt   string
+	argon2id string
+}
+
+// dummies hashes the dummy password by both algorithms with the selected parameters,
+// or the default ones of the algorithm not selected.
+func (h PasswordHashing) dummies() (dummyHashes, error) {
+	var d dummyHashes
+	bcryptHashing := PasswordHashing{Algorithm: HashBcrypt, BcryptCost: h.BcryptCost}
+	if bcryptHashing.Validate() != nil {
+		bcryptHashing.BcryptCost = bcrypt.DefaultCost
+	}
+	argon2Hashing := PasswordHashing{Algorithm: HashArgon2id, Argon2: h.Argon2}
+	if argon2Hashing.Validate() != nil {
+		argon2Hashing.Argon2 = DefaultArgon2Params
+	}
+
+	var err error
+	if d.bcrypt, err = bcryptHashing.hash("dummy password"); err != nil {
+		return d, err
+	}
+	if d.argon2id, err = argon2Hashing.hash("dummy password"); err != nil {
+		return d, err
+	}
+
+	return d, nil
+}
+
+// verifyEvenly verifies the password against the hash like verify, and also against the dummy hash
+// of the other algorithm, so every verification runs both algorithms and takes equally long whatever
+// algorithm made the hash. An empty hash, of an unknown user, is verified against both dummy hashes
+// and never matches.
+func (h PasswordHashing) verifyEvenly(hash, password string, d dummyHashes) (ok, rehash bool) {
+	bcryptHash, argon2Hash := d.bcrypt, d.argon2id
+	isArgon2 := strings.HasPrefix(hash, "$argon2id$")
+	if isArgon2 {
+		argon2Hash = hash
+	} else if hash != "" {
+		bcryptHash = hash
+	}
+
+	bcryptOK, bcryptRehash := h.verify(bcryptHash, password)
+	argon2OK, argon2Rehash := h.verify(argon2Hash, password)
+	switch {
+	case hash == "":
+		return false, false
+	case isArgon2:
+		return argon2OK, argon2Rehash
+	default:
+		return bcryptOK, bcryptRehash
+	}
+}
+
+// parseArgon2id parses the hash in the PHC string format: $argon2id$v=19$m=65536,t=3,p=4$<salt>$<key>.
+func parseArgon2id(hash string) (Argon2Params, []byte, []byte, error) {
+	var p Argon2Params
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return p, nil, nil, errors.New("malformed argon2id hash")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return p, nil, nil, fmt.Errorf("unsupported argon2id version %q", parts[2])
+	}
+
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Time, &p.Threads); err != nil {
+		return p, nil, nil, fmt.Errorf("malformed argon2id parameters: %w", err)
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return p, nil, nil, fmt.Errorf("malformed argon2id salt: %w", err)
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return p, nil, nil, fmt.Errorf("malformed argon2id key: %w", err)
+	}
+
+	p.SaltLength = uint32(len(salt))
+	p.KeyLength = uint32(len(key))
+
+	return p, salt, key, nil
+}
+
+// PasswordPolicy is the rules new passwords have to follow. The zero policy accepts any password.
+type PasswordPolicy struct {
+	MinLength  int             // Characters
+	MaxLength  int             // Characters; zero means no limit
+	MinClasses int             // Lowercase letters, uppercase letters, digits and other characters
+	Denylist   map[string]bool // Common passwords in lower case, which are rejected regardless of case
+}
+
+// NewPasswordPolicy creates a new PasswordPolicy rejecting the passwords of the denylist.
+func NewPasswordPolicy(minLength, maxLength, minClasses int, denylist []string) PasswordPolicy {
+	policy := PasswordPolicy{
+		MinLength:  minLength,
+		MaxLength:  maxLength,
+		MinClasses: minClasses,
+		Denylist:   make(map[string]bool, len(denylist)),
+	}
+	for _, p := range denylist {
+		policy.Denylist[strings.ToLower(p)] = true
+	}
+
+	return policy
+}
+
+// check returns the rules of the policy the password of the user breaks, reported for the field.
+func (p PasswordPolicy) check(field, username, password string) []domain.FieldViolation {
+	var violations []domain.FieldViolation
+	violate := func(code, format string, args ...any) {
+		violations = append(violations, domain.FieldViolation{
+			Field:       field,
+			Code:        code,
+			Description: fmt.Sprintf(format, args...),
+		})
+	}
+
+	length := len([]rune(password))
+	if length < p.MinLength {
+		violate("too_short", "must be at least %d characters long", p.MinLength)
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		violate("too_long", "must be at most %d characters long", p.MaxLength)
+	}
+
+	if classes := characterClasses(password); classes < p.MinClasses {
+		violate(
+			"too_few_classes",
+			"must contain at least %d of lowercase letters, uppercase letters, digits and other characters",
+			p.MinClasses,
+		)
+	}
+
+	if p.Denylist[strings.ToLower(password)] {
+		violate("too_common", "is too common")
+	}
+
+	if username != "" && strings.EqualFold(password, username) {
+		violate("same_as_username", "must differ from the username")
+	}
+
+	return violations
+}
+
+// characterClasses returns the number of classes of characters the password contains.
+func characterClasses(password string) int {
+	var lower, upper, digit, other int
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = 1
+		case unicode.IsUpper(r):
+			upper = 1
+		case unicode.IsDigit(r):
+			digit = 1
+		default:
+			other = 1
+		}
+	}
+
+	return lower + upper + digit + other
+}
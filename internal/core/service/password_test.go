@@ -0,0 +1,143 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"yadro-microservices/internal/core/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testArgon2Params are cheap argon2id parameters for tests.
+var testArgon2Params = Argon2Params{Time: 1, Memory: 1024, Threads: 1, SaltLength: 16, KeyLength: 32}
+
+func TestPasswordHashing_Argon2id(t *testing.T) {
+	hashing := PasswordHashing{Algorithm: HashArgon2id, Argon2: testArgon2Params}
+
+	hash, err := hashing.hash("correct horse")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(hash, "$argon2id$v=19$m=1024,t=1,p=1$"))
+
+	ok, rehash := hashing.verify(hash, "correct horse")
+	assert.True(t, ok)
+	assert.False(t, rehash)
+
+	ok, _ = hashing.verify(hash, "wrong horse")
+	assert.False(t, ok)
+
+	other, err := hashing.hash("correct horse")
+	require.NoError(t, err)
+	assert.NotEqual(t, hash, other, "every hash has its own salt")
+}
+
+func TestPasswordHashing_Rehash(t *testing.T) {
+	bcryptHashing := PasswordHashing{Algorithm: HashBcrypt, BcryptCost: bcrypt.MinCost}
+	argonHashing := PasswordHashing{Algorithm: HashArgon2id, Argon2: testArgon2Params}
+	stronger := argonHashing
+	stronger.Argon2.Time = 2
+
+	bcryptHash, err := bcryptHashing.hash("password")
+	require.NoError(t, err)
+	argonHash, err := argonHashing.hash("password")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		hashing PasswordHashing
+		hash    string
+		rehash  bool
+	}{
+		{name: "same bcrypt cost", hashing: bcryptHashing, hash: bcryptHash},
+		{name: "other bcrypt cost", hashing: DefaultPasswordHashing, hash: bcryptHash, rehash: true},
+		{name: "bcrypt to argon2id", hashing: argonHashing, hash: bcryptHash, rehash: true},
+		{name: "argon2id to bcrypt", hashing: bcryptHashing, hash: argonHash, rehash: true},
+		{name: "same argon2id parameters", hashing: argonHashing, hash: argonHash},
+		{name: "other argon2id parameters", hashing: stronger, hash: argonHash, rehash: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, rehash := tt.hashing.verify(tt.hash, "password")
+			assert.True(t, ok)
+			assert.Equal(t, tt.rehash, rehash)
+		})
+	}
+}
+
+func TestPasswordHashing_VerifyEvenly(t *testing.T) {
+	hashing := PasswordHashing{Algorithm: HashArgon2id, BcryptCost: bcrypt.MinCost, Argon2: testArgon2Params}
+	dummies, err := hashing.dummies()
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(dummies.bcrypt, "$2a$04$"))
+	assert.True(t, strings.HasPrefix(dummies.argon2id, "$argon2id$v=19$m=1024,t=1,p=1$"))
+
+	bcryptHash, err := PasswordHashing{Algorithm: HashBcrypt, BcryptCost: bcrypt.MinCost}.hash("password")
+	require.NoError(t, err)
+	argonHash, err := hashing.hash("password")
+	require.NoError(t, err)
+
+	ok, rehash := hashing.verifyEvenly(bcryptHash, "password", dummies)
+	assert.True(t, ok)
+	assert.True(t, rehash)
+	ok, rehash = hashing.verifyEvenly(argonHash, "password", dummies)
+	assert.True(t, ok)
+	assert.False(t, rehash)
+	ok, _ = hashing.verifyEvenly(argonHash, "wrong", dummies)
+	assert.False(t, ok)
+	// An unknown user never logs in, not even with the dummy password
+	ok, _ = hashing.verifyEvenly("", "dummy password", dummies)
+	assert.False(t, ok)
+}
+
+func TestPasswordHashing_MalformedHash(t *testing.T) {
+	hashing := PasswordHashing{Algorithm: HashArgon2id, Argon2: testArgon2Params}
+
+	for _, hash := range []string{"", "$argon2id$v=19$m=1024", "$argon2id$v=18$m=1024,t=1,p=1$c2FsdA$a2V5"} {
+		ok, _ := hashing.verify(hash, "password")
+		assert.False(t, ok, hash)
+	}
+}
+
+func TestPasswordHashing_Validate(t *testing.T) {
+	require.NoError(t, DefaultPasswordHashing.Validate())
+	require.NoError(t, PasswordHashing{Algorithm: HashArgon2id, Argon2: DefaultArgon2Params}.Validate())
+	require.Error(t, PasswordHashing{Algorithm: HashArgon2id}.Validate())
+	require.Error(t, PasswordHashing{Algorithm: "md5"}.Validate())
+}
+
+func TestPasswordPolicy_Check(t *testing.T) {
+	policy := NewPasswordPolicy(8, 16, 3, []string{"Password1!"})
+
+	tests := []struct {
+		name     string
+		password string
+		codes    []string
+	}{
+		{name: "valid", password: "Tr0ub4dor"},
+		{name: "too short", password: "Ab1!", codes: []string{"too_short"}},
+		{name: "too long", password: "Abcdefgh12345678!", codes: []string{"too_long"}},
+		{name: "too few classes", password: "abcdefgh1", codes: []string{"too_few_classes"}},
+		{name: "too common", password: "PASSWORD1!", codes: []string{"too_common"}},
+		{name: "same as username", password: "Valid_User1", codes: []string{"same_as_username"}},
+		{name: "several rules", password: "abc", codes: []string{"too_short", "too_few_classes"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var codes []string
+			for _, v := range policy.check("password", "valid_user1", tt.password) {
+				assert.Equal(t, "password", v.Field)
+				assert.NotEmpty(t, v.Description)
+				codes = append(codes, v.Code)
+			}
+			assert.Equal(t, tt.codes, codes)
+		})
+	}
+}
+
+func TestPasswordPolicy_Zero(t *testing.T) {
+	assert.Empty(t, PasswordPolicy{}.check("password", "user", "x"))
+	assert.Equal(t, []domain.FieldViolation(nil), PasswordPolicy{}.check("password", "", ""))
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+	"yadro-microservices/internal/core/domain"
+)
+
+// reconcileBatchSize is the number of unindexed comics added to the search engine at once.
+const reconcileBatchSize = 500
+
+// Reconcile adds comics which are saved to the database but not acknowledged by the search engine,
+// e.g. because indexing failed after the comics were saved or the engine was switched.
+// It returns the number of indexed comics.
+// If the update lock is set, it fails with domain.ErrUpdateInProgress while any process holds the lock.
+func (xs *XkcdService) Reconcile(ctx context.Context) (int, error) {
+	if xs.updateLock != nil {
+		unlock, err := xs.updateLock.TryLock(ctx)
+		if err != nil {
+			return 0, err
+		}
+		defer unlock()
+	}
+
+	indexed := 0
+	for {
+		comics, err := xs.comicsRep.GetUnindexed(ctx, xs.searchEngine.IndexName(), reconcileBatchSize)
+		if err != nil {
+			return indexed, fmt.Errorf("error getting unindexed comics: %w", err)
+		}
+		if len(comics) == 0 {
+			return indexed, nil
+		}
+
+		if err = xs.indexComics(comics); err != nil {
+			return indexed, err
+		}
+		indexed += len(comics)
+
+		if len(comics) < reconcileBatchSize {
+			return indexed, nil
+		}
+	}
+}
+
+// ScheduleReconcile runs Reconcile on start and then every interval until the context is canceled.
+func (xs *XkcdService) ScheduleReconcile(ctx context.Context, interval time.Duration) {
+	go func() {
+		log.Printf("Scheduling search index reconciliation every %s...", interval)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			indexed, err := xs.Reconcile(ctx)
+			if errors.Is(err, domain.ErrUpdateInProgress) {
+				log.Println("Skipping search index reconciliation: comics are being updated")
+			} else if err != nil {
+				log.Println("error while reconciling search index:", err)
+			}
+			if indexed > 0 {
+				log.Printf("Search index reconciled: %d comics indexed", indexed)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
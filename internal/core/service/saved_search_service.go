@@ -0,0 +1,203 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+	"yadro-microservices/internal/core/domain"
+	"yadro-microservices/internal/core/port"
+)
+
+// Limits of the saved searches of a user.
+const (
+	maxSavedSearches    = 50
+	maxSavedQueryLength = 200
+	maxWebhookURLLength = 2048
+)
+
+// SavedSearchService provides methods for managing saved searches of users
+// and collects new comics matching them into the inbox of the users.
+type SavedSearchService struct {
+	processor      port.ComicProcessor
+	savedSearchRep port.SavedSearchRepository
+	matcher        port.ComicMatcher
+	notifier       port.SavedSearchNotifier
+}
+
+// NewSavedSearchService creates a new instance of saved search service.
+func NewSavedSearchService(
+	processor port.ComicProcessor,
+	savedSearchRep port.SavedSearchRepository,
+	matcher port.ComicMatcher,
+	notifier port.SavedSearchNotifier,
+) *SavedSearchService {
+	return &SavedSearchService{
+		processor:      processor,
+		savedSearchRep: savedSearchRep,
+		matcher:        matcher,
+		notifier:       notifier,
+	}
+}
+
+// Create saves the search query of the user. If the webhook URL is not empty,
+// it is notified every time new comics match the query.
+func (ss *SavedSearchService) Create(
+	ctx context.Context,
+	username, query, webhookURL string,
+) (*domain.SavedSearch, error) {
+	query = strings.TrimSpace(query)
+	if query == "" || utf8.RuneCountInString(query) > maxSavedQueryLength {
+		return nil, fmt.Errorf("query must be from 1 to %d characters: %w", maxSavedQueryLength, domain.ErrInvalidInput)
+	}
+	if webhookURL != "" {
+		if !validWebhookURL(webhookURL) {
+			return nil, fmt.Errorf("webhook URL must be an absolute http(s) URL: %w", domain.ErrInvalidInput)
+		}
+		if err := ss.notifier.CheckURL(ctx, webhookURL); err != nil {
+			return nil, err
+		}
+	}
+
+	tokens, err := ss.processor.FullProcess(query)
+	if err != nil {
+		return nil, fmt.Errorf("error processing query: %w", err)
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("query has no words to search for: %w", domain.ErrInvalidInput)
+	}
+
+	count, err := ss.savedSearchRep.CountByUsername(ctx, username)
+	if err != nil {
+		return nil, fmt.Errorf("error counting saved searches: %w", err)
+	}
+	if count >= maxSavedSearches {
+		return nil, fmt.Errorf("at most %d searches can be saved: %w", maxSavedSearches, domain.ErrInvalidInput)
+	}
+
+	s := &domain.SavedSearch{
+		Username:   username,
+		Query:      query,
+		Tokens:     tokens,
+		WebhookURL: webhookURL,
+	}
+	if err = ss.savedSearchRep.Create(ctx, s); err != nil {
+		return nil, fmt.Errorf("error saving search: %w", err)
+	}
+
+	return s, nil
+}
+
+// Get returns the saved searches of the user.
+func (ss *SavedSearchService) Get(ctx context.Context, username string) ([]*domain.SavedSearch, error) {
+	searches, err := ss.savedSearchRep.GetByUsername(ctx, username)
+	if err != nil {
+		return nil, fmt.Errorf("error getting saved searches: %w", err)
+	}
+
+	return searches, nil
+}
+
+// Delete deletes the saved search of the user together with its inbox items.
+func (ss *SavedSearchService) Delete(ctx context.Context, username string, id int64) error {
+	if err := ss.savedSearchRep.Delete(ctx, username, id); err != nil {
+		return fmt.Errorf("error deleting saved search: %w", err)
+	}
+
+	return nil
+}
+
+// GetInbox returns the latest comics matching the saved searches of the user, limited to the given number.
+func (ss *SavedSearchService) GetInbox(
+	ctx context.Context,
+	username string,
+	unreadOnly bool,
+	limit int,
+) ([]*domain.InboxItem, error) {
+	inbox, err := ss.savedSearchRep.GetInbox(ctx, username, unreadOnly, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error getting inbox: %w", err)
+	}
+
+	return inbox, nil
+}
+
+// MarkRead marks the inbox item of the user as read.
+func (ss *SavedSearchService) MarkRead(ctx context.Context, username string, id int64) error {
+	if err := ss.savedSearchRep.MarkRead(ctx, username, id, time.Now()); err != nil {
+		return fmt.Errorf("error marking inbox item as read: %w", err)
+	}
+
+	return nil
+}
+
+// ComicsAdded matches the comics added by an update against all saved searches and records the matches
+// into the inbox of their users. Notifications about the new matches are queued for the webhooks
+// of the saved searches; failures to queue them are only logged.
+func (ss *SavedSearchService) ComicsAdded(ctx context.Context, comics domain.Comics) error {
+	searches, err := ss.savedSearchRep.GetAll(ctx)
+	if err != nil {
+		return fmt.Errorf("error getting saved searches: %w", err)
+	}
+
+	var errs []error
+	matched := 0
+	for _, s := range searches {
+		ids, err := ss.matcher.Match(ctx, s.Tokens, comics)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error matching saved search %d: %w", s.ID, err))
+			continue
+		}
+		if len(ids) == 0 {
+			continue
+		}
+
+		added, err := ss.savedSearchRep.AddMatches(ctx, s, ids)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error adding matches of saved search %d: %w", s.ID, err))
+			continue
+		}
+		matched += len(added)
+
+		if s.WebhookURL != "" && len(added) > 0 {
+			ss.notify(ctx, s, added, comics)
+		}
+	}
+
+	log.Printf("Saved searches: %d new matches for %d searches", matched, len(searches))
+	return errors.Join(errs...)
+}
+
+// notify queues the notification about the new matches of the saved search for its webhook.
+func (ss *SavedSearchService) notify(ctx context.Context, s *domain.SavedSearch, ids []int, comics domain.Comics) {
+	n := &domain.SavedSearchNotification{
+		SavedSearchID: s.ID,
+		Query:         s.Query,
+		Comics:        make([]*domain.FoundComic, 0, len(ids)),
+	}
+	for _, id := range ids {
+		n.Comics = append(n.Comics, &domain.FoundComic{ID: id, URL: comics[id].Img})
+	}
+
+	if err := ss.notifier.NotifySavedSearch(ctx, s, n); err != nil {
+		log.Printf("Error notifying webhook of saved search %d: %v", s.ID, err)
+	}
+}
+
+// validWebhookURL reports whether the URL is an absolute http or https URL.
+func validWebhookURL(rawURL string) bool {
+	if len(rawURL) > maxWebhookURLLength {
+		return false
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"yadro-microservices/internal/core/domain"
+	"yadro-microservices/internal/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSavedSearchCreate(t *testing.T) {
+	ctx := context.Background()
+
+	processorMock := new(mocks.ComicProcessor)
+	savedSearchRepMock := new(mocks.SavedSearchRepository)
+	notifierMock := new(mocks.SavedSearchNotifier)
+	service := NewSavedSearchService(processorMock, savedSearchRepMock, nil, notifierMock)
+
+	notifierMock.On("CheckURL", ctx, "https://example.com/hook").Return(nil).Once()
+	processorMock.On("FullProcess", "apple doctor").Return([]string{"appl", "doctor"}, nil)
+	savedSearchRepMock.On("CountByUsername", ctx, "user").Return(2, nil)
+	savedSearchRepMock.On("Create", ctx, mock.MatchedBy(func(s *domain.SavedSearch) bool {
+		return s.Username == "user" && s.Query == "apple doctor" && s.WebhookURL == "https://example.com/hook" &&
+			assert.ObjectsAreEqual([]string{"appl", "doctor"}, s.Tokens)
+	})).Return(nil).Once()
+
+	s, err := service.Create(ctx, "user", " apple doctor ", "https://example.com/hook")
+
+	require.NoError(t, err)
+	assert.Equal(t, "apple doctor", s.Query)
+	processorMock.AssertExpectations(t)
+	savedSearchRepMock.AssertExpectations(t)
+}
+
+func TestSavedSearchCreate_InvalidInput(t *testing.T) {
+	ctx := context.Background()
+
+	processorMock := new(mocks.ComicProcessor)
+	savedSearchRepMock := new(mocks.SavedSearchRepository)
+	notifierMock := new(mocks.SavedSearchNotifier)
+	service := NewSavedSearchService(processorMock, savedSearchRepMock, nil, notifierMock)
+
+	notifierMock.On("CheckURL", ctx, "http://127.0.0.1/hook").Return(domain.ErrInvalidInput)
+	processorMock.On("FullProcess", "the").Return([]string{}, nil)
+	processorMock.On("FullProcess", "apple").Return([]string{"appl"}, nil)
+	savedSearchRepMock.On("CountByUsername", ctx, "user").Return(maxSavedSearches, nil)
+
+	tests := []struct {
+		name       string
+		query      string
+		webhookURL string
+	}{
+		{name: "empty query", query: "  "},
+		{name: "long query", query: strings.Repeat("a", maxSavedQueryLength+1)},
+		{name: "stop words only", query: "the"},
+		{name: "relative webhook", query: "apple", webhookURL: "/hook"},
+		{name: "unsupported webhook scheme", query: "apple", webhookURL: "ftp://example.com/hook"},
+		{name: "internal webhook", query: "apple", webhookURL: "http://127.0.0.1/hook"},
+		{name: "too many searches", query: "apple"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.Create(ctx, "user", tt.query, tt.webhookURL)
+			require.ErrorIs(t, err, domain.ErrInvalidInput)
+		})
+	}
+	savedSearchRepMock.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestSavedSearchComicsAdded(t *testing.T) {
+	ctx := context.Background()
+
+	savedSearchRepMock := new(mocks.SavedSearchRepository)
+	matcherMock := new(mocks.ComicMatcher)
+	notifierMock := new(mocks.SavedSearchNotifier)
+	service := NewSavedSearchService(nil, savedSearchRepMock, matcherMock, notifierMock)
+
+	comics := domain.Comics{
+		3: {Img: "https://example.com/3.png", Keywords: []string{"appl"}},
+		4: {Img: "https://example.com/4.png", Keywords: []string{"appl", "doctor"}},
+	}
+	hooked := &domain.SavedSearch{ID: 1, Username: "user", Query: "apple", Tokens: []string{"appl"},
+		WebhookURL: "https://example.com/hook"}
+	quiet := &domain.SavedSearch{ID: 2, Username: "other", Query: "doctor", Tokens: []string{"doctor"}}
+	missing := &domain.SavedSearch{ID: 3, Username: "other", Query: "python", Tokens: []string{"python"}}
+
+	savedSearchRepMock.On("GetAll", ctx).Return([]*domain.SavedSearch{hooked, quiet, missing}, nil)
+	matcherMock.On("Match", ctx, hooked.Tokens, comics).Return([]int{4, 3}, nil)
+	matcherMock.On("Match", ctx, quiet.Tokens, comics).Return([]int{4}, nil)
+	matcherMock.On("Match", ctx, missing.Tokens, comics).Return([]int{}, nil)
+	// Comic 3 already matched the search, e.g. by an earlier update, so only comic 4 is new
+	savedSearchRepMock.On("AddMatches", ctx, hooked, []int{4, 3}).Return([]int{4}, nil).Once()
+	savedSearchRepMock.On("AddMatches", ctx, quiet, []int{4}).Return(nil, errors.New("db is down")).Once()
+	notifierMock.On("NotifySavedSearch", ctx, hooked, &domain.SavedSearchNotification{
+		SavedSearchID: 1,
+		Query:         "apple",
+		Comics:        []*domain.FoundComic{{ID: 4, URL: "https://example.com/4.png"}},
+	}).Return(errors.New("webhook queue is down")).Once()
+
+	err := service.ComicsAdded(ctx, comics)
+
+	// Failed notifications are only logged, while failed matches are reported
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "saved search 2")
+	assert.NotContains(t, err.Error(), "webhook")
+	savedSearchRepMock.AssertExpectations(t)
+	matcherMock.AssertExpectations(t)
+	notifierMock.AssertExpectations(t)
+}
+
+func TestSavedSearchMarkRead(t *testing.T) {
+	ctx := context.Background()
+
+	savedSearchRepMock := new(mocks.SavedSearchRepository)
+	service := NewSavedSearchService(nil, savedSearchRepMock, nil, nil)
+
+	savedSearchRepMock.On("MarkRead", ctx, "user", int64(7), mock.AnythingOfType("time.Time")).Return(nil).Once()
+	savedSearchRepMock.On("MarkRead", ctx, "other", int64(7), mock.Anything).Return(domain.ErrNotFound).Once()
+
+	require.NoError(t, service.MarkRead(ctx, "user", 7))
+	require.ErrorIs(t, service.MarkRead(ctx, "other", 7), domain.ErrNotFound)
+	savedSearchRepMock.AssertExpectations(t)
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+	"yadro-microservices/internal/core/domain"
+)
+
+// maxUpdateJobs is the number of the most recent update jobs kept for status requests.
+const maxUpdateJobs = 100
+
+// GetUpdateJob returns the update job with the given ID.
+func (xs *XkcdService) GetUpdateJob(_ context.Context, id int) (*domain.UpdateJob, error) {
+	xs.jobsMu.Lock()
+	defer xs.jobsMu.Unlock()
+
+	job, ok := xs.jobs[id]
+	if !ok {
+		return nil, fmt.Errorf("update job %d: %w", id, domain.ErrNotFound)
+	}
+
+	return job.Copy(), nil
+}
+
+// beginUpdate registers a new running update job. Only one update can run at a time,
+// so if another job is running, a copy of it is returned together with domain.ErrUpdateInProgress.
+// If the update lock is set, it is acquired as well, so that updates of other processes are excluded too;
+// in that case domain.ErrUpdateInProgress is returned without a job.
+func (xs *XkcdService) beginUpdate(ctx context.Context) (*domain.UpdateJob, error) {
+	xs.jobsMu.Lock()
+	defer xs.jobsMu.Unlock()
+
+	if xs.runningJob != nil {
+		return xs.runningJob.Copy(), domain.ErrUpdateInProgress
+	}
+
+	if xs.updateLock != nil {
+		unlock, err := xs.updateLock.TryLock(ctx)
+		if err != nil {
+			return nil, err
+		}
+		xs.unlockUpdate = unlock
+	}
+
+	xs.lastJobID++
+	job := &domain.UpdateJob{
+		ID:        xs.lastJobID,
+		Status:    domain.UpdateRunning,
+		StartedAt: time.Now(),
+	}
+	xs.jobs[job.ID] = job
+	xs.runningJob = job
+	delete(xs.jobs, job.ID-maxUpdateJobs)
+
+	log.Printf("Update job %d started", job.ID)
+	return job.Copy(), nil
+}
+
+// updateJob applies the change to the update job with the given ID.
+func (xs *XkcdService) updateJob(id int, change func(job *domain.UpdateJob)) {
+	xs.jobsMu.Lock()
+	defer xs.jobsMu.Unlock()
+
+	if job, ok := xs.jobs[id]; ok {
+		change(job)
+	}
+}
+
+// finishUpdate marks the update job as finished with the given error, allows the next update to start
+// and notifies the update listeners.
+func (xs *XkcdService) finishUpdate(ctx context.Context, id int, err error) {
+	finishedAt := time.Now()
+	if err == nil {
+		if stateErr := xs.stateRep.SetLastSuccess(ctx, finishedAt); stateErr != nil {
+			log.Println("Error saving last successful update time:", stateErr)
+		}
+	}
+
+	total, totalErr := xs.comicsRep.GetTotalComics(ctx)
+	if totalErr != nil {
+		log.Println("Error getting total number of comics:", totalErr)
+	}
+
+	job := xs.completeJob(id, finishedAt, total, err)
+	if job == nil {
+		return
+	}
+
+	log.Printf("Update job %d %s: %d new comics, %d total comics", job.ID, job.Status, job.Indexed, job.Total)
+	for _, listener := range xs.updateListeners {
+		listener.UpdateFinished(ctx, job)
+	}
+}
+
+// finishImport marks the import job as finished with the given error and allows the next update to start.
+// Unlike an update, an import does not count as a successful update and does not notify the update listeners.
+func (xs *XkcdService) finishImport(ctx context.Context, id int, err error) {
+	total, totalErr := xs.comicsRep.GetTotalComics(ctx)
+	if totalErr != nil {
+		log.Println("Error getting total number of comics:", totalErr)
+	}
+
+	if job := xs.completeJob(id, time.Now(), total, err); job != nil {
+		log.Printf("Import job %d %s: %d new comics, %d total comics", job.ID, job.Status, job.Indexed, job.Total)
+	}
+}
+
+// completeJob marks the job as finished and returns its copy, or nil if the job is unknown.
+func (xs *XkcdService) completeJob(id int, finishedAt time.Time, total int, err error) *domain.UpdateJob {
+	xs.jobsMu.Lock()
+	defer xs.jobsMu.Unlock()
+
+	if xs.runningJob != nil && xs.runningJob.ID == id {
+		xs.runningJob = nil
+		if xs.unlockUpdate != nil {
+			xs.unlockUpdate()
+			xs.unlockUpdate = nil
+		}
+	}
+
+	job, ok := xs.jobs[id]
+	if !ok {
+		return nil
+	}
+
+	job.FinishedAt = &finishedAt
+	job.Total = total
+	job.Status = domain.UpdateSucceeded
+	if err != nil {
+		job.Status = domain.UpdateFailed
+		job.Errors = append(job.Errors, err.Error())
+	}
+
+	return job.Copy()
+}
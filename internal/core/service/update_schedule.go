@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/robfig/cron/v3"
+	"log"
+	"time"
+	"yadro-microservices/internal/core/domain"
+)
+
+// ScheduleUpdate schedules comics updates according to the cron expression of the schedule.
+// Depending on the schedule, an update is also run on start or when a scheduled run was missed
+// since the last successful update, e.g. while the server was down.
+func (xs *XkcdService) ScheduleUpdate(ctx context.Context, schedule *domain.UpdateSchedule) error {
+	spec, err := cron.ParseStandard(schedule.Cron)
+	if err != nil {
+		return fmt.Errorf("error parsing cron expression %q: %w", schedule.Cron, err)
+	}
+
+	loc := schedule.Location
+	if loc == nil {
+		loc = time.Local
+	}
+
+	xs.scheduleMu.Lock()
+	xs.schedule = schedule
+	xs.scheduleMu.Unlock()
+
+	go func() {
+		log.Printf("Scheduling comics update with %q in %s...", schedule.Cron, loc)
+		if schedule.RunOnStartup {
+			log.Println("Comics update on startup started...")
+			xs.scheduledUpdate(ctx)
+		} else if schedule.CatchUp && xs.missedRun(ctx, spec, loc) {
+			log.Println("Scheduled comics update was missed, catching up...")
+			xs.scheduledUpdate(ctx)
+		}
+
+		for {
+			next := spec.Next(time.Now().In(loc))
+			xs.scheduleMu.Lock()
+			xs.nextRun = next
+			xs.scheduleMu.Unlock()
+
+			// Wait until the next update time
+			timer := time.NewTimer(time.Until(next))
+			select {
+			case <-ctx.Done():
+				// If the context is canceled, stop the scheduling
+				timer.Stop()
+				return
+			case <-timer.C:
+				log.Println("Scheduled comics update started...")
+				xs.scheduledUpdate(ctx)
+			}
+		}
+	}()
+
+	return nil
+}
+
+// GetScheduleStatus returns the update schedule together with the last and the next update times.
+func (xs *XkcdService) GetScheduleStatus(ctx context.Context) (*domain.UpdateScheduleStatus, error) {
+	status := &domain.UpdateScheduleStatus{}
+
+	xs.scheduleMu.Lock()
+	if xs.schedule != nil {
+		status.Cron = xs.schedule.Cron
+		status.Timezone = time.Local.String()
+		if xs.schedule.Location != nil {
+			status.Timezone = xs.schedule.Location.String()
+		}
+		status.RunOnStartup = xs.schedule.RunOnStartup
+		status.CatchUp = xs.schedule.CatchUp
+	}
+	if !xs.nextRun.IsZero() {
+		nextRun := xs.nextRun
+		status.NextRun = &nextRun
+	}
+	xs.scheduleMu.Unlock()
+
+	xs.jobsMu.Lock()
+	if xs.runningJob != nil {
+		status.RunningJobID = xs.runningJob.ID
+	}
+	xs.jobsMu.Unlock()
+
+	lastSuccess, err := xs.stateRep.GetLastSuccess(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error getting last successful update time: %w", err)
+	}
+	if !lastSuccess.IsZero() {
+		status.LastSuccess = &lastSuccess
+	}
+
+	return status, nil
+}
+
+// missedRun checks whether a scheduled run should have happened since the last successful update.
+// If comics have never been updated successfully, no run is considered missed: the first update
+// runs on schedule, or on start with RunOnStartup.
+func (xs *XkcdService) missedRun(ctx context.Context, spec cron.Schedule, loc *time.Location) bool {
+	lastSuccess, err := xs.stateRep.GetLastSuccess(ctx)
+	if err != nil {
+		log.Println("Error getting last successful update time:", err)
+		return false
+	}
+
+	if lastSuccess.IsZero() {
+		return false
+	}
+
+	return spec.Next(lastSuccess.In(loc)).Before(time.Now())
+}
+
+// scheduledUpdate runs the comics update unless another one is in progress.
+func (xs *XkcdService) scheduledUpdate(ctx context.Context) {
+	err := xs.UpdateComics(ctx)
+	if errors.Is(err, domain.ErrUpdateInProgress) {
+		log.Println("Skipping scheduled comics update: another update is in progress")
+		return
+	}
+	if err != nil {
+		log.Println("error while scheduled updating comics:", err)
+	}
+}
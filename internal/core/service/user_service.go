@@ -0,0 +1,40 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"yadro-microservices/internal/core/domain"
+	"yadro-microservices/internal/core/port"
+)
+
+// UserService provides methods for deleting users together with the data the xkcd server keeps for them.
+type UserService struct {
+	authClient  port.AuthClient
+	userDataRep port.UserDataRepository
+}
+
+// NewUserService creates a new instance of user service.
+func NewUserService(authClient port.AuthClient, userDataRep port.UserDataRepository) *UserService {
+	return &UserService{authClient: authClient, userDataRep: userDataRep}
+}
+
+// DeleteUser deletes the user from the auth server, then their favorites, collections, search history
+// and saved searches, so a user registered later under the same name does not get them.
+// The data is deleted even if the user is already gone, so a deletion that failed halfway can be repeated.
+func (us *UserService) DeleteUser(ctx context.Context, username string) error {
+	err := us.authClient.DeleteUser(ctx, username)
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return fmt.Errorf("error deleting user: %w", err)
+	}
+
+	if dataErr := us.userDataRep.DeleteUserData(context.WithoutCancel(ctx), username); dataErr != nil {
+		return fmt.Errorf("error deleting user data: %w", dataErr)
+	}
+
+	if err != nil {
+		return fmt.Errorf("error deleting user: %w", err)
+	}
+
+	return nil
+}
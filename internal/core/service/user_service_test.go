@@ -0,0 +1,44 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"yadro-microservices/internal/core/domain"
+	"yadro-microservices/internal/mocks"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserService_DeleteUser(t *testing.T) {
+	authClient := new(mocks.AuthClient)
+	authClient.On("DeleteUser", mock.Anything, "user1").Return(nil).Once()
+	authClient.On("DeleteUser", mock.Anything, "gone").Return(domain.ErrNotFound).Once()
+	authClient.On("DeleteUser", mock.Anything, "admin").Return(domain.ErrForbidden).Once()
+	userDataRep := new(mocks.UserDataRepository)
+	userDataRep.On("DeleteUserData", mock.Anything, "user1").Return(nil).Once()
+	// The data of a user deleted before is still deleted, so a failed deletion can be repeated
+	userDataRep.On("DeleteUserData", mock.Anything, "gone").Return(nil).Once()
+
+	userService := NewUserService(authClient, userDataRep)
+
+	require.NoError(t, userService.DeleteUser(context.Background(), "user1"))
+	require.ErrorIs(t, userService.DeleteUser(context.Background(), "gone"), domain.ErrNotFound)
+	require.ErrorIs(t, userService.DeleteUser(context.Background(), "admin"), domain.ErrForbidden)
+	authClient.AssertExpectations(t)
+	userDataRep.AssertExpectations(t)
+}
+
+func TestUserService_DeleteUserDataFailed(t *testing.T) {
+	authClient := new(mocks.AuthClient)
+	authClient.On("DeleteUser", mock.Anything, "user1").Return(nil).Once()
+	userDataRep := new(mocks.UserDataRepository)
+	userDataRep.On("DeleteUserData", mock.Anything, "user1").Return(errors.New("db down")).Once()
+
+	userService := NewUserService(authClient, userDataRep)
+
+	require.Error(t, userService.DeleteUser(context.Background(), "user1"))
+	authClient.AssertExpectations(t)
+	userDataRep.AssertExpectations(t)
+}
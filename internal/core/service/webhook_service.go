@@ -0,0 +1,377 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+	"yadro-microservices/internal/core/domain"
+	"yadro-microservices/internal/core/port"
+)
+
+// Limits of the webhook deliveries.
+const (
+	maxConcurrentDeliveries = 8
+	webhookSecretBytes      = 32
+	eventIDBytes            = 16
+	// deliveryPollInterval is how often idle workers look for deliveries queued by other replicas or due retries
+	deliveryPollInterval = time.Second
+	// deliveryLease is the time after which a claimed delivery is attempted again if its worker stopped
+	deliveryLease = time.Minute
+)
+
+// WebhookService provides methods for managing webhooks and delivers comics ingestion events to them.
+// It also delivers notifications of saved searches to their webhooks. Deliveries are queued in the repository,
+// so they survive restarts, and attempted by a fixed number of workers. Every attempt is logged;
+// failed attempts are retried with exponential backoff.
+type WebhookService struct {
+	webhookRep  port.WebhookRepository
+	sender      port.WebhookSender
+	maxAttempts int
+	backoff     time.Duration
+
+	wake chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewWebhookService creates a new instance of webhook service. A failed delivery is attempted
+// at most maxAttempts times; the delay before the n-th retry is backoff * 2^(n-1).
+func NewWebhookService(
+	webhookRep port.WebhookRepository,
+	sender port.WebhookSender,
+	maxAttempts int,
+	backoff time.Duration,
+) *WebhookService {
+	return &WebhookService{
+		webhookRep:  webhookRep,
+		sender:      sender,
+		maxAttempts: max(maxAttempts, 1),
+		backoff:     backoff,
+		wake:        make(chan struct{}, 1),
+	}
+}
+
+// Create registers the webhook for the given event types. The returned webhook contains
+// the generated secret signing its deliveries; it is not shown afterwards.
+func (ws *WebhookService) Create(ctx context.Context, url string, events []domain.EventType) (*domain.Webhook, error) {
+	if err := ws.CheckURL(ctx, url); err != nil {
+		return nil, err
+	}
+	if len(events) == 0 {
+		return nil, fmt.Errorf("at least one event type is required: %w", domain.ErrInvalidInput)
+	}
+
+	seen := make(map[domain.EventType]bool, len(events))
+	unique := make([]domain.EventType, 0, len(events))
+	for _, e := range events {
+		if !e.Valid() {
+			return nil, fmt.Errorf("unknown event type %q: %w", e, domain.ErrInvalidInput)
+		}
+		if !seen[e] {
+			seen[e] = true
+			unique = append(unique, e)
+		}
+	}
+
+	secret, err := randomHex(webhookSecretBytes)
+	if err != nil {
+		return nil, fmt.Errorf("error generating webhook secret: %w", err)
+	}
+
+	w := &domain.Webhook{URL: url, Events: unique, Secret: secret}
+	if err = ws.webhookRep.Create(ctx, w); err != nil {
+		return nil, fmt.Errorf("error saving webhook: %w", err)
+	}
+
+	return w, nil
+}
+
+// CheckURL returns an error wrapping ErrInvalidInput if the URL is not an absolute http(s) URL
+// or events cannot be delivered to it, e.g. because it points to an internal address.
+func (ws *WebhookService) CheckURL(ctx context.Context, url string) error {
+	if !validWebhookURL(url) {
+		return fmt.Errorf("webhook URL must be an absolute http(s) URL: %w", domain.ErrInvalidInput)
+	}
+	if err := ws.sender.CheckURL(ctx, url); err != nil {
+		return fmt.Errorf("webhook URL is not allowed: %v: %w", err, domain.ErrInvalidInput)
+	}
+
+	return nil
+}
+
+// List returns all webhooks without their secrets.
+func (ws *WebhookService) List(ctx context.Context) ([]*domain.Webhook, error) {
+	webhooks, err := ws.webhookRep.GetAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error getting webhooks: %w", err)
+	}
+
+	for _, w := range webhooks {
+		w.Secret = ""
+	}
+
+	return webhooks, nil
+}
+
+// Delete deletes the webhook together with its queued and logged deliveries.
+func (ws *WebhookService) Delete(ctx context.Context, id int64) error {
+	if err := ws.webhookRep.Delete(ctx, id); err != nil {
+		return fmt.Errorf("error deleting webhook: %w", err)
+	}
+
+	return nil
+}
+
+// GetDeliveries returns the latest delivery attempts of the webhook, limited to the given number.
+func (ws *WebhookService) GetDeliveries(
+	ctx context.Context,
+	webhookID int64,
+	limit int,
+) ([]*domain.WebhookDelivery, error) {
+	deliveries, err := ws.webhookRep.GetDeliveries(ctx, webhookID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error getting webhook deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// ComicsAdded publishes a single comic.added event with all comics added by an update.
+func (ws *WebhookService) ComicsAdded(ctx context.Context, comics domain.Comics) error {
+	if len(comics) == 0 {
+		return nil
+	}
+
+	ids := make([]int, 0, len(comics))
+	for id := range comics {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	data := &domain.AddedComics{Comics: make([]*domain.AddedComic, 0, len(ids))}
+	for _, id := range ids {
+		c := comics[id]
+		data.Comics = append(data.Comics, &domain.AddedComic{ID: id, Source: c.Source, Title: c.Title, URL: c.Img})
+	}
+
+	e, err := newEvent(domain.EventComicAdded, data)
+	if err != nil {
+		return err
+	}
+
+	return ws.Publish(ctx, e)
+}
+
+// UpdateFinished publishes an update.completed or update.failed event with the finished job.
+func (ws *WebhookService) UpdateFinished(ctx context.Context, job *domain.UpdateJob) {
+	eventType := domain.EventUpdateCompleted
+	if job.Status == domain.UpdateFailed {
+		eventType = domain.EventUpdateFailed
+	}
+
+	e, err := newEvent(eventType, job)
+	if err == nil {
+		err = ws.Publish(ctx, e)
+	}
+	if err != nil {
+		log.Printf("Error publishing %s event of update job %d: %v", eventType, job.ID, err)
+	}
+}
+
+// Publish queues the events for the webhooks subscribed to their types.
+func (ws *WebhookService) Publish(ctx context.Context, events ...*domain.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	webhooks, err := ws.webhookRep.GetAll(ctx)
+	if err != nil {
+		return fmt.Errorf("error getting webhooks: %w", err)
+	}
+
+	now := time.Now()
+	var deliveries []*domain.PendingDelivery
+	for _, w := range webhooks {
+		for _, e := range events {
+			if !subscribed(w, e.Type) {
+				continue
+			}
+
+			deliveries = append(deliveries, &domain.PendingDelivery{
+				WebhookID:     w.ID,
+				EventID:       e.ID,
+				EventType:     e.Type,
+				Event:         e,
+				Attempt:       1,
+				NextAttemptAt: now,
+			})
+		}
+	}
+
+	return ws.enqueue(ctx, deliveries)
+}
+
+// NotifySavedSearch queues the notification about new matches of the saved search for its webhook.
+func (ws *WebhookService) NotifySavedSearch(
+	ctx context.Context,
+	s *domain.SavedSearch,
+	n *domain.SavedSearchNotification,
+) error {
+	id, err := randomHex(eventIDBytes)
+	if err != nil {
+		return fmt.Errorf("error generating event ID: %w", err)
+	}
+
+	return ws.enqueue(ctx, []*domain.PendingDelivery{{
+		SavedSearchID: s.ID,
+		EventID:       id,
+		EventType:     domain.EventSavedSearchMatched,
+		Notification:  n,
+		Attempt:       1,
+		NextAttemptAt: time.Now(),
+	}})
+}
+
+// RunDeliveries starts the workers attempting the queued deliveries until the context is canceled.
+// Wait blocks until the workers have finished their current attempts.
+func (ws *WebhookService) RunDeliveries(ctx context.Context) {
+	log.Printf("Delivering webhooks with %d workers...", maxConcurrentDeliveries)
+	for range maxConcurrentDeliveries {
+		ws.wg.Add(1)
+		go func() {
+			defer ws.wg.Done()
+			ws.work(ctx)
+		}()
+	}
+}
+
+// Wait blocks until the delivery workers have stopped.
+func (ws *WebhookService) Wait() {
+	ws.wg.Wait()
+}
+
+// enqueue queues the deliveries and wakes up a worker.
+func (ws *WebhookService) enqueue(ctx context.Context, deliveries []*domain.PendingDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	if err := ws.webhookRep.Enqueue(ctx, deliveries); err != nil {
+		return fmt.Errorf("error queueing webhook deliveries: %w", err)
+	}
+
+	select {
+	case ws.wake <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// work attempts the due deliveries one by one, waiting for new ones when none is due.
+func (ws *WebhookService) work(ctx context.Context) {
+	for ctx.Err() == nil {
+		attempted, err := ws.deliverDue(ctx)
+		if err != nil {
+			log.Println("Error delivering webhooks:", err)
+		}
+		if attempted {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-ws.wake:
+		case <-time.After(deliveryPollInterval):
+		}
+	}
+}
+
+// deliverDue claims a due delivery and attempts it. It reports whether a delivery was attempted.
+func (ws *WebhookService) deliverDue(ctx context.Context) (bool, error) {
+	due, err := ws.webhookRep.ClaimDue(ctx, time.Now(), deliveryLease, 1)
+	if err != nil {
+		return false, fmt.Errorf("error claiming webhook deliveries: %w", err)
+	}
+	if len(due) == 0 {
+		return false, nil
+	}
+
+	// A started attempt is finished even on shutdown, so it is not made twice
+	ws.attempt(context.WithoutCancel(ctx), due[0])
+	return true, nil
+}
+
+// attempt sends the queued event or notification once and logs the attempt.
+// If the attempt failed, the next one is queued with exponential backoff until the attempts are exhausted.
+func (ws *WebhookService) attempt(ctx context.Context, p *domain.PendingDelivery) {
+	start := time.Now()
+	var status int
+	var err error
+	if p.Event != nil {
+		status, err = ws.sender.Send(ctx, p.URL, p.Secret, p.Event)
+	} else {
+		status, err = ws.sender.Notify(ctx, p.URL, p.Notification)
+	}
+	latency := time.Since(start)
+
+	d := &domain.WebhookDelivery{
+		ID:         p.ID,
+		WebhookID:  p.WebhookID,
+		EventID:    p.EventID,
+		EventType:  p.EventType,
+		Attempt:    p.Attempt,
+		StatusCode: status,
+		LatencyMs:  float64(latency.Microseconds()) / 1000,
+		CreatedAt:  start,
+	}
+
+	var retryAt time.Time
+	if err != nil {
+		d.Error = err.Error()
+		if p.Attempt < ws.maxAttempts {
+			retryAt = time.Now().Add(ws.backoff << (p.Attempt - 1))
+		} else {
+			log.Printf("Giving up delivering event %s after %d attempts", p.EventID, p.Attempt)
+		}
+	}
+
+	if err = ws.webhookRep.CompleteDelivery(ctx, d, retryAt); err != nil {
+		log.Println("Error logging webhook delivery:", err)
+	}
+}
+
+// subscribed reports whether the webhook is subscribed to the event type.
+func subscribed(w *domain.Webhook, eventType domain.EventType) bool {
+	for _, t := range w.Events {
+		if t == eventType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// newEvent creates an event of the given type with a random ID.
+func newEvent(eventType domain.EventType, data any) (*domain.Event, error) {
+	id, err := randomHex(eventIDBytes)
+	if err != nil {
+		return nil, fmt.Errorf("error generating event ID: %w", err)
+	}
+
+	return &domain.Event{ID: id, Type: eventType, CreatedAt: time.Now(), Data: data}, nil
+}
+
+// randomHex returns n random bytes encoded as hex.
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,205 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"yadro-microservices/internal/core/domain"
+	"yadro-microservices/internal/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookCreate(t *testing.T) {
+	ctx := context.Background()
+
+	webhookRepMock := new(mocks.WebhookRepository)
+	senderMock := new(mocks.WebhookSender)
+	service := NewWebhookService(webhookRepMock, senderMock, 1, 0)
+
+	senderMock.On("CheckURL", ctx, "https://example.com/hook").Return(nil).Once()
+	webhookRepMock.On("Create", ctx, mock.MatchedBy(func(w *domain.Webhook) bool {
+		return w.URL == "https://example.com/hook" && len(w.Secret) == 2*webhookSecretBytes &&
+			assert.ObjectsAreEqual([]domain.EventType{domain.EventComicAdded, domain.EventUpdateFailed}, w.Events)
+	})).Return(nil).Once()
+
+	events := []domain.EventType{domain.EventComicAdded, domain.EventUpdateFailed, domain.EventComicAdded}
+	w, err := service.Create(ctx, "https://example.com/hook", events)
+
+	require.NoError(t, err)
+	assert.NotEmpty(t, w.Secret)
+	webhookRepMock.AssertExpectations(t)
+}
+
+func TestWebhookCreate_InvalidInput(t *testing.T) {
+	senderMock := new(mocks.WebhookSender)
+	service := NewWebhookService(new(mocks.WebhookRepository), senderMock, 1, 0)
+
+	senderMock.On("CheckURL", mock.Anything, "https://example.com/hook").Return(nil)
+	senderMock.On("CheckURL", mock.Anything, "http://10.0.0.1/hook").
+		Return(errors.New("host 10.0.0.1 resolves to non-public address 10.0.0.1"))
+
+	tests := []struct {
+		name   string
+		url    string
+		events []domain.EventType
+	}{
+		{name: "relative URL", url: "/hook", events: []domain.EventType{domain.EventComicAdded}},
+		{name: "private URL", url: "http://10.0.0.1/hook", events: []domain.EventType{domain.EventComicAdded}},
+		{name: "no events", url: "https://example.com/hook"},
+		{name: "unknown event", url: "https://example.com/hook", events: []domain.EventType{"comic.removed"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.Create(context.Background(), tt.url, tt.events)
+			assert.ErrorIs(t, err, domain.ErrInvalidInput)
+		})
+	}
+}
+
+func TestWebhookList_HidesSecrets(t *testing.T) {
+	ctx := context.Background()
+
+	webhookRepMock := new(mocks.WebhookRepository)
+	service := NewWebhookService(webhookRepMock, nil, 1, 0)
+
+	webhookRepMock.On("GetAll", ctx).Return([]*domain.Webhook{{ID: 1, Secret: "secret"}}, nil)
+
+	webhooks, err := service.List(ctx)
+
+	require.NoError(t, err)
+	require.Len(t, webhooks, 1)
+	assert.Empty(t, webhooks[0].Secret)
+}
+
+func TestWebhookComicsAdded_QueuesOneEventPerWebhook(t *testing.T) {
+	ctx := context.Background()
+
+	webhookRepMock := new(mocks.WebhookRepository)
+	service := NewWebhookService(webhookRepMock, nil, 3, time.Millisecond)
+
+	webhookRepMock.On("GetAll", ctx).Return([]*domain.Webhook{
+		{ID: 1, URL: "https://a.example.com", Secret: "a", Events: []domain.EventType{domain.EventComicAdded}},
+		{ID: 2, URL: "https://b.example.com", Secret: "b", Events: []domain.EventType{domain.EventUpdateFailed}},
+	}, nil)
+	webhookRepMock.On("Enqueue", ctx, mock.MatchedBy(func(deliveries []*domain.PendingDelivery) bool {
+		if len(deliveries) != 1 {
+			return false
+		}
+		d := deliveries[0]
+		data, ok := d.Event.Data.(*domain.AddedComics)
+		return d.WebhookID == 1 && d.Attempt == 1 && d.EventType == domain.EventComicAdded && ok &&
+			len(data.Comics) == 2 && data.Comics[0].ID == 4 && data.Comics[1].URL == "https://example.com/5.png"
+	})).Return(nil).Once()
+
+	err := service.ComicsAdded(ctx, domain.Comics{
+		5: {Title: "Five", Img: "https://example.com/5.png"},
+		4: {Title: "Four", Img: "https://example.com/4.png"},
+	})
+
+	require.NoError(t, err)
+	webhookRepMock.AssertExpectations(t)
+}
+
+func TestWebhookNotifySavedSearch_Queues(t *testing.T) {
+	ctx := context.Background()
+
+	webhookRepMock := new(mocks.WebhookRepository)
+	service := NewWebhookService(webhookRepMock, nil, 3, time.Millisecond)
+
+	s := &domain.SavedSearch{ID: 7, Query: "apple", WebhookURL: "https://example.com/hook"}
+	n := &domain.SavedSearchNotification{SavedSearchID: 7, Query: "apple"}
+	webhookRepMock.On("Enqueue", ctx, mock.MatchedBy(func(deliveries []*domain.PendingDelivery) bool {
+		return len(deliveries) == 1 && deliveries[0].SavedSearchID == 7 && deliveries[0].Notification == n &&
+			deliveries[0].EventType == domain.EventSavedSearchMatched && deliveries[0].EventID != ""
+	})).Return(nil).Once()
+
+	require.NoError(t, service.NotifySavedSearch(ctx, s, n))
+	webhookRepMock.AssertExpectations(t)
+}
+
+func TestWebhookDeliverDue_QueuesRetry(t *testing.T) {
+	ctx := context.Background()
+
+	webhookRepMock := new(mocks.WebhookRepository)
+	senderMock := new(mocks.WebhookSender)
+	service := NewWebhookService(webhookRepMock, senderMock, 3, time.Hour)
+
+	e := &domain.Event{ID: "evt", Type: domain.EventUpdateFailed}
+	webhookRepMock.On("ClaimDue", mock.Anything, mock.Anything, deliveryLease, 1).Return([]*domain.PendingDelivery{
+		{ID: 10, WebhookID: 1, URL: "https://a.example.com", Secret: "a", EventID: "evt", Event: e, Attempt: 2},
+	}, nil).Once()
+	senderMock.On("Send", mock.Anything, "https://a.example.com", "a", e).
+		Return(503, errors.New("unexpected status code: 503")).Once()
+	start := time.Now()
+	webhookRepMock.On("CompleteDelivery", mock.Anything, mock.MatchedBy(func(d *domain.WebhookDelivery) bool {
+		return d.ID == 10 && d.Attempt == 2 && d.StatusCode == 503 && d.Error != ""
+	}), mock.MatchedBy(func(retryAt time.Time) bool {
+		// The delay doubles with every attempt
+		return !retryAt.Before(start.Add(2 * time.Hour))
+	})).Return(nil).Once()
+
+	attempted, err := service.deliverDue(ctx)
+
+	require.NoError(t, err)
+	assert.True(t, attempted)
+	webhookRepMock.AssertExpectations(t)
+	senderMock.AssertExpectations(t)
+}
+
+func TestWebhookDeliverDue_GivesUpAfterMaxAttempts(t *testing.T) {
+	ctx := context.Background()
+
+	webhookRepMock := new(mocks.WebhookRepository)
+	senderMock := new(mocks.WebhookSender)
+	service := NewWebhookService(webhookRepMock, senderMock, 2, time.Millisecond)
+
+	n := &domain.SavedSearchNotification{SavedSearchID: 7}
+	webhookRepMock.On("ClaimDue", mock.Anything, mock.Anything, deliveryLease, 1).Return([]*domain.PendingDelivery{
+		{ID: 11, SavedSearchID: 7, URL: "https://example.com/hook", Notification: n, Attempt: 2},
+	}, nil).Once()
+	senderMock.On("Notify", mock.Anything, "https://example.com/hook", n).
+		Return(0, errors.New("connection refused")).Once()
+	webhookRepMock.On("CompleteDelivery", mock.Anything, mock.MatchedBy(func(d *domain.WebhookDelivery) bool {
+		return d.ID == 11 && d.StatusCode == 0 && d.Error == "connection refused"
+	}), time.Time{}).Return(nil).Once()
+
+	attempted, err := service.deliverDue(ctx)
+
+	require.NoError(t, err)
+	assert.True(t, attempted)
+	webhookRepMock.AssertExpectations(t)
+	senderMock.AssertExpectations(t)
+}
+
+func TestWebhookRunDeliveries_StopsOnShutdown(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	webhookRepMock := new(mocks.WebhookRepository)
+	senderMock := new(mocks.WebhookSender)
+	service := NewWebhookService(webhookRepMock, senderMock, 1, time.Millisecond)
+
+	e := &domain.Event{ID: "evt", Type: domain.EventUpdateCompleted}
+	webhookRepMock.On("ClaimDue", mock.Anything, mock.Anything, deliveryLease, 1).Return([]*domain.PendingDelivery{
+		{ID: 12, WebhookID: 1, URL: "https://a.example.com", EventID: "evt", Event: e, Attempt: 1},
+	}, nil).Once()
+	webhookRepMock.On("ClaimDue", mock.Anything, mock.Anything, deliveryLease, 1).
+		Return([]*domain.PendingDelivery{}, nil)
+	delivered := make(chan struct{})
+	senderMock.On("Send", mock.Anything, "https://a.example.com", "", e).Return(204, nil).Once()
+	webhookRepMock.On("CompleteDelivery", mock.Anything, mock.MatchedBy(func(d *domain.WebhookDelivery) bool {
+		return d.ID == 12 && d.StatusCode == 204
+	}), time.Time{}).Run(func(mock.Arguments) { close(delivered) }).Return(nil).Once()
+
+	service.RunDeliveries(ctx)
+	<-delivered
+	cancel()
+	service.Wait()
+
+	webhookRepMock.AssertExpectations(t)
+	senderMock.AssertExpectations(t)
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"sync"
 	"time"
+	"yadro-microservices/internal/core/domain"
 	"yadro-microservices/internal/core/port"
 )
 
@@ -14,6 +16,27 @@ type XkcdService struct {
 	comicsRep    port.ComicRepository
 	processor    port.ComicProcessor
 	searchEngine port.SearchEngine
+	stateRep     port.UpdateStateRepository
+	queryRep     port.SearchQueryRepository
+	clickRep     port.ClickRepository
+	historyRep   port.SearchHistoryRepository
+
+	jobsMu     sync.Mutex
+	jobs       map[int]*domain.UpdateJob
+	lastJobID  int
+	runningJob *domain.UpdateJob
+
+	updateLock   port.UpdateLock
+	unlockUpdate func()
+
+	scheduleMu sync.Mutex
+	schedule   *domain.UpdateSchedule
+	nextRun    time.Time
+
+	indexMu sync.Mutex
+
+	listeners       []port.ComicsListener
+	updateListeners []port.UpdateListener
 }
 
 // NewXkcdService creates a new instance of XKCD service.
@@ -22,101 +45,266 @@ func NewXkcdService(
 	comicsRep port.ComicRepository,
 	processor port.ComicProcessor,
 	searchEngine port.SearchEngine,
+	stateRep port.UpdateStateRepository,
+	queryRep port.SearchQueryRepository,
+	clickRep port.ClickRepository,
+	historyRep port.SearchHistoryRepository,
 ) *XkcdService {
 	return &XkcdService{
 		client:       client,
 		comicsRep:    comicsRep,
 		processor:    processor,
 		searchEngine: searchEngine,
+		stateRep:     stateRep,
+		queryRep:     queryRep,
+		clickRep:     clickRep,
+		historyRep:   historyRep,
+		jobs:         make(map[int]*domain.UpdateJob),
 	}
 }
 
-// ScheduleUpdate schedules comics update at a specific time.
-func (xs *XkcdService) ScheduleUpdate(ctx context.Context, updateTime time.Time) {
-	go func() {
-		log.Println("Scheduling comics update...")
-		updateTime = time.Date(
-			time.Now().Year(),
-			time.Now().Month(),
-			time.Now().Day(),
-			updateTime.Hour(),
-			updateTime.Minute(),
-			updateTime.Second(),
-			0,
-			time.Local,
-		)
-
-		// Calculate the duration until the next update time
-		durationUntilNextUpdate := time.Until(updateTime)
-		// If the update time has already passed for today, set it for tomorrow
-		if durationUntilNextUpdate < 0 {
-			durationUntilNextUpdate += 24 * time.Hour
-		}
+// SetUpdateLock sets the lock shared with other processes which update, import or reconcile the comics.
+// It must be set before updates start.
+func (xs *XkcdService) SetUpdateLock(lock port.UpdateLock) {
+	xs.updateLock = lock
+}
+
+// AddComicsListener registers the listener notified about comics added by every update.
+// Listeners must be registered before updates start.
+func (xs *XkcdService) AddComicsListener(listener port.ComicsListener) {
+	xs.listeners = append(xs.listeners, listener)
+}
+
+// AddUpdateListener registers the listener notified about every finished update.
+// Listeners must be registered before updates start.
+func (xs *XkcdService) AddUpdateListener(listener port.UpdateListener) {
+	xs.updateListeners = append(xs.updateListeners, listener)
+}
+
+// UpdateComics retrieves comics from the comic sources, processes them, and saves them to the database.
+// It fails with domain.ErrUpdateInProgress if another update is running.
+func (xs *XkcdService) UpdateComics(ctx context.Context) error {
+	job, err := xs.beginUpdate(ctx)
+	if err != nil {
+		return err
+	}
 
-		// Wait until the next update time
-		timer := time.NewTimer(durationUntilNextUpdate)
-		defer timer.Stop()
-
-		for {
-			select {
-			case <-ctx.Done():
-				// If the context is canceled, stop the scheduling
-				return
-			case <-timer.C:
-				// Perform the update and reset the timer for the next day
-				log.Println("Scheduled comics update started...")
-				err := xs.UpdateComics(ctx)
-				if err != nil {
-					log.Println("error while scheduled updating comics:", err)
-				}
-
-				timer.Reset(24 * time.Hour)
-			}
+	return xs.runUpdate(ctx, job)
+}
+
+// StartUpdate starts the comics update in the background and returns the job tracking its progress.
+// If another update is running, its job is returned together with domain.ErrUpdateInProgress.
+// The job is nil if the update is run by another process.
+func (xs *XkcdService) StartUpdate(ctx context.Context) (*domain.UpdateJob, error) {
+	job, err := xs.beginUpdate(ctx)
+	if err != nil {
+		return job, err
+	}
+
+	go func() {
+		if err := xs.runUpdate(context.WithoutCancel(ctx), job); err != nil {
+			log.Printf("Update job %d failed: %v", job.ID, err)
 		}
 	}()
+
+	return job, nil
 }
 
-// UpdateComics retrieves comics from xkcd.com, processes them, and saves them to the database.
-func (xs *XkcdService) UpdateComics(
-	ctx context.Context,
-) error {
+// runUpdate performs the comics update tracked by the job.
+func (xs *XkcdService) runUpdate(ctx context.Context, job *domain.UpdateJob) (err error) {
+	defer func() {
+		xs.finishUpdate(ctx, job.ID, err)
+	}()
+
 	// Extract existing comic IDs into a map
 	existingIDs, err := xs.comicsRep.GetAllIDs(ctx)
 	if err != nil {
 		return fmt.Errorf("error extracting existing comic IDs: %w", err)
 	}
 
-	// Retrieve comics data from xkcd.com
-	log.Println("Retrieving comics data from xkcd.com...")
+	// Retrieve comics data from the sources
+	log.Println("Retrieving comics data from sources...")
 	clientCtx, clientCancel := context.WithTimeout(ctx, 3*time.Minute)
 	defer clientCancel()
 	newComics, err := xs.client.GetComics(clientCtx, existingIDs)
 	if err != nil {
-		return fmt.Errorf("error retrieving comics data: %w", err)
+		if newComics == nil {
+			return fmt.Errorf("error retrieving comics data: %w", err)
+		}
+
+		// Some comics were retrieved, so the update goes on with them
+		log.Println("Error retrieving some comics data:", err)
+		xs.updateJob(job.ID, func(j *domain.UpdateJob) {
+			j.Errors = append(j.Errors, err.Error())
+		})
+	}
+	xs.updateJob(job.ID, func(j *domain.UpdateJob) {
+		j.Fetched = len(newComics)
+	})
+
+	stats, err := xs.saveComics(newComics)
+	if err != nil {
+		return err
 	}
+	xs.updateJob(job.ID, func(j *domain.UpdateJob) {
+		j.Saved = stats.Inserted + stats.Updated
+		j.Inserted = stats.Inserted
+		j.Updated = stats.Updated
+	})
 
-	// Save comics data to database
+	if err = xs.indexComics(newComics); err != nil {
+		return err
+	}
+	xs.updateJob(job.ID, func(j *domain.UpdateJob) {
+		j.Indexed = len(newComics)
+	})
+
+	xs.notifyListeners(ctx, job.ID, insertedComics(newComics, stats))
+
+	return nil
+}
+
+// insertedComics returns the comics which were inserted rather than updated by saving.
+func insertedComics(comics domain.Comics, stats *domain.SaveStats) domain.Comics {
+	inserted := make(domain.Comics, len(stats.InsertedIDs))
+	for _, id := range stats.InsertedIDs {
+		if comic, ok := comics[id]; ok {
+			inserted[id] = comic
+		}
+	}
+
+	return inserted
+}
+
+// notifyListeners passes the comics added by the update or the import to the listeners.
+// Failures of the listeners are recorded in the update job but do not fail the update.
+func (xs *XkcdService) notifyListeners(ctx context.Context, jobID int, newComics domain.Comics) {
+	if len(newComics) == 0 {
+		return
+	}
+
+	for _, listener := range xs.listeners {
+		if err := listener.ComicsAdded(ctx, newComics); err != nil {
+			log.Println("Error notifying about added comics:", err)
+			xs.updateJob(jobID, func(j *domain.UpdateJob) {
+				j.Errors = append(j.Errors, err.Error())
+			})
+		}
+	}
+}
+
+// ImportComics saves the comics which are not stored yet to the database and adds them to the search engine.
+// It returns the number of imported comics. The import is tracked as an update job, so it fails
+// with domain.ErrUpdateInProgress if an update or another import is running. The comics listeners
+// are notified about the imported comics like about the ones added by an update.
+func (xs *XkcdService) ImportComics(ctx context.Context, comics domain.Comics) (imported int, err error) {
+	job, err := xs.beginUpdate(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		xs.finishImport(ctx, job.ID, err)
+	}()
+
+	existingIDs, err := xs.comicsRep.GetAllIDs(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("error extracting existing comic IDs: %w", err)
+	}
+
+	newComics := make(domain.Comics)
+	for id, comic := range comics {
+		if !existingIDs[id] {
+			newComics[id] = comic
+		}
+	}
+	xs.updateJob(job.ID, func(j *domain.UpdateJob) {
+		j.Fetched = len(newComics)
+	})
+
+	if len(newComics) == 0 {
+		return 0, nil
+	}
+
+	stats, err := xs.saveComics(newComics)
+	if err != nil {
+		return 0, err
+	}
+	xs.updateJob(job.ID, func(j *domain.UpdateJob) {
+		j.Saved = stats.Inserted + stats.Updated
+		j.Inserted = stats.Inserted
+		j.Updated = stats.Updated
+	})
+
+	if err = xs.indexComics(newComics); err != nil {
+		return 0, err
+	}
+	xs.updateJob(job.ID, func(j *domain.UpdateJob) {
+		j.Indexed = len(newComics)
+	})
+
+	xs.notifyListeners(ctx, job.ID, insertedComics(newComics, stats))
+
+	return len(newComics), nil
+}
+
+// ExportComics returns all comics stored in the database.
+func (xs *XkcdService) ExportComics(ctx context.Context) (domain.Comics, error) {
+	comics, err := xs.comicsRep.GetAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error getting comics: %w", err)
+	}
+
+	return comics, nil
+}
+
+// saveComics saves comics data to the database.
+func (xs *XkcdService) saveComics(newComics domain.Comics) (*domain.SaveStats, error) {
 	log.Println("Saving comics data to database...")
 	comicsRCtx, comicsCancel := context.WithTimeout(context.Background(), 2*time.Minute)
 	defer comicsCancel()
-	if err = xs.comicsRep.Save(comicsRCtx, newComics); err != nil {
-		return fmt.Errorf("error saving comics data to database: %w", err)
+	stats, err := xs.comicsRep.Save(comicsRCtx, newComics)
+	if err != nil {
+		return nil, fmt.Errorf("error saving comics data to database: %w", err)
 	}
 
-	// Add comics to the search engine
+	log.Printf("Comics saved: %d inserted, %d updated", stats.Inserted, stats.Updated)
+	return stats, nil
+}
+
+// indexComics adds comics to the search engine and marks them as indexed in the database.
+// Comics which are saved but not marked as indexed are picked up later by Reconcile.
+func (xs *XkcdService) indexComics(newComics domain.Comics) error {
+	// Indexing the same comics concurrently could count their tokens twice
+	xs.indexMu.Lock()
+	defer xs.indexMu.Unlock()
+
 	log.Println("Adding comics to search engine...")
 	searchEngineCtx, searchEngineCancel := context.WithTimeout(context.Background(), 1*time.Minute)
 	defer searchEngineCancel()
-	err = xs.searchEngine.CreateIndex(searchEngineCtx, newComics)
-	if err != nil {
+	if err := xs.searchEngine.CreateIndex(searchEngineCtx, newComics); err != nil {
 		return fmt.Errorf("error adding comics to search engine: %w", err)
 	}
 
+	ids := make([]int, 0, len(newComics))
+	for id := range newComics {
+		ids = append(ids, id)
+	}
+
+	comicsRCtx, comicsCancel := context.WithTimeout(context.Background(), 1*time.Minute)
+	defer comicsCancel()
+	if err := xs.comicsRep.MarkIndexed(comicsRCtx, xs.searchEngine.IndexName(), ids); err != nil {
+		return fmt.Errorf("error marking comics as indexed: %w", err)
+	}
+
 	return nil
 }
 
-// Search searches for comics by the query and returns their URLs.
-func (xs *XkcdService) Search(ctx context.Context, query string) ([]string, error) {
+// Search searches for comics by the query of the user and logs the query for analytics.
+// The query is also recorded in the search history of the user unless they opted out of it,
+// in which case it is logged anonymously. The user is nil for anonymous queries.
+// A query which could not be logged is still answered.
+func (xs *XkcdService) Search(ctx context.Context, query string, user *domain.Principal) (*domain.SearchResult, error) {
+	start := time.Now()
 	queryTokens, err := xs.processor.FullProcess(query)
 	if err != nil {
 		return nil, fmt.Errorf("error processing query: %w", err)
@@ -127,17 +315,101 @@ func (xs *XkcdService) Search(ctx context.Context, query string) ([]string, erro
 		return nil, fmt.Errorf("error searching comics: %w", err)
 	}
 
-	urls := make([]string, 0, len(ids))
+	result := &domain.SearchResult{Comics: make([]*domain.FoundComic, 0, len(ids))}
 	for _, id := range ids {
 		comic, err := xs.comicsRep.GetByID(ctx, id)
 		if err != nil {
 			return nil, fmt.Errorf("error getting comic by ID: %w", err)
 		}
 
-		urls = append(urls, comic.Img)
+		result.Comics = append(result.Comics, &domain.FoundComic{ID: id, URL: comic.Img})
+	}
+
+	result.QueryID, err = xs.queryRep.Save(ctx, &domain.SearchQuery{
+		Query:       query,
+		Tokens:      queryTokens,
+		Username:    loggedUsername(user),
+		ResultCount: len(ids),
+		ResultIDs:   ids,
+		Latency:     time.Since(start),
+		CreatedAt:   start,
+	})
+	if err != nil {
+		log.Println("Error logging search query:", err)
+	}
+
+	if user != nil && !user.HistoryDisabled {
+		err = xs.historyRep.Add(ctx, user.Username, &domain.HistoryEntry{
+			Query:       query,
+			ResultCount: len(ids),
+			CreatedAt:   start,
+		})
+		if err != nil {
+			log.Println("Error recording search history:", err)
+		}
+	}
+
+	return result, nil
+}
+
+// ReportClick records the comic the user opened from the results of the search query.
+// The click also counts towards the popularity of the comic for every token of the query.
+// Only one click is accepted per query and only on a comic from its results.
+func (xs *XkcdService) ReportClick(ctx context.Context, queryID int64, user *domain.Principal, comicID int) error {
+	if err := xs.clickRep.AddClick(ctx, queryID, loggedUsername(user), comicID, time.Now()); err != nil {
+		return fmt.Errorf("error recording click: %w", err)
+	}
+
+	return nil
+}
+
+// GetSearchAnalytics returns analytics of the search queries made since the given time.
+// Top lists are limited to the given number of queries.
+func (xs *XkcdService) GetSearchAnalytics(
+	ctx context.Context,
+	since time.Time,
+	limit int,
+) (*domain.SearchAnalytics, error) {
+	analytics, err := xs.queryRep.GetAnalytics(ctx, since, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error getting search analytics: %w", err)
+	}
+
+	return analytics, nil
+}
+
+// GetHistory returns the latest search queries of the user, limited to the given number.
+func (xs *XkcdService) GetHistory(ctx context.Context, username string, limit int) ([]*domain.HistoryEntry, error) {
+	history, err := xs.historyRep.Get(ctx, username, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error getting search history: %w", err)
+	}
+
+	return history, nil
+}
+
+// ClearHistory deletes the search history of the user and removes the user from the logged search queries,
+// which are kept for analytics.
+func (xs *XkcdService) ClearHistory(ctx context.Context, username string) error {
+	if err := xs.historyRep.Clear(ctx, username); err != nil {
+		return fmt.Errorf("error clearing search history: %w", err)
+	}
+
+	if err := xs.queryRep.Anonymize(ctx, username); err != nil {
+		return fmt.Errorf("error anonymizing search queries: %w", err)
+	}
+
+	return nil
+}
+
+// loggedUsername returns the name the search queries of the user are logged with. Queries of anonymous users
+// and of the users who opted out of the search history are logged without a name.
+func loggedUsername(user *domain.Principal) string {
+	if user == nil || user.HistoryDisabled {
+		return ""
 	}
 
-	return urls, nil
+	return user.Username
 }
 
 // GetNumberOfComics returns the total number of comics in the database.
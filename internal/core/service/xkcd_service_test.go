@@ -20,8 +20,12 @@ func TestUpdateComics(t *testing.T) {
 	comicsRepMock := new(mocks.ComicRepository)
 	processorMock := new(mocks.ComicProcessor)
 	searchEngineMock := new(mocks.SearchEngine)
+	stateRepMock := new(mocks.UpdateStateRepository)
+	queryRepMock := new(mocks.SearchQueryRepository)
+	clickRepMock := new(mocks.ClickRepository)
+	historyRepMock := new(mocks.SearchHistoryRepository)
 
-	service := NewXkcdService(clientMock, comicsRepMock, processorMock, searchEngineMock)
+	service := NewXkcdService(clientMock, comicsRepMock, processorMock, searchEngineMock, stateRepMock, queryRepMock, clickRepMock, historyRepMock)
 
 	existingIDs := map[int]bool{1: true, 2: true}
 	newComics := domain.Comics{
@@ -30,8 +34,12 @@ func TestUpdateComics(t *testing.T) {
 
 	comicsRepMock.On("GetAllIDs", mock.Anything).Return(existingIDs, nil)
 	clientMock.On("GetComics", mock.Anything, existingIDs).Return(newComics, nil)
-	comicsRepMock.On("Save", mock.Anything, newComics).Return(nil)
+	comicsRepMock.On("Save", mock.Anything, newComics).Return(&domain.SaveStats{Inserted: len(newComics)}, nil)
 	searchEngineMock.On("CreateIndex", mock.Anything, newComics).Return(nil)
+	searchEngineMock.On("IndexName").Return("redis")
+	comicsRepMock.On("MarkIndexed", mock.Anything, "redis", []int{1}).Return(nil)
+	comicsRepMock.On("GetTotalComics", mock.Anything).Return(3, nil)
+	stateRepMock.On("SetLastSuccess", mock.Anything, mock.Anything).Return(nil).Once()
 
 	err := service.UpdateComics(ctx)
 
@@ -42,6 +50,104 @@ func TestUpdateComics(t *testing.T) {
 	searchEngineMock.AssertExpectations(t)
 }
 
+func TestUpdateComics_NotifiesListeners(t *testing.T) {
+	ctx := context.Background()
+
+	clientMock := new(mocks.ComicClient)
+	comicsRepMock := new(mocks.ComicRepository)
+	searchEngineMock := new(mocks.SearchEngine)
+	stateRepMock := new(mocks.UpdateStateRepository)
+	listenerMock := new(mocks.ComicsListener)
+
+	service := NewXkcdService(clientMock, comicsRepMock, nil, searchEngineMock, stateRepMock, nil, nil, nil)
+	service.AddComicsListener(listenerMock)
+
+	newComics := domain.Comics{
+		3: {Img: "https://example.com/comic3.png"},
+	}
+
+	comicsRepMock.On("GetAllIDs", mock.Anything).Return(map[int]bool{}, nil)
+	clientMock.On("GetComics", mock.Anything, map[int]bool{}).Return(newComics, nil)
+	comicsRepMock.On("Save", mock.Anything, newComics).Return(&domain.SaveStats{Inserted: 1, InsertedIDs: []int{3}}, nil)
+	searchEngineMock.On("CreateIndex", mock.Anything, newComics).Return(nil)
+	searchEngineMock.On("IndexName").Return("redis")
+	comicsRepMock.On("MarkIndexed", mock.Anything, "redis", []int{3}).Return(nil)
+	comicsRepMock.On("GetTotalComics", mock.Anything).Return(1, nil)
+	stateRepMock.On("SetLastSuccess", mock.Anything, mock.Anything).Return(nil).Once()
+	listenerMock.On("ComicsAdded", mock.Anything, newComics).Return(errors.New("inbox is down")).Once()
+
+	job, err := service.StartUpdate(ctx)
+	require.NoError(t, err)
+
+	// A failing listener is recorded in the job but does not fail the update
+	require.Eventually(t, func() bool {
+		j, err := service.GetUpdateJob(ctx, job.ID)
+		return err == nil && j.Status == domain.UpdateSucceeded
+	}, time.Second, 10*time.Millisecond)
+	finished, err := service.GetUpdateJob(ctx, job.ID)
+	require.NoError(t, err)
+	assert.Contains(t, finished.Errors, "inbox is down")
+	listenerMock.AssertExpectations(t)
+}
+
+func TestUpdateComics_NotifiesOnlyInsertedComics(t *testing.T) {
+	ctx := context.Background()
+
+	clientMock := new(mocks.ComicClient)
+	comicsRepMock := new(mocks.ComicRepository)
+	searchEngineMock := new(mocks.SearchEngine)
+	stateRepMock := new(mocks.UpdateStateRepository)
+	listenerMock := new(mocks.ComicsListener)
+
+	service := NewXkcdService(clientMock, comicsRepMock, nil, searchEngineMock, stateRepMock, nil, nil, nil)
+	service.AddComicsListener(listenerMock)
+
+	// Comic 4 was stored already, e.g. by another process, so saving only updates it
+	newComics := domain.Comics{
+		3: {Img: "https://example.com/comic3.png"},
+		4: {Img: "https://example.com/comic4.png"},
+	}
+	stats := &domain.SaveStats{Inserted: 1, Updated: 1, InsertedIDs: []int{3}}
+
+	comicsRepMock.On("GetAllIDs", mock.Anything).Return(map[int]bool{}, nil)
+	clientMock.On("GetComics", mock.Anything, map[int]bool{}).Return(newComics, nil)
+	comicsRepMock.On("Save", mock.Anything, newComics).Return(stats, nil)
+	searchEngineMock.On("CreateIndex", mock.Anything, newComics).Return(nil)
+	searchEngineMock.On("IndexName").Return("redis")
+	comicsRepMock.On("MarkIndexed", mock.Anything, "redis", mock.Anything).Return(nil)
+	comicsRepMock.On("GetTotalComics", mock.Anything).Return(2, nil)
+	stateRepMock.On("SetLastSuccess", mock.Anything, mock.Anything).Return(nil).Once()
+	listenerMock.On("ComicsAdded", mock.Anything, domain.Comics{3: newComics[3]}).Return(nil).Once()
+
+	require.NoError(t, service.UpdateComics(ctx))
+	listenerMock.AssertExpectations(t)
+}
+
+func TestUpdateComics_NotifiesUpdateListeners(t *testing.T) {
+	ctx := context.Background()
+
+	clientMock := new(mocks.ComicClient)
+	comicsRepMock := new(mocks.ComicRepository)
+	stateRepMock := new(mocks.UpdateStateRepository)
+	listenerMock := new(mocks.UpdateListener)
+
+	service := NewXkcdService(clientMock, comicsRepMock, nil, nil, stateRepMock, nil, nil, nil)
+	service.AddUpdateListener(listenerMock)
+
+	comicsRepMock.On("GetAllIDs", mock.Anything).Return(map[int]bool{}, nil)
+	clientMock.On("GetComics", mock.Anything, map[int]bool{}).Return(nil, errors.New("sources are down"))
+	comicsRepMock.On("GetTotalComics", mock.Anything).Return(7, nil)
+	listenerMock.On("UpdateFinished", ctx, mock.MatchedBy(func(j *domain.UpdateJob) bool {
+		return j.Status == domain.UpdateFailed && j.Total == 7 && j.FinishedAt != nil
+	})).Once()
+
+	err := service.UpdateComics(ctx)
+
+	require.Error(t, err)
+	listenerMock.AssertExpectations(t)
+	stateRepMock.AssertNotCalled(t, "SetLastSuccess", mock.Anything, mock.Anything)
+}
+
 func TestUpdateComics_ErrorGettingIDs(t *testing.T) {
 	ctx := context.Background()
 
@@ -49,13 +155,18 @@ func TestUpdateComics_ErrorGettingIDs(t *testing.T) {
 	comicsRepMock := new(mocks.ComicRepository)
 	processorMock := new(mocks.ComicProcessor)
 	searchEngineMock := new(mocks.SearchEngine)
+	stateRepMock := new(mocks.UpdateStateRepository)
+	queryRepMock := new(mocks.SearchQueryRepository)
+	clickRepMock := new(mocks.ClickRepository)
+	historyRepMock := new(mocks.SearchHistoryRepository)
 
-	service := NewXkcdService(clientMock, comicsRepMock, processorMock, searchEngineMock)
+	service := NewXkcdService(clientMock, comicsRepMock, processorMock, searchEngineMock, stateRepMock, queryRepMock, clickRepMock, historyRepMock)
 
 	comicsRepMock.On(
 		"GetAllIDs",
 		mock.Anything,
 	).Return(nil, errors.New("database error"))
+	comicsRepMock.On("GetTotalComics", mock.Anything).Return(0, nil)
 
 	err := service.UpdateComics(ctx)
 
@@ -67,6 +178,112 @@ func TestUpdateComics_ErrorGettingIDs(t *testing.T) {
 	searchEngineMock.AssertNotCalled(t, "CreateIndex", mock.Anything, mock.Anything)
 }
 
+func TestStartUpdate(t *testing.T) {
+	ctx := context.Background()
+
+	clientMock := new(mocks.ComicClient)
+	comicsRepMock := new(mocks.ComicRepository)
+	processorMock := new(mocks.ComicProcessor)
+	searchEngineMock := new(mocks.SearchEngine)
+	stateRepMock := new(mocks.UpdateStateRepository)
+	queryRepMock := new(mocks.SearchQueryRepository)
+	clickRepMock := new(mocks.ClickRepository)
+	historyRepMock := new(mocks.SearchHistoryRepository)
+
+	service := NewXkcdService(clientMock, comicsRepMock, processorMock, searchEngineMock, stateRepMock, queryRepMock, clickRepMock, historyRepMock)
+
+	release := make(chan struct{})
+	newComics := domain.Comics{
+		3: {Img: "https://example.com/comic3.png"},
+		4: {Img: "https://example.com/comic4.png"},
+	}
+	comicsRepMock.On("GetAllIDs", mock.Anything).Return(map[int]bool{1: true, 2: true}, nil)
+	clientMock.On("GetComics", mock.Anything, mock.Anything).Run(func(_ mock.Arguments) {
+		<-release
+	}).Return(newComics, errors.New("source error")).Once()
+	comicsRepMock.On("Save", mock.Anything, newComics).Return(&domain.SaveStats{Inserted: 1, Updated: 1}, nil)
+	searchEngineMock.On("CreateIndex", mock.Anything, newComics).Return(nil)
+	searchEngineMock.On("IndexName").Return("redis")
+	comicsRepMock.On("MarkIndexed", mock.Anything, "redis", mock.Anything).Return(nil)
+	comicsRepMock.On("GetTotalComics", mock.Anything).Return(4, nil)
+	stateRepMock.On("SetLastSuccess", mock.Anything, mock.Anything).Return(nil).Once()
+
+	job, err := service.StartUpdate(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, domain.UpdateRunning, job.Status)
+
+	// Only one update can run at a time
+	running, err := service.StartUpdate(ctx)
+	require.ErrorIs(t, err, domain.ErrUpdateInProgress)
+	assert.Equal(t, job.ID, running.ID)
+	require.ErrorIs(t, service.UpdateComics(ctx), domain.ErrUpdateInProgress)
+
+	close(release)
+	require.Eventually(t, func() bool {
+		job, err = service.GetUpdateJob(ctx, job.ID)
+		return err == nil && job.Status != domain.UpdateRunning
+	}, 5*time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, domain.UpdateSucceeded, job.Status)
+	assert.NotNil(t, job.FinishedAt)
+	assert.Equal(t, 2, job.Fetched)
+	assert.Equal(t, 2, job.Saved)
+	assert.Equal(t, 1, job.Inserted)
+	assert.Equal(t, 1, job.Updated)
+	assert.Equal(t, 2, job.Indexed)
+	assert.Equal(t, 4, job.Total)
+	assert.Equal(t, []string{"source error"}, job.Errors)
+	clientMock.AssertExpectations(t)
+	comicsRepMock.AssertExpectations(t)
+	searchEngineMock.AssertExpectations(t)
+}
+
+func TestStartUpdate_Failure(t *testing.T) {
+	ctx := context.Background()
+
+	clientMock := new(mocks.ComicClient)
+	comicsRepMock := new(mocks.ComicRepository)
+	processorMock := new(mocks.ComicProcessor)
+	searchEngineMock := new(mocks.SearchEngine)
+	stateRepMock := new(mocks.UpdateStateRepository)
+	queryRepMock := new(mocks.SearchQueryRepository)
+	clickRepMock := new(mocks.ClickRepository)
+	historyRepMock := new(mocks.SearchHistoryRepository)
+
+	service := NewXkcdService(clientMock, comicsRepMock, processorMock, searchEngineMock, stateRepMock, queryRepMock, clickRepMock, historyRepMock)
+
+	comicsRepMock.On("GetAllIDs", mock.Anything).Return(map[int]bool{}, nil)
+	clientMock.On("GetComics", mock.Anything, mock.Anything).Return(nil, errors.New("source error"))
+	comicsRepMock.On("GetTotalComics", mock.Anything).Return(0, nil)
+
+	job, err := service.StartUpdate(ctx)
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		job, err = service.GetUpdateJob(ctx, job.ID)
+		return err == nil && job.Status != domain.UpdateRunning
+	}, 5*time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, domain.UpdateFailed, job.Status)
+	require.Len(t, job.Errors, 1)
+	assert.Contains(t, job.Errors[0], "error retrieving comics data")
+	comicsRepMock.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+
+	// The next update can start after the failed one
+	comicsRepMock.On("GetAllIDs", mock.Anything).Return(map[int]bool{}, nil)
+	next, err := service.StartUpdate(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, job.ID+1, next.ID)
+}
+
+func TestGetUpdateJob_NotFound(t *testing.T) {
+	service := NewXkcdService(nil, nil, nil, nil, nil, nil, nil, nil)
+
+	_, err := service.GetUpdateJob(context.Background(), 1)
+
+	require.ErrorIs(t, err, domain.ErrNotFound)
+}
+
 func TestSearch(t *testing.T) {
 	ctx := context.Background()
 
@@ -74,8 +291,12 @@ func TestSearch(t *testing.T) {
 	comicsRepMock := new(mocks.ComicRepository)
 	processorMock := new(mocks.ComicProcessor)
 	searchEngineMock := new(mocks.SearchEngine)
+	stateRepMock := new(mocks.UpdateStateRepository)
+	queryRepMock := new(mocks.SearchQueryRepository)
+	clickRepMock := new(mocks.ClickRepository)
+	historyRepMock := new(mocks.SearchHistoryRepository)
 
-	service := NewXkcdService(clientMock, comicsRepMock, processorMock, searchEngineMock)
+	service := NewXkcdService(clientMock, comicsRepMock, processorMock, searchEngineMock, stateRepMock, queryRepMock, clickRepMock, historyRepMock)
 
 	query := "test query"
 	queryTokens := []string{"test", "query"}
@@ -88,14 +309,78 @@ func TestSearch(t *testing.T) {
 	searchEngineMock.On("Search", mock.Anything, queryTokens).Return(ids, nil)
 	comicsRepMock.On("GetByID", ctx, 1).Return(comics[1], nil)
 	comicsRepMock.On("GetByID", ctx, 2).Return(comics[2], nil)
+	queryRepMock.On("Save", ctx, mock.MatchedBy(func(q *domain.SearchQuery) bool {
+		return q.Query == query && q.Username == "user" && q.ResultCount == 2 &&
+			assert.ObjectsAreEqual(queryTokens, q.Tokens) && assert.ObjectsAreEqual(ids, q.ResultIDs)
+	})).Return(int64(42), nil)
+	historyRepMock.On("Add", ctx, "user", mock.MatchedBy(func(e *domain.HistoryEntry) bool {
+		return e.Query == query && e.ResultCount == 2
+	})).Return(nil)
 
-	urls, err := service.Search(ctx, query)
+	result, err := service.Search(ctx, query, &domain.Principal{Username: "user"})
 
 	require.NoError(t, err)
-	assert.Equal(t, []string{"https://example.com/comic1.png", "https://example.com/comic2.png"}, urls)
+	assert.Equal(t, int64(42), result.QueryID)
+	assert.Equal(t, []string{"https://example.com/comic1.png", "https://example.com/comic2.png"}, result.URLs())
+	assert.Equal(t, 2, result.Comics[1].ID)
 	processorMock.AssertExpectations(t)
 	searchEngineMock.AssertExpectations(t)
 	comicsRepMock.AssertExpectations(t)
+	queryRepMock.AssertExpectations(t)
+	historyRepMock.AssertExpectations(t)
+}
+
+func TestSearch_QueryLoggingFailure(t *testing.T) {
+	ctx := context.Background()
+
+	clientMock := new(mocks.ComicClient)
+	comicsRepMock := new(mocks.ComicRepository)
+	processorMock := new(mocks.ComicProcessor)
+	searchEngineMock := new(mocks.SearchEngine)
+	stateRepMock := new(mocks.UpdateStateRepository)
+	queryRepMock := new(mocks.SearchQueryRepository)
+	clickRepMock := new(mocks.ClickRepository)
+	historyRepMock := new(mocks.SearchHistoryRepository)
+
+	service := NewXkcdService(clientMock, comicsRepMock, processorMock, searchEngineMock, stateRepMock, queryRepMock, clickRepMock, historyRepMock)
+
+	processorMock.On("FullProcess", "missing").Return([]string{"miss"}, nil)
+	searchEngineMock.On("Search", mock.Anything, []string{"miss"}).Return([]int{}, nil)
+	queryRepMock.On("Save", ctx, mock.Anything).Return(int64(0), errors.New("db is down"))
+
+	historyRepMock.On("Add", ctx, "user", mock.Anything).Return(errors.New("db is down"))
+
+	result, err := service.Search(ctx, "missing", &domain.Principal{Username: "user"})
+
+	require.NoError(t, err)
+	assert.Zero(t, result.QueryID)
+	assert.Empty(t, result.Comics)
+}
+
+func TestSearch_HistoryDisabled(t *testing.T) {
+	ctx := context.Background()
+
+	comicsRepMock := new(mocks.ComicRepository)
+	processorMock := new(mocks.ComicProcessor)
+	searchEngineMock := new(mocks.SearchEngine)
+	queryRepMock := new(mocks.SearchQueryRepository)
+	historyRepMock := new(mocks.SearchHistoryRepository)
+
+	service := NewXkcdService(nil, comicsRepMock, processorMock, searchEngineMock, nil, queryRepMock, nil, historyRepMock)
+
+	processorMock.On("FullProcess", "missing").Return([]string{"miss"}, nil)
+	searchEngineMock.On("Search", mock.Anything, []string{"miss"}).Return([]int{}, nil)
+	anonymous := mock.MatchedBy(func(q *domain.SearchQuery) bool { return q.Username == "" })
+	queryRepMock.On("Save", ctx, anonymous).Return(int64(1), nil).Twice()
+
+	_, err := service.Search(ctx, "missing", &domain.Principal{Username: "user", HistoryDisabled: true})
+	require.NoError(t, err)
+
+	_, err = service.Search(ctx, "missing", nil)
+	require.NoError(t, err)
+
+	queryRepMock.AssertExpectations(t)
+	historyRepMock.AssertNotCalled(t, "Add", mock.Anything, mock.Anything, mock.Anything)
 }
 
 func TestSearch_ErrorProcessingQuery(t *testing.T) {
@@ -105,8 +390,12 @@ func TestSearch_ErrorProcessingQuery(t *testing.T) {
 	comicsRepMock := new(mocks.ComicRepository)
 	processorMock := new(mocks.ComicProcessor)
 	searchEngineMock := new(mocks.SearchEngine)
+	stateRepMock := new(mocks.UpdateStateRepository)
+	queryRepMock := new(mocks.SearchQueryRepository)
+	clickRepMock := new(mocks.ClickRepository)
+	historyRepMock := new(mocks.SearchHistoryRepository)
 
-	service := NewXkcdService(clientMock, comicsRepMock, processorMock, searchEngineMock)
+	service := NewXkcdService(clientMock, comicsRepMock, processorMock, searchEngineMock, stateRepMock, queryRepMock, clickRepMock, historyRepMock)
 
 	query := "test query"
 	processorMock.On(
@@ -114,17 +403,17 @@ func TestSearch_ErrorProcessingQuery(t *testing.T) {
 		query,
 	).Return(nil, errors.New("processing error"))
 
-	urls, err := service.Search(ctx, query)
+	result, err := service.Search(ctx, query, &domain.Principal{Username: "user"})
 
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "error processing query")
-	assert.Nil(t, urls)
+	assert.Nil(t, result)
 	processorMock.AssertExpectations(t)
 	searchEngineMock.AssertNotCalled(t, "Search", mock.Anything, mock.Anything)
 	comicsRepMock.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
 }
 
-func TestScheduleUpdate(t *testing.T) {
+func TestScheduleUpdate_RunOnStartup(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
@@ -132,64 +421,497 @@ func TestScheduleUpdate(t *testing.T) {
 	comicsRepMock := new(mocks.ComicRepository)
 	processorMock := new(mocks.ComicProcessor)
 	searchEngineMock := new(mocks.SearchEngine)
+	stateRepMock := new(mocks.UpdateStateRepository)
+	queryRepMock := new(mocks.SearchQueryRepository)
+	clickRepMock := new(mocks.ClickRepository)
+	historyRepMock := new(mocks.SearchHistoryRepository)
 
 	updated := make(chan struct{})
 
-	service := NewXkcdService(clientMock, comicsRepMock, processorMock, searchEngineMock)
+	service := NewXkcdService(clientMock, comicsRepMock, processorMock, searchEngineMock, stateRepMock, queryRepMock, clickRepMock, historyRepMock)
 
 	comicsRepMock.On("GetAllIDs", mock.Anything).Return(map[int]bool{}, nil)
-	clientMock.On("GetComics", mock.Anything, mock.Anything).Run(func(_ mock.Arguments) {
-		updated <- struct{}{}
-	}).Return(domain.Comics{}, nil)
-	comicsRepMock.On("Save", mock.Anything, mock.Anything).Return(nil)
+	clientMock.On("GetComics", mock.Anything, mock.Anything).Return(domain.Comics{}, nil)
+	comicsRepMock.On("Save", mock.Anything, mock.Anything).Return(&domain.SaveStats{}, nil)
 	searchEngineMock.On("CreateIndex", mock.Anything, mock.Anything).Return(nil)
+	searchEngineMock.On("IndexName").Return("redis")
+	comicsRepMock.On("MarkIndexed", mock.Anything, "redis", mock.Anything).Return(nil)
+	comicsRepMock.On("GetTotalComics", mock.Anything).Return(0, nil)
+	stateRepMock.On("SetLastSuccess", mock.Anything, mock.Anything).Run(func(_ mock.Arguments) {
+		updated <- struct{}{}
+	}).Return(nil)
 
-	updateTime := time.Now().Add(5 * time.Second)
-
-	service.ScheduleUpdate(ctx, updateTime)
+	err := service.ScheduleUpdate(ctx, &domain.UpdateSchedule{
+		Cron:         "0 3 * * *",
+		Location:     time.UTC,
+		RunOnStartup: true,
+	})
+	require.NoError(t, err)
 
 	select {
-	case <-time.After(20 * time.Second):
+	case <-time.After(5 * time.Second):
+		t.Errorf("update was not run on startup")
 	case <-updated:
 	}
 
-	comicsRepMock.AssertExpectations(t)
 	clientMock.AssertExpectations(t)
 	comicsRepMock.AssertExpectations(t)
 	searchEngineMock.AssertExpectations(t)
+	stateRepMock.AssertNotCalled(t, "GetLastSuccess", mock.Anything)
 }
 
-func TestScheduleUpdate_CancelContext(t *testing.T) {
+func TestScheduleUpdate_CatchUp(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 
 	clientMock := new(mocks.ComicClient)
 	comicsRepMock := new(mocks.ComicRepository)
 	processorMock := new(mocks.ComicProcessor)
 	searchEngineMock := new(mocks.SearchEngine)
+	stateRepMock := new(mocks.UpdateStateRepository)
+	queryRepMock := new(mocks.SearchQueryRepository)
+	clickRepMock := new(mocks.ClickRepository)
+	historyRepMock := new(mocks.SearchHistoryRepository)
 
 	updated := make(chan struct{})
-	service := NewXkcdService(clientMock, comicsRepMock, processorMock, searchEngineMock)
 
+	service := NewXkcdService(clientMock, comicsRepMock, processorMock, searchEngineMock, stateRepMock, queryRepMock, clickRepMock, historyRepMock)
+
+	// The last update was two days ago, so the daily update was missed
+	stateRepMock.On("GetLastSuccess", mock.Anything).Return(time.Now().Add(-48*time.Hour), nil)
 	comicsRepMock.On("GetAllIDs", mock.Anything).Return(map[int]bool{}, nil)
 	clientMock.On("GetComics", mock.Anything, mock.Anything).Run(func(_ mock.Arguments) {
 		updated <- struct{}{}
 	}).Return(domain.Comics{}, nil)
-	comicsRepMock.On("Save", mock.Anything, mock.Anything).Return(nil)
+	comicsRepMock.On("Save", mock.Anything, mock.Anything).Return(&domain.SaveStats{}, nil)
 	searchEngineMock.On("CreateIndex", mock.Anything, mock.Anything).Return(nil)
+	searchEngineMock.On("IndexName").Return("redis")
+	comicsRepMock.On("MarkIndexed", mock.Anything, "redis", mock.Anything).Return(nil)
+	comicsRepMock.On("GetTotalComics", mock.Anything).Return(0, nil)
+	stateRepMock.On("SetLastSuccess", mock.Anything, mock.Anything).Return(nil)
+
+	err := service.ScheduleUpdate(ctx, &domain.UpdateSchedule{
+		Cron:    "0 3 * * *",
+		CatchUp: true,
+	})
+	require.NoError(t, err)
+
+	select {
+	case <-time.After(5 * time.Second):
+		t.Errorf("missed update was not caught up")
+	case <-updated:
+	}
+
+	clientMock.AssertExpectations(t)
+}
+
+func TestScheduleUpdate_NoMissedRun(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	clientMock := new(mocks.ComicClient)
+	comicsRepMock := new(mocks.ComicRepository)
+	processorMock := new(mocks.ComicProcessor)
+	searchEngineMock := new(mocks.SearchEngine)
+	stateRepMock := new(mocks.UpdateStateRepository)
+	queryRepMock := new(mocks.SearchQueryRepository)
+	clickRepMock := new(mocks.ClickRepository)
+	historyRepMock := new(mocks.SearchHistoryRepository)
+
+	service := NewXkcdService(clientMock, comicsRepMock, processorMock, searchEngineMock, stateRepMock, queryRepMock, clickRepMock, historyRepMock)
+
+	// The last update has just finished, so no scheduled run can have been missed since
+	lastSuccess := time.Now()
+	stateRepMock.On("GetLastSuccess", mock.Anything).Return(lastSuccess, nil)
+
+	err := service.ScheduleUpdate(ctx, &domain.UpdateSchedule{
+		Cron:     "0 */6 * * *",
+		Location: time.UTC,
+		CatchUp:  true,
+	})
+	require.NoError(t, err)
+
+	var status *domain.UpdateScheduleStatus
+	require.Eventually(t, func() bool {
+		status, err = service.GetScheduleStatus(ctx)
+		return err == nil && status.NextRun != nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, "0 */6 * * *", status.Cron)
+	assert.Equal(t, "UTC", status.Timezone)
+	assert.True(t, status.LastSuccess.Equal(lastSuccess))
+	assert.Equal(t, 0, status.NextRun.Hour()%6)
+	assert.Equal(t, 0, status.NextRun.Minute())
+	assert.True(t, status.NextRun.After(time.Now()))
+	clientMock.AssertNotCalled(t, "GetComics", mock.Anything, mock.Anything)
+}
+
+func TestScheduleUpdate_NeverUpdated(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	clientMock := new(mocks.ComicClient)
+	stateRepMock := new(mocks.UpdateStateRepository)
+	service := NewXkcdService(clientMock, nil, nil, nil, stateRepMock, nil, nil, nil)
+
+	stateRepMock.On("GetLastSuccess", mock.Anything).Return(time.Time{}, nil)
+
+	err := service.ScheduleUpdate(ctx, &domain.UpdateSchedule{Cron: "0 3 * * *", CatchUp: true})
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		status, err := service.GetScheduleStatus(ctx)
+		return err == nil && status.NextRun != nil
+	}, 5*time.Second, 10*time.Millisecond)
+	clientMock.AssertNotCalled(t, "GetComics", mock.Anything, mock.Anything)
+}
+
+func TestScheduleUpdate_InvalidCron(t *testing.T) {
+	service := NewXkcdService(nil, nil, nil, nil, nil, nil, nil, nil)
+
+	err := service.ScheduleUpdate(context.Background(), &domain.UpdateSchedule{Cron: "every day"})
+
+	require.Error(t, err)
+}
+
+func TestScheduleUpdate_CancelContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
 
-	updateTime := time.Now().Add(5 * time.Second)
+	clientMock := new(mocks.ComicClient)
+	comicsRepMock := new(mocks.ComicRepository)
+	processorMock := new(mocks.ComicProcessor)
+	searchEngineMock := new(mocks.SearchEngine)
+	stateRepMock := new(mocks.UpdateStateRepository)
+	queryRepMock := new(mocks.SearchQueryRepository)
+	clickRepMock := new(mocks.ClickRepository)
+	historyRepMock := new(mocks.SearchHistoryRepository)
 
-	service.ScheduleUpdate(ctx, updateTime)
+	service := NewXkcdService(clientMock, comicsRepMock, processorMock, searchEngineMock, stateRepMock, queryRepMock, clickRepMock, historyRepMock)
+
+	err := service.ScheduleUpdate(ctx, &domain.UpdateSchedule{Cron: "* * * * *"})
+	require.NoError(t, err)
 
 	cancel()
+	time.Sleep(100 * time.Millisecond)
 
-	select {
-	case <-time.After(10 * time.Second):
-	case <-updated:
-		t.Errorf("update was not canceled")
+	clientMock.AssertNotCalled(t, "GetComics", mock.Anything, mock.Anything)
+	comicsRepMock.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+	searchEngineMock.AssertNotCalled(t, "CreateIndex", mock.Anything, mock.Anything)
+}
+
+func TestImportComics(t *testing.T) {
+	ctx := context.Background()
+
+	clientMock := new(mocks.ComicClient)
+	comicsRepMock := new(mocks.ComicRepository)
+	processorMock := new(mocks.ComicProcessor)
+	searchEngineMock := new(mocks.SearchEngine)
+	stateRepMock := new(mocks.UpdateStateRepository)
+	queryRepMock := new(mocks.SearchQueryRepository)
+	clickRepMock := new(mocks.ClickRepository)
+	historyRepMock := new(mocks.SearchHistoryRepository)
+
+	listenerMock := new(mocks.ComicsListener)
+	updateListenerMock := new(mocks.UpdateListener)
+
+	service := NewXkcdService(clientMock, comicsRepMock, processorMock, searchEngineMock, stateRepMock, queryRepMock, clickRepMock, historyRepMock)
+	service.AddComicsListener(listenerMock)
+	service.AddUpdateListener(updateListenerMock)
+
+	comics := domain.Comics{
+		1: {Img: "https://example.com/comic1.png"},
+		2: {Img: "https://example.com/comic2.png"},
 	}
+	newComics := domain.Comics{
+		2: {Img: "https://example.com/comic2.png"},
+	}
+
+	comicsRepMock.On("GetAllIDs", mock.Anything).Return(map[int]bool{1: true}, nil)
+	comicsRepMock.On("Save", mock.Anything, newComics).Return(&domain.SaveStats{Inserted: 1, InsertedIDs: []int{2}}, nil)
+	searchEngineMock.On("CreateIndex", mock.Anything, newComics).Return(nil)
+	searchEngineMock.On("IndexName").Return("redis")
+	comicsRepMock.On("MarkIndexed", mock.Anything, "redis", mock.Anything).Return(nil)
+	comicsRepMock.On("GetTotalComics", mock.Anything).Return(2, nil)
+	listenerMock.On("ComicsAdded", mock.Anything, newComics).Return(nil).Once()
+
+	imported, err := service.ImportComics(ctx, comics)
 
+	require.NoError(t, err)
+	assert.Equal(t, 1, imported)
+	job, err := service.GetUpdateJob(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, domain.UpdateSucceeded, job.Status)
+	assert.Equal(t, 1, job.Indexed)
+	assert.Equal(t, 2, job.Total)
+	comicsRepMock.AssertExpectations(t)
+	searchEngineMock.AssertExpectations(t)
+	listenerMock.AssertExpectations(t)
 	clientMock.AssertNotCalled(t, "GetComics", mock.Anything, mock.Anything)
+	stateRepMock.AssertNotCalled(t, "SetLastSuccess", mock.Anything, mock.Anything)
+	updateListenerMock.AssertNotCalled(t, "UpdateFinished", mock.Anything, mock.Anything)
+}
+
+func TestImportComics_UpdateInProgress(t *testing.T) {
+	ctx := context.Background()
+
+	comicsRepMock := new(mocks.ComicRepository)
+	service := NewXkcdService(nil, comicsRepMock, nil, nil, nil, nil, nil, nil)
+
+	_, err := service.beginUpdate(ctx)
+	require.NoError(t, err)
+
+	_, err = service.ImportComics(ctx, domain.Comics{1: {Source: "xkcd", Img: "https://example.com/comic1.png"}})
+
+	require.ErrorIs(t, err, domain.ErrUpdateInProgress)
+	comicsRepMock.AssertNotCalled(t, "GetAllIDs", mock.Anything)
+}
+
+func TestImportComics_LockedByAnotherProcess(t *testing.T) {
+	ctx := context.Background()
+
+	comicsRepMock := new(mocks.ComicRepository)
+	lockMock := new(mocks.UpdateLock)
+	service := NewXkcdService(nil, comicsRepMock, nil, nil, nil, nil, nil, nil)
+	service.SetUpdateLock(lockMock)
+
+	lockMock.On("TryLock", mock.Anything).Return(nil, domain.ErrUpdateInProgress).Once()
+
+	_, err := service.ImportComics(ctx, domain.Comics{1: {Source: "xkcd", Img: "https://example.com/comic1.png"}})
+
+	require.ErrorIs(t, err, domain.ErrUpdateInProgress)
+	lockMock.AssertExpectations(t)
+	comicsRepMock.AssertNotCalled(t, "GetAllIDs", mock.Anything)
+
+	// The failed attempt does not block the next one
+	unlocked := false
+	lockMock.On("TryLock", mock.Anything).Return(func() { unlocked = true }, nil).Once()
+	comicsRepMock.On("GetAllIDs", mock.Anything).Return(map[int]bool{1: true}, nil)
+	comicsRepMock.On("GetTotalComics", mock.Anything).Return(1, nil)
+
+	_, err = service.ImportComics(ctx, domain.Comics{1: {Source: "xkcd", Img: "https://example.com/comic1.png"}})
+
+	require.NoError(t, err)
+	assert.True(t, unlocked)
+	lockMock.AssertExpectations(t)
+}
+
+func TestImportComics_NothingNew(t *testing.T) {
+	ctx := context.Background()
+
+	clientMock := new(mocks.ComicClient)
+	comicsRepMock := new(mocks.ComicRepository)
+	processorMock := new(mocks.ComicProcessor)
+	searchEngineMock := new(mocks.SearchEngine)
+	stateRepMock := new(mocks.UpdateStateRepository)
+	queryRepMock := new(mocks.SearchQueryRepository)
+	clickRepMock := new(mocks.ClickRepository)
+	historyRepMock := new(mocks.SearchHistoryRepository)
+
+	service := NewXkcdService(clientMock, comicsRepMock, processorMock, searchEngineMock, stateRepMock, queryRepMock, clickRepMock, historyRepMock)
+
+	comicsRepMock.On("GetAllIDs", mock.Anything).Return(map[int]bool{1: true}, nil)
+	comicsRepMock.On("GetTotalComics", mock.Anything).Return(1, nil)
+
+	imported, err := service.ImportComics(ctx, domain.Comics{1: {Img: "https://example.com/comic1.png"}})
+
+	require.NoError(t, err)
+	assert.Equal(t, 0, imported)
 	comicsRepMock.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
 	searchEngineMock.AssertNotCalled(t, "CreateIndex", mock.Anything, mock.Anything)
 }
+
+func TestReconcile(t *testing.T) {
+	ctx := context.Background()
+
+	clientMock := new(mocks.ComicClient)
+	comicsRepMock := new(mocks.ComicRepository)
+	processorMock := new(mocks.ComicProcessor)
+	searchEngineMock := new(mocks.SearchEngine)
+	stateRepMock := new(mocks.UpdateStateRepository)
+	queryRepMock := new(mocks.SearchQueryRepository)
+	clickRepMock := new(mocks.ClickRepository)
+	historyRepMock := new(mocks.SearchHistoryRepository)
+
+	service := NewXkcdService(clientMock, comicsRepMock, processorMock, searchEngineMock, stateRepMock, queryRepMock, clickRepMock, historyRepMock)
+
+	unindexed := domain.Comics{
+		5: {Img: "https://example.com/comic5.png", Keywords: []string{"comic"}},
+	}
+
+	comicsRepMock.On("GetUnindexed", mock.Anything, "redis", reconcileBatchSize).Return(unindexed, nil).Once()
+	searchEngineMock.On("CreateIndex", mock.Anything, unindexed).Return(nil)
+	searchEngineMock.On("IndexName").Return("redis")
+	comicsRepMock.On("MarkIndexed", mock.Anything, "redis", []int{5}).Return(nil)
+
+	indexed, err := service.Reconcile(ctx)
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, indexed)
+	comicsRepMock.AssertExpectations(t)
+	searchEngineMock.AssertExpectations(t)
+}
+
+func TestReconcile_LockedByAnotherProcess(t *testing.T) {
+	ctx := context.Background()
+
+	comicsRepMock := new(mocks.ComicRepository)
+	lockMock := new(mocks.UpdateLock)
+	service := NewXkcdService(nil, comicsRepMock, nil, nil, nil, nil, nil, nil)
+	service.SetUpdateLock(lockMock)
+
+	lockMock.On("TryLock", mock.Anything).Return(nil, domain.ErrUpdateInProgress).Once()
+
+	indexed, err := service.Reconcile(ctx)
+
+	require.ErrorIs(t, err, domain.ErrUpdateInProgress)
+	assert.Equal(t, 0, indexed)
+	comicsRepMock.AssertNotCalled(t, "GetUnindexed", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestReconcile_IndexingFailure(t *testing.T) {
+	ctx := context.Background()
+
+	clientMock := new(mocks.ComicClient)
+	comicsRepMock := new(mocks.ComicRepository)
+	processorMock := new(mocks.ComicProcessor)
+	searchEngineMock := new(mocks.SearchEngine)
+	stateRepMock := new(mocks.UpdateStateRepository)
+	queryRepMock := new(mocks.SearchQueryRepository)
+	clickRepMock := new(mocks.ClickRepository)
+	historyRepMock := new(mocks.SearchHistoryRepository)
+
+	service := NewXkcdService(clientMock, comicsRepMock, processorMock, searchEngineMock, stateRepMock, queryRepMock, clickRepMock, historyRepMock)
+
+	unindexed := domain.Comics{
+		5: {Img: "https://example.com/comic5.png", Keywords: []string{"comic"}},
+	}
+
+	comicsRepMock.On("GetUnindexed", mock.Anything, "redis", reconcileBatchSize).Return(unindexed, nil).Once()
+	searchEngineMock.On("CreateIndex", mock.Anything, unindexed).Return(errors.New("redis is down"))
+	searchEngineMock.On("IndexName").Return("redis")
+
+	indexed, err := service.Reconcile(ctx)
+
+	require.Error(t, err)
+	assert.Equal(t, 0, indexed)
+	comicsRepMock.AssertNotCalled(t, "MarkIndexed", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUpdateComics_IndexingFailureLeavesComicsUnindexed(t *testing.T) {
+	ctx := context.Background()
+
+	clientMock := new(mocks.ComicClient)
+	comicsRepMock := new(mocks.ComicRepository)
+	processorMock := new(mocks.ComicProcessor)
+	searchEngineMock := new(mocks.SearchEngine)
+	stateRepMock := new(mocks.UpdateStateRepository)
+	queryRepMock := new(mocks.SearchQueryRepository)
+	clickRepMock := new(mocks.ClickRepository)
+	historyRepMock := new(mocks.SearchHistoryRepository)
+
+	service := NewXkcdService(clientMock, comicsRepMock, processorMock, searchEngineMock, stateRepMock, queryRepMock, clickRepMock, historyRepMock)
+
+	newComics := domain.Comics{
+		3: {Img: "https://example.com/comic3.png"},
+	}
+
+	comicsRepMock.On("GetAllIDs", mock.Anything).Return(map[int]bool{}, nil)
+	clientMock.On("GetComics", mock.Anything, mock.Anything).Return(newComics, nil)
+	comicsRepMock.On("Save", mock.Anything, newComics).Return(&domain.SaveStats{Inserted: len(newComics)}, nil)
+	searchEngineMock.On("CreateIndex", mock.Anything, newComics).Return(errors.New("redis is down")).Once()
+	searchEngineMock.On("IndexName").Return("redis")
+	comicsRepMock.On("GetTotalComics", mock.Anything).Return(1, nil)
+
+	err := service.UpdateComics(ctx)
+	require.Error(t, err)
+	comicsRepMock.AssertNotCalled(t, "MarkIndexed", mock.Anything, mock.Anything, mock.Anything)
+
+	// The next reconciliation indexes the saved comics
+	comicsRepMock.On("GetUnindexed", mock.Anything, "redis", reconcileBatchSize).Return(newComics, nil).Once()
+	searchEngineMock.On("CreateIndex", mock.Anything, newComics).Return(nil).Once()
+	searchEngineMock.On("IndexName").Return("redis")
+	comicsRepMock.On("MarkIndexed", mock.Anything, "redis", []int{3}).Return(nil)
+
+	indexed, err := service.Reconcile(ctx)
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, indexed)
+	comicsRepMock.AssertExpectations(t)
+	searchEngineMock.AssertExpectations(t)
+}
+
+func TestReportClick(t *testing.T) {
+	ctx := context.Background()
+
+	clickRepMock := new(mocks.ClickRepository)
+	service := NewXkcdService(nil, nil, nil, nil, nil, nil, clickRepMock, nil)
+
+	at := mock.AnythingOfType("time.Time")
+	clickRepMock.On("AddClick", ctx, int64(42), "user", 353, at).Return(nil).Once()
+	clickRepMock.On("AddClick", ctx, int64(43), "user", 353, at).Return(domain.ErrNotFound).Once()
+	clickRepMock.On("AddClick", ctx, int64(44), "user", 353, at).Return(domain.ErrAlreadyExists).Once()
+	clickRepMock.On("AddClick", ctx, int64(45), "user", 353, at).Return(domain.ErrInvalidInput).Once()
+	clickRepMock.On("AddClick", ctx, int64(46), "", 353, at).Return(nil).Once()
+
+	user := &domain.Principal{Username: "user"}
+	require.NoError(t, service.ReportClick(ctx, 42, user, 353))
+	require.ErrorIs(t, service.ReportClick(ctx, 43, user, 353), domain.ErrNotFound)
+	require.ErrorIs(t, service.ReportClick(ctx, 44, user, 353), domain.ErrAlreadyExists)
+	require.ErrorIs(t, service.ReportClick(ctx, 45, user, 353), domain.ErrInvalidInput)
+	// Queries of the users without history are logged anonymously, and so are their clicks
+	require.NoError(t, service.ReportClick(ctx, 46, &domain.Principal{Username: "user", HistoryDisabled: true}, 353))
+	clickRepMock.AssertExpectations(t)
+}
+
+func TestGetSearchAnalytics(t *testing.T) {
+	ctx := context.Background()
+
+	queryRepMock := new(mocks.SearchQueryRepository)
+	service := NewXkcdService(nil, nil, nil, nil, nil, queryRepMock, nil, nil)
+
+	since := time.Now().AddDate(0, 0, -7)
+	analytics := &domain.SearchAnalytics{
+		Since:      since,
+		TopQueries: []domain.QueryStats{{Query: "python", Count: 3, AvgResults: 1, Clicks: 2}},
+	}
+	queryRepMock.On("GetAnalytics", ctx, since, 10).Return(analytics, nil)
+
+	result, err := service.GetSearchAnalytics(ctx, since, 10)
+
+	require.NoError(t, err)
+	assert.Equal(t, analytics, result)
+}
+
+func TestGetHistory(t *testing.T) {
+	ctx := context.Background()
+
+	historyRepMock := new(mocks.SearchHistoryRepository)
+	service := NewXkcdService(nil, nil, nil, nil, nil, nil, nil, historyRepMock)
+
+	history := []*domain.HistoryEntry{{ID: 1, Query: "python", ResultCount: 3}}
+	historyRepMock.On("Get", ctx, "user", 50).Return(history, nil).Once()
+	historyRepMock.On("Get", ctx, "other", 50).Return(nil, errors.New("db is down")).Once()
+
+	result, err := service.GetHistory(ctx, "user", 50)
+	require.NoError(t, err)
+	assert.Equal(t, history, result)
+
+	_, err = service.GetHistory(ctx, "other", 50)
+	require.Error(t, err)
+	historyRepMock.AssertExpectations(t)
+}
+
+func TestClearHistory(t *testing.T) {
+	ctx := context.Background()
+
+	queryRepMock := new(mocks.SearchQueryRepository)
+	historyRepMock := new(mocks.SearchHistoryRepository)
+	service := NewXkcdService(nil, nil, nil, nil, nil, queryRepMock, nil, historyRepMock)
+
+	historyRepMock.On("Clear", ctx, "user").Return(nil).Once()
+	queryRepMock.On("Anonymize", ctx, "user").Return(nil).Once()
+
+	require.NoError(t, service.ClearHistory(ctx, "user"))
+	historyRepMock.AssertExpectations(t)
+	queryRepMock.AssertExpectations(t)
+}
@@ -0,0 +1 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS history_disabled BOOLEAN NOT NULL DEFAULT FALSE;
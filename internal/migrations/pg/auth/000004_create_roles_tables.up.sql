@@ -0,0 +1,44 @@
+CREATE TABLE IF NOT EXISTS roles
+(
+    name TEXT PRIMARY KEY
+);
+
+CREATE TABLE IF NOT EXISTS permissions
+(
+    name TEXT PRIMARY KEY
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions
+(
+    role       TEXT NOT NULL REFERENCES roles (name) ON DELETE CASCADE,
+    permission TEXT NOT NULL REFERENCES permissions (name) ON DELETE CASCADE,
+    PRIMARY KEY (role, permission)
+);
+
+INSERT INTO roles (name) VALUES ('user'), ('admin') ON CONFLICT DO NOTHING;
+
+INSERT INTO permissions (name)
+VALUES ('comics:search'),
+       ('comics:update'),
+       ('analytics:read'),
+       ('webhooks:manage'),
+       ('users:register'),
+       ('account:manage')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO role_permissions (role, permission)
+VALUES ('user', 'comics:search'),
+       ('user', 'account:manage'),
+       ('admin', 'comics:search'),
+       ('admin', 'account:manage'),
+       ('admin', 'comics:update'),
+       ('admin', 'analytics:read'),
+       ('admin', 'webhooks:manage'),
+       ('admin', 'users:register')
+ON CONFLICT DO NOTHING;
+
+-- Users may have roles other than the standard ones; such roles are kept, without permissions
+INSERT INTO roles (name) SELECT DISTINCT role FROM users ON CONFLICT DO NOTHING;
+
+ALTER TABLE users
+    ADD CONSTRAINT users_role_fkey FOREIGN KEY (role) REFERENCES roles (name);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS verification_tokens;
+
+ALTER TABLE users DROP COLUMN IF EXISTS pending;
+ALTER TABLE users DROP COLUMN IF EXISTS email;
@@ -0,0 +1,5 @@
+DELETE FROM permissions WHERE name = 'users:manage';
+
+ALTER TABLE users DROP COLUMN IF EXISTS last_login;
+ALTER TABLE users DROP COLUMN IF EXISTS disabled;
+ALTER TABLE users DROP COLUMN IF EXISTS created_at;
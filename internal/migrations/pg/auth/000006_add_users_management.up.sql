@@ -0,0 +1,6 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+ALTER TABLE users ADD COLUMN IF NOT EXISTS disabled BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS last_login TIMESTAMPTZ;
+
+INSERT INTO permissions (name) VALUES ('users:manage') ON CONFLICT DO NOTHING;
+INSERT INTO role_permissions (role, permission) VALUES ('admin', 'users:manage') ON CONFLICT DO NOTHING;
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS login_failures
+(
+    id         BIGSERIAL PRIMARY KEY,
+    username   TEXT        NOT NULL,
+    client_ip  TEXT        NOT NULL DEFAULT '',
+    reason     TEXT        NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS login_failures_username_idx ON login_failures (username, created_at);
+CREATE INDEX IF NOT EXISTS login_failures_client_ip_idx ON login_failures (client_ip, created_at);
@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS users_changed ON users;
+DROP FUNCTION IF EXISTS record_user_change();
+DROP TABLE IF EXISTS user_changes;
@@ -0,0 +1,24 @@
+-- Changes of users outdating the tokens issued to them before, fetched by the services verifying tokens locally.
+-- The trigger records changes made by any replica of the auth server or directly in the database
+CREATE TABLE IF NOT EXISTS user_changes
+(
+    id         BIGSERIAL PRIMARY KEY,
+    username   TEXT        NOT NULL,
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS user_changes_changed_at_idx ON user_changes (changed_at);
+
+CREATE OR REPLACE FUNCTION record_user_change() RETURNS TRIGGER
+    LANGUAGE plpgsql
+    AS $$
+BEGIN
+    INSERT INTO user_changes (username) VALUES (OLD.username);
+    RETURN NULL;
+END
+$$;
+
+DROP TRIGGER IF EXISTS users_changed ON users;
+CREATE TRIGGER users_changed
+    AFTER UPDATE OF role, disabled, history_disabled OR DELETE ON users
+    FOR EACH ROW EXECUTE FUNCTION record_user_change();
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS refresh_tokens_expires_at_idx;
+DROP INDEX IF EXISTS sessions_revoked_at_idx;
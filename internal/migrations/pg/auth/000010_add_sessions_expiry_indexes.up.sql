@@ -0,0 +1,3 @@
+-- Revoked sessions are fetched by the services verifying tokens locally, and expired tokens are purged
+CREATE INDEX IF NOT EXISTS sessions_revoked_at_idx ON sessions (revoked_at) WHERE revoked_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS refresh_tokens_expires_at_idx ON refresh_tokens (expires_at);
@@ -0,0 +1,9 @@
+-- Pending sign-ups with the email of another user are dropped before emails are made unique;
+-- their users can sign up again with their own email
+DELETE FROM users u
+WHERE u.pending AND EXISTS (
+    SELECT 1 FROM users o
+    WHERE LOWER(o.email) = LOWER(u.email) AND o.username <> u.username AND (NOT o.pending OR o.username < u.username)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS users_email_key ON users (LOWER(email));
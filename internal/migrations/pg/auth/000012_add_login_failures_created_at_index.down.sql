@@ -0,0 +1 @@
+DROP INDEX IF EXISTS login_failures_created_at_idx;
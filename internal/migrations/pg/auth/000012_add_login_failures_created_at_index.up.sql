@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS login_failures_created_at_idx ON login_failures (created_at);
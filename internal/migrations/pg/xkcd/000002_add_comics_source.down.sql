@@ -0,0 +1,2 @@
+ALTER TABLE comics DROP COLUMN IF EXISTS source;
+ALTER TABLE comics ALTER COLUMN id TYPE INT;
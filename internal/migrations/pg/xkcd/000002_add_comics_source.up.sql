@@ -0,0 +1,2 @@
+ALTER TABLE comics ALTER COLUMN id TYPE BIGINT;
+ALTER TABLE comics ADD COLUMN IF NOT EXISTS source TEXT NOT NULL DEFAULT 'xkcd';
@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS update_state
+(
+    id              BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    last_success_at TIMESTAMPTZ NOT NULL
+);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS comics_unindexed_idx;
+ALTER TABLE comics DROP COLUMN IF EXISTS indexed;
@@ -0,0 +1,3 @@
+-- Comics saved before are picked up by reconciliation; indexing them again replaces their old postings
+ALTER TABLE comics ADD COLUMN IF NOT EXISTS indexed BOOLEAN NOT NULL DEFAULT FALSE;
+CREATE INDEX IF NOT EXISTS comics_unindexed_idx ON comics (id) WHERE NOT indexed;
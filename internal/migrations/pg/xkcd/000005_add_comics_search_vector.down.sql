@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS comics_search_vector_idx;
+ALTER TABLE comics DROP COLUMN IF EXISTS search_vector;
+DROP FUNCTION IF EXISTS comics_keywords_text(TEXT[]);
+ALTER TABLE comics DROP COLUMN IF EXISTS title;
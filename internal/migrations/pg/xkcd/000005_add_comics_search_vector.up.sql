@@ -0,0 +1,15 @@
+ALTER TABLE comics ADD COLUMN IF NOT EXISTS title TEXT NOT NULL DEFAULT '';
+
+-- array_to_string is only stable, so it is wrapped to be usable in the generated column
+CREATE OR REPLACE FUNCTION comics_keywords_text(keywords TEXT[]) RETURNS TEXT
+    LANGUAGE SQL IMMUTABLE PARALLEL SAFE
+    AS $$ SELECT array_to_string(keywords, ' ') $$;
+
+-- Titles, keywords and queries all use the english configuration, so a title word matches the query token
+-- the application stemmed from it; keywords, stemmed by the application already, stem to themselves
+ALTER TABLE comics ADD COLUMN IF NOT EXISTS search_vector TSVECTOR GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', title), 'A') ||
+    setweight(to_tsvector('english', comics_keywords_text(keywords)), 'B')
+) STORED;
+
+CREATE INDEX IF NOT EXISTS comics_search_vector_idx ON comics USING GIN (search_vector);
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS shadow_comparisons
+(
+    id                 BIGSERIAL PRIMARY KEY,
+    query              TEXT[]           NOT NULL,
+    primary_ids        BIGINT[]         NOT NULL,
+    shadow_ids         BIGINT[]         NOT NULL,
+    jaccard            DOUBLE PRECISION NOT NULL,
+    rank_correlation   DOUBLE PRECISION NOT NULL,
+    primary_latency_ms DOUBLE PRECISION NOT NULL,
+    shadow_latency_ms  DOUBLE PRECISION NOT NULL,
+    latency_delta_ms   DOUBLE PRECISION NOT NULL,
+    shadow_error       TEXT             NOT NULL DEFAULT '',
+    created_at         TIMESTAMPTZ      NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS shadow_comparisons_created_at_idx ON shadow_comparisons (created_at);
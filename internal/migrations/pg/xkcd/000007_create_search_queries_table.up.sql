@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS search_queries
+(
+    id               BIGSERIAL PRIMARY KEY,
+    query            TEXT             NOT NULL,
+    tokens           TEXT[]           NOT NULL,
+    normalized_query TEXT             NOT NULL,
+    username         TEXT             NOT NULL,
+    result_count     INT              NOT NULL,
+    latency_ms       DOUBLE PRECISION NOT NULL,
+    clicked_comic_id BIGINT,
+    created_at       TIMESTAMPTZ      NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS search_queries_created_at_idx ON search_queries (created_at);
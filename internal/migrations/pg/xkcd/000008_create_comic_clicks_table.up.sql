@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS comic_clicks
+(
+    token      TEXT             NOT NULL,
+    comic_id   BIGINT           NOT NULL,
+    clicks     DOUBLE PRECISION NOT NULL,
+    updated_at TIMESTAMPTZ      NOT NULL,
+    PRIMARY KEY (token, comic_id)
+);
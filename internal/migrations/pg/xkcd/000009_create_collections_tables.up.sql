@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS favorites
+(
+    username   TEXT        NOT NULL,
+    comic_id   BIGINT      NOT NULL REFERENCES comics (id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (username, comic_id)
+);
+
+CREATE TABLE IF NOT EXISTS collections
+(
+    id         BIGSERIAL PRIMARY KEY,
+    username   TEXT        NOT NULL,
+    name       TEXT        NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (username, name)
+);
+
+CREATE TABLE IF NOT EXISTS collection_comics
+(
+    collection_id BIGINT      NOT NULL REFERENCES collections (id) ON DELETE CASCADE,
+    comic_id      BIGINT      NOT NULL REFERENCES comics (id) ON DELETE CASCADE,
+    added_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (collection_id, comic_id)
+);
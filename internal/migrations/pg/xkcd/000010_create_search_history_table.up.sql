@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS search_history
+(
+    id           BIGSERIAL PRIMARY KEY,
+    username     TEXT        NOT NULL,
+    query        TEXT        NOT NULL,
+    result_count INT         NOT NULL,
+    created_at   TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS search_history_username_idx ON search_history (username, created_at DESC);
@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS saved_searches
+(
+    id          BIGSERIAL PRIMARY KEY,
+    username    TEXT        NOT NULL,
+    query       TEXT        NOT NULL,
+    tokens      TEXT[]      NOT NULL,
+    webhook_url TEXT        NOT NULL DEFAULT '',
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS saved_searches_username_idx ON saved_searches (username);
+
+CREATE TABLE IF NOT EXISTS saved_search_matches
+(
+    id              BIGSERIAL PRIMARY KEY,
+    saved_search_id BIGINT      NOT NULL REFERENCES saved_searches (id) ON DELETE CASCADE,
+    username        TEXT        NOT NULL,
+    comic_id        BIGINT      NOT NULL REFERENCES comics (id) ON DELETE CASCADE,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    read_at         TIMESTAMPTZ,
+    UNIQUE (saved_search_id, comic_id)
+);
+
+CREATE INDEX IF NOT EXISTS saved_search_matches_username_idx ON saved_search_matches (username, created_at DESC);
@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS webhooks
+(
+    id         BIGSERIAL PRIMARY KEY,
+    url        TEXT        NOT NULL,
+    events     TEXT[]      NOT NULL,
+    secret     TEXT        NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries
+(
+    id          BIGSERIAL PRIMARY KEY,
+    webhook_id  BIGINT           NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+    event_id    TEXT             NOT NULL,
+    event_type  TEXT             NOT NULL,
+    attempt     INT              NOT NULL,
+    status_code INT              NOT NULL,
+    error       TEXT             NOT NULL,
+    latency_ms  DOUBLE PRECISION NOT NULL,
+    created_at  TIMESTAMPTZ      NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS webhook_deliveries_webhook_idx ON webhook_deliveries (webhook_id, created_at DESC);
@@ -0,0 +1 @@
+ALTER TABLE search_queries DROP COLUMN IF EXISTS result_ids;
@@ -0,0 +1,2 @@
+-- Clicks are accepted only on comics returned by the query
+ALTER TABLE search_queries ADD COLUMN IF NOT EXISTS result_ids BIGINT[] NOT NULL DEFAULT '{}';
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS webhook_deliveries_pending_idx;
+DELETE FROM webhook_deliveries WHERE pending OR webhook_id IS NULL;
+ALTER TABLE webhook_deliveries
+    DROP COLUMN IF EXISTS next_attempt_at,
+    DROP COLUMN IF EXISTS pending,
+    DROP COLUMN IF EXISTS payload,
+    DROP COLUMN IF EXISTS saved_search_id;
+ALTER TABLE webhook_deliveries ALTER COLUMN webhook_id SET NOT NULL;
@@ -0,0 +1,10 @@
+-- Pending rows are delivery attempts waiting in the queue; the rest are the log of made attempts.
+-- Notifications of saved searches are queued in the same table, with saved_search_id instead of webhook_id
+ALTER TABLE webhook_deliveries ALTER COLUMN webhook_id DROP NOT NULL;
+ALTER TABLE webhook_deliveries
+    ADD COLUMN IF NOT EXISTS saved_search_id BIGINT REFERENCES saved_searches (id) ON DELETE CASCADE,
+    ADD COLUMN IF NOT EXISTS payload         JSONB,
+    ADD COLUMN IF NOT EXISTS pending         BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS next_attempt_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS webhook_deliveries_pending_idx ON webhook_deliveries (next_attempt_at) WHERE pending;
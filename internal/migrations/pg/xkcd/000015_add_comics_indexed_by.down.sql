@@ -0,0 +1,3 @@
+ALTER TABLE comics DROP COLUMN IF EXISTS indexed_by;
+ALTER TABLE comics ADD COLUMN IF NOT EXISTS indexed BOOLEAN NOT NULL DEFAULT FALSE;
+CREATE INDEX IF NOT EXISTS comics_unindexed_idx ON comics (id) WHERE NOT indexed;
@@ -0,0 +1,6 @@
+-- Comics are tracked by every search index they were added to, so switching or adding an engine
+-- leaves them unindexed for the new one. Indexes comics were added to before are unknown,
+-- so all comics are indexed again by reconciliation
+DROP INDEX IF EXISTS comics_unindexed_idx;
+ALTER TABLE comics DROP COLUMN IF EXISTS indexed;
+ALTER TABLE comics ADD COLUMN IF NOT EXISTS indexed_by TEXT[] NOT NULL DEFAULT '{}';
@@ -1,4 +1,4 @@
-// Code generated by mockery v2.43.2. DO NOT EDIT.
+// Code generated by mockery v2.43.2 DO NOT EDIT.
 
 package mocks
 
@@ -7,6 +7,8 @@ import (
 	domain "yadro-microservices/internal/core/domain"
 
 	mock "github.com/stretchr/testify/mock"
+
+	time "time"
 )
 
 // AuthClient is an autogenerated mock type for the AuthClient type
@@ -14,27 +16,251 @@ type AuthClient struct {
 	mock.Mock
 }
 
-// Login provides a mock function with given fields: ctx, username, password
-func (_m *AuthClient) Login(ctx context.Context, username string, password string) (string, error) {
-	ret := _m.Called(ctx, username, password)
+// ChangePassword provides a mock function with given fields: ctx, username, oldPassword, newPassword, clientIP
+func (_m *AuthClient) ChangePassword(ctx context.Context, username string, oldPassword string, newPassword string, clientIP string) error {
+	ret := _m.Called(ctx, username, oldPassword, newPassword, clientIP)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ChangePassword")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, string) error); ok {
+		r0 = rf(ctx, username, oldPassword, newPassword, clientIP)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ConfirmSignUp provides a mock function with given fields: ctx, token
+func (_m *AuthClient) ConfirmSignUp(ctx context.Context, token string) error {
+	ret := _m.Called(ctx, token)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ConfirmSignUp")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, token)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateAPIKey provides a mock function with given fields: ctx, username, name, permissions, ttl
+func (_m *AuthClient) CreateAPIKey(ctx context.Context, username string, name string, permissions []domain.Permission, ttl time.Duration) (*domain.APIKey, error) {
+	ret := _m.Called(ctx, username, name, permissions, ttl)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateAPIKey")
+	}
+
+	var r0 *domain.APIKey
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, []domain.Permission, time.Duration) (*domain.APIKey, error)); ok {
+		return rf(ctx, username, name, permissions, ttl)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, []domain.Permission, time.Duration) *domain.APIKey); ok {
+		r0 = rf(ctx, username, name, permissions, ttl)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.APIKey)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, []domain.Permission, time.Duration) error); ok {
+		r1 = rf(ctx, username, name, permissions, ttl)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteUser provides a mock function with given fields: ctx, username
+func (_m *AuthClient) DeleteUser(ctx context.Context, username string) error {
+	ret := _m.Called(ctx, username)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteUser")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, username)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetChanges provides a mock function with given fields: ctx, since
+func (_m *AuthClient) GetChanges(ctx context.Context, since time.Time) (*domain.Changes, error) {
+	ret := _m.Called(ctx, since)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetChanges")
+	}
+
+	var r0 *domain.Changes
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) (*domain.Changes, error)); ok {
+		return rf(ctx, since)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) *domain.Changes); ok {
+		r0 = rf(ctx, since)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Changes)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time) error); ok {
+		r1 = rf(ctx, since)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListAPIKeys provides a mock function with given fields: ctx, username
+func (_m *AuthClient) ListAPIKeys(ctx context.Context, username string) ([]*domain.APIKey, error) {
+	ret := _m.Called(ctx, username)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListAPIKeys")
+	}
+
+	var r0 []*domain.APIKey
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]*domain.APIKey, error)); ok {
+		return rf(ctx, username)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []*domain.APIKey); ok {
+		r0 = rf(ctx, username)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*domain.APIKey)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, username)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListUsers provides a mock function with given fields: ctx, offset, limit
+func (_m *AuthClient) ListUsers(ctx context.Context, offset int, limit int) (*domain.UserPage, error) {
+	ret := _m.Called(ctx, offset, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListUsers")
+	}
+
+	var r0 *domain.UserPage
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) (*domain.UserPage, error)); ok {
+		return rf(ctx, offset, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) *domain.UserPage); ok {
+		r0 = rf(ctx, offset, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.UserPage)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, int) error); ok {
+		r1 = rf(ctx, offset, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Login provides a mock function with given fields: ctx, username, password, clientIP
+func (_m *AuthClient) Login(ctx context.Context, username string, password string, clientIP string) (*domain.TokenPair, error) {
+	ret := _m.Called(ctx, username, password, clientIP)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Login")
 	}
 
-	var r0 string
+	var r0 *domain.TokenPair
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) (*domain.TokenPair, error)); ok {
+		return rf(ctx, username, password, clientIP)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) *domain.TokenPair); ok {
+		r0 = rf(ctx, username, password, clientIP)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.TokenPair)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string) error); ok {
+		r1 = rf(ctx, username, password, clientIP)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Logout provides a mock function with given fields: ctx, token
+func (_m *AuthClient) Logout(ctx context.Context, token string) error {
+	ret := _m.Called(ctx, token)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Logout")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, token)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Refresh provides a mock function with given fields: ctx, refreshToken
+func (_m *AuthClient) Refresh(ctx context.Context, refreshToken string) (*domain.TokenPair, error) {
+	ret := _m.Called(ctx, refreshToken)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Refresh")
+	}
+
+	var r0 *domain.TokenPair
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, string) (string, error)); ok {
-		return rf(ctx, username, password)
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*domain.TokenPair, error)); ok {
+		return rf(ctx, refreshToken)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string, string) string); ok {
-		r0 = rf(ctx, username, password)
+	if rf, ok := ret.Get(0).(func(context.Context, string) *domain.TokenPair); ok {
+		r0 = rf(ctx, refreshToken)
 	} else {
-		r0 = ret.Get(0).(string)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.TokenPair)
+		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
-		r1 = rf(ctx, username, password)
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, refreshToken)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -60,24 +286,144 @@ func (_m *AuthClient) Register(ctx context.Context, username string, password st
 	return r0
 }
 
+// RevokeAPIKey provides a mock function with given fields: ctx, username, id
+func (_m *AuthClient) RevokeAPIKey(ctx context.Context, username string, id int64) error {
+	ret := _m.Called(ctx, username, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RevokeAPIKey")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64) error); ok {
+		r0 = rf(ctx, username, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetDisabled provides a mock function with given fields: ctx, username, disabled
+func (_m *AuthClient) SetDisabled(ctx context.Context, username string, disabled bool) error {
+	ret := _m.Called(ctx, username, disabled)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetDisabled")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, bool) error); ok {
+		r0 = rf(ctx, username, disabled)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetHistoryDisabled provides a mock function with given fields: ctx, username, disabled
+func (_m *AuthClient) SetHistoryDisabled(ctx context.Context, username string, disabled bool) error {
+	ret := _m.Called(ctx, username, disabled)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetHistoryDisabled")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, bool) error); ok {
+		r0 = rf(ctx, username, disabled)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetRole provides a mock function with given fields: ctx, username, role
+func (_m *AuthClient) SetRole(ctx context.Context, username string, role domain.Role) error {
+	ret := _m.Called(ctx, username, role)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetRole")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, domain.Role) error); ok {
+		r0 = rf(ctx, username, role)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SignUp provides a mock function with given fields: ctx, username, email, password
+func (_m *AuthClient) SignUp(ctx context.Context, username string, email string, password string) error {
+	ret := _m.Called(ctx, username, email, password)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SignUp")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) error); ok {
+		r0 = rf(ctx, username, email, password)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ValidateAPIKey provides a mock function with given fields: ctx, key
+func (_m *AuthClient) ValidateAPIKey(ctx context.Context, key string) (*domain.Principal, error) {
+	ret := _m.Called(ctx, key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ValidateAPIKey")
+	}
+
+	var r0 *domain.Principal
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*domain.Principal, error)); ok {
+		return rf(ctx, key)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *domain.Principal); ok {
+		r0 = rf(ctx, key)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Principal)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, key)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // ValidateToken provides a mock function with given fields: ctx, token
-func (_m *AuthClient) ValidateToken(ctx context.Context, token string) (*domain.User, error) {
+func (_m *AuthClient) ValidateToken(ctx context.Context, token string) (*domain.Principal, error) {
 	ret := _m.Called(ctx, token)
 
 	if len(ret) == 0 {
 		panic("no return value specified for ValidateToken")
 	}
 
-	var r0 *domain.User
+	var r0 *domain.Principal
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) (*domain.User, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*domain.Principal, error)); ok {
 		return rf(ctx, token)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string) *domain.User); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, string) *domain.Principal); ok {
 		r0 = rf(ctx, token)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*domain.User)
+			r0 = ret.Get(0).(*domain.Principal)
 		}
 	}
 
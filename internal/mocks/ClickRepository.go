@@ -0,0 +1,48 @@
+// Code generated by mockery v2.43.2 DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	time "time"
+)
+
+// ClickRepository is an autogenerated mock type for the ClickRepository type
+type ClickRepository struct {
+	mock.Mock
+}
+
+// AddClick provides a mock function with given fields: ctx, queryID, username, comicID, at
+func (_m *ClickRepository) AddClick(ctx context.Context, queryID int64, username string, comicID int, at time.Time) error {
+	ret := _m.Called(ctx, queryID, username, comicID, at)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AddClick")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string, int, time.Time) error); ok {
+		r0 = rf(ctx, queryID, username, comicID, at)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewClickRepository creates a new instance of ClickRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewClickRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *ClickRepository {
+	mock := &ClickRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,209 @@
+// Code generated by mockery v2.43.2 DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	domain "yadro-microservices/internal/core/domain"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// CollectionService is an autogenerated mock type for the CollectionService type
+type CollectionService struct {
+	mock.Mock
+}
+
+// AddFavorite provides a mock function with given fields: ctx, username, comicID
+func (_m *CollectionService) AddFavorite(ctx context.Context, username string, comicID int) error {
+	ret := _m.Called(ctx, username, comicID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AddFavorite")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) error); ok {
+		r0 = rf(ctx, username, comicID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// AddToCollection provides a mock function with given fields: ctx, username, id, comicID
+func (_m *CollectionService) AddToCollection(ctx context.Context, username string, id int64, comicID int) error {
+	ret := _m.Called(ctx, username, id, comicID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AddToCollection")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64, int) error); ok {
+		r0 = rf(ctx, username, id, comicID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateCollection provides a mock function with given fields: ctx, username, name
+func (_m *CollectionService) CreateCollection(ctx context.Context, username string, name string) (*domain.Collection, error) {
+	ret := _m.Called(ctx, username, name)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateCollection")
+	}
+
+	var r0 *domain.Collection
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (*domain.Collection, error)); ok {
+		return rf(ctx, username, name)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *domain.Collection); ok {
+		r0 = rf(ctx, username, name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Collection)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, username, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteCollection provides a mock function with given fields: ctx, username, id
+func (_m *CollectionService) DeleteCollection(ctx context.Context, username string, id int64) error {
+	ret := _m.Called(ctx, username, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteCollection")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64) error); ok {
+		r0 = rf(ctx, username, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetCollections provides a mock function with given fields: ctx, username
+func (_m *CollectionService) GetCollections(ctx context.Context, username string) ([]*domain.Collection, error) {
+	ret := _m.Called(ctx, username)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetCollections")
+	}
+
+	var r0 []*domain.Collection
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]*domain.Collection, error)); ok {
+		return rf(ctx, username)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []*domain.Collection); ok {
+		r0 = rf(ctx, username)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*domain.Collection)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, username)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetFavorites provides a mock function with given fields: ctx, username
+func (_m *CollectionService) GetFavorites(ctx context.Context, username string) ([]*domain.FoundComic, error) {
+	ret := _m.Called(ctx, username)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetFavorites")
+	}
+
+	var r0 []*domain.FoundComic
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]*domain.FoundComic, error)); ok {
+		return rf(ctx, username)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []*domain.FoundComic); ok {
+		r0 = rf(ctx, username)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*domain.FoundComic)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, username)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RemoveFavorite provides a mock function with given fields: ctx, username, comicID
+func (_m *CollectionService) RemoveFavorite(ctx context.Context, username string, comicID int) error {
+	ret := _m.Called(ctx, username, comicID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RemoveFavorite")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) error); ok {
+		r0 = rf(ctx, username, comicID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RemoveFromCollection provides a mock function with given fields: ctx, username, id, comicID
+func (_m *CollectionService) RemoveFromCollection(ctx context.Context, username string, id int64, comicID int) error {
+	ret := _m.Called(ctx, username, id, comicID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RemoveFromCollection")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64, int) error); ok {
+		r0 = rf(ctx, username, id, comicID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewCollectionService creates a new instance of CollectionService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewCollectionService(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *CollectionService {
+	mock := &CollectionService{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,59 @@
+// Code generated by mockery v2.43.2 DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	domain "yadro-microservices/internal/core/domain"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// ComicMatcher is an autogenerated mock type for the ComicMatcher type
+type ComicMatcher struct {
+	mock.Mock
+}
+
+// Match provides a mock function with given fields: ctx, queryTokens, comics
+func (_m *ComicMatcher) Match(ctx context.Context, queryTokens []string, comics domain.Comics) ([]int, error) {
+	ret := _m.Called(ctx, queryTokens, comics)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Match")
+	}
+
+	var r0 []int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []string, domain.Comics) ([]int, error)); ok {
+		return rf(ctx, queryTokens, comics)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []string, domain.Comics) []int); ok {
+		r0 = rf(ctx, queryTokens, comics)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]int)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []string, domain.Comics) error); ok {
+		r1 = rf(ctx, queryTokens, comics)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewComicMatcher creates a new instance of ComicMatcher. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewComicMatcher(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *ComicMatcher {
+	mock := &ComicMatcher{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
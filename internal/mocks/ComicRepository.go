@@ -1,4 +1,4 @@
-// Code generated by mockery v2.43.1. DO NOT EDIT.
+// Code generated by mockery v2.43.2 DO NOT EDIT.
 
 package mocks
 
@@ -132,22 +132,82 @@ func (_m *ComicRepository) GetTotalComics(ctx context.Context) (int, error) {
 	return r0, r1
 }
 
+// GetUnindexed provides a mock function with given fields: ctx, index, limit
+func (_m *ComicRepository) GetUnindexed(ctx context.Context, index string, limit int) (domain.Comics, error) {
+	ret := _m.Called(ctx, index, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUnindexed")
+	}
+
+	var r0 domain.Comics
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) (domain.Comics, error)); ok {
+		return rf(ctx, index, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) domain.Comics); ok {
+		r0 = rf(ctx, index, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(domain.Comics)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, int) error); ok {
+		r1 = rf(ctx, index, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MarkIndexed provides a mock function with given fields: ctx, index, ids
+func (_m *ComicRepository) MarkIndexed(ctx context.Context, index string, ids []int) error {
+	ret := _m.Called(ctx, index, ids)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MarkIndexed")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []int) error); ok {
+		r0 = rf(ctx, index, ids)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Save provides a mock function with given fields: ctx, c
-func (_m *ComicRepository) Save(ctx context.Context, c domain.Comics) error {
+func (_m *ComicRepository) Save(ctx context.Context, c domain.Comics) (*domain.SaveStats, error) {
 	ret := _m.Called(ctx, c)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Save")
 	}
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, domain.Comics) error); ok {
+	var r0 *domain.SaveStats
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.Comics) (*domain.SaveStats, error)); ok {
+		return rf(ctx, c)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, domain.Comics) *domain.SaveStats); ok {
 		r0 = rf(ctx, c)
 	} else {
-		r0 = ret.Error(0)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.SaveStats)
+		}
 	}
 
-	return r0
+	if rf, ok := ret.Get(1).(func(context.Context, domain.Comics) error); ok {
+		r1 = rf(ctx, c)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
 // NewComicRepository creates a new instance of ComicRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
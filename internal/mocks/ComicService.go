@@ -1,11 +1,14 @@
-// Code generated by mockery v2.43.1. DO NOT EDIT.
+// Code generated by mockery v2.43.2 DO NOT EDIT.
 
 package mocks
 
 import (
 	context "context"
+	domain "yadro-microservices/internal/core/domain"
 
 	mock "github.com/stretchr/testify/mock"
+
+	time "time"
 )
 
 // ComicService is an autogenerated mock type for the ComicService type
@@ -13,6 +16,54 @@ type ComicService struct {
 	mock.Mock
 }
 
+// ClearHistory provides a mock function with given fields: ctx, username
+func (_m *ComicService) ClearHistory(ctx context.Context, username string) error {
+	ret := _m.Called(ctx, username)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ClearHistory")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, username)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetHistory provides a mock function with given fields: ctx, username, limit
+func (_m *ComicService) GetHistory(ctx context.Context, username string, limit int) ([]*domain.HistoryEntry, error) {
+	ret := _m.Called(ctx, username, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetHistory")
+	}
+
+	var r0 []*domain.HistoryEntry
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) ([]*domain.HistoryEntry, error)); ok {
+		return rf(ctx, username, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) []*domain.HistoryEntry); ok {
+		r0 = rf(ctx, username, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*domain.HistoryEntry)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, int) error); ok {
+		r1 = rf(ctx, username, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetNumberOfComics provides a mock function with given fields: ctx
 func (_m *ComicService) GetNumberOfComics(ctx context.Context) (int, error) {
 	ret := _m.Called(ctx)
@@ -41,29 +92,59 @@ func (_m *ComicService) GetNumberOfComics(ctx context.Context) (int, error) {
 	return r0, r1
 }
 
-// Search provides a mock function with given fields: ctx, query
-func (_m *ComicService) Search(ctx context.Context, query string) ([]string, error) {
-	ret := _m.Called(ctx, query)
+// GetScheduleStatus provides a mock function with given fields: ctx
+func (_m *ComicService) GetScheduleStatus(ctx context.Context) (*domain.UpdateScheduleStatus, error) {
+	ret := _m.Called(ctx)
 
 	if len(ret) == 0 {
-		panic("no return value specified for Search")
+		panic("no return value specified for GetScheduleStatus")
+	}
+
+	var r0 *domain.UpdateScheduleStatus
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (*domain.UpdateScheduleStatus, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) *domain.UpdateScheduleStatus); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.UpdateScheduleStatus)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetSearchAnalytics provides a mock function with given fields: ctx, since, limit
+func (_m *ComicService) GetSearchAnalytics(ctx context.Context, since time.Time, limit int) (*domain.SearchAnalytics, error) {
+	ret := _m.Called(ctx, since, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetSearchAnalytics")
 	}
 
-	var r0 []string
+	var r0 *domain.SearchAnalytics
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) ([]string, error)); ok {
-		return rf(ctx, query)
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, int) (*domain.SearchAnalytics, error)); ok {
+		return rf(ctx, since, limit)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string) []string); ok {
-		r0 = rf(ctx, query)
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, int) *domain.SearchAnalytics); ok {
+		r0 = rf(ctx, since, limit)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]string)
+			r0 = ret.Get(0).(*domain.SearchAnalytics)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = rf(ctx, query)
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time, int) error); ok {
+		r1 = rf(ctx, since, limit)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -71,17 +152,75 @@ func (_m *ComicService) Search(ctx context.Context, query string) ([]string, err
 	return r0, r1
 }
 
-// UpdateComics provides a mock function with given fields: ctx
-func (_m *ComicService) UpdateComics(ctx context.Context) error {
-	ret := _m.Called(ctx)
+// GetUpdateJob provides a mock function with given fields: ctx, id
+func (_m *ComicService) GetUpdateJob(ctx context.Context, id int) (*domain.UpdateJob, error) {
+	ret := _m.Called(ctx, id)
 
 	if len(ret) == 0 {
-		panic("no return value specified for UpdateComics")
+		panic("no return value specified for GetUpdateJob")
+	}
+
+	var r0 *domain.UpdateJob
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) (*domain.UpdateJob, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) *domain.UpdateJob); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.UpdateJob)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ImportComics provides a mock function with given fields: ctx, comics
+func (_m *ComicService) ImportComics(ctx context.Context, comics domain.Comics) (int, error) {
+	ret := _m.Called(ctx, comics)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ImportComics")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.Comics) (int, error)); ok {
+		return rf(ctx, comics)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, domain.Comics) int); ok {
+		r0 = rf(ctx, comics)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, domain.Comics) error); ok {
+		r1 = rf(ctx, comics)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ReportClick provides a mock function with given fields: ctx, queryID, user, comicID
+func (_m *ComicService) ReportClick(ctx context.Context, queryID int64, user *domain.Principal, comicID int) error {
+	ret := _m.Called(ctx, queryID, user, comicID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReportClick")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
-		r0 = rf(ctx)
+	if rf, ok := ret.Get(0).(func(context.Context, int64, *domain.Principal, int) error); ok {
+		r0 = rf(ctx, queryID, user, comicID)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -89,6 +228,66 @@ func (_m *ComicService) UpdateComics(ctx context.Context) error {
 	return r0
 }
 
+// Search provides a mock function with given fields: ctx, query, user
+func (_m *ComicService) Search(ctx context.Context, query string, user *domain.Principal) (*domain.SearchResult, error) {
+	ret := _m.Called(ctx, query, user)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Search")
+	}
+
+	var r0 *domain.SearchResult
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, *domain.Principal) (*domain.SearchResult, error)); ok {
+		return rf(ctx, query, user)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, *domain.Principal) *domain.SearchResult); ok {
+		r0 = rf(ctx, query, user)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.SearchResult)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, *domain.Principal) error); ok {
+		r1 = rf(ctx, query, user)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// StartUpdate provides a mock function with given fields: ctx
+func (_m *ComicService) StartUpdate(ctx context.Context) (*domain.UpdateJob, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for StartUpdate")
+	}
+
+	var r0 *domain.UpdateJob
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (*domain.UpdateJob, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) *domain.UpdateJob); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.UpdateJob)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // NewComicService creates a new instance of ComicService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewComicService(t interface {
@@ -0,0 +1,47 @@
+// Code generated by mockery v2.43.2 DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	domain "yadro-microservices/internal/core/domain"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// ComicsListener is an autogenerated mock type for the ComicsListener type
+type ComicsListener struct {
+	mock.Mock
+}
+
+// ComicsAdded provides a mock function with given fields: ctx, comics
+func (_m *ComicsListener) ComicsAdded(ctx context.Context, comics domain.Comics) error {
+	ret := _m.Called(ctx, comics)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ComicsAdded")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.Comics) error); ok {
+		r0 = rf(ctx, comics)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewComicsListener creates a new instance of ComicsListener. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewComicsListener(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *ComicsListener {
+	mock := &ComicsListener{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,133 @@
+// Code generated by mockery v2.43.2 DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	domain "yadro-microservices/internal/core/domain"
+
+	mock "github.com/stretchr/testify/mock"
+
+	time "time"
+)
+
+// LoginFailureRepository is an autogenerated mock type for the LoginFailureRepository type
+type LoginFailureRepository struct {
+	mock.Mock
+}
+
+// Delete provides a mock function with given fields: ctx, id
+func (_m *LoginFailureRepository) Delete(ctx context.Context, id int64) error {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Delete")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteBefore provides a mock function with given fields: ctx, before
+func (_m *LoginFailureRepository) DeleteBefore(ctx context.Context, before time.Time) error {
+	ret := _m.Called(ctx, before)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteBefore")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) error); ok {
+		r0 = rf(ctx, before)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetStats provides a mock function with given fields: ctx, username, clientIP, since, beforeID
+func (_m *LoginFailureRepository) GetStats(ctx context.Context, username string, clientIP string, since time.Time, beforeID int64) (*domain.LoginFailureStats, error) {
+	ret := _m.Called(ctx, username, clientIP, since, beforeID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetStats")
+	}
+
+	var r0 *domain.LoginFailureStats
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, time.Time, int64) (*domain.LoginFailureStats, error)); ok {
+		return rf(ctx, username, clientIP, since, beforeID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, time.Time, int64) *domain.LoginFailureStats); ok {
+		r0 = rf(ctx, username, clientIP, since, beforeID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.LoginFailureStats)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, time.Time, int64) error); ok {
+		r1 = rf(ctx, username, clientIP, since, beforeID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Save provides a mock function with given fields: ctx, f
+func (_m *LoginFailureRepository) Save(ctx context.Context, f *domain.LoginFailure) error {
+	ret := _m.Called(ctx, f)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Save")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.LoginFailure) error); ok {
+		r0 = rf(ctx, f)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetReason provides a mock function with given fields: ctx, id, reason
+func (_m *LoginFailureRepository) SetReason(ctx context.Context, id int64, reason domain.LoginFailureReason) error {
+	ret := _m.Called(ctx, id, reason)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetReason")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, domain.LoginFailureReason) error); ok {
+		r0 = rf(ctx, id, reason)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewLoginFailureRepository creates a new instance of LoginFailureRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewLoginFailureRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *LoginFailureRepository {
+	mock := &LoginFailureRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,65 @@
+// Code generated by mockery v2.43.2 DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	domain "yadro-microservices/internal/core/domain"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// SavedSearchNotifier is an autogenerated mock type for the SavedSearchNotifier type
+type SavedSearchNotifier struct {
+	mock.Mock
+}
+
+// CheckURL provides a mock function with given fields: ctx, url
+func (_m *SavedSearchNotifier) CheckURL(ctx context.Context, url string) error {
+	ret := _m.Called(ctx, url)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CheckURL")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, url)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NotifySavedSearch provides a mock function with given fields: ctx, s, n
+func (_m *SavedSearchNotifier) NotifySavedSearch(ctx context.Context, s *domain.SavedSearch, n *domain.SavedSearchNotification) error {
+	ret := _m.Called(ctx, s, n)
+
+	if len(ret) == 0 {
+		panic("no return value specified for NotifySavedSearch")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.SavedSearch, *domain.SavedSearchNotification) error); ok {
+		r0 = rf(ctx, s, n)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewSavedSearchNotifier creates a new instance of SavedSearchNotifier. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewSavedSearchNotifier(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *SavedSearchNotifier {
+	mock := &SavedSearchNotifier{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,233 @@
+// Code generated by mockery v2.43.2 DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	domain "yadro-microservices/internal/core/domain"
+
+	mock "github.com/stretchr/testify/mock"
+
+	time "time"
+)
+
+// SavedSearchRepository is an autogenerated mock type for the SavedSearchRepository type
+type SavedSearchRepository struct {
+	mock.Mock
+}
+
+// AddMatches provides a mock function with given fields: ctx, s, comicIDs
+func (_m *SavedSearchRepository) AddMatches(ctx context.Context, s *domain.SavedSearch, comicIDs []int) ([]int, error) {
+	ret := _m.Called(ctx, s, comicIDs)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AddMatches")
+	}
+
+	var r0 []int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.SavedSearch, []int) ([]int, error)); ok {
+		return rf(ctx, s, comicIDs)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.SavedSearch, []int) []int); ok {
+		r0 = rf(ctx, s, comicIDs)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]int)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *domain.SavedSearch, []int) error); ok {
+		r1 = rf(ctx, s, comicIDs)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CountByUsername provides a mock function with given fields: ctx, username
+func (_m *SavedSearchRepository) CountByUsername(ctx context.Context, username string) (int, error) {
+	ret := _m.Called(ctx, username)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CountByUsername")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (int, error)); ok {
+		return rf(ctx, username)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) int); ok {
+		r0 = rf(ctx, username)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, username)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Create provides a mock function with given fields: ctx, s
+func (_m *SavedSearchRepository) Create(ctx context.Context, s *domain.SavedSearch) error {
+	ret := _m.Called(ctx, s)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Create")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.SavedSearch) error); ok {
+		r0 = rf(ctx, s)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Delete provides a mock function with given fields: ctx, username, id
+func (_m *SavedSearchRepository) Delete(ctx context.Context, username string, id int64) error {
+	ret := _m.Called(ctx, username, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Delete")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64) error); ok {
+		r0 = rf(ctx, username, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetAll provides a mock function with given fields: ctx
+func (_m *SavedSearchRepository) GetAll(ctx context.Context) ([]*domain.SavedSearch, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAll")
+	}
+
+	var r0 []*domain.SavedSearch
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]*domain.SavedSearch, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []*domain.SavedSearch); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*domain.SavedSearch)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetByUsername provides a mock function with given fields: ctx, username
+func (_m *SavedSearchRepository) GetByUsername(ctx context.Context, username string) ([]*domain.SavedSearch, error) {
+	ret := _m.Called(ctx, username)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetByUsername")
+	}
+
+	var r0 []*domain.SavedSearch
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]*domain.SavedSearch, error)); ok {
+		return rf(ctx, username)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []*domain.SavedSearch); ok {
+		r0 = rf(ctx, username)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*domain.SavedSearch)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, username)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetInbox provides a mock function with given fields: ctx, username, unreadOnly, limit
+func (_m *SavedSearchRepository) GetInbox(ctx context.Context, username string, unreadOnly bool, limit int) ([]*domain.InboxItem, error) {
+	ret := _m.Called(ctx, username, unreadOnly, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetInbox")
+	}
+
+	var r0 []*domain.InboxItem
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, bool, int) ([]*domain.InboxItem, error)); ok {
+		return rf(ctx, username, unreadOnly, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, bool, int) []*domain.InboxItem); ok {
+		r0 = rf(ctx, username, unreadOnly, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*domain.InboxItem)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, bool, int) error); ok {
+		r1 = rf(ctx, username, unreadOnly, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MarkRead provides a mock function with given fields: ctx, username, id, at
+func (_m *SavedSearchRepository) MarkRead(ctx context.Context, username string, id int64, at time.Time) error {
+	ret := _m.Called(ctx, username, id, at)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MarkRead")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64, time.Time) error); ok {
+		r0 = rf(ctx, username, id, at)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewSavedSearchRepository creates a new instance of SavedSearchRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewSavedSearchRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *SavedSearchRepository {
+	mock := &SavedSearchRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
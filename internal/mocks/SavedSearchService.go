@@ -0,0 +1,155 @@
+// Code generated by mockery v2.43.2 DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	domain "yadro-microservices/internal/core/domain"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// SavedSearchService is an autogenerated mock type for the SavedSearchService type
+type SavedSearchService struct {
+	mock.Mock
+}
+
+// Create provides a mock function with given fields: ctx, username, query, webhookURL
+func (_m *SavedSearchService) Create(ctx context.Context, username string, query string, webhookURL string) (*domain.SavedSearch, error) {
+	ret := _m.Called(ctx, username, query, webhookURL)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Create")
+	}
+
+	var r0 *domain.SavedSearch
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) (*domain.SavedSearch, error)); ok {
+		return rf(ctx, username, query, webhookURL)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) *domain.SavedSearch); ok {
+		r0 = rf(ctx, username, query, webhookURL)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.SavedSearch)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string) error); ok {
+		r1 = rf(ctx, username, query, webhookURL)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Delete provides a mock function with given fields: ctx, username, id
+func (_m *SavedSearchService) Delete(ctx context.Context, username string, id int64) error {
+	ret := _m.Called(ctx, username, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Delete")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64) error); ok {
+		r0 = rf(ctx, username, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Get provides a mock function with given fields: ctx, username
+func (_m *SavedSearchService) Get(ctx context.Context, username string) ([]*domain.SavedSearch, error) {
+	ret := _m.Called(ctx, username)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Get")
+	}
+
+	var r0 []*domain.SavedSearch
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]*domain.SavedSearch, error)); ok {
+		return rf(ctx, username)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []*domain.SavedSearch); ok {
+		r0 = rf(ctx, username)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*domain.SavedSearch)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, username)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetInbox provides a mock function with given fields: ctx, username, unreadOnly, limit
+func (_m *SavedSearchService) GetInbox(ctx context.Context, username string, unreadOnly bool, limit int) ([]*domain.InboxItem, error) {
+	ret := _m.Called(ctx, username, unreadOnly, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetInbox")
+	}
+
+	var r0 []*domain.InboxItem
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, bool, int) ([]*domain.InboxItem, error)); ok {
+		return rf(ctx, username, unreadOnly, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, bool, int) []*domain.InboxItem); ok {
+		r0 = rf(ctx, username, unreadOnly, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*domain.InboxItem)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, bool, int) error); ok {
+		r1 = rf(ctx, username, unreadOnly, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MarkRead provides a mock function with given fields: ctx, username, id
+func (_m *SavedSearchService) MarkRead(ctx context.Context, username string, id int64) error {
+	ret := _m.Called(ctx, username, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MarkRead")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64) error); ok {
+		r0 = rf(ctx, username, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewSavedSearchService creates a new instance of SavedSearchService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewSavedSearchService(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *SavedSearchService {
+	mock := &SavedSearchService{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -1,4 +1,4 @@
-// Code generated by mockery v2.43.1. DO NOT EDIT.
+// Code generated by mockery v2.43.2 DO NOT EDIT.
 
 package mocks
 
@@ -32,6 +32,24 @@ func (_m *SearchEngine) CreateIndex(ctx context.Context, comics domain.Comics) e
 	return r0
 }
 
+// IndexName provides a mock function with no fields
+func (_m *SearchEngine) IndexName() string {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for IndexName")
+	}
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func() string); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	return r0
+}
+
 // Search provides a mock function with given fields: ctx, queryTokens
 func (_m *SearchEngine) Search(ctx context.Context, queryTokens []string) ([]int, error) {
 	ret := _m.Called(ctx, queryTokens)
@@ -0,0 +1,95 @@
+// Code generated by mockery v2.43.2 DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	domain "yadro-microservices/internal/core/domain"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// SearchHistoryRepository is an autogenerated mock type for the SearchHistoryRepository type
+type SearchHistoryRepository struct {
+	mock.Mock
+}
+
+// Add provides a mock function with given fields: ctx, username, e
+func (_m *SearchHistoryRepository) Add(ctx context.Context, username string, e *domain.HistoryEntry) error {
+	ret := _m.Called(ctx, username, e)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Add")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, *domain.HistoryEntry) error); ok {
+		r0 = rf(ctx, username, e)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Clear provides a mock function with given fields: ctx, username
+func (_m *SearchHistoryRepository) Clear(ctx context.Context, username string) error {
+	ret := _m.Called(ctx, username)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Clear")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, username)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Get provides a mock function with given fields: ctx, username, limit
+func (_m *SearchHistoryRepository) Get(ctx context.Context, username string, limit int) ([]*domain.HistoryEntry, error) {
+	ret := _m.Called(ctx, username, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Get")
+	}
+
+	var r0 []*domain.HistoryEntry
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) ([]*domain.HistoryEntry, error)); ok {
+		return rf(ctx, username, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) []*domain.HistoryEntry); ok {
+		r0 = rf(ctx, username, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*domain.HistoryEntry)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, int) error); ok {
+		r1 = rf(ctx, username, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewSearchHistoryRepository creates a new instance of SearchHistoryRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewSearchHistoryRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *SearchHistoryRepository {
+	mock := &SearchHistoryRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
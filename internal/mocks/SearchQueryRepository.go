@@ -0,0 +1,107 @@
+// Code generated by mockery v2.43.2 DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	domain "yadro-microservices/internal/core/domain"
+
+	mock "github.com/stretchr/testify/mock"
+
+	time "time"
+)
+
+// SearchQueryRepository is an autogenerated mock type for the SearchQueryRepository type
+type SearchQueryRepository struct {
+	mock.Mock
+}
+
+// Anonymize provides a mock function with given fields: ctx, username
+func (_m *SearchQueryRepository) Anonymize(ctx context.Context, username string) error {
+	ret := _m.Called(ctx, username)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Anonymize")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, username)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetAnalytics provides a mock function with given fields: ctx, since, limit
+func (_m *SearchQueryRepository) GetAnalytics(ctx context.Context, since time.Time, limit int) (*domain.SearchAnalytics, error) {
+	ret := _m.Called(ctx, since, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAnalytics")
+	}
+
+	var r0 *domain.SearchAnalytics
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, int) (*domain.SearchAnalytics, error)); ok {
+		return rf(ctx, since, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, int) *domain.SearchAnalytics); ok {
+		r0 = rf(ctx, since, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.SearchAnalytics)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time, int) error); ok {
+		r1 = rf(ctx, since, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Save provides a mock function with given fields: ctx, q
+func (_m *SearchQueryRepository) Save(ctx context.Context, q *domain.SearchQuery) (int64, error) {
+	ret := _m.Called(ctx, q)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Save")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.SearchQuery) (int64, error)); ok {
+		return rf(ctx, q)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.SearchQuery) int64); ok {
+		r0 = rf(ctx, q)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *domain.SearchQuery) error); ok {
+		r1 = rf(ctx, q)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewSearchQueryRepository creates a new instance of SearchQueryRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewSearchQueryRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *SearchQueryRepository {
+	mock := &SearchQueryRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,47 @@
+// Code generated by mockery v2.43.2 DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	domain "yadro-microservices/internal/core/domain"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// ShadowComparisonRepository is an autogenerated mock type for the ShadowComparisonRepository type
+type ShadowComparisonRepository struct {
+	mock.Mock
+}
+
+// Save provides a mock function with given fields: ctx, c
+func (_m *ShadowComparisonRepository) Save(ctx context.Context, c *domain.ShadowComparison) error {
+	ret := _m.Called(ctx, c)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Save")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.ShadowComparison) error); ok {
+		r0 = rf(ctx, c)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewShadowComparisonRepository creates a new instance of ShadowComparisonRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewShadowComparisonRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *ShadowComparisonRepository {
+	mock := &ShadowComparisonRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,34 @@
+// Code generated by mockery v2.43.2 DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	domain "yadro-microservices/internal/core/domain"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// UpdateListener is an autogenerated mock type for the UpdateListener type
+type UpdateListener struct {
+	mock.Mock
+}
+
+// UpdateFinished provides a mock function with given fields: ctx, job
+func (_m *UpdateListener) UpdateFinished(ctx context.Context, job *domain.UpdateJob) {
+	_m.Called(ctx, job)
+}
+
+// NewUpdateListener creates a new instance of UpdateListener. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewUpdateListener(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *UpdateListener {
+	mock := &UpdateListener{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,58 @@
+// Code generated by mockery v2.43.2 DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// UpdateLock is an autogenerated mock type for the UpdateLock type
+type UpdateLock struct {
+	mock.Mock
+}
+
+// TryLock provides a mock function with given fields: ctx
+func (_m *UpdateLock) TryLock(ctx context.Context) (func(), error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for TryLock")
+	}
+
+	var r0 func()
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (func(), error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) func()); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(func())
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewUpdateLock creates a new instance of UpdateLock. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewUpdateLock(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *UpdateLock {
+	mock := &UpdateLock{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,46 @@
+// Code generated by mockery v2.43.2 DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// UserDataRepository is an autogenerated mock type for the UserDataRepository type
+type UserDataRepository struct {
+	mock.Mock
+}
+
+// DeleteUserData provides a mock function with given fields: ctx, username
+func (_m *UserDataRepository) DeleteUserData(ctx context.Context, username string) error {
+	ret := _m.Called(ctx, username)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteUserData")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, username)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewUserDataRepository creates a new instance of UserDataRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewUserDataRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *UserDataRepository {
+	mock := &UserDataRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,103 @@
+// Code generated by mockery v2.43.2 DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	domain "yadro-microservices/internal/core/domain"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// WebhookSender is an autogenerated mock type for the WebhookSender type
+type WebhookSender struct {
+	mock.Mock
+}
+
+// CheckURL provides a mock function with given fields: ctx, url
+func (_m *WebhookSender) CheckURL(ctx context.Context, url string) error {
+	ret := _m.Called(ctx, url)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CheckURL")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, url)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Notify provides a mock function with given fields: ctx, url, n
+func (_m *WebhookSender) Notify(ctx context.Context, url string, n *domain.SavedSearchNotification) (int, error) {
+	ret := _m.Called(ctx, url, n)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Notify")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, *domain.SavedSearchNotification) (int, error)); ok {
+		return rf(ctx, url, n)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, *domain.SavedSearchNotification) int); ok {
+		r0 = rf(ctx, url, n)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, *domain.SavedSearchNotification) error); ok {
+		r1 = rf(ctx, url, n)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Send provides a mock function with given fields: ctx, url, secret, e
+func (_m *WebhookSender) Send(ctx context.Context, url string, secret string, e *domain.Event) (int, error) {
+	ret := _m.Called(ctx, url, secret, e)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Send")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, *domain.Event) (int, error)); ok {
+		return rf(ctx, url, secret, e)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, *domain.Event) int); ok {
+		r0 = rf(ctx, url, secret, e)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, *domain.Event) error); ok {
+		r1 = rf(ctx, url, secret, e)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewWebhookSender creates a new instance of WebhookSender. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewWebhookSender(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *WebhookSender {
+	mock := &WebhookSender{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
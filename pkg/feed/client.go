@@ -0,0 +1,230 @@
+package feed
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Item represents a single entry of an RSS or Atom feed.
+type Item struct {
+	GUID        string // Stable identifier of the entry, falls back to the link when the feed has none
+	Title       string
+	Link        string
+	Description string // Plain text of the entry with HTML markup removed
+	Image       string // URL of the first image attached to or embedded in the entry
+}
+
+// Client struct represents a client to fetch RSS and Atom feeds.
+type Client struct {
+	client *http.Client // HTTP client
+}
+
+// NewClient creates a new instance of feed client.
+func NewClient() *Client {
+	return &Client{
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Fetch retrieves the feed at the given URL and returns its items.
+func (c *Client) Fetch(ctx context.Context, url string) ([]*Item, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("HTTP request failed: %w", err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP request failed with status code: %d", resp.StatusCode)
+	}
+
+	return Parse(resp.Body)
+}
+
+// rss is a document in the RSS 2.0 format.
+type rss struct {
+	Items []struct {
+		GUID        string  `xml:"guid"`
+		Title       string  `xml:"title"`
+		Link        string  `xml:"link"`
+		Description string  `xml:"description"`
+		Enclosures  []media `xml:"enclosure"`
+		Media       []media `xml:"http://search.yahoo.com/mrss/ content"`
+		Thumbnails  []media `xml:"http://search.yahoo.com/mrss/ thumbnail"`
+		Content     string  `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	} `xml:"channel>item"`
+}
+
+// atom is a document in the Atom format.
+type atom struct {
+	Entries []struct {
+		ID    string `xml:"id"`
+		Title string `xml:"title"`
+		Links []struct {
+			Href string `xml:"href,attr"`
+			Rel  string `xml:"rel,attr"`
+			Type string `xml:"type,attr"`
+		} `xml:"link"`
+		Summary string `xml:"summary"`
+		Content string `xml:"content"`
+	} `xml:"entry"`
+}
+
+// media is an attached media object, such as an RSS enclosure or a Media RSS element.
+type media struct {
+	URL    string `xml:"url,attr"`
+	Type   string `xml:"type,attr"`
+	Medium string `xml:"medium,attr"`
+}
+
+// isImage reports whether the media object is an image.
+func (m media) isImage() bool {
+	return m.URL != "" && (m.Medium == "image" || strings.HasPrefix(m.Type, "image/"))
+}
+
+// Parse decodes an RSS 2.0 or Atom document and returns its items.
+func Parse(r io.Reader) ([]*Item, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read feed: %w", err)
+	}
+
+	var root struct {
+		XMLName xml.Name
+	}
+	if err = xml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("failed to decode feed: %w", err)
+	}
+
+	switch root.XMLName.Local {
+	case "rss":
+		return parseRSS(data)
+	case "feed":
+		return parseAtom(data)
+	default:
+		return nil, errors.New("unsupported feed format: " + root.XMLName.Local)
+	}
+}
+
+func parseRSS(data []byte) ([]*Item, error) {
+	var doc rss
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to decode RSS feed: %w", err)
+	}
+
+	items := make([]*Item, 0, len(doc.Items))
+	for _, it := range doc.Items {
+		body := it.Description
+		if it.Content != "" {
+			body = it.Content
+		}
+
+		item := &Item{
+			GUID:        strings.TrimSpace(it.GUID),
+			Title:       strings.TrimSpace(it.Title),
+			Link:        strings.TrimSpace(it.Link),
+			Description: plainText(body),
+		}
+
+		for _, m := range append(it.Enclosures, it.Media...) {
+			if m.isImage() {
+				item.Image = m.URL
+				break
+			}
+		}
+		if item.Image == "" {
+			item.Image = firstImage(body)
+		}
+		if item.Image == "" && len(it.Thumbnails) > 0 {
+			item.Image = it.Thumbnails[0].URL
+		}
+
+		items = append(items, fillGUID(item))
+	}
+
+	return items, nil
+}
+
+func parseAtom(data []byte) ([]*Item, error) {
+	var doc atom
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to decode Atom feed: %w", err)
+	}
+
+	items := make([]*Item, 0, len(doc.Entries))
+	for _, e := range doc.Entries {
+		body := e.Summary
+		if e.Content != "" {
+			body = e.Content
+		}
+
+		item := &Item{
+			GUID:        strings.TrimSpace(e.ID),
+			Title:       strings.TrimSpace(e.Title),
+			Description: plainText(body),
+		}
+
+		for _, l := range e.Links {
+			switch {
+			case l.Rel == "enclosure" && strings.HasPrefix(l.Type, "image/") && item.Image == "":
+				item.Image = l.Href
+			case (l.Rel == "" || l.Rel == "alternate") && item.Link == "":
+				item.Link = l.Href
+			}
+		}
+		if item.Image == "" {
+			item.Image = firstImage(body)
+		}
+
+		items = append(items, fillGUID(item))
+	}
+
+	return items, nil
+}
+
+// fillGUID makes sure the item has an identifier, as GUIDs are optional in RSS.
+func fillGUID(item *Item) *Item {
+	if item.GUID == "" {
+		item.GUID = item.Link
+	}
+	if item.GUID == "" {
+		item.GUID = item.Title
+	}
+
+	return item
+}
+
+var (
+	imgRe = regexp.MustCompile(`(?i)<img[^>]+src\s*=\s*["']([^"']+)["']`)
+	tagRe = regexp.MustCompile(`<[^>]*>`)
+)
+
+// firstImage returns the source of the first image embedded in the HTML fragment.
+func firstImage(fragment string) string {
+	m := imgRe.FindStringSubmatch(fragment)
+	if m == nil {
+		return ""
+	}
+
+	return html.UnescapeString(m[1])
+}
+
+// plainText strips HTML markup and entities from the fragment.
+func plainText(fragment string) string {
+	text := html.UnescapeString(tagRe.ReplaceAllString(fragment, " "))
+	return strings.Join(strings.Fields(text), " ")
+}
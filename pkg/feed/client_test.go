@@ -0,0 +1,101 @@
+package feed
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const rssFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:media="http://search.yahoo.com/mrss/">
+  <channel>
+    <title>Test Comics</title>
+    <item>
+      <title>First Comic</title>
+      <link>https://example.com/comics/1</link>
+      <guid>comic-1</guid>
+      <description>&lt;p&gt;Hello &amp;amp; welcome&lt;/p&gt;&lt;img src="https://example.com/1.png"/&gt;</description>
+    </item>
+    <item>
+      <title>Second Comic</title>
+      <link>https://example.com/comics/2</link>
+      <description>Plain description</description>
+      <media:content url="https://example.com/2.png" medium="image"/>
+    </item>
+  </channel>
+</rss>`
+
+const atomFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <title>Test Comics</title>
+  <entry>
+    <id>tag:example.com,2024:1</id>
+    <title>Atom Comic</title>
+    <link rel="alternate" href="https://example.com/atom/1"/>
+    <link rel="enclosure" type="image/png" href="https://example.com/atom/1.png"/>
+    <summary>Atom summary</summary>
+  </entry>
+</feed>`
+
+func TestParse_RSS(t *testing.T) {
+	items, err := Parse(strings.NewReader(rssFeed))
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+
+	assert.Equal(t, "comic-1", items[0].GUID)
+	assert.Equal(t, "First Comic", items[0].Title)
+	assert.Equal(t, "Hello & welcome", items[0].Description)
+	assert.Equal(t, "https://example.com/1.png", items[0].Image)
+
+	assert.Equal(t, "https://example.com/comics/2", items[1].GUID)
+	assert.Equal(t, "https://example.com/2.png", items[1].Image)
+}
+
+func TestParse_Atom(t *testing.T) {
+	items, err := Parse(strings.NewReader(atomFeed))
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+
+	assert.Equal(t, "tag:example.com,2024:1", items[0].GUID)
+	assert.Equal(t, "Atom Comic", items[0].Title)
+	assert.Equal(t, "https://example.com/atom/1", items[0].Link)
+	assert.Equal(t, "https://example.com/atom/1.png", items[0].Image)
+	assert.Equal(t, "Atom summary", items[0].Description)
+}
+
+func TestParse_UnsupportedFormat(t *testing.T) {
+	_, err := Parse(strings.NewReader(`<html><body></body></html>`))
+	require.Error(t, err)
+}
+
+func TestFetch(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, err := w.Write([]byte(atomFeed))
+		assert.NoError(t, err)
+	}))
+	defer mockServer.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	items, err := NewClient().Fetch(ctx, mockServer.URL)
+	require.NoError(t, err)
+	assert.Len(t, items, 1)
+}
+
+func TestFetch_ErrorStatus(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer mockServer.Close()
+
+	_, err := NewClient().Fetch(context.Background(), mockServer.URL)
+	require.Error(t, err)
+}
@@ -0,0 +1,83 @@
+package fts
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Popularity is the number of click-throughs to a document from the search results for a token.
+type Popularity struct {
+	Token     string
+	ID        int
+	Clicks    float64   // Number of clicks, decayed up to UpdatedAt
+	UpdatedAt time.Time // Time of the last click
+}
+
+// PopularityRepository is an interface that defines the behavior of a repository that stores click-throughs.
+type PopularityRepository interface {
+	GetPopularity(ctx context.Context, tokens []string) ([]*Popularity, error)
+}
+
+// ClickThrough configures how click-through popularity is blended into the search score.
+type ClickThrough struct {
+	Popularity PopularityRepository
+	Weight     float64       // Score added for a single click
+	HalfLife   time.Duration // Period after which a click counts half; zero disables decay
+}
+
+// Decay returns the number of clicks left after the period elapsed since they were made.
+func Decay(clicks float64, elapsed, halfLife time.Duration) float64 {
+	if halfLife <= 0 || elapsed <= 0 {
+		return clicks
+	}
+
+	return clicks * math.Exp2(-float64(elapsed)/float64(halfLife))
+}
+
+// BoostByClicks is a search modifier that boosts the found documents which were clicked from the results
+// of the query tokens before. It does not add new documents, so it must follow the modifiers finding them.
+func BoostByClicks(ctx context.Context, ct *ClickThrough) SearchModifier {
+	return func(queryTokens []string, results *SearchResults) error {
+		if ct.Weight == 0 || len(*results) == 0 {
+			return nil
+		}
+
+		boosts, err := ct.Boosts(ctx, queryTokens)
+		if err != nil {
+			return err
+		}
+
+		for _, r := range *results {
+			r.Boost += boosts[r.ID]
+		}
+
+		return nil
+	}
+}
+
+// Boosts returns the score added to every document clicked from the results of the query tokens before.
+func (ct *ClickThrough) Boosts(ctx context.Context, queryTokens []string) (map[int]float64, error) {
+	tokens := make([]string, 0, len(queryTokens))
+	seen := make(map[string]bool, len(queryTokens))
+	for _, token := range queryTokens {
+		if !seen[token] {
+			seen[token] = true
+			tokens = append(tokens, token)
+		}
+	}
+
+	popularity, err := ct.Popularity.GetPopularity(ctx, tokens)
+	if err != nil {
+		return nil, fmt.Errorf("error getting click-through popularity: %w", err)
+	}
+
+	now := time.Now()
+	boosts := make(map[int]float64)
+	for _, p := range popularity {
+		boosts[p.ID] += ct.Weight * Decay(p.Clicks, now.Sub(p.UpdatedAt), ct.HalfLife)
+	}
+
+	return boosts, nil
+}
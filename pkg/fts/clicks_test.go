@@ -0,0 +1,95 @@
+package fts_test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+	"yadro-microservices/pkg/fts"
+)
+
+func TestDecay(t *testing.T) {
+	halfLife := 24 * time.Hour
+
+	if got := fts.Decay(8, 48*time.Hour, halfLife); math.Abs(got-2) > 1e-9 {
+		t.Errorf("Decay after two half-lives = %v, want 2", got)
+	}
+	if got := fts.Decay(8, 48*time.Hour, 0); got != 8 {
+		t.Errorf("Decay without half-life = %v, want 8", got)
+	}
+	if got := fts.Decay(8, -time.Hour, halfLife); got != 8 {
+		t.Errorf("Decay of future clicks = %v, want 8", got)
+	}
+}
+
+func TestBoostByClicks(t *testing.T) {
+	now := time.Now()
+	popularity := &MockPopularity{
+		Popularity: []*fts.Popularity{
+			{Token: "apple", ID: 2, Clicks: 4, UpdatedAt: now},
+			{Token: "banana", ID: 2, Clicks: 2, UpdatedAt: now.Add(-time.Hour)},
+			{Token: "apple", ID: 3, Clicks: 10, UpdatedAt: now},
+		},
+	}
+
+	results := fts.SearchResults{
+		{ID: 1, NumberOfTokens: 2, Score: 3},
+		{ID: 2, NumberOfTokens: 2, Score: 2},
+	}
+
+	modifier := fts.BoostByClicks(context.Background(), &fts.ClickThrough{
+		Popularity: popularity,
+		Weight:     0.5,
+		HalfLife:   time.Hour,
+	})
+	if err := modifier([]string{"apple", "banana", "apple"}, &results); err != nil {
+		t.Fatalf("BoostByClicks modifier returned an error: %v", err)
+	}
+
+	if !reflect.DeepEqual(popularity.Tokens, []string{"apple", "banana"}) {
+		t.Errorf("BoostByClicks requested popularity for %v, want unique query tokens", popularity.Tokens)
+	}
+	if results[0].Boost != 0 {
+		t.Errorf("Document without clicks was boosted by %v", results[0].Boost)
+	}
+	// 0.5 * 4 for apple and 0.5 * 2 decayed by one half-life for banana
+	if math.Abs(results[1].Boost-2.5) > 1e-3 {
+		t.Errorf("Document boost = %v, want 2.5", results[1].Boost)
+	}
+	if len(results) != 2 {
+		t.Errorf("BoostByClicks added documents which were not found")
+	}
+
+	// The clicked document outranks the one with more occurrences of the tokens
+	if err := fts.ReturnMostRelevant(1)(nil, &results); err != nil {
+		t.Fatalf("ReturnMostRelevant modifier returned an error: %v", err)
+	}
+	if results[0].ID != 2 {
+		t.Errorf("Most relevant document = %d, want 2", results[0].ID)
+	}
+}
+
+func TestBoostByClicks_Error(t *testing.T) {
+	results := fts.SearchResults{{ID: 1, NumberOfTokens: 1, Score: 1}}
+
+	modifier := fts.BoostByClicks(context.Background(), &fts.ClickThrough{
+		Popularity: &MockPopularity{Err: errors.New("database is down")},
+		Weight:     1,
+	})
+	if err := modifier([]string{"apple"}, &results); err == nil {
+		t.Errorf("BoostByClicks modifier did not return an error")
+	}
+}
+
+type MockPopularity struct {
+	Popularity []*fts.Popularity
+	Err        error
+	Tokens     []string
+}
+
+func (m *MockPopularity) GetPopularity(_ context.Context, tokens []string) ([]*fts.Popularity, error) {
+	m.Tokens = tokens
+	return m.Popularity, m.Err
+}
@@ -20,6 +20,7 @@ type Indexer interface {
 type IndexRepository interface {
 	Get(ctx context.Context, word string) ([]*Index, error)
 	Add(ctx context.Context, indexes map[string][]*Index, documents map[int]bool) error
+	Remove(ctx context.Context, ids []int) error
 	DocumentIsIndexed(ctx context.Context, id int) (bool, error)
 	MarkDocumentAsIndexed(ctx context.Context, id int) error
 }
@@ -37,17 +38,19 @@ func NewInvertedIndexer(indexRep IndexRepository) *InvertedIndexer {
 }
 
 // Add creates an inverted index from the given documents.
+// Documents which are already indexed are re-indexed: their old postings are replaced by the new ones.
 func (i *InvertedIndexer) Add(ctx context.Context, docs []*Document) error {
 	indexes := make(map[string][]*Index)
 	indexedDocuments := make(map[int]bool)
+	var reindexed []int
 
 	for _, doc := range docs {
 		isIndexed, err := i.IndexRep.DocumentIsIndexed(ctx, doc.ID)
 		if err != nil {
 			return fmt.Errorf("error checking if document is indexed: %w", err)
 		}
-		if isIndexed { // If the document is already indexed, skip it
-			continue
+		if isIndexed {
+			reindexed = append(reindexed, doc.ID)
 		}
 
 		for _, token := range doc.Tokens {
@@ -81,6 +84,12 @@ func (i *InvertedIndexer) Add(ctx context.Context, docs []*Document) error {
 		indexedDocuments[doc.ID] = true
 	}
 
+	if len(reindexed) > 0 {
+		if err := i.IndexRep.Remove(ctx, reindexed); err != nil {
+			return fmt.Errorf("error removing old indexes of documents: %w", err)
+		}
+	}
+
 	err := i.IndexRep.Add(ctx, indexes, indexedDocuments)
 	if err != nil {
 		return fmt.Errorf("error saving index to db: %w", err)
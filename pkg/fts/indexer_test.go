@@ -96,3 +96,34 @@ func TestInvertedIndexer_Add_NoTokens(t *testing.T) {
 		t.Errorf("Indexes should remain empty when adding a document with no tokens")
 	}
 }
+
+func TestInvertedIndexer_Add_Reindex(t *testing.T) {
+	mockRepo := mock.NewIndexRepository()
+	indexer := fts.NewInvertedIndexer(mockRepo)
+
+	err := indexer.Add(context.Background(), []*fts.Document{
+		{ID: 1, Tokens: []string{"apple", "banana"}},
+		{ID: 2, Tokens: []string{"banana"}},
+	})
+	if err != nil {
+		t.Errorf("Error creating inverted index: %v", err)
+	}
+
+	err = indexer.Add(context.Background(), []*fts.Document{{ID: 1, Tokens: []string{"cherry", "cherry"}}})
+	if err != nil {
+		t.Errorf("Error re-indexing document: %v", err)
+	}
+
+	expectedIndexes := map[string][]*fts.Index{
+		"banana": {
+			{ID: 2, Score: 1},
+		},
+		"cherry": {
+			{ID: 1, Score: 2},
+		},
+	}
+
+	if !reflect.DeepEqual(mockRepo.Indexes, expectedIndexes) {
+		t.Errorf("Old indexes of a re-indexed document should be replaced by the new ones")
+	}
+}
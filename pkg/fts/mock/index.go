@@ -3,6 +3,7 @@ package mock
 import (
 	"context"
 	"errors"
+	"slices"
 	"yadro-microservices/pkg/fts"
 )
 
@@ -41,8 +42,27 @@ func (r *IndexRepository) Add(_ context.Context, indexes map[string][]*fts.Index
 	return nil
 }
 
+func (r *IndexRepository) Remove(_ context.Context, ids []int) error {
+	for word, indexList := range r.Indexes {
+		kept := indexList[:0]
+		for _, index := range indexList {
+			if !slices.Contains(ids, index.ID) {
+				kept = append(kept, index)
+			}
+		}
+
+		if len(kept) == 0 {
+			delete(r.Indexes, word)
+			continue
+		}
+		r.Indexes[word] = kept
+	}
+
+	return nil
+}
+
 func (r *IndexRepository) DocumentIsIndexed(_ context.Context, id int) (bool, error) {
-	return r.IndexedDocuments[id], nil
+	return r.Documents[id] || r.IndexedDocuments[id], nil
 }
 
 func (r *IndexRepository) MarkDocumentAsIndexed(_ context.Context, id int) error {
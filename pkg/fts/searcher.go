@@ -13,9 +13,15 @@ type Document struct {
 }
 
 type SearchResult struct {
-	ID             int // ID of the document
-	NumberOfTokens int // Number of tokens matched with query
-	Score          int // Total number of occurrences of the tokens
+	ID             int     // ID of the document
+	NumberOfTokens int     // Number of tokens matched with query
+	Score          int     // Total number of occurrences of the tokens
+	Boost          float64 // Score added by modifiers, e.g. for click-through popularity
+}
+
+// TotalScore returns the score of the document together with its boost.
+func (sr *SearchResult) TotalScore() float64 {
+	return float64(sr.Score) + sr.Boost
 }
 
 // SearchResults implements the sort.Interface.
@@ -25,11 +31,12 @@ func (s SearchResults) Len() int { return len(s) }
 
 func (s SearchResults) Less(i, j int) bool {
 	if s[i].NumberOfTokens == s[j].NumberOfTokens {
-		if s[i].Score == s[j].Score {
+		if s[i].TotalScore() == s[j].TotalScore() {
 			return s[i].ID < s[j].ID
 		} // Third priority is the ID of the document
 
-		return s[i].Score > s[j].Score // Second priority is the total number of occurrences of different tokens
+		// Second priority is the total number of occurrences of different tokens together with the boost
+		return s[i].TotalScore() > s[j].TotalScore()
 	}
 
 	return s[i].NumberOfTokens > s[j].NumberOfTokens // First priority is the number of distinct matched tokens
@@ -93,6 +100,24 @@ func ReturnMostRelevant(n int) SearchModifier {
 	}
 }
 
+// ReturnMatchingAll keeps only the search results which matched every query token and sorts them by relevance.
+// It must follow the modifiers finding the documents.
+func ReturnMatchingAll() SearchModifier {
+	return func(queryTokens []string, results *SearchResults) error {
+		matching := (*results)[:0]
+		for _, r := range *results {
+			if r.NumberOfTokens == len(queryTokens) {
+				matching = append(matching, r)
+			}
+		}
+
+		sort.Sort(matching)
+		*results = matching
+
+		return nil
+	}
+}
+
 // ThroughIndexes is a search modifier that searches using the indexer.
 func ThroughIndexes(ctx context.Context, indexer Indexer) SearchModifier {
 	return func(queryTokens []string, results *SearchResults) error {
@@ -120,6 +120,27 @@ func TestThroughDocs(t *testing.T) {
 	}
 }
 
+func TestReturnMatchingAll(t *testing.T) {
+	docs := []*fts.Document{
+		{ID: 1, Tokens: []string{"apple", "banana"}},
+		{ID: 2, Tokens: []string{"banana", "orange"}},
+		{ID: 3, Tokens: []string{"banana", "apple", "apple"}},
+	}
+
+	results, err := (&fts.FullTextSearcher{}).Search(
+		[]string{"apple", "banana"},
+		fts.ThroughDocs(docs),
+		fts.ReturnMatchingAll(),
+	)
+	if err != nil {
+		t.Fatalf("Search returned an error: %v", err)
+	}
+
+	if !reflect.DeepEqual(results, []int{3, 1}) {
+		t.Errorf("ReturnMatchingAll modifier returned %v, expected [3 1]", results)
+	}
+}
+
 type MockIndexer struct {
 	Indexes map[string][]*fts.Index
 }
@@ -0,0 +1,79 @@
+package relevance
+
+import "math"
+
+// PrecisionAtK returns the share of relevant results among the first k results.
+func PrecisionAtK(results []int, relevant map[int]bool, k int) float64 {
+	if k <= 0 {
+		return 0
+	}
+
+	return float64(hitsAtK(results, relevant, k)) / float64(k)
+}
+
+// RecallAtK returns the share of relevant documents found among the first k results.
+// It is 0 if there are no relevant documents.
+func RecallAtK(results []int, relevant map[int]bool, k int) float64 {
+	if len(relevant) == 0 {
+		return 0
+	}
+
+	return float64(hitsAtK(results, relevant, k)) / float64(len(relevant))
+}
+
+// ReciprocalRankAtK returns the reciprocal of the rank of the first relevant result among the first k results,
+// or 0 if there is none. Averaged over queries, it gives the mean reciprocal rank (MRR).
+func ReciprocalRankAtK(results []int, relevant map[int]bool, k int) float64 {
+	for i, id := range top(results, k) {
+		if relevant[id] {
+			return 1 / float64(i+1)
+		}
+	}
+
+	return 0
+}
+
+// NDCGAtK returns the normalized discounted cumulative gain of the first k results with binary relevance:
+// the gain of the results divided by the gain of the ideal ranking with all relevant documents first.
+func NDCGAtK(results []int, relevant map[int]bool, k int) float64 {
+	var dcg float64
+	seen := make(map[int]bool)
+	for i, id := range top(results, k) {
+		if relevant[id] && !seen[id] {
+			dcg += 1 / math.Log2(float64(i+2))
+		}
+		seen[id] = true
+	}
+
+	var idcg float64
+	for i := 0; i < min(k, len(relevant)); i++ {
+		idcg += 1 / math.Log2(float64(i+2))
+	}
+
+	if idcg == 0 {
+		return 0
+	}
+
+	return dcg / idcg
+}
+
+// hitsAtK returns the number of distinct relevant results among the first k results.
+func hitsAtK(results []int, relevant map[int]bool, k int) int {
+	hits := make(map[int]bool)
+	for _, id := range top(results, k) {
+		if relevant[id] {
+			hits[id] = true
+		}
+	}
+
+	return len(hits)
+}
+
+// top returns the first k results.
+func top(results []int, k int) []int {
+	if k < 0 {
+		return nil
+	}
+
+	return results[:min(k, len(results))]
+}
@@ -0,0 +1,77 @@
+package relevance
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics(t *testing.T) {
+	relevant := map[int]bool{1: true, 3: true, 7: true}
+
+	tests := []struct {
+		name      string
+		results   []int
+		k         int
+		precision float64
+		recall    float64
+		rr        float64
+		ndcg      float64
+	}{
+		{
+			name:      "ideal ranking",
+			results:   []int{1, 3, 7, 2},
+			k:         3,
+			precision: 1,
+			recall:    1,
+			rr:        1,
+			ndcg:      1,
+		},
+		{
+			name:      "relevant results ranked lower",
+			results:   []int{2, 1, 4, 3},
+			k:         4,
+			precision: 0.5,
+			recall:    2.0 / 3,
+			rr:        0.5,
+			ndcg:      (1/math.Log2(3) + 1/math.Log2(5)) / (1 + 1/math.Log2(3) + 1/math.Log2(4)),
+		},
+		{
+			name:      "fewer results than k",
+			results:   []int{3},
+			k:         5,
+			precision: 0.2,
+			recall:    1.0 / 3,
+			rr:        1,
+			ndcg:      1 / (1 + 1/math.Log2(3) + 1/math.Log2(4)),
+		},
+		{
+			name:    "no relevant results",
+			results: []int{2, 4},
+			k:       2,
+		},
+		{
+			name: "no results",
+			k:    10,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.InDelta(t, tt.precision, PrecisionAtK(tt.results, relevant, tt.k), 1e-9)
+			assert.InDelta(t, tt.recall, RecallAtK(tt.results, relevant, tt.k), 1e-9)
+			assert.InDelta(t, tt.rr, ReciprocalRankAtK(tt.results, relevant, tt.k), 1e-9)
+			assert.InDelta(t, tt.ndcg, NDCGAtK(tt.results, relevant, tt.k), 1e-9)
+		})
+	}
+}
+
+func TestMetrics_NoRelevantDocuments(t *testing.T) {
+	results := []int{1, 2}
+
+	assert.Zero(t, PrecisionAtK(results, nil, 2))
+	assert.Zero(t, RecallAtK(results, nil, 2))
+	assert.Zero(t, ReciprocalRankAtK(results, nil, 2))
+	assert.Zero(t, NDCGAtK(results, nil, 2))
+}
@@ -0,0 +1,75 @@
+package relevance
+
+// Jaccard returns the Jaccard similarity of two result lists: the size of their intersection
+// divided by the size of their union. Two empty lists are considered identical.
+func Jaccard(a, b []int) float64 {
+	setA := make(map[int]bool, len(a))
+	for _, id := range a {
+		setA[id] = true
+	}
+
+	union := len(setA)
+	intersection := 0
+	seen := make(map[int]bool, len(b))
+	for _, id := range b {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		if setA[id] {
+			intersection++
+		} else {
+			union++
+		}
+	}
+
+	if union == 0 {
+		return 1
+	}
+
+	return float64(intersection) / float64(union)
+}
+
+// SpearmanCorrelation returns Spearman's rank correlation coefficient of the results present in both lists.
+// The results are re-ranked within the intersection, so the coefficient shows whether the common results
+// are ordered the same way: 1 means the same order and -1 means the reversed one.
+// It is 1 if the lists share a single result and 0 if they share none.
+func SpearmanCorrelation(a, b []int) float64 {
+	inB := make(map[int]bool, len(b))
+	for _, id := range b {
+		inB[id] = true
+	}
+
+	// Ranks of the common results in the first list
+	ranksA := make(map[int]int)
+	for _, id := range a {
+		if _, ok := ranksA[id]; !ok && inB[id] {
+			ranksA[id] = len(ranksA)
+		}
+	}
+
+	n := len(ranksA)
+	switch n {
+	case 0:
+		return 0
+	case 1:
+		return 1
+	}
+
+	var sumSquares, rankB int
+	seen := make(map[int]bool, n)
+	for _, id := range b {
+		rankA, ok := ranksA[id]
+		if !ok || seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		d := rankA - rankB
+		sumSquares += d * d
+		rankB++
+	}
+
+	return 1 - 6*float64(sumSquares)/float64(n*(n*n-1))
+}
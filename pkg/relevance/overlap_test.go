@@ -0,0 +1,49 @@
+package relevance
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJaccard(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     []int
+		expected float64
+	}{
+		{"identical", []int{1, 2, 3}, []int{3, 2, 1}, 1},
+		{"disjoint", []int{1, 2}, []int{3, 4}, 0},
+		{"partial", []int{1, 2, 3}, []int{2, 3, 4}, 0.5},
+		{"one empty", []int{1}, nil, 0},
+		{"both empty", nil, nil, 1},
+		{"duplicates", []int{1, 1, 2}, []int{2, 2}, 0.5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.InDelta(t, tt.expected, Jaccard(tt.a, tt.b), 1e-9)
+		})
+	}
+}
+
+func TestSpearmanCorrelation(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     []int
+		expected float64
+	}{
+		{"same order", []int{1, 2, 3, 4}, []int{1, 2, 3, 4}, 1},
+		{"reversed order", []int{1, 2, 3, 4}, []int{4, 3, 2, 1}, -1},
+		{"one swap", []int{1, 2, 3}, []int{2, 1, 3}, 0.5},
+		{"only common results are ranked", []int{1, 5, 2, 3}, []int{1, 2, 6, 3}, 1},
+		{"single common result", []int{1, 2}, []int{2, 3}, 1},
+		{"no common results", []int{1, 2}, []int{3, 4}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.InDelta(t, tt.expected, SpearmanCorrelation(tt.a, tt.b), 1e-9)
+		})
+	}
+}